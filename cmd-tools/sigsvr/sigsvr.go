@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 
 	"github.com/ontio/ontology/cmd"
@@ -29,6 +30,7 @@ import (
 	clisvrcom "github.com/ontio/ontology/cmd/sigsvr/common"
 	"github.com/ontio/ontology/cmd/sigsvr/store"
 	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/urfave/cli"
@@ -47,6 +49,9 @@ func setupSigSvr() *cli.App {
 		utils.CliAddressFlag,
 		utils.CliRpcPortFlag,
 		utils.CliABIPathFlag,
+		utils.CliUnixSocketFlag,
+		utils.CliSignGuardDirFlag,
+		utils.CliConsensusAccountsFlag,
 	}
 	app.Commands = []cli.Command{
 		cmdsvr.ImportWalletCommand,
@@ -81,20 +86,39 @@ func startSigSvr(ctx *cli.Context) {
 		return
 	}
 	log.Infof("Load wallet data success. Account number:%d", accountNum)
-
-	rpcAddress := ctx.String(utils.GetFlagName(utils.CliAddressFlag))
-	rpcPort := ctx.Uint(utils.GetFlagName(utils.CliRpcPortFlag))
-	if rpcPort == 0 {
-		log.Errorf("Please using sig server port by --%s flag", utils.GetFlagName(utils.CliRpcPortFlag))
-		return
+	clisvrcom.DefSignGuardDir = ctx.String(utils.GetFlagName(utils.CliSignGuardDirFlag))
+	consensusAccounts := ctx.String(utils.GetFlagName(utils.CliConsensusAccountsFlag))
+	if consensusAccounts != "" {
+		for _, addr := range strings.Split(consensusAccounts, ",") {
+			addr = strings.TrimSpace(addr)
+			_, err = common.AddressFromBase58(addr)
+			if err != nil {
+				log.Errorf("Invalid consensus account:%s error:%s", addr, err)
+				return
+			}
+			clisvrcom.DefConsensusAccounts[addr] = true
+		}
 	}
-	go cmdsvr.DefCliRpcSvr.Start(rpcAddress, rpcPort)
 
 	abiPath := ctx.GlobalString(utils.GetFlagName(utils.CliABIPathFlag))
 	abi.DefAbiMgr.Init(abiPath)
 
-	log.Infof("Sig server init success")
-	log.Infof("Sig server listing on: %s:%d", rpcAddress, rpcPort)
+	unixSocket := ctx.String(utils.GetFlagName(utils.CliUnixSocketFlag))
+	if unixSocket != "" {
+		go cmdsvr.DefCliRpcSvr.StartUnix(unixSocket)
+		log.Infof("Sig server init success")
+		log.Infof("Sig server listing on: %s", unixSocket)
+	} else {
+		rpcAddress := ctx.String(utils.GetFlagName(utils.CliAddressFlag))
+		rpcPort := ctx.Uint(utils.GetFlagName(utils.CliRpcPortFlag))
+		if rpcPort == 0 {
+			log.Errorf("Please using sig server port by --%s flag", utils.GetFlagName(utils.CliRpcPortFlag))
+			return
+		}
+		go cmdsvr.DefCliRpcSvr.Start(rpcAddress, rpcPort)
+		log.Infof("Sig server init success")
+		log.Infof("Sig server listing on: %s:%d", rpcAddress, rpcPort)
+	}
 
 	exit := make(chan bool, 0)
 	sc := make(chan os.Signal, 1)
@@ -50,6 +50,12 @@ func SetOntologyConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 		cfg.P2PNode.NetworkMagic = config.GetNetworkMagic(cfg.P2PNode.NetworkId)
 		cfg.Common.GasPrice = 0
 	}
+	if cfg.Common.LightMode {
+		if cfg.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
+			return nil, fmt.Errorf("light mode is not supported in test mode")
+		}
+		cfg.Consensus.EnableConsensus = false
+	}
 	if cfg.P2PNode.NetworkId == config.NETWORK_ID_MAIN_NET ||
 		cfg.P2PNode.NetworkId == config.NETWORK_ID_POLARIS_NET {
 		defNetworkId, err := cfg.GetDefaultNetworkId()
@@ -87,6 +93,13 @@ func setGenesis(ctx *cli.Context, cfg *config.OntologyConfig) error {
 		if cfg.Genesis.SOLO.GenBlockTime <= 1 {
 			cfg.Genesis.SOLO.GenBlockTime = config.DEFAULT_GEN_BLOCK_TIME
 		}
+		sealMode := ctx.String(utils.GetFlagName(utils.TestModeSealModeFlag))
+		switch sealMode {
+		case config.SOLO_SEAL_MODE_INTERVAL, config.SOLO_SEAL_MODE_INSTANT, config.SOLO_SEAL_MODE_MANUAL:
+			cfg.Genesis.SOLO.SealMode = sealMode
+		default:
+			return fmt.Errorf("unknown seal mode:%s", sealMode)
+		}
 		return nil
 	}
 
@@ -136,6 +149,7 @@ func setCommonConfig(ctx *cli.Context, cfg *config.CommonConfig) {
 	cfg.GasLimit = ctx.Uint64(utils.GetFlagName(utils.GasLimitFlag))
 	cfg.GasPrice = ctx.Uint64(utils.GetFlagName(utils.GasPriceFlag))
 	cfg.DataDir = ctx.String(utils.GetFlagName(utils.DataDirFlag))
+	cfg.LightMode = ctx.Bool(utils.GetFlagName(utils.LightModeFlag))
 }
 
 func setConsensusConfig(ctx *cli.Context, cfg *config.ConsensusConfig) {
@@ -153,6 +167,10 @@ func setP2PNodeConfig(ctx *cli.Context, cfg *config.P2PNodeConfig) {
 	cfg.MaxConnInBound = ctx.Uint(utils.GetFlagName(utils.MaxConnInBoundFlag))
 	cfg.MaxConnOutBound = ctx.Uint(utils.GetFlagName(utils.MaxConnOutBoundFlag))
 	cfg.MaxConnInBoundForSingleIP = ctx.Uint(utils.GetFlagName(utils.MaxConnInBoundForSingleIPFlag))
+	cfg.DisableEncryption = ctx.Bool(utils.GetFlagName(utils.DisableP2PEncryptionFlag))
+	cfg.DisableCompression = ctx.Bool(utils.GetFlagName(utils.DisableP2PCompressionFlag))
+	cfg.ExternalAddr = ctx.String(utils.GetFlagName(utils.ExternalAddrFlag))
+	cfg.NAT = ctx.String(utils.GetFlagName(utils.NATFlag))
 
 	rsvfile := ctx.String(utils.GetFlagName(utils.ReservedPeersFileFlag))
 	if cfg.ReservedPeersOnly {
@@ -197,3 +215,9 @@ func SetRpcPort(ctx *cli.Context) {
 		config.DefConfig.Rpc.HttpJsonPort = ctx.Uint(utils.GetFlagName(utils.RPCPortFlag))
 	}
 }
+
+func SetLocalRpcPort(ctx *cli.Context) {
+	if ctx.IsSet(utils.GetFlagName(utils.RPCLocalProtFlag)) {
+		config.DefConfig.Rpc.HttpLocalPort = ctx.Uint(utils.GetFlagName(utils.RPCLocalProtFlag))
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/core/payload"
 	httpcom "github.com/ontio/ontology/http/base/common"
+	"github.com/ontio/ontology/vm/crossvm_codec"
 	"github.com/urfave/cli"
 )
 
@@ -52,6 +53,7 @@ var (
 					utils.TransactionGasLimitFlag,
 					utils.ContractVmTypeFlag,
 					utils.ContractCodeFileFlag,
+					utils.ContractAbiFileFlag,
 					utils.ContractNameFlag,
 					utils.ContractVersionFlag,
 					utils.ContractAuthorFlag,
@@ -82,6 +84,11 @@ var (
      Return type support bytearray(encoded to hex string), string, integer, boolean. 
      If return type is object array, enclose array with '[]'. 
      For example: [string,int,bool,string]
+
+  Wasm contract abi
+     If the wasm contract is deployed with abi, the first parameter is method name and the others are typed by abi, 
+     so the type prefix can be omitted. Return value and notify are also decoded by abi if --return flag is not set.
+     For example: transfer,AGc7Ehz4ZdtQ8qdgNSxdA7DgngZWq64uUM,AbP3Hy3YtuuvYGEQ4fyqxyw6hoMYVpGXnC,100
 `,
 				Flags: []cli.Flag{
 					utils.RPCPortFlag,
@@ -112,6 +119,36 @@ var (
 					utils.AccountAddressFlag,
 				},
 			},
+			{
+				Action: debugContract,
+				Name:   "debug",
+				Usage:  "Debug neovm smart contract invocation step by step",
+				ArgsUsage: `The invocation is pre-executed by the local rpc server of node, and paused at the first opcode.
+
+  Contract is specified by --address and --params as invoke command, or by --code as invokecode command.
+
+  Breakpoint
+     Breakpoint is an offset or an opcode name, and can be prefixed with contract address to limit the contract.
+     For example: 12,SYSCALL,AFmseVrdL9f9oyCzZefL9tG6UbvhUMqNMV:30
+
+  Debug commands
+     step, s [count]     Execute next opcode, called contracts are stepped into
+     continue, c         Continue until a breakpoint is hit or the execution finished
+     break, b <bp>       Add breakpoint
+     delete, d [index]   Delete breakpoint of index, or all breakpoints
+     breakpoints, bl     List breakpoints
+     stack               Print evaluation and alt stack
+     storage             Print storage of current contract
+     quit, q             Stop debugging
+`,
+				Flags: []cli.Flag{
+					utils.RPCLocalProtFlag,
+					utils.ContractAddrFlag,
+					utils.ContractParamsFlag,
+					utils.ContractCodeFileFlag,
+					utils.ContractBreakpointFlag,
+				},
+			},
 		},
 	}
 )
@@ -140,12 +177,21 @@ func deployContract(ctx *cli.Context) error {
 		return fmt.Errorf("read code:%s error:%s", codeFile, err)
 	}
 
+	code := strings.TrimSpace(string(codeStr))
+	if vmtype == payload.WASMVM_TYPE {
+		code, err = prepareWasmCodeAbi(ctx, code)
+		if err != nil {
+			return err
+		}
+	} else if ctx.IsSet(utils.GetFlagName(utils.ContractAbiFileFlag)) {
+		return fmt.Errorf("%s only support wasm contract", utils.ContractAbiFileFlag.Name)
+	}
+
 	name := ctx.String(utils.GetFlagName(utils.ContractNameFlag))
 	version := ctx.String(utils.GetFlagName(utils.ContractVersionFlag))
 	author := ctx.String(utils.GetFlagName(utils.ContractAuthorFlag))
 	email := ctx.String(utils.GetFlagName(utils.ContractEmailFlag))
 	desc := ctx.String(utils.GetFlagName(utils.ContractDescFlag))
-	code := strings.TrimSpace(string(codeStr))
 	gasPrice := ctx.Uint64(utils.GetFlagName(utils.TransactionGasPriceFlag))
 	gasLimit := ctx.Uint64(utils.GetFlagName(utils.TransactionGasLimitFlag))
 	networkId, err := utils.GetNetworkId()
@@ -190,6 +236,35 @@ func deployContract(ctx *cli.Context) error {
 	return nil
 }
 
+//prepareWasmCodeAbi embed the abi file to wasm code if specified, and validate the ontio_abi section of code
+func prepareWasmCodeAbi(ctx *cli.Context, code string) (string, error) {
+	c, err := common.HexToBytes(code)
+	if err != nil {
+		return "", fmt.Errorf("contract code convert hex to bytes error:%s", err)
+	}
+	abiFile := ctx.String(utils.GetFlagName(utils.ContractAbiFileFlag))
+	if abiFile == "" {
+		_, err = crossvm_codec.ReadWasmContractAbi(c)
+		if err != nil {
+			return "", fmt.Errorf("invalid wasm contract abi:%s", err)
+		}
+		return code, nil
+	}
+	abiData, err := ioutil.ReadFile(abiFile)
+	if err != nil {
+		return "", fmt.Errorf("read abi:%s error:%s", abiFile, err)
+	}
+	contractAbi, err := utils.NewWasmContractAbi(abiData)
+	if err != nil {
+		return "", err
+	}
+	c, err = crossvm_codec.AppendAbiSection(c, contractAbi)
+	if err != nil {
+		return "", fmt.Errorf("append abi to code error:%s", err)
+	}
+	return common.ToHexString(c), nil
+}
+
 func invokeCodeContract(ctx *cli.Context) error {
 	SetRpcPort(ctx)
 	if !ctx.IsSet(utils.GetFlagName(utils.ContractCodeFileFlag)) {
@@ -299,8 +374,21 @@ func invokeContract(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	var contractAbi *crossvm_codec.WasmContractAbi
+	if vmtype == payload.WASMVM_TYPE {
+		contractAbi, err = utils.GetContractAbi(contractAddr)
+		if err != nil {
+			PrintWarnMsg("Get contract abi error:%s, params will not be typed by abi", err)
+		}
+	}
 	paramsStr := ctx.String(utils.GetFlagName(utils.ContractParamsFlag))
-	params, err := utils.ParseParams(paramsStr)
+	var params []interface{}
+	var methodAbi *crossvm_codec.WasmMethodAbi
+	if contractAbi != nil {
+		params, methodAbi, err = utils.ParseWasmFunc(paramsStr, contractAbi)
+	} else {
+		params, err = utils.ParseParams(paramsStr)
+	}
 	if err != nil {
 		return fmt.Errorf("parseParams error:%s", err)
 	}
@@ -327,8 +415,27 @@ func invokeContract(ctx *cli.Context) error {
 
 		PrintInfoMsg("Contract invoke successfully")
 		PrintInfoMsg("  Gas limit:%d", preResult.Gas)
+		if contractAbi != nil {
+			for _, notify := range preResult.Notify {
+				if notify.ContractAddress != contractAddr.ToHexString() {
+					continue
+				}
+				if name, fields, ok := utils.ParseWasmNotify(notify.States, contractAbi); ok {
+					PrintInfoMsg("  Event:%s %+v", name, fields)
+				}
+			}
+		}
 
 		rawReturnTypes := ctx.String(utils.GetFlagName(utils.ContractReturnTypeFlag))
+		if rawReturnTypes == "" && methodAbi != nil {
+			rawValue, _ := preResult.Result.(string)
+			value, err := utils.ParseWasmReturnValue(rawValue, methodAbi.ReturnType)
+			if err != nil {
+				return fmt.Errorf("parseReturnValue value:%s type:%s error:%s", rawValue, methodAbi.ReturnType, err)
+			}
+			PrintInfoMsg("  Return:%+v", value)
+			return nil
+		}
 		if rawReturnTypes == "" {
 			PrintInfoMsg("  Return:%s (raw value)", preResult.Result)
 			return nil
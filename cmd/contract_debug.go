@@ -0,0 +1,228 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	httpcom "github.com/ontio/ontology/http/base/common"
+	"github.com/ontio/ontology/smartcontract/debugger"
+	"github.com/urfave/cli"
+)
+
+func debugContract(ctx *cli.Context) error {
+	SetLocalRpcPort(ctx)
+	mutable, err := debugTransaction(ctx)
+	if err != nil {
+		return err
+	}
+	if mutable == nil {
+		PrintErrorMsg("Missing %s or %s argument.", utils.ContractAddrFlag.Name, utils.ContractCodeFileFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		return err
+	}
+	breakpoints, err := utils.ParseDebugBreakpoints(ctx.String(utils.GetFlagName(utils.ContractBreakpointFlag)))
+	if err != nil {
+		return fmt.Errorf("parse breakpoints error:%s", err)
+	}
+
+	status, err := utils.DebugStart(hex.EncodeToString(common.SerializeToBytes(tx)), true, breakpoints)
+	if err != nil {
+		return fmt.Errorf("start debug session error:%s", err)
+	}
+	PrintInfoMsg("Debug session:%d", status.Session)
+	printDebugStatus(status)
+
+	reader := bufio.NewReader(os.Stdin)
+	for status.State != debugger.STATE_FINISHED {
+		fmt.Print("(debug) ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			_, err = utils.DebugStop(status.Session)
+			return err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		var next *httpcom.DebugStatus
+		switch fields[0] {
+		case "step", "s":
+			count := 1
+			if len(fields) > 1 {
+				count, err = strconv.Atoi(fields[1])
+				if err != nil || count <= 0 {
+					PrintErrorMsg("Invalid step count:%s", fields[1])
+					continue
+				}
+			}
+			for i := 0; i < count; i++ {
+				next, err = utils.DebugStep(status.Session)
+				if err != nil || next.State == debugger.STATE_FINISHED {
+					break
+				}
+			}
+		case "continue", "c":
+			next, err = utils.DebugContinue(status.Session)
+		case "break", "b":
+			if len(fields) < 2 {
+				PrintErrorMsg("Missing breakpoint")
+				continue
+			}
+			bp, err := utils.ParseDebugBreakpoint(fields[1])
+			if err != nil {
+				PrintErrorMsg("Invalid breakpoint:%s", err)
+				continue
+			}
+			if err = utils.DebugSetBreakpoints(status.Session, append(breakpoints, bp)); err != nil {
+				PrintErrorMsg("Set breakpoints error:%s", err)
+				continue
+			}
+			breakpoints = append(breakpoints, bp)
+			PrintInfoMsg("Breakpoint %d:%s", len(breakpoints)-1, bp)
+		case "delete", "d":
+			remain := make([]utils.DebugBreakpoint, 0, len(breakpoints))
+			if len(fields) > 1 {
+				index, err := strconv.Atoi(fields[1])
+				if err != nil || index < 0 || index >= len(breakpoints) {
+					PrintErrorMsg("Invalid breakpoint index:%s", fields[1])
+					continue
+				}
+				remain = append(remain, breakpoints[:index]...)
+				remain = append(remain, breakpoints[index+1:]...)
+			}
+			if err = utils.DebugSetBreakpoints(status.Session, remain); err != nil {
+				PrintErrorMsg("Set breakpoints error:%s", err)
+				continue
+			}
+			breakpoints = remain
+		case "breakpoints", "bl":
+			for i, bp := range breakpoints {
+				PrintInfoMsg("  %d:%s", i, bp)
+			}
+		case "stack":
+			printDebugStack(status)
+		case "storage":
+			printDebugStorage(status)
+		case "quit", "q":
+			next, err = utils.DebugStop(status.Session)
+		default:
+			PrintErrorMsg("Unknown command:%s", fields[0])
+		}
+		if err != nil {
+			return err
+		}
+		if next != nil {
+			status = next
+			printDebugStatus(status)
+		}
+	}
+	return nil
+}
+
+//debugTransaction return the neovm transaction to debug, nil if the contract is not specified
+func debugTransaction(ctx *cli.Context) (*types.MutableTransaction, error) {
+	if ctx.IsSet(utils.GetFlagName(utils.ContractCodeFileFlag)) {
+		codeFile := ctx.String(utils.GetFlagName(utils.ContractCodeFileFlag))
+		codeStr, err := ioutil.ReadFile(codeFile)
+		if err != nil {
+			return nil, fmt.Errorf("read code:%s error:%s", codeFile, err)
+		}
+		code, err := common.HexToBytes(strings.TrimSpace(string(codeStr)))
+		if err != nil {
+			return nil, fmt.Errorf("contract code convert hex to bytes error:%s", err)
+		}
+		return httpcom.NewSmartContractTransaction(0, 0, code)
+	}
+	if !ctx.IsSet(utils.GetFlagName(utils.ContractAddrFlag)) {
+		return nil, nil
+	}
+	contractAddrStr := ctx.String(utils.GetFlagName(utils.ContractAddrFlag))
+	contractAddr, err := common.AddressFromHexString(contractAddrStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contract address error:%s", err)
+	}
+	params, err := utils.ParseParams(ctx.String(utils.GetFlagName(utils.ContractParamsFlag)))
+	if err != nil {
+		return nil, fmt.Errorf("parseParams error:%s", err)
+	}
+	return httpcom.NewNeovmInvokeTransaction(0, 0, contractAddr, params)
+}
+
+func printDebugStatus(status *httpcom.DebugStatus) {
+	if status.Snapshot != nil {
+		snapshot := status.Snapshot
+		top := "<empty>"
+		if len(snapshot.EvalStack) > 0 {
+			top = utils.DebugStackItem(snapshot.EvalStack[0])
+		}
+		PrintInfoMsg("Paused at %s:%d %s depth:%d top:%s", snapshot.Contract, snapshot.Offset, snapshot.OpCode,
+			snapshot.CallDepth, top)
+		return
+	}
+	PrintInfoMsg("Execution finished")
+	if status.Error != "" {
+		PrintErrorMsg("  Error:%s", status.Error)
+	}
+	if status.Result != nil {
+		PrintInfoMsg("  State:%d", status.Result.State)
+		PrintInfoMsg("  Gas:%d", status.Result.Gas)
+		PrintInfoMsg("  Return:%s (raw value)", status.Result.Result)
+		for _, notify := range status.Result.Notify {
+			PrintInfoMsg("  Notify:%s %v", notify.ContractAddress, notify.States)
+		}
+	}
+}
+
+func printDebugStack(status *httpcom.DebugStatus) {
+	if status.Snapshot == nil {
+		return
+	}
+	PrintInfoMsg("Evaluation stack:")
+	for i, item := range status.Snapshot.EvalStack {
+		PrintInfoMsg("  %d:%s", i, utils.DebugStackItem(item))
+	}
+	PrintInfoMsg("Alt stack:")
+	for i, item := range status.Snapshot.AltStack {
+		PrintInfoMsg("  %d:%s", i, utils.DebugStackItem(item))
+	}
+}
+
+func printDebugStorage(status *httpcom.DebugStatus) {
+	if status.Snapshot == nil {
+		return
+	}
+	PrintInfoMsg("Storage of %s:", status.Snapshot.Contract)
+	for _, item := range status.Snapshot.Storage {
+		PrintInfoMsg("  %s:%s", item.Key, item.Value)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/urfave/cli"
+)
+
+//ForkCommand starts a test mode node forked from an existing ledger. Action of the command is set by main package,
+//and all node flags are accepted besides the fork flags.
+var ForkCommand = cli.Command{
+	Name:      "fork",
+	Usage:     "Start a test mode node forked from an existing ledger",
+	ArgsUsage: "",
+	Flags: []cli.Flag{
+		utils.ForkSourceFlag,
+		utils.ForkDataDirFlag,
+	},
+	Description: "Fork starts a solo node whose states are read through from the source ledger at its current block height. " +
+		"Transactions execute against the contracts and balances of source ledger, while new blocks are produced " +
+		"locally and saved to fork dir only. The source ledger is never modified. Other flags are the same as test mode.",
+}
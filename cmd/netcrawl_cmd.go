@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/p2pserver/crawler"
+	"github.com/urfave/cli"
+)
+
+var NetCrawlCommand = cli.Command{
+	Name:      "netcrawl",
+	Usage:     "Crawl the p2p network to collect node information and topology",
+	ArgsUsage: "",
+	Action:    netCrawl,
+	Flags: []cli.Flag{
+		utils.NetworkIdFlag,
+		utils.ConfigFlag,
+		utils.NetCrawlSeedsFlag,
+		utils.NetCrawlFormatFlag,
+		utils.NetCrawlOutputFlag,
+		utils.NetCrawlDotFlag,
+		utils.NetCrawlTimeoutFlag,
+		utils.NetCrawlConcurrencyFlag,
+		utils.NetCrawlMaxNodesFlag,
+		utils.DisableP2PEncryptionFlag,
+		utils.LogLevelFlag,
+	},
+	Description: "Netcrawl connects to the seed nodes, queries their neighbors by address and dht find node requests, " +
+		"and visits all the reachable nodes. Version, height, services and ping latency of each node are written " +
+		"in json or csv, and the topology can be written in dot format for graphviz.",
+}
+
+func netCrawl(ctx *cli.Context) error {
+	// keep stdout clean for the output
+	log.InitLog(ctx.Int(utils.GetFlagName(utils.LogLevelFlag)), os.Stderr)
+
+	// messages are framed with the magic of network
+	p2pCfg := config.DefConfig.P2PNode
+	p2pCfg.NetworkId = uint32(ctx.Uint(utils.GetFlagName(utils.NetworkIdFlag)))
+	p2pCfg.NetworkMagic = config.GetNetworkMagic(p2pCfg.NetworkId)
+	p2pCfg.NetworkName = config.GetNetworkName(p2pCfg.NetworkId)
+
+	seeds, err := crawlSeeds(ctx)
+	if err != nil {
+		return err
+	}
+	format := ctx.String(utils.GetFlagName(utils.NetCrawlFormatFlag))
+	var write func(io.Writer, *crawler.Result) error
+	switch format {
+	case "json":
+		write = crawler.WriteJSON
+	case "csv":
+		write = crawler.WriteCSV
+	default:
+		return fmt.Errorf("unknown output format:%s", format)
+	}
+
+	c := crawler.NewCrawler()
+	c.Timeout = time.Duration(ctx.Uint(utils.GetFlagName(utils.NetCrawlTimeoutFlag))) * time.Second
+	c.Concurrency = int(ctx.Uint(utils.GetFlagName(utils.NetCrawlConcurrencyFlag)))
+	c.MaxNodes = int(ctx.Uint(utils.GetFlagName(utils.NetCrawlMaxNodesFlag)))
+	c.Encrypt = !ctx.Bool(utils.GetFlagName(utils.DisableP2PEncryptionFlag))
+	if c.Timeout == 0 {
+		c.Timeout = crawler.DEFAULT_TIMEOUT
+	}
+
+	start := time.Now()
+	result := c.Crawl(seeds)
+	log.Infof("crawled %d nodes, %d unreachable, cost %s", len(result.Nodes), len(result.Unreachable),
+		time.Since(start))
+
+	err = writeCrawlFile(ctx.String(utils.GetFlagName(utils.NetCrawlOutputFlag)), result, write)
+	if err != nil {
+		return err
+	}
+	dotFile := ctx.String(utils.GetFlagName(utils.NetCrawlDotFlag))
+	if dotFile != "" {
+		return writeCrawlFile(dotFile, result, crawler.WriteDOT)
+	}
+	return nil
+}
+
+func crawlSeeds(ctx *cli.Context) ([]string, error) {
+	if seeds := ctx.String(utils.GetFlagName(utils.NetCrawlSeedsFlag)); seeds != "" {
+		return strings.Split(seeds, ","), nil
+	}
+	if ctx.IsSet(utils.GetFlagName(utils.ConfigFlag)) {
+		genesis := config.NewGenesisConfig()
+		err := utils.GetJsonObjectFromFile(ctx.String(utils.GetFlagName(utils.ConfigFlag)), genesis)
+		if err != nil {
+			return nil, fmt.Errorf("load genesis config error:%s", err)
+		}
+		return genesis.SeedList, nil
+	}
+	switch ctx.Uint(utils.GetFlagName(utils.NetworkIdFlag)) {
+	case config.NETWORK_ID_MAIN_NET:
+		return config.MainNetConfig.SeedList, nil
+	case config.NETWORK_ID_POLARIS_NET:
+		return config.PolarisConfig.SeedList, nil
+	}
+	return nil, fmt.Errorf("missing %s argument", utils.NetCrawlSeedsFlag.Name)
+}
+
+//writeCrawlFile writes to stdout if file is empty
+func writeCrawlFile(file string, result *crawler.Result, write func(io.Writer, *crawler.Result) error) error {
+	if file == "" {
+		return write(os.Stdout, result)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return write(f, result)
+}
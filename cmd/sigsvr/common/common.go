@@ -21,13 +21,57 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/cmd/sigsvr/store"
+	"github.com/ontio/ontology/consensus/signer"
 )
 
 var DefWalletStore *store.WalletStore
 
+// DefSignGuardDir is the path to save the double sign guard records of block signing accounts
+var DefSignGuardDir string
+
+// DefConsensusAccounts are the addresses of consensus signing accounts, whose block and consensus data
+// can only be signed by sigblock or sigconsensus
+var DefConsensusAccounts = make(map[string]bool)
+
+// IsConsensusAccount returns true if address is configured as a consensus signing account
+func IsConsensusAccount(address string) bool {
+	return DefConsensusAccounts[address]
+}
+
+var signGuardLock sync.Mutex
+var signGuards = make(map[string]*signer.DoubleSignGuard)
+
+// GetSignGuard returns the double sign guard of account address
+func GetSignGuard(address string) (*signer.DoubleSignGuard, error) {
+	signGuardLock.Lock()
+	defer signGuardLock.Unlock()
+
+	guard, ok := signGuards[address]
+	if ok {
+		return guard, nil
+	}
+	if DefSignGuardDir == "" {
+		guard = signer.NewMemDoubleSignGuard()
+	} else {
+		err := os.MkdirAll(DefSignGuardDir, 0700)
+		if err != nil {
+			return nil, err
+		}
+		guard, err = signer.NewDoubleSignGuard(filepath.Join(DefSignGuardDir, address+".json"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	signGuards[address] = guard
+	return guard, nil
+}
+
 type CliRpcRequest struct {
 	Qid     string          `json:"qid"`
 	Params  json.RawMessage `json:"params"`
@@ -29,6 +29,7 @@ const (
 	CLIERR_ABI_NOT_FOUND       = 1007
 	CLIERR_ABI_UNMATCH         = 1008
 	CLIERR_DUPLICATE_SIG       = 1009
+	CLIERR_DOUBLE_SIGN         = 1010
 	CLIERR_INTERNAL_ERR        = 900
 )
 
@@ -42,6 +43,7 @@ var RPCErrorDesc = map[int]string{
 	CLIERR_ABI_NOT_FOUND:       "abi not found",
 	CLIERR_ABI_UNMATCH:         "abi unmatch",
 	CLIERR_DUPLICATE_SIG:       "Duplicate sig",
+	CLIERR_DOUBLE_SIGN:         "double sign refused",
 	CLIERR_INTERNAL_ERR:        "internal error",
 }
 
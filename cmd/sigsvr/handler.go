@@ -24,6 +24,10 @@ func init() {
 	DefCliRpcSvr.RegHandler("createaccount", handlers.CreateAccount)
 	DefCliRpcSvr.RegHandler("exportaccount", handlers.ExportAccount)
 	DefCliRpcSvr.RegHandler("sigdata", handlers.SigData)
+	DefCliRpcSvr.RegHandler("sigblock", handlers.SigBlock)
+	DefCliRpcSvr.RegHandler("sigconsensus", handlers.SigConsensus)
+	DefCliRpcSvr.RegHandler("computevrf", handlers.ComputeVrf)
+	DefCliRpcSvr.RegHandler("getpublickey", handlers.GetPublicKey)
 	DefCliRpcSvr.RegHandler("sigrawtx", handlers.SigRawTransaction)
 	DefCliRpcSvr.RegHandler("sigmutilrawtx", handlers.SigMutilRawTransaction)
 	DefCliRpcSvr.RegHandler("sigtransfertx", handlers.SigTransferTransaction)
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/ontio/ontology-crypto/vrf"
+	clisvrcom "github.com/ontio/ontology/cmd/sigsvr/common"
+	"github.com/ontio/ontology/common/log"
+)
+
+type ComputeVrfReq struct {
+	RawData string `json:"raw_data"`
+}
+
+type ComputeVrfRsp struct {
+	VrfValue string `json:"vrf_value"`
+	VrfProof string `json:"vrf_proof"`
+}
+
+func ComputeVrf(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &ComputeVrfReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	rawData, err := hex.DecodeString(rawReq.RawData)
+	if err != nil {
+		log.Infof("Cli Qid:%s ComputeVrf hex.DecodeString error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	signer, err := req.GetAccount()
+	if err != nil {
+		log.Infof("Cli Qid:%s ComputeVrf GetAccount:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	value, proof, err := vrf.Vrf(signer.PrivateKey, rawData)
+	if err != nil {
+		log.Infof("Cli Qid:%s ComputeVrf vrf error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	resp.Result = &ComputeVrfRsp{
+		VrfValue: hex.EncodeToString(value),
+		VrfProof: hex.EncodeToString(proof),
+	}
+}
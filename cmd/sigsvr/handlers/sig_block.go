@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	clisvrcom "github.com/ontio/ontology/cmd/sigsvr/common"
+	cliutil "github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+)
+
+type SigBlockReq struct {
+	Kind      string `json:"kind"`
+	Height    uint32 `json:"height"`
+	BlockHash string `json:"block_hash"`
+}
+
+type SigBlockRsp struct {
+	SignedData string `json:"signed_data"`
+}
+
+// SigBlock signs the block hash for consensus, and refuses to sign two different blocks of the same kind at the same height
+func SigBlock(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigBlockReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	blockHash, err := common.Uint256FromHexString(rawReq.BlockHash)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigBlock Uint256FromHexString error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	signer, err := req.GetAccount()
+	if err != nil {
+		log.Infof("Cli Qid:%s SigBlock GetAccount:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	guard, err := clisvrcom.GetSignGuard(signer.Address.ToBase58())
+	if err != nil {
+		log.Infof("Cli Qid:%s SigBlock GetSignGuard error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	err = guard.Check(rawReq.Kind, rawReq.Height, blockHash)
+	if err != nil {
+		log.Warnf("Cli Qid:%s SigBlock %s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_DOUBLE_SIGN
+		resp.ErrorInfo = err.Error()
+		return
+	}
+	sigData, err := cliutil.Sign(blockHash[:], signer)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigBlock Sign error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	resp.Result = &SigBlockRsp{
+		SignedData: hex.EncodeToString(sigData),
+	}
+}
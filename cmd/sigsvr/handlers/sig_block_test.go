@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	clisvrcom "github.com/ontio/ontology/cmd/sigsvr/common"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/consensus/signer"
+)
+
+func TestSigBlock(t *testing.T) {
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	sigBlock := func(height uint32, blockHash common.Uint256) *clisvrcom.CliRpcResponse {
+		data, err := json.Marshal(&SigBlockReq{
+			Kind:      signer.KIND_BLOCK,
+			Height:    height,
+			BlockHash: blockHash.ToHexString(),
+		})
+		if err != nil {
+			t.Fatalf("json.Marshal SigBlockReq error:%s", err)
+		}
+		req := &clisvrcom.CliRpcRequest{
+			Qid:     "t",
+			Method:  "sigblock",
+			Params:  data,
+			Account: defAcc.Address.ToBase58(),
+			Pwd:     string(pwd),
+		}
+		resp := &clisvrcom.CliRpcResponse{}
+		SigBlock(req, resp)
+		return resp
+	}
+
+	blockHash := common.Uint256{1, 2, 3}
+	if resp := sigBlock(100, blockHash); resp.ErrorCode != 0 {
+		t.Errorf("SigBlock failed. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	if resp := sigBlock(100, blockHash); resp.ErrorCode != 0 {
+		t.Errorf("SigBlock same block again failed. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	if resp := sigBlock(100, common.Uint256{4, 5, 6}); resp.ErrorCode != clisvrcom.CLIERR_DOUBLE_SIGN {
+		t.Errorf("SigBlock different block at same height should be refused. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	if resp := sigBlock(101, common.Uint256{4, 5, 6}); resp.ErrorCode != 0 {
+		t.Errorf("SigBlock next height failed. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+}
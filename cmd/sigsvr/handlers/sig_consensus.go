@@ -0,0 +1,92 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	clisvrcom "github.com/ontio/ontology/cmd/sigsvr/common"
+	cliutil "github.com/ontio/ontology/cmd/utils"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
+)
+
+type SigConsensusReq struct {
+	RawData string `json:"raw_data"`
+}
+
+type SigConsensusRsp struct {
+	SignedData string `json:"signed_data"`
+}
+
+// SigConsensus signs the unsigned serialization of a p2p consensus payload, any other data is refused
+func SigConsensus(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
+	rawReq := &SigConsensusReq{}
+	err := json.Unmarshal(req.Params, rawReq)
+	if err != nil {
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	rawData, err := hex.DecodeString(rawReq.RawData)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigConsensus hex.DecodeString error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	if !isConsensusData(rawData) {
+		log.Warnf("Cli Qid:%s SigConsensus refuse to sign data which is not consensus payload", req.Qid)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		return
+	}
+	signer, err := req.GetAccount()
+	if err != nil {
+		log.Infof("Cli Qid:%s SigConsensus GetAccount:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
+		return
+	}
+	sigData, err := cliutil.Sign(rawData, signer)
+	if err != nil {
+		log.Infof("Cli Qid:%s SigConsensus Sign error:%s", req.Qid, err)
+		resp.ErrorCode = clisvrcom.CLIERR_INTERNAL_ERR
+		return
+	}
+	resp.Result = &SigConsensusRsp{
+		SignedData: hex.EncodeToString(sigData),
+	}
+}
+
+//isConsensusData returns true if data is the unsigned serialization of a p2p consensus payload
+func isConsensusData(data []byte) bool {
+	source := common.NewZeroCopySource(data)
+	payload := &p2pmsg.ConsensusPayload{}
+	return payload.DeserializationUnsigned(source) == nil && source.Len() == 0
+}
+
+//isBlockData returns true if data may be a block hash, which must be signed with the double sign guard of sigblock,
+//or a serialized block or header
+func isBlockData(data []byte) bool {
+	if len(data) == common.UINT256_SIZE {
+		return true
+	}
+	_, err := types.HeaderFromRawBytes(data)
+	return err == nil
+}
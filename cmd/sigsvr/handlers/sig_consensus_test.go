@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	clisvrcom "github.com/ontio/ontology/cmd/sigsvr/common"
+	"github.com/ontio/ontology/common"
+	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
+)
+
+func TestSigConsensus(t *testing.T) {
+	defAcc, err := testWallet.GetDefaultAccount(pwd)
+	if err != nil {
+		t.Errorf("GetDefaultAccount error:%s", err)
+		return
+	}
+	sign := func(method string, rawData []byte) *clisvrcom.CliRpcResponse {
+		data, err := json.Marshal(&SigConsensusReq{RawData: hex.EncodeToString(rawData)})
+		if err != nil {
+			t.Fatalf("json.Marshal SigConsensusReq error:%s", err)
+		}
+		req := &clisvrcom.CliRpcRequest{
+			Qid:     "t",
+			Method:  method,
+			Params:  data,
+			Account: defAcc.Address.ToBase58(),
+			Pwd:     string(pwd),
+		}
+		resp := &clisvrcom.CliRpcResponse{}
+		if method == "sigdata" {
+			SigData(req, resp)
+		} else {
+			SigConsensus(req, resp)
+		}
+		return resp
+	}
+
+	payload := &p2pmsg.ConsensusPayload{Height: 100, Data: []byte("consensus msg")}
+	sink := common.NewZeroCopySink(nil)
+	payload.SerializationUnsigned(sink)
+	if resp := sign("sigconsensus", sink.Bytes()); resp.ErrorCode != 0 {
+		t.Errorf("SigConsensus failed. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	if resp := sign("sigconsensus", []byte("HelloWorld")); resp.ErrorCode != clisvrcom.CLIERR_INVALID_PARAMS {
+		t.Errorf("SigConsensus should refuse data which is not consensus payload. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	blockHash := common.Uint256{1, 2, 3}
+	if resp := sign("sigdata", blockHash[:]); resp.ErrorCode != 0 {
+		t.Errorf("SigData should sign digest of account which is not consensus account. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	clisvrcom.DefConsensusAccounts[defAcc.Address.ToBase58()] = true
+	defer delete(clisvrcom.DefConsensusAccounts, defAcc.Address.ToBase58())
+	if resp := sign("sigdata", sink.Bytes()); resp.ErrorCode != clisvrcom.CLIERR_INVALID_PARAMS {
+		t.Errorf("SigData should refuse consensus payload. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+	if resp := sign("sigdata", blockHash[:]); resp.ErrorCode != clisvrcom.CLIERR_INVALID_PARAMS {
+		t.Errorf("SigData should refuse block hash. ErrorCode:%d", resp.ErrorCode)
+		return
+	}
+}
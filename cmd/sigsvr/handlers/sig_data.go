@@ -53,6 +53,12 @@ func SigData(req *clisvrcom.CliRpcRequest, resp *clisvrcom.CliRpcResponse) {
 		resp.ErrorCode = clisvrcom.CLIERR_ACCOUNT_UNLOCK
 		return
 	}
+	if clisvrcom.IsConsensusAccount(signer.Address.ToBase58()) && (isBlockData(rawData) || isConsensusData(rawData)) {
+		log.Warnf("Cli Qid:%s SigData refuse to sign block or consensus data by consensus account", req.Qid)
+		resp.ErrorCode = clisvrcom.CLIERR_INVALID_PARAMS
+		resp.ErrorInfo = "block and consensus data of consensus account must be signed by sigblock or sigconsensus"
+		return
+	}
 	sigData, err := cliutil.Sign(rawData, signer)
 	if err != nil {
 		log.Infof("Cli Qid:%s SigData Sign error:%s", req.Qid, err)
@@ -22,7 +22,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
 
 	"github.com/ontio/ontology/cmd/sigsvr/common"
 	"github.com/ontio/ontology/common/log"
@@ -62,6 +64,31 @@ func (this *CliRpcServer) Start(address string, port uint) {
 	}
 }
 
+//StartUnix serves the cli rpc on unix socket, so that only local processes could access it
+func (this *CliRpcServer) StartUnix(sockPath string) {
+	this.httpSvtMux = http.NewServeMux()
+	this.httpSvr = &http.Server{
+		Handler: this.httpSvtMux,
+	}
+	this.httpSvtMux.HandleFunc("/cli", this.Handler)
+	os.Remove(sockPath)
+	listener, err := net.Listen("unix", sockPath)
+	if err != nil {
+		panic(fmt.Sprintf("listen unix socket %s error:%s", sockPath, err))
+	}
+	err = os.Chmod(sockPath, 0600)
+	if err != nil {
+		panic(fmt.Sprintf("chmod unix socket %s error:%s", sockPath, err))
+	}
+	err = this.httpSvr.Serve(listener)
+	if err != nil {
+		if err == http.ErrServerClosed {
+			return
+		}
+		panic(fmt.Sprintf("httpSvr.Serve error:%s", err))
+	}
+}
+
 func (this *CliRpcServer) RegHandler(method string, handler func(req *common.CliRpcRequest, resp *common.CliRpcResponse)) {
 	this.handlers[method] = handler
 }
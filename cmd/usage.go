@@ -104,6 +104,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.DisableLogFileFlag,
 			utils.DisableEventLogFlag,
 			utils.DataDirFlag,
+			utils.LightModeFlag,
 			utils.WasmVerifyMethodFlag,
 		},
 	},
@@ -135,6 +136,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableConsensusFlag,
 			utils.MaxTxInBlockFlag,
+			utils.ConsensusSignerFlag,
 		},
 	},
 	{
@@ -158,6 +160,10 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MaxConnInBoundFlag,
 			utils.MaxConnOutBoundFlag,
 			utils.MaxConnInBoundForSingleIPFlag,
+			utils.DisableP2PEncryptionFlag,
+			utils.DisableP2PCompressionFlag,
+			utils.ExternalAddrFlag,
+			utils.NATFlag,
 		},
 	},
 	{
@@ -189,6 +195,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.EnableTestModeFlag,
 			utils.TestModeGenBlockTimeFlag,
+			utils.TestModeSealModeFlag,
 		},
 	},
 	{
@@ -198,6 +205,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ContractAddrFlag,
 			utils.ContractAuthorFlag,
 			utils.ContractCodeFileFlag,
+			utils.ContractAbiFileFlag,
 			utils.ContractDescFlag,
 			utils.ContractEmailFlag,
 			utils.ContractNameFlag,
@@ -206,6 +214,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ContractPrepareInvokeFlag,
 			utils.ContractParamsFlag,
 			utils.ContractReturnTypeFlag,
+			utils.ContractBreakpointFlag,
 		},
 	},
 	{
@@ -255,6 +264,25 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ImportEndHeightFlag,
 		},
 	},
+	{
+		Name: "FORK",
+		Flags: []cli.Flag{
+			utils.ForkSourceFlag,
+			utils.ForkDataDirFlag,
+		},
+	},
+	{
+		Name: "NETCRAWL",
+		Flags: []cli.Flag{
+			utils.NetCrawlSeedsFlag,
+			utils.NetCrawlFormatFlag,
+			utils.NetCrawlOutputFlag,
+			utils.NetCrawlDotFlag,
+			utils.NetCrawlTimeoutFlag,
+			utils.NetCrawlConcurrencyFlag,
+			utils.NetCrawlMaxNodesFlag,
+		},
+	},
 	{
 		Name: "MISC",
 	},
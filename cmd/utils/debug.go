@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	rpccommon "github.com/ontio/ontology/http/base/common"
+)
+
+//DebugBreakpoint of neovm debug session, an empty contract matches any contract,
+//a negative offset matches any offset and an empty opcode matches any opcode
+type DebugBreakpoint struct {
+	Contract string `json:"contract,omitempty"`
+	Offset   int    `json:"offset"`
+	OpCode   string `json:"opcode,omitempty"`
+}
+
+func (this DebugBreakpoint) String() string {
+	var parts []string
+	if this.Contract != "" {
+		parts = append(parts, this.Contract)
+	}
+	if this.Offset >= 0 {
+		parts = append(parts, strconv.Itoa(this.Offset))
+	}
+	if this.OpCode != "" {
+		parts = append(parts, this.OpCode)
+	}
+	return strings.Join(parts, ":")
+}
+
+//ParseDebugBreakpoint parses breakpoint like [contract:]offset or [contract:]opcode
+func ParseDebugBreakpoint(str string) (DebugBreakpoint, error) {
+	bp := DebugBreakpoint{Offset: -1}
+	str = strings.TrimSpace(str)
+	if index := strings.LastIndex(str, ":"); index >= 0 {
+		bp.Contract = str[:index]
+		str = str[index+1:]
+		if _, err := rpccommon.GetAddress(bp.Contract); err != nil {
+			return bp, fmt.Errorf("invalid contract address:%s", bp.Contract)
+		}
+	}
+	if str == "" {
+		return bp, fmt.Errorf("missing offset or opcode")
+	}
+	if offset, err := strconv.Atoi(str); err == nil {
+		if offset < 0 {
+			return bp, fmt.Errorf("invalid offset:%d", offset)
+		}
+		bp.Offset = offset
+	} else {
+		bp.OpCode = strings.ToUpper(str)
+	}
+	return bp, nil
+}
+
+//ParseDebugBreakpoints parses breakpoints separated by comma ','
+func ParseDebugBreakpoints(str string) ([]DebugBreakpoint, error) {
+	breakpoints := make([]DebugBreakpoint, 0)
+	for _, item := range strings.Split(str, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		bp, err := ParseDebugBreakpoint(item)
+		if err != nil {
+			return nil, err
+		}
+		breakpoints = append(breakpoints, bp)
+	}
+	return breakpoints, nil
+}
+
+//DebugStart pre-executes neovm transaction in a new debug session of the local rpc server
+func DebugStart(txData string, stopOnEntry bool, breakpoints []DebugBreakpoint) (*rpccommon.DebugStatus, error) {
+	entry := 0
+	if stopOnEntry {
+		entry = 1
+	}
+	return sendDebugRequest("debugstart", []interface{}{txData, entry, breakpoints})
+}
+
+//DebugStep executes one opcode of the debug session
+func DebugStep(session uint64) (*rpccommon.DebugStatus, error) {
+	return sendDebugRequest("debugstep", []interface{}{session})
+}
+
+//DebugContinue executes the debug session until a breakpoint is hit or the execution finished
+func DebugContinue(session uint64) (*rpccommon.DebugStatus, error) {
+	return sendDebugRequest("debugcontinue", []interface{}{session})
+}
+
+//DebugStop aborts the debug session
+func DebugStop(session uint64) (*rpccommon.DebugStatus, error) {
+	return sendDebugRequest("debugstop", []interface{}{session})
+}
+
+//DebugSetBreakpoints replace the breakpoints of debug session
+func DebugSetBreakpoints(session uint64, breakpoints []DebugBreakpoint) error {
+	_, ontErr := sendLocalRpcRequest("debugsetbreakpoints", []interface{}{session, breakpoints})
+	if ontErr != nil {
+		return ontErr.Error
+	}
+	return nil
+}
+
+func sendDebugRequest(method string, params []interface{}) (*rpccommon.DebugStatus, error) {
+	data, ontErr := sendLocalRpcRequest(method, params)
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	status := &rpccommon.DebugStatus{}
+	err := json.Unmarshal(data, status)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal DebugStatus:%s error:%s", data, err)
+	}
+	return status, nil
+}
+
+//DebugStackItem format stack item of debug snapshot
+func DebugStackItem(item interface{}) string {
+	switch v := item.(type) {
+	case string:
+		if v == "" {
+			return `""`
+		}
+		return v
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, sub := range v {
+			items = append(items, DebugStackItem(sub))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
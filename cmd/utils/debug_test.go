@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDebugBreakpoints(t *testing.T) {
+	breakpoints, err := ParseDebugBreakpoints("12, syscall,AFmseVrdL9f9oyCzZefL9tG6UbvhUMqNMV:30")
+	assert.Nil(t, err)
+	assert.Equal(t, []DebugBreakpoint{
+		{Offset: 12},
+		{Offset: -1, OpCode: "SYSCALL"},
+		{Contract: "AFmseVrdL9f9oyCzZefL9tG6UbvhUMqNMV", Offset: 30},
+	}, breakpoints)
+	assert.Equal(t, "AFmseVrdL9f9oyCzZefL9tG6UbvhUMqNMV:30", breakpoints[2].String())
+
+	breakpoints, err = ParseDebugBreakpoints("")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(breakpoints))
+
+	_, err = ParseDebugBreakpoints("-1")
+	assert.NotNil(t, err)
+	_, err = ParseDebugBreakpoints("abc:12")
+	assert.NotNil(t, err)
+}
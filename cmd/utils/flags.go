@@ -32,6 +32,8 @@ const (
 	DEFAULT_ABI_PATH      = "./abi"
 	DEFAULT_EXPORT_HEIGHT = 0
 	DEFAULT_WALLET_PATH   = "./wallet_data"
+	DEFAULT_GUARD_PATH    = "./sign_guard"
+	DEFAULT_FORK_DIR      = "./Fork"
 )
 
 var (
@@ -82,6 +84,10 @@ var (
 		Usage: "Block data storage `<path>`",
 		Value: config.DEFAULT_DATA_DIR,
 	}
+	LightModeFlag = cli.BoolFlag{
+		Name:  "light",
+		Usage: "Run as light node, which only syncs block headers and queries states from full node peers",
+	}
 
 	//Consensus setting
 	EnableConsensusFlag = cli.BoolFlag{
@@ -114,6 +120,11 @@ var (
 		Usage: "Block-out `<time>`(s) in test mode.",
 		Value: config.DEFAULT_GEN_BLOCK_TIME,
 	}
+	TestModeSealModeFlag = cli.StringFlag{
+		Name:  "testmode-seal-mode",
+		Usage: "Block sealing `<mode>` in test mode. interval: seal block every testmode-gen-block-time; instant: seal block as soon as transaction enters tx pool; manual: seal block by generateblocks local rpc",
+		Value: config.SOLO_SEAL_MODE_INTERVAL,
+	}
 
 	//P2P setting
 	ReservedPeersOnlyFlag = cli.BoolFlag{
@@ -155,6 +166,23 @@ var (
 		Usage: "Max connection `<number>` in bound for single ip",
 		Value: config.DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP,
 	}
+	DisableP2PEncryptionFlag = cli.BoolFlag{
+		Name:  "disable-p2p-encryption",
+		Usage: "Disable encrypted transport with peers. Peers without encryption support always connect in plaintext.",
+	}
+	DisableP2PCompressionFlag = cli.BoolFlag{
+		Name:  "disable-p2p-compression",
+		Usage: "Disable message compression with peers. Peers without compression support always receive uncompressed messages.",
+	}
+	ExternalAddrFlag = cli.StringFlag{
+		Name:  "external-addr",
+		Usage: "Public address `<ip[:port]>` advertised to peers, used when the node is behind NAT",
+	}
+	NATFlag = cli.StringFlag{
+		Name:  "nat",
+		Usage: "Port mapping mechanism `<none|any|upnp|pmp|pmp:gatewayip>` of NAT gateway",
+		Value: "none",
+	}
 	// RPC settings
 	RPCDisabledFlag = cli.BoolFlag{
 		Name:  "disable-rpc",
@@ -208,6 +236,10 @@ var (
 		Hidden: true,
 		Usage:  "Account `<password>` when Ontology node starts.",
 	}
+	ConsensusSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "Remote consensus signer `<endpoint>`, such as unix:///var/run/sigsvr.sock or http://127.0.0.1:20000. If not specific, sign with the account in wallet",
+	}
 	AccountAddressFlag = cli.StringFlag{
 		Name:  "account,a",
 		Usage: "Account `<address>` when the Ontology node starts. If not specific, using default account instead",
@@ -316,6 +348,10 @@ var (
 		Usage: "Set `<text>` as the description of the contract",
 		Value: "",
 	}
+	ContractAbiFileFlag = cli.StringFlag{
+		Name:  "abifile",
+		Usage: "Json abi `<file>` of wasm contract, embed to code as ontio_abi section when deploy",
+	}
 	ContractParamsFlag = cli.StringFlag{
 		Name:  "params",
 		Usage: "Contract parameters list to invoke. separate params with comma ','",
@@ -332,6 +368,10 @@ var (
 		Name:  "return",
 		Usage: "Return `<type>` of contract. bytearray(hexstring), string, int, boolean",
 	}
+	ContractBreakpointFlag = cli.StringFlag{
+		Name:  "break",
+		Usage: "Breakpoints of debug session, separate with comma ','. `<[contract:]offset|opcode>`",
+	}
 
 	//information cmd settings
 	BlockHashInfoFlag = cli.StringFlag{
@@ -456,6 +496,19 @@ var (
 		Usage: "Wallet data `<path>`",
 		Value: DEFAULT_WALLET_PATH,
 	}
+	CliUnixSocketFlag = cli.StringFlag{
+		Name:  "unixsocket",
+		Usage: "Unix socket `<file>` to listen on instead of tcp",
+	}
+	CliSignGuardDirFlag = cli.StringFlag{
+		Name:  "signguarddir",
+		Usage: "Double sign guard data `<path>` for block signing",
+		Value: DEFAULT_GUARD_PATH,
+	}
+	CliConsensusAccountsFlag = cli.StringFlag{
+		Name:  "consensusaccounts",
+		Usage: "Consensus signing account `<addresses>` separated by ',', whose block and consensus data can only be signed by sigblock or sigconsensus",
+	}
 
 	//Export setting
 	ExportFileFlag = cli.StringFlag{
@@ -479,6 +532,50 @@ var (
 		Value: "m",
 	}
 
+	//Fork setting
+	ForkSourceFlag = cli.StringFlag{
+		Name:  "fork-source",
+		Usage: "Ledger data `<path>` to fork from, e.g. ./Chain/ontology. It is opened read-only and never modified, the node using it should be stopped",
+	}
+	ForkDataDirFlag = cli.StringFlag{
+		Name:  "fork-dir",
+		Usage: "Storage `<path>` of blocks and states produced after fork",
+		Value: DEFAULT_FORK_DIR,
+	}
+
+	//NetCrawl setting
+	NetCrawlSeedsFlag = cli.StringFlag{
+		Name:  "seeds",
+		Usage: "Comma separated seed `<addresses>` to start crawling, default is the seed list of network",
+	}
+	NetCrawlFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Output `<format>` (json|csv) of crawled nodes",
+		Value: "json",
+	}
+	NetCrawlOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "Output `<file>` of crawled nodes, default is stdout",
+	}
+	NetCrawlDotFlag = cli.StringFlag{
+		Name:  "dot",
+		Usage: "Output `<file>` of network topology in graphviz dot format",
+	}
+	NetCrawlTimeoutFlag = cli.UintFlag{
+		Name:  "timeout",
+		Usage: "Timeout `<seconds>` of connecting and querying each node",
+		Value: 5,
+	}
+	NetCrawlConcurrencyFlag = cli.UintFlag{
+		Name:  "concurrency",
+		Usage: "Max `<number>` of nodes queried at the same time",
+		Value: 16,
+	}
+	NetCrawlMaxNodesFlag = cli.UintFlag{
+		Name:  "max-nodes",
+		Usage: "Stop crawling after `<number>` nodes are visited, 0 means unlimited",
+	}
+
 	//PreExecute switcher
 	TxpoolPreExecDisableFlag = cli.BoolFlag{
 		Name:  "disable-tx-pool-pre-exec",
@@ -44,6 +44,7 @@ import (
 	rpccommon "github.com/ontio/ontology/http/base/common"
 	"github.com/ontio/ontology/smartcontract/service/native/ont"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/vm/crossvm_codec"
 )
 
 const (
@@ -606,6 +607,20 @@ func GetTxHeight(txHash string) (uint32, error) {
 	return height, nil
 }
 
+//GetContractAbi return the abi of wasm contract, nil if contract has no abi
+func GetContractAbi(contractAddress common.Address) (*crossvm_codec.WasmContractAbi, error) {
+	data, ontErr := sendRpcRequest("getcontractabi", []interface{}{contractAddress.ToHexString()})
+	if ontErr != nil {
+		return nil, ontErr.Error
+	}
+	var contractAbi *crossvm_codec.WasmContractAbi
+	err := json.Unmarshal(data, &contractAbi)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal WasmContractAbi:%s error:%s", data, err)
+	}
+	return contractAbi, nil
+}
+
 func DeployContract(
 	gasPrice,
 	gasLimit uint64,
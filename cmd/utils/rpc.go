@@ -72,6 +72,17 @@ type JsonRpcResponse struct {
 }
 
 func sendRpcRequest(method string, params []interface{}) ([]byte, *OntologyError) {
+	addr := fmt.Sprintf("http://localhost:%d", config.DefConfig.Rpc.HttpJsonPort)
+	return sendRpcRequestTo(addr, method, params)
+}
+
+//sendLocalRpcRequest send request to the local rpc server, which only serves local and test methods
+func sendLocalRpcRequest(method string, params []interface{}) ([]byte, *OntologyError) {
+	addr := fmt.Sprintf("http://localhost:%d/local", config.DefConfig.Rpc.HttpLocalPort)
+	return sendRpcRequestTo(addr, method, params)
+}
+
+func sendRpcRequestTo(addr string, method string, params []interface{}) ([]byte, *OntologyError) {
 	rpcReq := &JsonRpcRequest{
 		Version: JSON_RPC_VERSION,
 		Id:      "cli",
@@ -83,7 +94,6 @@ func sendRpcRequest(method string, params []interface{}) ([]byte, *OntologyError
 		return nil, NewOntologyError(fmt.Errorf("JsonRpcRequest json.Marshal error:%s", err))
 	}
 
-	addr := fmt.Sprintf("http://localhost:%d", config.DefConfig.Rpc.HttpJsonPort)
 	resp, err := http.Post(addr, "application/json", strings.NewReader(string(data)))
 	if err != nil {
 		return nil, NewOntologyError(err)
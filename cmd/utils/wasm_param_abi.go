@@ -0,0 +1,206 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package utils
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/vm/crossvm_codec"
+)
+
+func NewWasmContractAbi(abiData []byte) (*crossvm_codec.WasmContractAbi, error) {
+	abi := &crossvm_codec.WasmContractAbi{}
+	err := json.Unmarshal(abiData, abi)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal WasmContractAbi error:%s", err)
+	}
+	err = abi.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid wasm contract abi:%s", err)
+	}
+	return abi, nil
+}
+
+//ParseWasmFunc return the params to invoke wasm contract method, typed by abi.
+//The first raw param is method name, the others are method params, such as transfer,AGc...,AbP...,int:100
+//Param type prefix can be omitted, if set it must be the same as abi.
+func ParseWasmFunc(rawParamStr string, contractAbi *crossvm_codec.WasmContractAbi) ([]interface{}, *crossvm_codec.WasmMethodAbi, error) {
+	rawParams, _, err := parseRawParamsString(rawParamStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rawParams) == 0 {
+		return nil, nil, fmt.Errorf("missing method name")
+	}
+	rawName, ok := rawParams[0].(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid method name")
+	}
+	name, err := parseWasmRawParam(rawName, crossvm_codec.ABI_TYPE_STRING)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid method name:%s", err)
+	}
+	method := contractAbi.GetMethod(name.(string))
+	if method == nil {
+		return nil, nil, fmt.Errorf("method:%s not found in abi", name)
+	}
+	if len(rawParams)-1 != len(method.Parameters) {
+		return nil, nil, fmt.Errorf("method:%s need %d params, got %d", method.Name, len(method.Parameters), len(rawParams)-1)
+	}
+	res := []interface{}{method.Name}
+	for i, paramAbi := range method.Parameters {
+		rawParam, ok := rawParams[i+1].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("param:%s type:%s cannot be array", paramAbi.Name, paramAbi.Type)
+		}
+		param, err := parseWasmRawParam(rawParam, paramAbi.Type)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse param:%s error:%s", paramAbi.Name, err)
+		}
+		res = append(res, param)
+	}
+	return res, method, nil
+}
+
+func parseWasmRawParam(rawParam string, abiType string) (interface{}, error) {
+	pValue := strings.TrimSpace(rawParam)
+	ps := strings.Split(pValue, PARAM_TYPE_SPLIT_INC)
+	switch len(ps) {
+	case 1:
+	case 2:
+		pType := strings.ToLower(strings.TrimSpace(ps[0]))
+		if pType != abiType {
+			return nil, fmt.Errorf("param type:%s doesnot match abi type:%s", pType, abiType)
+		}
+		pValue = strings.TrimSpace(ps[1])
+	default:
+		return nil, fmt.Errorf("invalid param:%s", rawParam)
+	}
+	return ParseWasmParam(pValue, abiType)
+}
+
+//ParseWasmParam parse string value to the type of abi
+func ParseWasmParam(value string, abiType string) (interface{}, error) {
+	switch abiType {
+	case crossvm_codec.ABI_TYPE_BYTEARRAY:
+		res, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse byte array param:%s error:%s", value, err)
+		}
+		return res, nil
+	case crossvm_codec.ABI_TYPE_STRING:
+		return value, nil
+	case crossvm_codec.ABI_TYPE_INT:
+		res, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return nil, fmt.Errorf("parse integer param:%s failed", value)
+		}
+		return res, nil
+	case crossvm_codec.ABI_TYPE_BOOL:
+		return ParseNeovmParamBoolean(value)
+	case crossvm_codec.ABI_TYPE_ADDRESS:
+		addr, err := common.AddressFromBase58(value)
+		if err != nil {
+			addr, err = common.AddressFromHexString(value)
+			if err != nil {
+				return nil, fmt.Errorf("parse address param:%s failed", value)
+			}
+		}
+		return addr, nil
+	case crossvm_codec.ABI_TYPE_H256:
+		hash, err := common.Uint256FromHexString(value)
+		if err != nil {
+			return nil, fmt.Errorf("parse h256 param:%s error:%s", value, err)
+		}
+		return hash, nil
+	default:
+		return nil, fmt.Errorf("unknown param type:%s", abiType)
+	}
+}
+
+//ParseWasmReturnValue decode the hex encoded return value of wasm contract by abi return type
+func ParseWasmReturnValue(hexStr string, returnType string) (interface{}, error) {
+	data, err := common.HexToBytes(hexStr)
+	if err != nil {
+		return nil, fmt.Errorf("common.HexToBytes:%s error:%s", hexStr, err)
+	}
+	source := common.NewZeroCopySource(data)
+	var res interface{}
+	var irregular, eof bool
+	switch returnType {
+	case crossvm_codec.ABI_TYPE_VOID:
+		return nil, nil
+	case crossvm_codec.ABI_TYPE_BYTEARRAY:
+		var bs []byte
+		bs, _, irregular, eof = source.NextVarBytes()
+		res = common.ToHexString(bs)
+	case crossvm_codec.ABI_TYPE_STRING:
+		res, _, irregular, eof = source.NextString()
+	case crossvm_codec.ABI_TYPE_INT:
+		var val common.I128
+		val, eof = source.NextI128()
+		res = val.ToBigInt().String()
+	case crossvm_codec.ABI_TYPE_BOOL:
+		res, irregular, eof = source.NextBool()
+	case crossvm_codec.ABI_TYPE_ADDRESS:
+		var addr common.Address
+		addr, eof = source.NextAddress()
+		res = addr.ToBase58()
+	case crossvm_codec.ABI_TYPE_H256:
+		var hash common.Uint256
+		hash, eof = source.NextHash()
+		res = hash.ToHexString()
+	default:
+		return nil, fmt.Errorf("unknown return type:%s", returnType)
+	}
+	if irregular {
+		return nil, common.ErrIrregularData
+	}
+	if eof {
+		return nil, fmt.Errorf("parse return value:%s type:%s error:unexpected eof", hexStr, returnType)
+	}
+	return res, nil
+}
+
+//ParseWasmNotify label the states of wasm contract notify with the param names of abi event.
+//Return false if states doesnot match any event.
+func ParseWasmNotify(states interface{}, contractAbi *crossvm_codec.WasmContractAbi) (string, map[string]interface{}, bool) {
+	values, ok := states.([]interface{})
+	if !ok || len(values) == 0 {
+		return "", nil, false
+	}
+	name, ok := values[0].(string)
+	if !ok {
+		return "", nil, false
+	}
+	event := contractAbi.GetEvent(name)
+	if event == nil || len(event.Parameters) != len(values)-1 {
+		return "", nil, false
+	}
+	fields := make(map[string]interface{}, len(event.Parameters))
+	for i, param := range event.Parameters {
+		fields[param.Name] = values[i+1]
+	}
+	return name, fields, true
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWasmAbi = `{
+  "methods": [
+    {
+      "name": "transfer",
+      "parameters": [
+        {"name": "from", "type": "address"},
+        {"name": "to", "type": "address"},
+        {"name": "amount", "type": "int"}
+      ],
+      "returntype": "bool"
+    }
+  ],
+  "events": [
+    {
+      "name": "transfer",
+      "parameters": [
+        {"name": "from", "type": "address"},
+        {"name": "to", "type": "address"},
+        {"name": "amount", "type": "int"}
+      ]
+    }
+  ]
+}`
+
+func TestParseWasmFunc(t *testing.T) {
+	contractAbi, err := NewWasmContractAbi([]byte(testWasmAbi))
+	assert.Nil(t, err)
+
+	from := common.AddressFromVmCode([]byte("from"))
+	to := common.AddressFromVmCode([]byte("to"))
+	params, method, err := ParseWasmFunc("transfer,"+from.ToBase58()+",address:"+to.ToHexString()+",100", contractAbi)
+	assert.Nil(t, err)
+	assert.Equal(t, "transfer", method.Name)
+	assert.Equal(t, []interface{}{"transfer", from, to, big.NewInt(100)}, params)
+
+	_, _, err = ParseWasmFunc("string:transfer,"+from.ToBase58()+","+to.ToBase58()+",string:100", contractAbi)
+	assert.NotNil(t, err)
+	_, _, err = ParseWasmFunc("transfer,"+from.ToBase58()+","+to.ToBase58(), contractAbi)
+	assert.NotNil(t, err)
+	_, _, err = ParseWasmFunc("approve", contractAbi)
+	assert.NotNil(t, err)
+}
+
+func TestParseWasmReturnValue(t *testing.T) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteI128(common.I128FromInt64(-5))
+	value, err := ParseWasmReturnValue(common.ToHexString(sink.Bytes()), "int")
+	assert.Nil(t, err)
+	assert.Equal(t, "-5", value)
+
+	value, err = ParseWasmReturnValue("01", "bool")
+	assert.Nil(t, err)
+	assert.Equal(t, true, value)
+
+	_, err = ParseWasmReturnValue("0568656c6c", "string")
+	assert.NotNil(t, err)
+}
+
+func TestParseWasmNotify(t *testing.T) {
+	contractAbi, err := NewWasmContractAbi([]byte(testWasmAbi))
+	assert.Nil(t, err)
+
+	name, fields, ok := ParseWasmNotify([]interface{}{"transfer", "AGc", "AbP", "100"}, contractAbi)
+	assert.True(t, ok)
+	assert.Equal(t, "transfer", name)
+	assert.Equal(t, map[string]interface{}{"from": "AGc", "to": "AbP", "amount": "100"}, fields)
+
+	_, _, ok = ParseWasmNotify([]interface{}{"transfer", "AGc"}, contractAbi)
+	assert.False(t, ok)
+	_, _, ok = ParseWasmNotify("transfer", contractAbi)
+	assert.False(t, ok)
+}
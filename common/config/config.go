@@ -56,6 +56,10 @@ const (
 	CONSENSUS_TYPE_SOLO = "solo"
 	CONSENSUS_TYPE_VBFT = "vbft"
 
+	SOLO_SEAL_MODE_INTERVAL = "interval" //seal block every GenBlockTime seconds
+	SOLO_SEAL_MODE_INSTANT  = "instant"  //seal block as soon as transaction enters tx pool
+	SOLO_SEAL_MODE_MANUAL   = "manual"   //seal block only by generateblocks rpc
+
 	DEFAULT_LOG_LEVEL                       = log.InfoLog
 	DEFAULT_NODE_PORT                       = 20338
 	DEFAULT_RPC_PORT                        = 20336
@@ -188,6 +192,72 @@ func GetNewOntIdHeight() uint32 {
 	}
 }
 
+func GetStorageAccountingHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_STORAGE_ACCOUNTING_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_STORAGE_ACCOUNTING_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetSchedulerHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_SCHEDULER_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_SCHEDULER_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetWasmDeployValidationHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_WASM_DEPLOY_VALIDATION_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_WASM_DEPLOY_VALIDATION_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetWasmStorageIterHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_WASM_STORAGE_ITER_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_WASM_STORAGE_ITER_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetCryptoApiHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_CRYPTO_API_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_CRYPTO_API_POLARIS
+	default:
+		return 0
+	}
+}
+
+func GetContractUpgradeHeight() uint32 {
+	switch DefConfig.P2PNode.NetworkId {
+	case NETWORK_ID_MAIN_NET:
+		return constants.BLOCKHEIGHT_CONTRACT_UPGRADE_MAINNET
+	case NETWORK_ID_POLARIS_NET:
+		return constants.BLOCKHEIGHT_CONTRACT_UPGRADE_POLARIS
+	default:
+		return 0
+	}
+}
+
 func GetNetworkName(id uint32) string {
 	name, ok := NETWORK_NAME[id]
 	if ok {
@@ -536,6 +606,7 @@ type DBFTConfig struct {
 type SOLOConfig struct {
 	GenBlockTime uint
 	Bookkeepers  []string
+	SealMode     string
 }
 
 type CommonConfig struct {
@@ -547,6 +618,7 @@ type CommonConfig struct {
 	GasPrice         uint64
 	DataDir          string
 	WasmVerifyMethod VerifyMethod
+	LightMode        bool
 }
 
 type ConsensusConfig struct {
@@ -575,6 +647,10 @@ type P2PNodeConfig struct {
 	MaxConnInBound            uint
 	MaxConnOutBound           uint
 	MaxConnInBoundForSingleIP uint
+	DisableEncryption         bool
+	DisableCompression        bool
+	ExternalAddr              string
+	NAT                       string
 }
 
 type RpcConfig struct {
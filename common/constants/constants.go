@@ -103,3 +103,27 @@ const BLOCKHEIGHT_NEW_ONTID_POLARIS = 12150000
 
 const BLOCKHEIGHT_ONTFS_MAINNET = 8550000
 const BLOCKHEIGHT_ONTFS_POLARIS = 12250000
+
+// contract storage size accounting height, not scheduled on mainnet and polaris yet
+const BLOCKHEIGHT_STORAGE_ACCOUNTING_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_STORAGE_ACCOUNTING_POLARIS = 0xFFFFFFFF
+
+// native scheduler contract height, not scheduled on mainnet and polaris yet
+const BLOCKHEIGHT_SCHEDULER_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_SCHEDULER_POLARIS = 0xFFFFFFFF
+
+// wasm deploy validation height, not scheduled on mainnet and polaris yet
+const BLOCKHEIGHT_WASM_DEPLOY_VALIDATION_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_WASM_DEPLOY_VALIDATION_POLARIS = 0xFFFFFFFF
+
+// wasm storage iteration host functions height, not scheduled on mainnet and polaris yet
+const BLOCKHEIGHT_WASM_STORAGE_ITER_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_WASM_STORAGE_ITER_POLARIS = 0xFFFFFFFF
+
+// neovm crypto syscalls and wasm crypto host functions height, not scheduled on mainnet and polaris yet
+const BLOCKHEIGHT_CRYPTO_API_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_CRYPTO_API_POLARIS = 0xFFFFFFFF
+
+// in-place contract upgrade height, not scheduled on mainnet and polaris yet
+const BLOCKHEIGHT_CONTRACT_UPGRADE_MAINNET = 0xFFFFFFFF
+const BLOCKHEIGHT_CONTRACT_UPGRADE_POLARIS = 0xFFFFFFFF
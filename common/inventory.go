@@ -23,5 +23,6 @@ type InventoryType byte
 const (
 	TRANSACTION InventoryType = 0x01
 	BLOCK       InventoryType = 0x02
+	FULL_BLOCK  InventoryType = 0x03 //block requested as full block even if compact block is supported
 	CONSENSUS   InventoryType = 0xe0
 )
@@ -57,3 +57,54 @@ func ComputeMerkleRoot(hashes []Uint256) Uint256 {
 
 	return hashes[0]
 }
+
+func hashMerkleNode(left, right Uint256) Uint256 {
+	temp := sha256.Sum256(append(left[:], right[:]...))
+	return Uint256(sha256.Sum256(temp[:]))
+}
+
+//ComputeMerklePath return the sibling hashes from the leaf at index up to the root
+func ComputeMerklePath(hashes []Uint256, index uint32) []Uint256 {
+	if int(index) >= len(hashes) {
+		return nil
+	}
+	level := make([]Uint256, len(hashes))
+	copy(level, hashes)
+	path := make([]Uint256, 0)
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if int(sibling) >= len(level) {
+			sibling = index
+		}
+		path = append(path, level[sibling])
+		next := make([]Uint256, (len(level)+1)/2)
+		for i := range next {
+			left := level[2*i]
+			right := left
+			if 2*i+1 < len(level) {
+				right = level[2*i+1]
+			}
+			next[i] = hashMerkleNode(left, right)
+		}
+		level = next
+		index /= 2
+	}
+	return path
+}
+
+//VerifyMerklePath check whether the leaf at index is included in the merkle tree with root
+func VerifyMerklePath(leaf Uint256, index uint32, path []Uint256, root Uint256) bool {
+	if len(path) < 32 && index>>uint(len(path)) != 0 {
+		return false
+	}
+	hash := leaf
+	for _, sibling := range path {
+		if index%2 == 0 {
+			hash = hashMerkleNode(hash, sibling)
+		} else {
+			hash = hashMerkleNode(sibling, hash)
+		}
+		index /= 2
+	}
+	return hash == root
+}
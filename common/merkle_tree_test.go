@@ -183,3 +183,21 @@ func computeMerkleRootOld(hashes []Uint256) Uint256 {
 	tree, _ := newMerkleTree(hashes)
 	return tree.Root.Hash
 }
+
+func TestMerklePath(t *testing.T) {
+	for n := 1; n < 40; n++ {
+		data := make([]Uint256, n)
+		for i := range data {
+			data[i] = Uint256(sha256.Sum256([]byte(fmt.Sprint(i))))
+		}
+		leaves := make([]Uint256, n)
+		copy(leaves, data)
+		root := ComputeMerkleRoot(data)
+		for i := range leaves {
+			path := ComputeMerklePath(leaves, uint32(i))
+			assert.True(t, VerifyMerklePath(leaves[i], uint32(i), path, root))
+			assert.False(t, VerifyMerklePath(leaves[(i+1)%n], uint32(i), path, root) && n > 1)
+		}
+	}
+	assert.Nil(t, ComputeMerklePath(nil, 0))
+}
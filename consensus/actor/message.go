@@ -18,7 +18,10 @@
 
 package actor
 
-import "github.com/ontio/ontology/core/types"
+import (
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+)
 
 type StartConsensus struct{}
 type StopConsensus struct{}
@@ -28,3 +31,22 @@ type TimeOut struct{}
 type BlockCompleted struct {
 	Block *types.Block
 }
+
+//GenerateBlocks requests solo consensus to seal Count blocks immediately
+type GenerateBlocks struct {
+	Count uint32
+}
+
+type GenerateBlocksRsp struct {
+	BlockHashes []common.Uint256
+	Error       error
+}
+
+//SetNextBlockTimestamp requests solo consensus to use Timestamp for the next sealed block
+type SetNextBlockTimestamp struct {
+	Timestamp uint32
+}
+
+type SetNextBlockTimestampRsp struct {
+	Error error
+}
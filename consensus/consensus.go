@@ -20,9 +20,9 @@ package consensus
 
 import (
 	"github.com/ontio/ontology-eventbus/actor"
-	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/consensus/dbft"
+	"github.com/ontio/ontology/consensus/signer"
 	"github.com/ontio/ontology/consensus/solo"
 	"github.com/ontio/ontology/consensus/vbft"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
@@ -40,7 +40,7 @@ const (
 	CONSENSUS_VBFT = "vbft"
 )
 
-func NewConsensusService(consensusType string, account *account.Account, txpool *actor.PID, ledger *actor.PID, p2p p2p.P2P) (ConsensusService, error) {
+func NewConsensusService(consensusType string, signer signer.Signer, txpool *actor.PID, ledger *actor.PID, p2p p2p.P2P) (ConsensusService, error) {
 	if consensusType == "" {
 		consensusType = CONSENSUS_DBFT
 	}
@@ -48,11 +48,11 @@ func NewConsensusService(consensusType string, account *account.Account, txpool
 	var err error
 	switch consensusType {
 	case CONSENSUS_DBFT:
-		consensus, err = dbft.NewDbftService(account, txpool, p2p)
+		consensus, err = dbft.NewDbftService(signer, txpool, p2p)
 	case CONSENSUS_SOLO:
-		consensus, err = solo.NewSoloService(account, txpool)
+		consensus, err = solo.NewSoloService(signer, txpool)
 	case CONSENSUS_VBFT:
-		consensus, err = vbft.NewVbftServer(account, txpool, p2p)
+		consensus, err = vbft.NewVbftServer(signer, txpool, p2p)
 	}
 	log.Infof("ConsensusType:%s", consensusType)
 	return consensus, err
@@ -24,11 +24,11 @@ import (
 	"time"
 
 	"github.com/ontio/ontology-eventbus/actor"
-	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/consensus/signer"
 	"github.com/ontio/ontology/core/genesis"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/signature"
@@ -44,7 +44,7 @@ import (
 
 type DbftService struct {
 	context           ConsensusContext
-	Account           *account.Account
+	Signer            signer.Signer
 	timer             *time.Timer
 	timerHeight       uint32
 	timeView          byte
@@ -59,9 +59,9 @@ type DbftService struct {
 	sub *events.ActorSubscriber
 }
 
-func NewDbftService(bkAccount *account.Account, txpool *actor.PID, p2p p2p.P2P) (*DbftService, error) {
+func NewDbftService(bkSigner signer.Signer, txpool *actor.PID, p2p p2p.P2P) (*DbftService, error) {
 	service := &DbftService{
-		Account:       bkAccount,
+		Signer:        bkSigner,
 		timer:         time.NewTimer(time.Second * 15),
 		started:       false,
 		ledger:        ledger.DefLedger,
@@ -275,7 +275,7 @@ func (ds *DbftService) InitializeConsensus(viewNum byte) error {
 	log.Debug("[InitializeConsensus] viewNum: ", viewNum)
 
 	if viewNum == 0 {
-		ds.context.Reset(ds.Account)
+		ds.context.Reset(ds.Signer.PubKey())
 	} else {
 		if ds.context.State.HasFlag(BlockGenerated) {
 			return nil
@@ -487,9 +487,9 @@ func (ds *DbftService) PrepareRequestReceived(payload *p2pmsg.ConsensusPayload,
 		return
 	}
 
-	sig, err := signature.Sign(ds.Account, blockHash[:])
+	sig, err := ds.Signer.SignBlock(ds.blockSignKind(), ds.context.Height, blockHash)
 	if err != nil {
-		log.Error("[DbftService] signing failed")
+		log.Errorf("[DbftService] signing failed: %s", err)
 		return
 	}
 	ds.context.Signatures[ds.context.BookkeeperIndex] = sig
@@ -602,10 +602,15 @@ func (ds *DbftService) RequestChangeView() {
 	ds.CheckExpectedView(ds.context.ExpectedView[ds.context.BookkeeperIndex])
 }
 
+//blockSignKind returns the kind of block signature in current view, a bookkeeper signs at most one block in each view
+func (ds *DbftService) blockSignKind() string {
+	return fmt.Sprintf("%s_view_%d", signer.KIND_BLOCK, ds.context.ViewNumber)
+}
+
 func (ds *DbftService) SignAndRelay(payload *p2pmsg.ConsensusPayload) {
 	sink := common.NewZeroCopySink(nil)
 	payload.SerializationUnsigned(sink)
-	payload.Signature, _ = signature.Sign(ds.Account, sink.Bytes())
+	payload.Signature, _ = ds.Signer.Sign(sink.Bytes())
 
 	msg := msgpack.NewConsensus(payload)
 	ds.p2p.Broadcast(msg)
@@ -697,7 +702,12 @@ func (ds *DbftService) Timeout() {
 			//build block and sign
 			block := ds.context.MakeHeader()
 			blockHash := block.Hash()
-			ds.context.Signatures[ds.context.BookkeeperIndex], _ = signature.Sign(ds.Account, blockHash[:])
+			sig, err := ds.Signer.SignBlock(ds.blockSignKind(), ds.context.Height, blockHash)
+			if err != nil {
+				log.Errorf("[Timeout] signing failed: %s", err)
+				return
+			}
+			ds.context.Signatures[ds.context.BookkeeperIndex] = sig
 		}
 		payload := ds.context.MakePrepareRequest()
 		ds.SignAndRelay(payload)
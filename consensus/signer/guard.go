@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package signer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+)
+
+// number of heights kept by the guard below the highest signed height, older records are pruned
+// and signing at those heights is refused
+const GUARD_HISTORY_SIZE = 1024
+
+type signedBlock struct {
+	Kind      string `json:"kind,omitempty"`
+	Height    uint32 `json:"height"`
+	BlockHash string `json:"block_hash"`
+}
+
+type signKey struct {
+	kind   string
+	height uint32
+}
+
+// DoubleSignGuard records the block hash signed as each kind at each height
+// and refuses to sign a different block of a kind at a height which has been
+// signed before. When created with a file path, the records survive process
+// restarts.
+type DoubleSignGuard struct {
+	lock    sync.Mutex
+	path    string
+	signed  map[signKey]common.Uint256
+	highest uint32 //highest signed height
+}
+
+// NewMemDoubleSignGuard creates a guard which keeps its records in memory only
+func NewMemDoubleSignGuard() *DoubleSignGuard {
+	return &DoubleSignGuard{
+		signed: make(map[signKey]common.Uint256),
+	}
+}
+
+// NewDoubleSignGuard creates a guard persisted to the file at path
+func NewDoubleSignGuard(path string) (*DoubleSignGuard, error) {
+	guard := &DoubleSignGuard{
+		path:   path,
+		signed: make(map[signKey]common.Uint256),
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return guard, nil
+		}
+		return nil, fmt.Errorf("read sign guard file %s error: %s", path, err)
+	}
+	var records []*signedBlock
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse sign guard file %s error: %s", path, err)
+	}
+	for _, rec := range records {
+		hash, err := common.Uint256FromHexString(rec.BlockHash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block hash %s in sign guard file: %s", rec.BlockHash, err)
+		}
+		guard.signed[signKey{kind: rec.Kind, height: rec.Height}] = hash
+		if rec.Height > guard.highest {
+			guard.highest = rec.Height
+		}
+	}
+	return guard, nil
+}
+
+// Check returns an error if another hash has been signed as kind at height, or
+// height is so far below the highest signed height that its records may have
+// been pruned, otherwise records blockHash as signed as kind at height.
+func (self *DoubleSignGuard) Check(kind string, height uint32, blockHash common.Uint256) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	key := signKey{kind: kind, height: height}
+	if signed, present := self.signed[key]; present {
+		if signed != blockHash {
+			return fmt.Errorf("refuse to sign %s %s at height %d: %s already signed",
+				kind, blockHash.ToHexString(), height, signed.ToHexString())
+		}
+		return nil
+	}
+	if self.highest > GUARD_HISTORY_SIZE && height <= self.highest-GUARD_HISTORY_SIZE {
+		return fmt.Errorf("refuse to sign %s %s at height %d: too far below signed height %d",
+			kind, blockHash.ToHexString(), height, self.highest)
+	}
+	highest := self.highest
+	if height > highest {
+		highest = height
+	}
+	pruned := make(map[signKey]common.Uint256)
+	if highest > GUARD_HISTORY_SIZE {
+		for k, hash := range self.signed {
+			if k.height <= highest-GUARD_HISTORY_SIZE {
+				pruned[k] = hash
+				delete(self.signed, k)
+			}
+		}
+	}
+	self.signed[key] = blockHash
+	if err := self.persist(); err != nil {
+		delete(self.signed, key)
+		for k, hash := range pruned {
+			self.signed[k] = hash
+		}
+		return err
+	}
+	self.highest = highest
+	return nil
+}
+
+func (self *DoubleSignGuard) persist() error {
+	if self.path == "" {
+		return nil
+	}
+	records := make([]*signedBlock, 0, len(self.signed))
+	for key, hash := range self.signed {
+		records = append(records, &signedBlock{Kind: key.kind, Height: key.height, BlockHash: hash.ToHexString()})
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("marshal sign guard records error: %s", err)
+	}
+	tmp := self.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write sign guard file error: %s", err)
+	}
+	if err := os.Rename(tmp, self.path); err != nil {
+		return fmt.Errorf("save sign guard file error: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package signer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoubleSignGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sign_guard")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "guard.json")
+
+	guard, err := NewDoubleSignGuard(path)
+	assert.Nil(t, err)
+	assert.Nil(t, guard.Check(KIND_BLOCK, 10, common.Uint256{1}))
+	assert.Nil(t, guard.Check(KIND_BLOCK, 10, common.Uint256{1}))
+	assert.NotNil(t, guard.Check(KIND_BLOCK, 10, common.Uint256{2}))
+	assert.Nil(t, guard.Check(KIND_BLOCK, 11, common.Uint256{2}))
+
+	reloaded, err := NewDoubleSignGuard(path)
+	assert.Nil(t, err)
+	assert.Nil(t, reloaded.Check(KIND_BLOCK, 10, common.Uint256{1}))
+	assert.NotNil(t, reloaded.Check(KIND_BLOCK, 11, common.Uint256{3}))
+}
+
+func TestDoubleSignGuardKind(t *testing.T) {
+	guard := NewMemDoubleSignGuard()
+	assert.Nil(t, guard.Check(KIND_PROPOSAL, 10, common.Uint256{1}))
+	assert.Nil(t, guard.Check(KIND_EMPTY_PROPOSAL, 10, common.Uint256{2}))
+	assert.NotNil(t, guard.Check(KIND_PROPOSAL, 10, common.Uint256{3}))
+	assert.NotNil(t, guard.Check(KIND_EMPTY_PROPOSAL, 10, common.Uint256{3}))
+	assert.Nil(t, guard.Check(KIND_COMMIT, 10, common.Uint256{3}))
+}
+
+func TestDoubleSignGuardPrune(t *testing.T) {
+	guard := NewMemDoubleSignGuard()
+	assert.Nil(t, guard.Check(KIND_BLOCK, 1, common.Uint256{1}))
+	assert.Nil(t, guard.Check(KIND_BLOCK, GUARD_HISTORY_SIZE+1, common.Uint256{1}))
+	assert.Equal(t, 1, len(guard.signed))
+}
+
+func TestDoubleSignGuardHugeHeight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sign_guard")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "guard.json")
+
+	guard, err := NewDoubleSignGuard(path)
+	assert.Nil(t, err)
+	assert.Nil(t, guard.Check(KIND_BLOCK, 10, common.Uint256{1}))
+	assert.Nil(t, guard.Check(KIND_BLOCK, 1<<31, common.Uint256{2}))
+	assert.NotNil(t, guard.Check(KIND_BLOCK, 10, common.Uint256{3}))
+	assert.NotNil(t, guard.Check(KIND_COMMIT, 10, common.Uint256{3}))
+
+	reloaded, err := NewDoubleSignGuard(path)
+	assert.Nil(t, err)
+	assert.NotNil(t, reloaded.Check(KIND_BLOCK, 10, common.Uint256{3}))
+	assert.Nil(t, reloaded.Check(KIND_BLOCK, 1<<31-1, common.Uint256{3}))
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package signer
+
+import (
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/vrf"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
+)
+
+// LocalSigner signs with an in-memory account
+type LocalSigner struct {
+	account *account.Account
+	guard   *DoubleSignGuard
+}
+
+func NewLocalSigner(acc *account.Account) *LocalSigner {
+	return &LocalSigner{
+		account: acc,
+		guard:   NewMemDoubleSignGuard(),
+	}
+}
+
+func (self *LocalSigner) PubKey() keypair.PublicKey {
+	return self.account.PublicKey
+}
+
+func (self *LocalSigner) Sign(data []byte) ([]byte, error) {
+	return signature.Sign(self.account, data)
+}
+
+func (self *LocalSigner) SignBlock(kind string, height uint32, blockHash common.Uint256) ([]byte, error) {
+	if err := self.guard.Check(kind, height, blockHash); err != nil {
+		return nil, err
+	}
+	return signature.Sign(self.account, blockHash[:])
+}
+
+func (self *LocalSigner) ComputeVrf(data []byte) ([]byte, []byte, error) {
+	return vrf.Vrf(self.account.PrivateKey, data)
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+)
+
+const (
+	UNIX_SOCKET_PREFIX     = "unix://"
+	REMOTE_SIGNER_TIMEOUT  = 5 * time.Second
+	REMOTE_SIGNER_CLI_PATH = "/cli"
+)
+
+type remoteRequest struct {
+	Qid     string      `json:"qid"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	Account string      `json:"account"`
+	Pwd     string      `json:"pwd"`
+}
+
+type remoteResponse struct {
+	Qid       string          `json:"qid"`
+	Method    string          `json:"method"`
+	Result    json.RawMessage `json:"result"`
+	ErrorCode int             `json:"error_code"`
+	ErrorInfo string          `json:"error_info"`
+}
+
+type rawDataReq struct {
+	RawData string `json:"raw_data"`
+}
+
+type signedDataRsp struct {
+	SignedData string `json:"signed_data"`
+}
+
+type blockReq struct {
+	Kind      string `json:"kind"`
+	Height    uint32 `json:"height"`
+	BlockHash string `json:"block_hash"`
+}
+
+type vrfRsp struct {
+	Value string `json:"vrf_value"`
+	Proof string `json:"vrf_proof"`
+}
+
+type pubKeyRsp struct {
+	PublicKey string `json:"public_key"`
+}
+
+// RemoteSigner delegates signing to a sigsvr daemon, reached by http or by
+// unix socket, which holds the bookkeeper key.
+type RemoteSigner struct {
+	url     string
+	account string
+	pwd     string
+	client  *http.Client
+	pubKey  keypair.PublicKey
+	guard   *DoubleSignGuard
+}
+
+// NewRemoteSigner connects to the signing daemon at endpoint, which is either
+// a http url like "http://127.0.0.1:20000" or a unix socket path like
+// "unix:///var/run/sigsvr.sock", and loads the public key of account.
+func NewRemoteSigner(endpoint, account, pwd string) (*RemoteSigner, error) {
+	self := &RemoteSigner{
+		account: account,
+		pwd:     pwd,
+		guard:   NewMemDoubleSignGuard(),
+	}
+	transport := &http.Transport{}
+	if strings.HasPrefix(endpoint, UNIX_SOCKET_PREFIX) {
+		sockPath := strings.TrimPrefix(endpoint, UNIX_SOCKET_PREFIX)
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", sockPath)
+		}
+		self.url = "http://unix" + REMOTE_SIGNER_CLI_PATH
+	} else {
+		self.url = strings.TrimSuffix(endpoint, "/") + REMOTE_SIGNER_CLI_PATH
+	}
+	self.client = &http.Client{
+		Transport: transport,
+		Timeout:   REMOTE_SIGNER_TIMEOUT,
+	}
+
+	rsp := &pubKeyRsp{}
+	if err := self.call("getpublickey", struct{}{}, rsp); err != nil {
+		return nil, fmt.Errorf("get public key from remote signer error: %s", err)
+	}
+	data, err := hex.DecodeString(rsp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key from remote signer: %s", err)
+	}
+	self.pubKey, err = keypair.DeserializePublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key from remote signer: %s", err)
+	}
+	return self, nil
+}
+
+func (self *RemoteSigner) PubKey() keypair.PublicKey {
+	return self.pubKey
+}
+
+func (self *RemoteSigner) Sign(data []byte) ([]byte, error) {
+	rsp := &signedDataRsp{}
+	if err := self.call("sigconsensus", &rawDataReq{RawData: hex.EncodeToString(data)}, rsp); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(rsp.SignedData)
+}
+
+func (self *RemoteSigner) SignBlock(kind string, height uint32, blockHash common.Uint256) ([]byte, error) {
+	if err := self.guard.Check(kind, height, blockHash); err != nil {
+		return nil, err
+	}
+	rsp := &signedDataRsp{}
+	req := &blockReq{
+		Kind:      kind,
+		Height:    height,
+		BlockHash: blockHash.ToHexString(),
+	}
+	if err := self.call("sigblock", req, rsp); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(rsp.SignedData)
+}
+
+func (self *RemoteSigner) ComputeVrf(data []byte) ([]byte, []byte, error) {
+	rsp := &vrfRsp{}
+	if err := self.call("computevrf", &rawDataReq{RawData: hex.EncodeToString(data)}, rsp); err != nil {
+		return nil, nil, err
+	}
+	value, err := hex.DecodeString(rsp.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vrf value: %s", err)
+	}
+	proof, err := hex.DecodeString(rsp.Proof)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid vrf proof: %s", err)
+	}
+	return value, proof, nil
+}
+
+func (self *RemoteSigner) call(method string, params interface{}, result interface{}) error {
+	data, err := json.Marshal(&remoteRequest{
+		Qid:     "1",
+		Method:  method,
+		Params:  params,
+		Account: self.account,
+		Pwd:     self.pwd,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal %s request error: %s", method, err)
+	}
+	httpRsp, err := self.client.Post(self.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s request error: %s", method, err)
+	}
+	defer httpRsp.Body.Close()
+	body, err := ioutil.ReadAll(httpRsp.Body)
+	if err != nil {
+		return fmt.Errorf("read %s response error: %s", method, err)
+	}
+	rsp := &remoteResponse{}
+	if err := json.Unmarshal(body, rsp); err != nil {
+		return fmt.Errorf("unmarshal %s response error: %s", method, err)
+	}
+	if rsp.ErrorCode != 0 {
+		return fmt.Errorf("%s failed, error code: %d, error info: %s", method, rsp.ErrorCode, rsp.ErrorInfo)
+	}
+	if err := json.Unmarshal(rsp.Result, result); err != nil {
+		return fmt.Errorf("unmarshal %s result error: %s", method, err)
+	}
+	return nil
+}
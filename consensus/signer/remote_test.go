@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// serveTestSigner mocks the sigsvr consensus signing methods with acc
+func serveTestSigner(t *testing.T, acc *account.Account, listener net.Listener) {
+	local := NewLocalSigner(acc)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		req := &testRequest{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(req))
+		rsp := &remoteResponse{Method: req.Method}
+		var result interface{}
+		switch req.Method {
+		case "getpublickey":
+			result = &pubKeyRsp{PublicKey: hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey))}
+		case "sigconsensus":
+			raw := &rawDataReq{}
+			assert.Nil(t, json.Unmarshal(req.Params, raw))
+			data, _ := hex.DecodeString(raw.RawData)
+			sig, _ := local.Sign(data)
+			result = &signedDataRsp{SignedData: hex.EncodeToString(sig)}
+		case "sigblock":
+			blk := &blockReq{}
+			assert.Nil(t, json.Unmarshal(req.Params, blk))
+			hash, _ := common.Uint256FromHexString(blk.BlockHash)
+			sig, err := local.SignBlock(blk.Kind, blk.Height, hash)
+			if err != nil {
+				rsp.ErrorCode = 1010
+				rsp.ErrorInfo = err.Error()
+			}
+			result = &signedDataRsp{SignedData: hex.EncodeToString(sig)}
+		}
+		rsp.Result, _ = json.Marshal(result)
+		data, _ := json.Marshal(rsp)
+		w.Write(data)
+	}
+	go http.Serve(listener, http.HandlerFunc(handler))
+}
+
+func TestRemoteSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote_signer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "sigsvr.sock")
+	listener, err := net.Listen("unix", sockPath)
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	acc := account.NewAccount("")
+	serveTestSigner(t, acc, listener)
+
+	remote, err := NewRemoteSigner(UNIX_SOCKET_PREFIX+sockPath, acc.Address.ToBase58(), "pwd")
+	assert.Nil(t, err)
+	assert.True(t, keypair.ComparePublicKey(acc.PublicKey, remote.PubKey()))
+
+	data := []byte("consensus payload")
+	sig, err := remote.Sign(data)
+	assert.Nil(t, err)
+	assert.Nil(t, signature.Verify(acc.PublicKey, data, sig))
+
+	hash := common.Uint256{1, 2, 3}
+	sig, err = remote.SignBlock(KIND_BLOCK, 100, hash)
+	assert.Nil(t, err)
+	assert.Nil(t, signature.Verify(acc.PublicKey, hash[:], sig))
+	_, err = remote.SignBlock(KIND_BLOCK, 100, common.Uint256{4, 5, 6})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package signer abstracts the key used by consensus services, so that the
+// bookkeeper private key can be kept either in process or in a separate
+// signing daemon.
+package signer
+
+import (
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/common"
+)
+
+// kinds of hashes signed by SignBlock, an honest consensus node signs at most
+// one hash of each kind at a height
+const (
+	KIND_BLOCK          = "block"
+	KIND_PROPOSAL       = "proposal"
+	KIND_EMPTY_PROPOSAL = "empty_proposal"
+	KIND_ENDORSE        = "endorse"
+	KIND_EMPTY_ENDORSE  = "empty_endorse"
+	KIND_COMMIT         = "commit"
+	KIND_EMPTY_COMMIT   = "empty_commit"
+	KIND_CROSS_CHAIN    = "cross_chain"
+	KIND_STATE_ROOT     = "state_root"
+)
+
+// Signer is used by consensus services to sign blocks and consensus messages
+type Signer interface {
+	// PubKey returns the public key of the bookkeeper
+	PubKey() keypair.PublicKey
+	// Sign signs the unsigned serialization of p2p consensus payloads
+	Sign(data []byte) ([]byte, error)
+	// SignBlock signs a block hash, or another hash the chain is bound to at
+	// height, as kind. It refuses to sign a hash different from the one
+	// already signed as the same kind at the same height.
+	SignBlock(kind string, height uint32, blockHash common.Uint256) ([]byte, error)
+	// ComputeVrf computes the vrf value and proof of data
+	ComputeVrf(data []byte) ([]byte, []byte, error)
+}
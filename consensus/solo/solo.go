@@ -20,18 +20,18 @@ package solo
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"time"
 
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-eventbus/actor"
-	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/consensus/signer"
 	"github.com/ontio/ontology/core/ledger"
-	"github.com/ontio/ontology/core/signature"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/events"
 	"github.com/ontio/ontology/events/message"
@@ -43,22 +43,28 @@ import (
  */
 const ContextVersion uint32 = 0
 
+//max number of blocks sealed by one GenerateBlocks request
+const MAX_GENERATE_BLOCKS = 1000
+
 type SoloService struct {
-	Account          *account.Account
+	Signer           signer.Signer
 	poolActor        *actorTypes.TxPoolActor
 	incrValidator    *increment.IncrementValidator
 	existCh          chan interface{}
 	genBlockInterval time.Duration
+	sealMode         string
+	nextTimestamp    uint32
 	pid              *actor.PID
 	sub              *events.ActorSubscriber
 }
 
-func NewSoloService(bkAccount *account.Account, txpool *actor.PID) (*SoloService, error) {
+func NewSoloService(bkSigner signer.Signer, txpool *actor.PID) (*SoloService, error) {
 	service := &SoloService{
-		Account:          bkAccount,
+		Signer:           bkSigner,
 		poolActor:        &actorTypes.TxPoolActor{Pool: txpool},
 		incrValidator:    increment.NewIncrementValidator(20),
 		genBlockInterval: time.Duration(config.DefConfig.Genesis.SOLO.GenBlockTime) * time.Second,
+		sealMode:         config.DefConfig.Genesis.SOLO.SealMode,
 	}
 
 	props := actor.FromProducer(func() actor.Actor {
@@ -91,9 +97,18 @@ func (self *SoloService) Receive(context actor.Context) {
 		}
 
 		self.sub.Subscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+		self.existCh = make(chan interface{})
+		log.Infof("solo seal mode: %s", self.sealMode)
+
+		switch self.sealMode {
+		case config.SOLO_SEAL_MODE_INSTANT:
+			self.sub.Subscribe(message.TOPIC_TX_POOL_ADD)
+			return
+		case config.SOLO_SEAL_MODE_MANUAL:
+			return
+		}
 
 		timer := time.NewTicker(self.genBlockInterval)
-		self.existCh = make(chan interface{})
 		go func() {
 			defer timer.Stop()
 			existCh := self.existCh
@@ -112,16 +127,40 @@ func (self *SoloService) Receive(context actor.Context) {
 			self.existCh = nil
 			self.incrValidator.Clean()
 			self.sub.Unsubscribe(message.TOPIC_SAVE_BLOCK_COMPLETE)
+			if self.sealMode == config.SOLO_SEAL_MODE_INSTANT {
+				self.sub.Unsubscribe(message.TOPIC_TX_POOL_ADD)
+			}
 		}
 	case *message.SaveBlockCompleteMsg:
 		log.Infof("solo actor receives block complete event. block height=%d txnum=%d", msg.Block.Header.Height, len(msg.Block.Transactions))
-		self.incrValidator.AddBlock(msg.Block)
+		// blocks sealed by self have been added after submitting
+		if _, end := self.incrValidator.BlockRange(); msg.Block.Header.Height >= end {
+			self.incrValidator.AddBlock(msg.Block)
+		}
 
 	case *actorTypes.TimeOut:
-		err := self.genBlock()
+		_, err := self.genBlock(true)
 		if err != nil {
 			log.Errorf("Solo genBlock error %s", err)
 		}
+	case *message.TxPoolAddMsg:
+		if self.existCh == nil {
+			return
+		}
+		_, err := self.genBlock(false)
+		if err != nil {
+			log.Errorf("Solo genBlock error %s", err)
+		}
+	case *actorTypes.GenerateBlocks:
+		rsp := self.generateBlocks(msg.Count)
+		if sender := context.Sender(); sender != nil {
+			sender.Request(rsp, context.Self())
+		}
+	case *actorTypes.SetNextBlockTimestamp:
+		rsp := self.setNextBlockTimestamp(msg.Timestamp)
+		if sender := context.Sender(); sender != nil {
+			sender.Request(rsp, context.Self())
+		}
 	default:
 		log.Info("solo actor: Unknown msg ", msg, "type", reflect.TypeOf(msg))
 	}
@@ -141,15 +180,51 @@ func (self *SoloService) Halt() error {
 	return nil
 }
 
-func (self *SoloService) genBlock() error {
+func (self *SoloService) generateBlocks(count uint32) *actorTypes.GenerateBlocksRsp {
+	rsp := &actorTypes.GenerateBlocksRsp{}
+	if count == 0 || count > MAX_GENERATE_BLOCKS {
+		rsp.Error = fmt.Errorf("block count should be in range [1, %d]", MAX_GENERATE_BLOCKS)
+		return rsp
+	}
+	for i := uint32(0); i < count; i++ {
+		hash, err := self.genBlock(true)
+		if err != nil {
+			rsp.Error = err
+			return rsp
+		}
+		rsp.BlockHashes = append(rsp.BlockHashes, hash)
+	}
+	return rsp
+}
+
+func (self *SoloService) setNextBlockTimestamp(timestamp uint32) *actorTypes.SetNextBlockTimestampRsp {
+	header, err := ledger.DefLedger.GetHeaderByHeight(ledger.DefLedger.GetCurrentBlockHeight())
+	if err != nil {
+		return &actorTypes.SetNextBlockTimestampRsp{Error: err}
+	}
+	if timestamp <= header.Timestamp {
+		return &actorTypes.SetNextBlockTimestampRsp{
+			Error: fmt.Errorf("timestamp %d should be greater than current block timestamp %d", timestamp, header.Timestamp),
+		}
+	}
+	self.nextTimestamp = timestamp
+	return &actorTypes.SetNextBlockTimestampRsp{}
+}
+
+// genBlock seals a new block with transactions in tx pool, and skips sealing when there is
+// no transaction if sealEmpty is false. The returned hash is empty if no block is sealed.
+func (self *SoloService) genBlock(sealEmpty bool) (common.Uint256, error) {
 	block, err := self.makeBlock()
 	if err != nil {
-		return fmt.Errorf("makeBlock error %s", err)
+		return common.UINT256_EMPTY, fmt.Errorf("makeBlock error %s", err)
+	}
+	if !sealEmpty && len(block.Transactions) == 0 {
+		return common.UINT256_EMPTY, nil
 	}
 
 	result, err := ledger.DefLedger.ExecuteBlock(block)
 	if err != nil {
-		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
+		return common.UINT256_EMPTY, fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
 	}
 
 	var msg *types.CrossChainMsg
@@ -160,29 +235,36 @@ func (self *SoloService) genBlock() error {
 			StatesRoot: result.CrossStatesRoot,
 		}
 		hash := msg.Hash()
-		sig, err := signature.Sign(self.Account, hash[:])
+		sig, err := self.Signer.SignBlock(signer.KIND_CROSS_CHAIN, msg.Height, hash)
 		if err != nil {
-			return fmt.Errorf("[Signature],Sign error:%s.", err)
+			return common.UINT256_EMPTY, fmt.Errorf("[Signature],Sign error:%s.", err)
 		}
 		msg.SigData = [][]byte{sig}
 	}
 
 	err = ledger.DefLedger.SubmitBlock(block, msg, result)
 	if err != nil {
-		return fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
+		return common.UINT256_EMPTY, fmt.Errorf("genBlock DefLedgerPid.RequestFuture Height:%d error:%s", block.Header.Height, err)
 	}
-	return nil
+	// add block to increment validator immediately, so that the transactions will not be packed
+	// again before the tx pool cleans them
+	self.incrValidator.AddBlock(block)
+	self.nextTimestamp = 0
+	return block.Hash(), nil
 }
 
 func (self *SoloService) makeBlock() (*types.Block, error) {
 	log.Debug()
-	owner := self.Account.PublicKey
+	owner := self.Signer.PubKey()
 	nextBookkeeper, err := types.AddressFromBookkeepers([]keypair.PublicKey{owner})
 	if err != nil {
 		return nil, fmt.Errorf("GetBookkeeperAddress error:%s", err)
 	}
 	prevHash := ledger.DefLedger.GetCurrentBlockHash()
 	height := ledger.DefLedger.GetCurrentBlockHeight()
+	if height == math.MaxUint32 {
+		return nil, fmt.Errorf("block height overflow")
+	}
 
 	validHeight := height
 
@@ -213,13 +295,28 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 	}
 	txRoot := common.ComputeMerkleRoot(txHash)
 
+	prevHeader, err := ledger.DefLedger.GetHeaderByHash(prevHash)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderByHash error:%s", err)
+	}
+	timestamp := uint32(time.Now().Unix())
+	if self.nextTimestamp != 0 {
+		timestamp = self.nextTimestamp
+	}
+	if timestamp <= prevHeader.Timestamp {
+		if prevHeader.Timestamp == math.MaxUint32 {
+			return nil, fmt.Errorf("block timestamp overflow")
+		}
+		timestamp = prevHeader.Timestamp + 1
+	}
+
 	blockRoot := ledger.DefLedger.GetBlockRootWithNewTxRoots(height+1, []common.Uint256{txRoot})
 	header := &types.Header{
 		Version:          ContextVersion,
 		PrevBlockHash:    prevHash,
 		TransactionsRoot: txRoot,
 		BlockRoot:        blockRoot,
-		Timestamp:        uint32(time.Now().Unix()),
+		Timestamp:        timestamp,
 		Height:           height + 1,
 		ConsensusData:    common.GetNonce(),
 		NextBookkeeper:   nextBookkeeper,
@@ -231,7 +328,7 @@ func (self *SoloService) makeBlock() (*types.Block, error) {
 
 	blockHash := block.Hash()
 
-	sig, err := signature.Sign(self.Account, blockHash[:])
+	sig, err := self.Signer.SignBlock(signer.KIND_BLOCK, block.Header.Height, blockHash)
 	if err != nil {
 		return nil, fmt.Errorf("[Signature],Sign error:%s.", err)
 	}
@@ -26,9 +26,9 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/consensus/signer"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/ledger"
-	"github.com/ontio/ontology/core/signature"
 	"github.com/ontio/ontology/core/types"
 )
 
@@ -183,7 +183,7 @@ func (self *Server) constructHeartbeatMsg() (*peerHeartbeatMsg, error) {
 	return msg, nil
 }
 
-func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, txs []*types.Transaction, consensusPayload []byte, blocktimestamp uint32) (*types.Block, error) {
+func (self *Server) constructBlock(kind string, blkNum uint32, prevBlkHash common.Uint256, txs []*types.Transaction, consensusPayload []byte, blocktimestamp uint32) (*types.Block, error) {
 	txHash := []common.Uint256{}
 	for _, t := range txs {
 		txHash = append(txHash, t.Hash())
@@ -211,11 +211,11 @@ func (self *Server) constructBlock(blkNum uint32, prevBlkHash common.Uint256, tx
 		Transactions: txs,
 	}
 	blkHash := blk.Hash()
-	sig, err := signature.Sign(self.account, blkHash[:])
+	sig, err := self.signer.SignBlock(kind, blkNum, blkHash)
 	if err != nil {
 		return nil, fmt.Errorf("sign block failed, block hash:%s, error: %s", blkHash.ToHexString(), err)
 	}
-	blkHeader.Bookkeepers = []keypair.PublicKey{self.account.PublicKey}
+	blkHeader.Bookkeepers = []keypair.PublicKey{self.signer.PubKey()}
 	blkHeader.SigData = [][]byte{sig}
 
 	return blk, nil
@@ -236,7 +236,7 @@ func (self *Server) constructCrossChainMsg(blkNum uint32) (*types.CrossChainMsg,
 		StatesRoot: root,
 	}
 	hash := msg.Hash()
-	sig, err := signature.Sign(self.account, hash[:])
+	sig, err := self.signer.SignBlock(signer.KIND_CROSS_CHAIN, msg.Height, hash)
 	if err != nil {
 		return nil, fmt.Errorf("sign cross chain msg root failed,msg hash:%s,err:%s", hash.ToHexString(), err)
 	}
@@ -255,7 +255,7 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 		blocktimestamp = prevBlk.Block.Header.Timestamp + 1
 	}
 
-	vrfValue, vrfProof, err := computeVrf(self.account.PrivateKey, blkNum, prevBlk.getVrfValue())
+	vrfValue, vrfProof, err := computeVrf(self.signer, blkNum, prevBlk.getVrfValue())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get vrf and proof: %s", err)
 	}
@@ -279,11 +279,11 @@ func (self *Server) constructProposalMsg(blkNum uint32, sysTxs, userTxs []*types
 		return nil, err
 	}
 
-	emptyBlk, err := self.constructBlock(blkNum, prevBlkHash, sysTxs, consensusPayload, blocktimestamp)
+	emptyBlk, err := self.constructBlock(signer.KIND_EMPTY_PROPOSAL, blkNum, prevBlkHash, sysTxs, consensusPayload, blocktimestamp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct empty block: %s", err)
 	}
-	blk, err := self.constructBlock(blkNum, prevBlkHash, append(sysTxs, userTxs...), consensusPayload, blocktimestamp)
+	blk, err := self.constructBlock(signer.KIND_PROPOSAL, blkNum, prevBlkHash, append(sysTxs, userTxs...), consensusPayload, blocktimestamp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to constuct blk: %s", err)
 	}
@@ -314,11 +314,13 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 	var proposerSig, endorserSig []byte
 	var blkHash common.Uint256
 	var err error
+	kind := signer.KIND_ENDORSE
 	if !forEmpty {
 		proposerSig = proposal.Block.Block.Header.SigData[0]
 		blkHash = proposal.Block.Block.Hash()
 
 	} else {
+		kind = signer.KIND_EMPTY_ENDORSE
 		if proposal.Block.EmptyBlock == nil {
 			return nil, fmt.Errorf("blk %d proposal from %d has no empty proposal",
 				proposal.GetBlockNum(), proposal.Block.getProposer())
@@ -327,7 +329,7 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 		proposerSig = proposal.Block.EmptyBlock.Header.SigData[0]
 		blkHash = proposal.Block.EmptyBlock.Hash()
 	}
-	endorserSig, err = signature.Sign(self.account, blkHash[:])
+	endorserSig, err = self.signer.SignBlock(kind, proposal.Block.getBlockNum(), blkHash)
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, err: %s", blkHash, err)
 	}
@@ -343,7 +345,7 @@ func (self *Server) constructEndorseMsg(proposal *blockProposalMsg, forEmpty boo
 	}
 	if proposal.Block.CrossChainMsg != nil {
 		hash := proposal.Block.CrossChainMsg.Hash()
-		sig, err := signature.Sign(self.account, hash[:])
+		sig, err := self.signer.SignBlock(signer.KIND_CROSS_CHAIN, proposal.Block.CrossChainMsg.Height, hash)
 		if err != nil {
 			return nil, fmt.Errorf("sign cross chain msg root failed,msg hash:%s,err:%s", hash.ToHexString(), err)
 		}
@@ -360,6 +362,7 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 	var proposerSig, committerSig []byte
 	var blkHash common.Uint256
 	var err error
+	kind := signer.KIND_COMMIT
 
 	if !forEmpty {
 		proposerSig = proposal.Block.Block.Header.SigData[0]
@@ -370,10 +373,11 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 				proposal.GetBlockNum(), proposal.Block.getProposer())
 		}
 
+		kind = signer.KIND_EMPTY_COMMIT
 		proposerSig = proposal.Block.EmptyBlock.Header.SigData[0]
 		blkHash = proposal.Block.EmptyBlock.Hash()
 	}
-	committerSig, err = signature.Sign(self.account, blkHash[:])
+	committerSig, err = self.signer.SignBlock(kind, proposal.Block.getBlockNum(), blkHash)
 	if err != nil {
 		return nil, fmt.Errorf("endorser failed to sign block. hash:%x, caused by: %s", blkHash, err)
 	}
@@ -411,7 +415,7 @@ func (self *Server) constructCommitMsg(proposal *blockProposalMsg, endorses []*b
 	}
 
 	if proposal.Block.CrossChainMsg != nil && commitCrossChain {
-		sig, err := signature.Sign(self.account, hash[:])
+		sig, err := self.signer.SignBlock(signer.KIND_CROSS_CHAIN, proposal.Block.CrossChainMsg.Height, hash)
 		if err != nil {
 			return nil, fmt.Errorf("sign cross chain msg root failed,msg hash:%s,err:%s", hash.ToHexString(), err)
 		}
@@ -454,7 +458,7 @@ func (self *Server) constructProposalFetchMsg(blkNum uint32, proposer uint32) *p
 }
 
 func (self *Server) constructBlockSubmitMsg(blkNum uint32, stateRoot common.Uint256) (*blockSubmitMsg, error) {
-	submitSig, err := signature.Sign(self.account, stateRoot[:])
+	submitSig, err := self.signer.SignBlock(signer.KIND_STATE_ROOT, blkNum, stateRoot)
 	if err != nil {
 		return nil, fmt.Errorf("submit failed to sign stateroot hash:%x, err: %s", stateRoot, err)
 	}
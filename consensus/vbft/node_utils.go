@@ -25,7 +25,7 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
-	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
 	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
 	p2pmsg "github.com/ontio/ontology/p2pserver/message/types"
 )
@@ -404,12 +404,12 @@ func (self *Server) sendToPeer(peerIdx uint32, data []byte) error {
 	}
 	msg := &p2pmsg.ConsensusPayload{
 		Data:  data,
-		Owner: self.account.PublicKey,
+		Owner: self.signer.PubKey(),
 	}
 
 	sink := common.NewZeroCopySink(nil)
 	msg.SerializationUnsigned(sink)
-	msg.Signature, _ = signature.Sign(self.account, sink.Bytes())
+	msg.Signature, _ = self.signer.Sign(sink.Bytes())
 
 	cons := msgpack.NewConsensus(msg)
 	p2pid, present := self.peerPool.getP2pId(peerIdx)
@@ -428,15 +428,21 @@ func (self *Server) broadcast(msg ConsensusMsg) {
 	}
 }
 
+//announceBlock announces the block proposed by self to neighbors, p2p relays it as compact block if peer supports
+func (self *Server) announceBlock(block *types.Block) {
+	msg := msgpack.NewInv(msgpack.NewInvPayload(common.BLOCK, []common.Uint256{block.Hash()}))
+	go self.p2p.Broadcast(msg)
+}
+
 func (self *Server) broadcastToAll(data []byte) {
 	payload := &p2pmsg.ConsensusPayload{
 		Data:  data,
-		Owner: self.account.PublicKey,
+		Owner: self.signer.PubKey(),
 	}
 
 	sink := common.NewZeroCopySink(nil)
 	payload.SerializationUnsigned(sink)
-	payload.Signature, _ = signature.Sign(self.account, sink.Bytes())
+	payload.Signature, _ = self.signer.Sign(sink.Bytes())
 
 	msg := msgpack.NewConsensus(payload)
 	go self.p2p.Broadcast(msg)
@@ -29,10 +29,10 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology-crypto/vrf"
 	"github.com/ontio/ontology-eventbus/actor"
-	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	actorTypes "github.com/ontio/ontology/consensus/actor"
+	"github.com/ontio/ontology/consensus/signer"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
@@ -89,7 +89,7 @@ type p2pMsgPayload struct {
 
 type Server struct {
 	Index         uint32
-	account       *account.Account
+	signer        signer.Signer
 	poolActor     *actorTypes.TxPoolActor
 	p2p           p2p.P2P
 	ledger        *ledger.Ledger
@@ -130,10 +130,10 @@ type Server struct {
 	quitWg     sync.WaitGroup
 }
 
-func NewVbftServer(account *account.Account, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
+func NewVbftServer(signer signer.Signer, txpool *actor.PID, p2p p2p.P2P) (*Server, error) {
 	server := &Server{
 		msgHistoryDuration: 64,
-		account:            account,
+		signer:             signer,
 		poolActor:          &actorTypes.TxPoolActor{Pool: txpool},
 		p2p:                p2p,
 		ledger:             ledger.DefLedger,
@@ -213,6 +213,9 @@ func (self *Server) handleBlockPersistCompleted(block *types.Block) {
 	}
 	self.completedBlockNum = block.Header.Height
 	self.incrValidator.AddBlock(block)
+	if info, err := vconfig.VbftBlock(block.Header); err == nil && info.Proposer == self.Index {
+		self.announceBlock(block)
+	}
 	if self.nonConsensusNode() {
 		self.chainStore.ReloadFromLedger()
 		self.metaLock.Lock()
@@ -366,7 +369,7 @@ func (self *Server) updateChainConfig() error {
 	// 2. remove nonparticipation consensus node
 	// 3. update statemgr peers
 	// 4. reset remove peer connections, create new connections with new peers
-	pubkey := vconfig.PubkeyID(self.account.PublicKey)
+	pubkey := vconfig.PubkeyID(self.signer.PubKey())
 	peermap := make(map[uint32]string)
 	for _, p := range self.config.Peers {
 		peermap[p.Index] = p.ID
@@ -427,7 +430,7 @@ func (self *Server) initialize() error {
 	// TODO: load config from chain
 
 	// TODO: configurable log
-	selfNodeId := vconfig.PubkeyID(self.account.PublicKey)
+	selfNodeId := vconfig.PubkeyID(self.signer.PubKey())
 	log.Infof("server: %s starting", selfNodeId)
 
 	store, err := OpenBlockStore(self.ledger, self.pid)
@@ -476,7 +479,7 @@ func (self *Server) initialize() error {
 	}
 
 	//index equal math.MaxUint32  is noconsensus node
-	id := vconfig.PubkeyID(self.account.PublicKey)
+	id := vconfig.PubkeyID(self.signer.PubKey())
 	index, present := self.peerPool.GetPeerIndex(id)
 	if present {
 		self.Index = index
@@ -516,7 +519,7 @@ func (self *Server) initialize() error {
 
 func (self *Server) start() error {
 	// check if server pubkey support VRF
-	if !vrf.ValidatePrivateKey(self.account.PrivateKey) || !vrf.ValidatePublicKey(self.account.PublicKey) {
+	if !vrf.ValidatePublicKey(self.signer.PubKey()) {
 		return fmt.Errorf("server %d consensus start failed: invalid account key for VRF", self.Index)
 	}
 
@@ -30,6 +30,7 @@ import (
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/consensus/signer"
 	vconfig "github.com/ontio/ontology/consensus/vbft/config"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/signature"
@@ -95,7 +96,7 @@ type vrfData struct {
 	PrevVrf  []byte `json:"prev_vrf"`
 }
 
-func computeVrf(sk keypair.PrivateKey, blkNum uint32, prevVrf []byte) ([]byte, []byte, error) {
+func computeVrf(signer signer.Signer, blkNum uint32, prevVrf []byte) ([]byte, []byte, error) {
 	data, err := json.Marshal(&vrfData{
 		BlockNum: blkNum,
 		PrevVrf:  prevVrf,
@@ -104,7 +105,7 @@ func computeVrf(sk keypair.PrivateKey, blkNum uint32, prevVrf []byte) ([]byte, [
 		return nil, nil, fmt.Errorf("computeVrf failed to marshal vrfData: %s", err)
 	}
 
-	return vrf.Vrf(sk, data)
+	return signer.ComputeVrf(data)
 }
 
 func verifyVrf(pk keypair.PublicKey, blkNum uint32, prevVrf, newVrf, proof []byte) error {
@@ -26,6 +26,7 @@ import (
 
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/consensus/signer"
 )
 
 func HashBlock(blk *Block) (common.Uint256, error) {
@@ -90,7 +91,7 @@ func TestVrf(t *testing.T) {
 	user := account.NewAccount("")
 	prevVrf := []byte("test string")
 	blkNum := uint32(10)
-	v1, p1, err := computeVrf(user.PrivKey(), blkNum, prevVrf)
+	v1, p1, err := computeVrf(signer.NewLocalSigner(user), blkNum, prevVrf)
 	if err != nil {
 		t.Fatalf("compute vrf: %s", err)
 	}
@@ -30,6 +30,7 @@ import (
 	"github.com/ontio/ontology/core/store/ledgerstore"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
 	cstate "github.com/ontio/ontology/smartcontract/states"
 )
 
@@ -49,6 +50,23 @@ func NewLedger(dataDir string, stateHashHeight uint32) (*Ledger, error) {
 	}, nil
 }
 
+//NewForkLedger return a ledger forked from the head of ledger in srcDir and the genesis block of source ledger.
+//New data are saved to forkDir only
+func NewForkLedger(srcDir, forkDir string) (*Ledger, *types.Block, error) {
+	ldgStore, err := ledgerstore.NewForkLedgerStore(srcDir, forkDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("NewForkLedgerStore error %s", err)
+	}
+	genesisBlock, err := ldgStore.GetGenesisBlock()
+	if err != nil {
+		ldgStore.Close()
+		return nil, nil, fmt.Errorf("get genesis block error %s", err)
+	}
+	return &Ledger{
+		ldgStore: ldgStore,
+	}, genesisBlock, nil
+}
+
 func (self *Ledger) GetStore() store.LedgerStore {
 	return self.ldgStore
 }
@@ -73,6 +91,16 @@ func (self *Ledger) AddBlock(block *types.Block, ccMsg *types.CrossChainMsg, sta
 	return err
 }
 
+//AddBlocks save consecutive blocks, return the number of leading blocks in ledger after the call
+func (self *Ledger) AddBlocks(blocks []*types.Block, ccMsgs []*types.CrossChainMsg, stateMerkleRoots []common.Uint256) (int, error) {
+	saved, err := self.ldgStore.AddBlocks(blocks, ccMsgs, stateMerkleRoots)
+	if err != nil && saved < len(blocks) {
+		block := blocks[saved]
+		log.Errorf("Ledger AddBlocks BlockHeight:%d BlockHash:%x error:%s", block.Header.Height, block.Hash(), err)
+	}
+	return saved, err
+}
+
 func (self *Ledger) ExecuteBlock(b *types.Block) (store.ExecuteResult, error) {
 	return self.ldgStore.ExecuteBlock(b)
 }
@@ -172,6 +200,14 @@ func (self *Ledger) GetContractState(contractHash common.Address) (*payload.Depl
 	return self.ldgStore.GetContractState(contractHash)
 }
 
+func (self *Ledger) GetContractHistory(contractHash common.Address) (*states.ContractHistory, error) {
+	return self.ldgStore.GetContractHistory(contractHash)
+}
+
+func (self *Ledger) GetContractStorageSize(contractHash common.Address) (uint64, uint64, error) {
+	return self.ldgStore.GetContractStorageSize(contractHash)
+}
+
 func (self *Ledger) GetMerkleProof(proofHeight, rootHeight uint32) ([]common.Uint256, error) {
 	return self.ldgStore.GetMerkleProof(proofHeight, rootHeight)
 }
@@ -180,6 +216,15 @@ func (self *Ledger) PreExecuteContract(tx *types.Transaction) (*cstate.PreExecRe
 	return self.ldgStore.PreExecuteContract(tx)
 }
 
+//PreExecuteContractWithDebugger pre-execute the transaction with every neovm opcode reported to the debugger
+func (self *Ledger) PreExecuteContractWithDebugger(tx *types.Transaction, debugger neovm.Debugger) (*cstate.PreExecResult, error) {
+	ldgStore, ok := self.ldgStore.(*ledgerstore.LedgerStoreImp)
+	if !ok {
+		return nil, fmt.Errorf("ledger store does not support debugging")
+	}
+	return ldgStore.PreExecuteContractWithParam(tx, ledgerstore.PrexecuteParam{MinGas: true, Debugger: debugger})
+}
+
 func (self *Ledger) PreExecuteContractBatch(txes []*types.Transaction, atomic bool) ([]*cstate.PreExecResult, uint32, error) {
 	return self.ldgStore.PreExecuteContractBatch(txes, atomic)
 }
@@ -207,3 +252,11 @@ func (self *Ledger) Close() error {
 func (self *Ledger) EnableBlockPrune(numBeforeCurr uint32) {
 	self.ldgStore.EnableBlockPrune(numBeforeCurr)
 }
+
+func (self *Ledger) EnableLightMode() {
+	self.ldgStore.EnableLightMode()
+}
+
+func (self *Ledger) IsLightMode() bool {
+	return self.ldgStore.IsLightMode()
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package states
+
+import (
+	"io"
+
+	"github.com/ontio/ontology/common"
+)
+
+//ContractVersion records one upgrade of the code deployed at a contract address
+type ContractVersion struct {
+	Version      uint32
+	CodeHash     common.Uint256
+	PrevCodeHash common.Uint256
+	Height       uint32
+	Upgrader     common.Address
+	TxHash       common.Uint256
+}
+
+func (this *ContractVersion) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.Version)
+	sink.WriteHash(this.CodeHash)
+	sink.WriteHash(this.PrevCodeHash)
+	sink.WriteUint32(this.Height)
+	sink.WriteAddress(this.Upgrader)
+	sink.WriteHash(this.TxHash)
+}
+
+func (this *ContractVersion) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	if this.Version, eof = source.NextUint32(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	if this.CodeHash, eof = source.NextHash(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	if this.PrevCodeHash, eof = source.NextHash(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	if this.Height, eof = source.NextUint32(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	if this.Upgrader, eof = source.NextAddress(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	if this.TxHash, eof = source.NextHash(); eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//ContractHistory is the upgrade history of a contract address, ordered by version
+type ContractHistory struct {
+	Versions []*ContractVersion
+}
+
+func (this *ContractHistory) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Versions)))
+	for _, v := range this.Versions {
+		v.Serialization(sink)
+	}
+}
+
+func (this *ContractHistory) Deserialization(source *common.ZeroCopySource) error {
+	n, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	var versions []*ContractVersion
+	for i := uint64(0); i < n; i++ {
+		v := new(ContractVersion)
+		if err := v.Deserialization(source); err != nil {
+			return err
+		}
+		versions = append(versions, v)
+	}
+	this.Versions = versions
+	return nil
+}
+
+//Latest return the last upgrade record, or nil if the contract has never been upgraded
+func (this *ContractHistory) Latest() *ContractVersion {
+	if len(this.Versions) == 0 {
+		return nil
+	}
+	return this.Versions[len(this.Versions)-1]
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package states
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractHistory_Serialize_Deserialize(t *testing.T) {
+	history := &ContractHistory{}
+	assert.Nil(t, history.Latest())
+	for i := uint32(1); i <= 3; i++ {
+		history.Versions = append(history.Versions, &ContractVersion{
+			Version:      i,
+			CodeHash:     common.Uint256{byte(i)},
+			PrevCodeHash: common.Uint256{byte(i - 1)},
+			Height:       i * 10,
+			Upgrader:     common.Address{byte(i)},
+			TxHash:       common.Uint256{0xff, byte(i)},
+		})
+	}
+
+	raw := common.SerializeToBytes(history)
+	var decoded ContractHistory
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(raw)))
+	assert.Equal(t, history, &decoded)
+	assert.Equal(t, uint32(3), decoded.Latest().Version)
+
+	assert.NotNil(t, decoded.Deserialization(common.NewZeroCopySource(raw[:len(raw)-1])))
+}
@@ -29,9 +29,12 @@ const (
 	DATA_STATE_MERKLE_ROOT                 = 0x21 // block height => write set hash + state merkle root
 
 	// Transaction
-	ST_BOOKKEEPER DataEntryPrefix = 0x03 //BookKeeper state key prefix
-	ST_CONTRACT   DataEntryPrefix = 0x04 //Smart contract state key prefix
-	ST_STORAGE    DataEntryPrefix = 0x05 //Smart contract storage key prefix
+	ST_BOOKKEEPER        DataEntryPrefix = 0x03 //BookKeeper state key prefix
+	ST_CONTRACT          DataEntryPrefix = 0x04 //Smart contract state key prefix
+	ST_STORAGE           DataEntryPrefix = 0x05 //Smart contract storage key prefix
+	ST_CONTRACT_HISTORY  DataEntryPrefix = 0x06 //Smart contract upgrade history key prefix
+	ST_STORAGE_SIZE      DataEntryPrefix = 0x07 //Smart contract storage size key prefix
+	ST_STORAGE_ACCOUNTED DataEntryPrefix = 0x08 //Smart contract storage item accounted flag key prefix
 
 	IX_HEADER_HASH_LIST DataEntryPrefix = 0x09 //Block height => block hash key prefix
 
@@ -42,6 +45,7 @@ const (
 	SYS_BLOCK_MERKLE_TREE    DataEntryPrefix = 0x13 // Block merkle tree root key prefix
 	SYS_STATE_MERKLE_TREE    DataEntryPrefix = 0x20 // state merkle tree root key prefix
 	SYS_CROSS_CHAIN_MSG      DataEntryPrefix = 0x22 // state merkle tree root key prefix
+	SYS_LIGHT_MODE           DataEntryPrefix = 0x23 // light mode flag key prefix
 
 	EVENT_NOTIFY DataEntryPrefix = 0x14 //Event notify key prefix
 
@@ -26,6 +26,7 @@ import (
 )
 
 var ErrNotFound = errors.New("not found")
+var ErrLightMode = errors.New("not available in light mode")
 
 //Store iterator for iterate store
 type StoreIterator interface {
@@ -41,6 +41,14 @@ type BlockStore struct {
 
 //NewBlockStore return the block store instance
 func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
+	store, err := leveldbstore.NewLevelDBStore(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	return newBlockStore(dbDir, store, enableCache)
+}
+
+func newBlockStore(dbDir string, store *leveldbstore.LevelDBStore, enableCache bool) (*BlockStore, error) {
 	var cache *BlockCache
 	var err error
 	if enableCache {
@@ -49,11 +57,6 @@ func NewBlockStore(dbDir string, enableCache bool) (*BlockStore, error) {
 			return nil, fmt.Errorf("NewBlockCache error %s", err)
 		}
 	}
-
-	store, err := leveldbstore.NewLevelDBStore(dbDir)
-	if err != nil {
-		return nil, err
-	}
 	blockStore := &BlockStore{
 		dbDir:       dbDir,
 		enableCache: enableCache,
@@ -442,6 +445,23 @@ func (this *BlockStore) SaveVersion(ver byte) error {
 	return this.store.Put(key, []byte{ver})
 }
 
+//IsLightMode return whether the block store only saves block headers
+func (this *BlockStore) IsLightMode() (bool, error) {
+	_, err := this.store.Get(genLightModeKey())
+	if err == scom.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+//SaveLightMode mark the block store only saves block headers
+func (this *BlockStore) SaveLightMode() error {
+	return this.store.Put(genLightModeKey(), []byte{1})
+}
+
 //ClearAll clear all the data of block store
 func (this *BlockStore) ClearAll() error {
 	this.NewBatch()
@@ -496,6 +516,10 @@ func genVersionKey() []byte {
 	return []byte{byte(scom.SYS_VERSION)}
 }
 
+func genLightModeKey() []byte {
+	return []byte{byte(scom.SYS_LIGHT_MODE)}
+}
+
 func genHeaderIndexListKey(startHeight uint32) []byte {
 	sink := common.NewZeroCopySink(nil)
 	sink.WriteByte(byte(scom.IX_HEADER_HASH_LIST))
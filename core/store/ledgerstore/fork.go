@@ -0,0 +1,206 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/types"
+)
+
+//ForkInfoFile records the block of source ledger where the fork starts
+const ForkInfoFile = "fork.json"
+
+//ForkInfo is the source block where the fork starts
+type ForkInfo struct {
+	Height    uint32 `json:"height"`
+	BlockHash string `json:"block_hash"`
+}
+
+//NewForkLedgerStore return LedgerStoreImp instance which reads through the ledger in srcDir at its current block
+//height. All new data are saved to forkDir, and the ledger in srcDir is never modified. Ledger keeps no historical
+//states, to fork at an earlier height, build the source ledger by importing blocks with end height first.
+func NewForkLedgerStore(srcDir, forkDir string) (*LedgerStoreImp, error) {
+	var opened []scom.PersistStore
+	closeAll := func() {
+		for _, store := range opened {
+			store.Close()
+		}
+	}
+	bases := make(map[string]*leveldbstore.LevelDBStore)
+	for _, dir := range []string{DBDirBlock, DBDirState, DBDirEvent, DBDirCrossChain} {
+		store, err := leveldbstore.NewReadOnlyLevelDBStore(filepath.Join(srcDir, dir))
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("open source store %s error %s", dir, err)
+		}
+		opened = append(opened, store)
+		bases[dir] = store
+	}
+
+	srcHash, srcHeight, err := (&BlockStore{store: bases[DBDirBlock]}).GetCurrentBlock()
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("get source current block error %s", err)
+	}
+	stateHashHeight, err := forkStateHashCheckHeight(&StateStore{store: bases[DBDirState]})
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	info := &ForkInfo{Height: srcHeight, BlockHash: srcHash.ToHexString()}
+	err = initForkDir(srcDir, forkDir, info)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+
+	stores := make(map[string]*leveldbstore.LevelDBStore)
+	for _, dir := range []string{DBDirBlock, DBDirState, DBDirEvent, DBDirCrossChain} {
+		local, err := leveldbstore.NewLevelDBStore(filepath.Join(forkDir, dir))
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("open fork store %s error %s", dir, err)
+		}
+		opened = append(opened, local)
+		stores[dir] = leveldbstore.NewForkStore(bases[dir], local)
+	}
+
+	ledgerStore := &LedgerStoreImp{
+		headerIndex:          make(map[uint32]common.Uint256),
+		headerCache:          make(map[common.Uint256]*types.Header, 0),
+		vbftPeerInfoheader:   make(map[string]uint32),
+		vbftPeerInfoblock:    make(map[string]uint32),
+		savingBlockSemaphore: make(chan bool, 1),
+		stateHashCheckHeight: stateHashHeight,
+		forked:               true,
+		forkHeight:           srcHeight,
+	}
+	ledgerStore.blockStore, err = newBlockStore(filepath.Join(forkDir, DBDirBlock), stores[DBDirBlock], true)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("NewBlockStore error %s", err)
+	}
+	ledgerStore.stateStore, err = newStateStore(filepath.Join(forkDir, DBDirState),
+		filepath.Join(forkDir, MerkleTreeStorePath), stores[DBDirState], stateHashHeight)
+	if err != nil {
+		closeAll()
+		return nil, fmt.Errorf("NewStateStore error %s", err)
+	}
+	ledgerStore.eventStore = &EventStore{dbDir: filepath.Join(forkDir, DBDirEvent), store: stores[DBDirEvent]}
+	ledgerStore.crossChainStore = &CrossChainStore{
+		dbDir: filepath.Join(forkDir, DBDirCrossChain),
+		store: stores[DBDirCrossChain],
+	}
+
+	log.Infof("Fork ledger from %s at height %d, block hash %s", srcDir, info.Height, info.BlockHash)
+	return ledgerStore, nil
+}
+
+//forkStateHashCheckHeight return the state hash check height of source ledger, which is derived from the size
+//of state merkle tree
+func forkStateHashCheckHeight(stateStore *StateStore) (uint32, error) {
+	_, height, err := stateStore.GetCurrentBlock()
+	if err != nil {
+		return 0, fmt.Errorf("get source current state error %s", err)
+	}
+	treeSize, _, err := stateStore.GetStateMerkleTree()
+	if err != nil && err != scom.ErrNotFound {
+		return 0, fmt.Errorf("get source state merkle tree error %s", err)
+	}
+	return height + 1 - treeSize, nil
+}
+
+//initForkDir creates the fork dir with the block merkle tree copied from srcDir, or checks that the existing fork
+//dir is forked from the same source block
+func initForkDir(srcDir, forkDir string, info *ForkInfo) error {
+	infoPath := filepath.Join(forkDir, ForkInfoFile)
+	if common.FileExisted(infoPath) {
+		data, err := ioutil.ReadFile(infoPath)
+		if err != nil {
+			return fmt.Errorf("read fork info error %s", err)
+		}
+		old := &ForkInfo{}
+		err = json.Unmarshal(data, old)
+		if err != nil {
+			return fmt.Errorf("parse fork info error %s", err)
+		}
+		if *old != *info {
+			return fmt.Errorf("source ledger has changed since forked at height %d, block hash %s",
+				old.Height, old.BlockHash)
+		}
+		return nil
+	}
+
+	err := os.MkdirAll(forkDir, 0755)
+	if err != nil {
+		return fmt.Errorf("create fork dir error %s", err)
+	}
+	err = copyFile(filepath.Join(srcDir, MerkleTreeStorePath), filepath.Join(forkDir, MerkleTreeStorePath))
+	if err != nil {
+		return fmt.Errorf("copy merkle tree error %s", err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(infoPath, data, 0644)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+//GetGenesisBlock return the genesis block in block store. It's available before the ledger store is initialized
+func (this *LedgerStoreImp) GetGenesisBlock() (*types.Block, error) {
+	blockHash, err := this.blockStore.GetBlockHash(0)
+	if err != nil {
+		return nil, err
+	}
+	return this.blockStore.GetBlock(blockHash)
+}
+
+//isForkStart return whether the block is the first block produced after fork, whose bookkeepers are different
+//from the next bookkeeper of source ledger
+func (this *LedgerStoreImp) isForkStart(height uint32) bool {
+	return this.forked && height == this.forkHeight+1
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkLedgerStore(t *testing.T) {
+	srcDir, forkDir := "test/forksrc", "test/fork"
+	acc := account.NewAccount("")
+	bookkeepers := []keypair.PublicKey{acc.PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+
+	srcStore, err := NewLedgerStore(srcDir, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, srcStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Nil(t, srcStore.Close())
+
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+
+	forkStore, err := NewForkLedgerStore(srcDir, forkDir)
+	assert.Nil(t, err)
+	assert.Nil(t, forkStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+
+	// new blocks are signed by a bookkeeper different from the source ledger
+	forkAcc := account.NewAccount("")
+	block := newForkTestBlock(t, forkStore, forkAcc)
+	result, err := forkStore.ExecuteBlock(block)
+	assert.Nil(t, err)
+	assert.Nil(t, forkStore.SubmitBlock(block, nil, result))
+	assert.Equal(t, uint32(1), forkStore.GetCurrentBlockHeight())
+	assert.Nil(t, forkStore.Close())
+
+	forkStore, err = NewForkLedgerStore(srcDir, forkDir)
+	assert.Nil(t, err)
+	assert.Nil(t, forkStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Equal(t, block.Hash(), forkStore.GetCurrentBlockHash())
+	assert.Nil(t, forkStore.Close())
+
+	srcStore, err = NewLedgerStore(srcDir, 0)
+	assert.Nil(t, err)
+	_, height, err := srcStore.blockStore.GetCurrentBlock()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), height)
+	assert.Nil(t, srcStore.Close())
+}
+
+func newForkTestBlock(t *testing.T, store *LedgerStoreImp, acc *account.Account) *types.Block {
+	return newTestBlockWithTxs(t, store, acc, nil)
+}
@@ -73,6 +73,10 @@ type PrexecuteParam struct {
 	JitMode    bool
 	WasmFactor uint64
 	MinGas     bool
+	//override gas table entries, such as wasm gas schedule params
+	GasTable map[string]uint64
+	//debugger of neovm contracts
+	Debugger neovm.Debugger
 }
 
 //LedgerStoreImp is main store struct fo ledger
@@ -94,6 +98,11 @@ type LedgerStoreImp struct {
 	savingBlockSemaphore       chan bool
 	closing                    bool
 	preserveBlockHistoryLength uint32 // block could be pruned if blockHeight + preserveBlockHistoryLength < currHeight , disable prune if equals 0
+
+	forked     bool   //Whether the ledger is forked from a source ledger
+	forkHeight uint32 //Block height of source ledger where the fork starts
+
+	lightMode bool //Whether only block headers are synced and saved
 }
 
 //NewLedgerStore return LedgerStoreImp instance
@@ -177,6 +186,12 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if err != nil {
 			return fmt.Errorf("init error %s", err)
 		}
+		if this.lightMode {
+			err = this.blockStore.SaveLightMode()
+			if err != nil {
+				return fmt.Errorf("SaveLightMode error %s", err)
+			}
+		}
 		genHash := genesisBlock.Hash()
 		log.Infof("GenesisBlock init success. GenesisBlock hash:%s\n", genHash.ToHexString())
 	} else {
@@ -188,6 +203,13 @@ func (this *LedgerStoreImp) InitLedgerStoreWithGenesisBlock(genesisBlock *types.
 		if !exist {
 			return fmt.Errorf("GenesisBlock arenot init correctly")
 		}
+		lightMode, err := this.blockStore.IsLightMode()
+		if err != nil {
+			return fmt.Errorf("IsLightMode error %s", err)
+		}
+		if lightMode != this.lightMode {
+			return fmt.Errorf("ledger data is incompatible with light mode setting, ledger light mode:%v", lightMode)
+		}
 		err = this.init()
 		if err != nil {
 			return fmt.Errorf("init error %s", err)
@@ -256,6 +278,10 @@ func (this *LedgerStoreImp) init() error {
 	if err != nil {
 		return fmt.Errorf("loadHeaderIndexList error %s", err)
 	}
+	//light ledger has no states of blocks except genesis block
+	if this.lightMode {
+		return nil
+	}
 	err = this.recoverStore()
 	if err != nil {
 		return fmt.Errorf("recoverStore error %s", err)
@@ -324,7 +350,7 @@ func (this *LedgerStoreImp) recoverStore() error {
 		if err != nil {
 			return fmt.Errorf("save to state store height:%d error:%s", i, err)
 		}
-		this.saveBlockToEventStore(block)
+		this.saveBlockToEventStore(block, result)
 		err = this.eventStore.CommitTo()
 		if err != nil {
 			return fmt.Errorf("eventStore.CommitTo height:%d error %s", i, err)
@@ -487,7 +513,7 @@ func (this *LedgerStoreImp) verifyHeader(header *types.Header, vbftPeerInfo map[
 		if err != nil {
 			return vbftPeerInfo, err
 		}
-		if prevHeader.NextBookkeeper != address {
+		if prevHeader.NextBookkeeper != address && !this.isForkStart(header.Height) {
 			return vbftPeerInfo, fmt.Errorf("bookkeeper address error")
 		}
 
@@ -532,6 +558,9 @@ func (this *LedgerStoreImp) AddHeader(header *types.Header) error {
 	if err != nil {
 		return fmt.Errorf("verifyHeader error %s", err)
 	}
+	if this.lightMode {
+		return this.saveLightHeader(header)
+	}
 	this.addHeaderCache(header)
 	this.setHeaderIndex(header.Height, header.Hash())
 	return nil
@@ -552,11 +581,45 @@ func (this *LedgerStoreImp) AddHeaders(headers []*types.Header) error {
 	return nil
 }
 
+//saveLightHeader persist the header as a block without transactions in light mode
+func (this *LedgerStoreImp) saveLightHeader(header *types.Header) error {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+	if this.closing {
+		return errors.NewErr("save header error: ledger is closing")
+	}
+	block := &types.Block{Header: header}
+	blockHash := block.Hash()
+	this.blockStore.NewBatch()
+	err := this.saveBlockToBlockStore(block)
+	if err != nil {
+		return fmt.Errorf("save to block store height:%d error:%s", header.Height, err)
+	}
+	err = this.blockStore.CommitTo()
+	if err != nil {
+		return fmt.Errorf("blockStore.CommitTo height:%d error %s", header.Height, err)
+	}
+	this.setCurrentBlock(header.Height, blockHash)
+	this.vbftPeerInfoblock = this.vbftPeerInfoheader
+
+	if events.DefActorPublisher != nil {
+		events.DefActorPublisher.Publish(
+			message.TOPIC_SAVE_BLOCK_COMPLETE,
+			&message.SaveBlockCompleteMsg{
+				Block: block,
+			})
+	}
+	return nil
+}
+
 func (this *LedgerStoreImp) GetStateMerkleRoot(height uint32) (common.Uint256, error) {
 	return this.stateStore.GetStateMerkleRoot(height)
 }
 
 func (this *LedgerStoreImp) ExecuteBlock(block *types.Block) (result store.ExecuteResult, err error) {
+	if this.lightMode {
+		return result, scom.ErrLightMode
+	}
 	this.getSavingBlockLock()
 	defer this.releaseSavingBlockLock()
 	currBlockHeight := this.GetCurrentBlockHeight()
@@ -576,6 +639,9 @@ func (this *LedgerStoreImp) ExecuteBlock(block *types.Block) (result store.Execu
 }
 
 func (this *LedgerStoreImp) SubmitBlock(block *types.Block, ccMsg *types.CrossChainMsg, result store.ExecuteResult) error {
+	if this.lightMode {
+		return scom.ErrLightMode
+	}
 	this.getSavingBlockLock()
 	defer this.releaseSavingBlockLock()
 	if this.closing {
@@ -591,28 +657,9 @@ func (this *LedgerStoreImp) SubmitBlock(block *types.Block, ccMsg *types.CrossCh
 		return fmt.Errorf("block height %d not equal next block height %d", blockHeight, nextBlockHeight)
 	}
 
-	var err error
-	this.vbftPeerInfoblock, err = this.verifyHeader(block.Header, this.vbftPeerInfoblock)
+	err := this.verifyBlock(block, ccMsg)
 	if err != nil {
-		return fmt.Errorf("verifyHeader error %s", err)
-	}
-	if ccMsg != nil {
-		if ccMsg.Height != currBlockHeight {
-			return fmt.Errorf("cross chain msg height %d not equal next block height %d", blockHeight, ccMsg.Height)
-		}
-		if ccMsg.Version != types.CURR_CROSS_STATES_VERSION {
-			return fmt.Errorf("error cross chain msg version excepted:%d actual:%d", types.CURR_CROSS_STATES_VERSION, ccMsg.Version)
-		}
-		root, err := this.stateStore.GetCrossStatesRoot(ccMsg.Height)
-		if err != nil {
-			return fmt.Errorf("get cross states root fail:%s", err)
-		}
-		if root != ccMsg.StatesRoot {
-			return fmt.Errorf("cross state root compare fail, expected:%x actual:%x", ccMsg.StatesRoot, root)
-		}
-		if err := this.verifyCrossChainMsg(ccMsg, block.Header.Bookkeepers); err != nil {
-			return fmt.Errorf("verifyCrossChainMsg error: %s", err)
-		}
+		return err
 	}
 
 	err = this.submitBlock(block, ccMsg, result)
@@ -626,6 +673,9 @@ func (this *LedgerStoreImp) SubmitBlock(block *types.Block, ccMsg *types.CrossCh
 //AddBlock add the block to store.
 //When the block is not the next block, it will be cache. until the missing block arrived
 func (this *LedgerStoreImp) AddBlock(block *types.Block, ccMsg *types.CrossChainMsg, stateMerkleRoot common.Uint256) error {
+	if this.lightMode {
+		return scom.ErrLightMode
+	}
 	currBlockHeight := this.GetCurrentBlockHeight()
 	blockHeight := block.Header.Height
 	if blockHeight <= currBlockHeight {
@@ -635,6 +685,22 @@ func (this *LedgerStoreImp) AddBlock(block *types.Block, ccMsg *types.CrossChain
 	if blockHeight != nextBlockHeight {
 		return fmt.Errorf("block height %d not equal next block height %d", blockHeight, nextBlockHeight)
 	}
+	err := this.verifyBlock(block, ccMsg)
+	if err != nil {
+		return err
+	}
+	err = this.saveBlock(block, ccMsg, stateMerkleRoot)
+	if err != nil {
+		return fmt.Errorf("saveBlock error %s", err)
+	}
+	this.delHeaderCache(block.Hash())
+	return nil
+}
+
+//verifyBlock check the header and cross chain msg of the next block
+func (this *LedgerStoreImp) verifyBlock(block *types.Block, ccMsg *types.CrossChainMsg) error {
+	currBlockHeight := this.GetCurrentBlockHeight()
+	blockHeight := block.Header.Height
 	var err error
 	this.vbftPeerInfoblock, err = this.verifyHeader(block.Header, this.vbftPeerInfoblock)
 	if err != nil {
@@ -658,11 +724,6 @@ func (this *LedgerStoreImp) AddBlock(block *types.Block, ccMsg *types.CrossChain
 			return fmt.Errorf("verifyCrossChainMsg error: %s", err)
 		}
 	}
-	err = this.saveBlock(block, ccMsg, stateMerkleRoot)
-	if err != nil {
-		return fmt.Errorf("saveBlock error %s", err)
-	}
-	this.delHeaderCache(block.Hash())
 	return nil
 }
 
@@ -688,7 +749,29 @@ func (this *LedgerStoreImp) saveBlockToBlockStore(block *types.Block) error {
 }
 
 func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.ExecuteResult, err error) {
-	overlay := this.stateStore.NewOverlayDB()
+	result, err = this.executeBlockWith(block, this, this.stateStore.store)
+	if err != nil {
+		return
+	}
+	result.MerkleRoot = this.stateMerkleRoot(block.Header.Height, result)
+	return
+}
+
+//stateMerkleRoot return the state merkle root of the block next to current block
+func (this *LedgerStoreImp) stateMerkleRoot(height uint32, result store.ExecuteResult) common.Uint256 {
+	if height < this.stateHashCheckHeight {
+		return common.UINT256_EMPTY
+	} else if height == this.stateHashCheckHeight {
+		// write set hash is the total state hash at check height
+		return result.Hash
+	}
+	return this.stateStore.GetStateMerkleRootWithNewHash(result.Hash)
+}
+
+//executeBlockWith execute block on the state of store, the merkle root of result is not calculated
+func (this *LedgerStoreImp) executeBlockWith(block *types.Block, ledger store.LedgerStore,
+	state scom.PersistStore) (result store.ExecuteResult, err error) {
+	overlay := overlaydb.NewOverlayDB(state)
 	if block.Header.Height != 0 {
 		config := &smartcontract.Config{
 			Time:   block.Header.Timestamp,
@@ -696,7 +779,7 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 			Tx:     &types.Transaction{},
 		}
 
-		err = refreshGlobalParam(config, storage.NewCacheDB(this.stateStore.NewOverlayDB()), this)
+		err = refreshGlobalParam(config, storage.NewCacheDB(overlaydb.NewOverlayDB(state)), ledger)
 		if err != nil {
 			return
 		}
@@ -710,17 +793,19 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 		return true
 	})
 
-	cache := storage.NewCacheDB(overlay)
-	for _, tx := range block.Transactions {
-		cache.Reset()
-		notify, crossStateHashes, e := this.handleTransaction(overlay, cache, gasTable, block, tx)
-		if e != nil {
-			err = e
-			return
-		}
-		result.Notify = append(result.Notify, notify)
-		result.CrossStates = append(result.CrossStates, crossStateHashes...)
+	scheduled, err := this.executeScheduledCalls(ledger, overlay, gasTable, block)
+	if err != nil {
+		return
+	}
+	if parallelExecEnabled(block) {
+		result.Notify, result.CrossStates, err = this.executeTransactionsParallel(ledger, state, overlay, gasTable, block)
+	} else {
+		result.Notify, result.CrossStates, err = this.executeTransactions(ledger, overlay, gasTable, block)
+	}
+	if err != nil {
+		return
 	}
+	result.Notify = append(scheduled, result.Notify...)
 	result.Hash = overlay.ChangeHash()
 	result.WriteSet = overlay.GetWriteSet()
 	if len(result.CrossStates) != 0 {
@@ -729,19 +814,13 @@ func (this *LedgerStoreImp) executeBlock(block *types.Block) (result store.Execu
 	} else {
 		result.CrossStatesRoot = common.UINT256_EMPTY
 	}
-	if block.Header.Height < this.stateHashCheckHeight {
-		result.MerkleRoot = common.UINT256_EMPTY
-	} else if block.Header.Height == this.stateHashCheckHeight {
+	if block.Header.Height == this.stateHashCheckHeight {
 		res, e := calculateTotalStateHash(overlay)
 		if e != nil {
 			err = e
 			return
 		}
-
-		result.MerkleRoot = res
-		result.Hash = result.MerkleRoot
-	} else {
-		result.MerkleRoot = this.stateStore.GetStateMerkleRootWithNewHash(result.Hash)
+		result.Hash = res
 	}
 
 	return
@@ -818,10 +897,16 @@ func (this *LedgerStoreImp) saveBlockToStateStore(block *types.Block, result sto
 	return nil
 }
 
-func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block) {
+func (this *LedgerStoreImp) saveBlockToEventStore(block *types.Block, result store.ExecuteResult) {
 	blockHash := block.Hash()
 	blockHeight := block.Header.Height
 	txs := make([]common.Uint256, 0)
+	//scheduled calls are executed before transactions
+	if scheduled := len(result.Notify) - len(block.Transactions); scheduled > 0 {
+		for _, notify := range result.Notify[:scheduled] {
+			txs = append(txs, notify.TxHash)
+		}
+	}
 	for _, tx := range block.Transactions {
 		txHash := tx.Hash()
 		txs = append(txs, txHash)
@@ -908,7 +993,7 @@ func (this *LedgerStoreImp) submitBlock(block *types.Block, crossChainMsg *types
 	if err != nil {
 		return fmt.Errorf("save to state store height:%d error:%s", blockHeight, err)
 	}
-	this.saveBlockToEventStore(block)
+	this.saveBlockToEventStore(block, result)
 	err = this.blockStore.CommitTo()
 	if err != nil {
 		return fmt.Errorf("blockStore.CommitTo height:%d error %s", blockHeight, err)
@@ -963,7 +1048,7 @@ func (this *LedgerStoreImp) saveBlock(block *types.Block, ccMsg *types.CrossChai
 	return this.submitBlock(block, ccMsg, result)
 }
 
-func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cache *storage.CacheDB, gasTable map[string]uint64,
+func (this *LedgerStoreImp) handleTransaction(ledger store.LedgerStore, overlay *overlaydb.OverlayDB, cache *storage.CacheDB, gasTable map[string]uint64,
 	block *types.Block, tx *types.Transaction) (*event.ExecuteNotify, []common.Uint256, error) {
 	txHash := tx.Hash()
 	notify := &event.ExecuteNotify{TxHash: txHash, State: event.CONTRACT_STATE_FAIL}
@@ -971,7 +1056,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 	var err error
 	switch tx.TxType {
 	case types.Deploy:
-		err = this.stateStore.HandleDeployTransaction(this, overlay, gasTable, cache, tx, block, notify)
+		err = this.stateStore.HandleDeployTransaction(ledger, overlay, gasTable, cache, tx, block, notify)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -979,7 +1064,7 @@ func (this *LedgerStoreImp) handleTransaction(overlay *overlaydb.OverlayDB, cach
 			log.Debugf("HandleDeployTransaction tx %s error %s", txHash.ToHexString(), err)
 		}
 	case types.InvokeNeo, types.InvokeWasm:
-		crossStateHashes, err = this.stateStore.HandleInvokeTransaction(this, overlay, gasTable, cache, tx, block, notify)
+		crossStateHashes, err = this.stateStore.HandleInvokeTransaction(ledger, overlay, gasTable, cache, tx, block, notify)
 		if overlay.Error() != nil {
 			return nil, nil, fmt.Errorf("HandleInvokeTransaction tx %s error %s", txHash.ToHexString(), overlay.Error())
 		}
@@ -1053,6 +1138,9 @@ func (this *LedgerStoreImp) GetCrossChainMsg(height uint32) (*types.CrossChainMs
 }
 
 func (this *LedgerStoreImp) GetCrossStatesProof(height uint32, key []byte) ([]byte, error) {
+	if this.lightMode {
+		return nil, scom.ErrLightMode
+	}
 	hashes, err := this.stateStore.GetCrossStates(height)
 	if err != nil {
 		return nil, fmt.Errorf("GetCrossStates:%s", err)
@@ -1128,16 +1216,41 @@ func (this *LedgerStoreImp) GetBookkeeperState() (*states.BookkeeperState, error
 
 //GetMerkleProof return the block merkle proof. Wrap function of StateStore.GetMerkleProof
 func (this *LedgerStoreImp) GetMerkleProof(proofHeight, rootHeight uint32) ([]common.Uint256, error) {
+	if this.lightMode {
+		return nil, scom.ErrLightMode
+	}
 	return this.stateStore.GetMerkleProof(proofHeight, rootHeight)
 }
 
 //GetContractState return contract by contract address. Wrap function of StateStore.GetContractState
 func (this *LedgerStoreImp) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
+	if this.lightMode {
+		return nil, scom.ErrLightMode
+	}
 	return this.stateStore.GetContractState(contractHash)
 }
 
+//GetContractHistory return the upgrade history of contract. Wrap function of StateStore.GetContractHistory
+func (this *LedgerStoreImp) GetContractHistory(contractHash common.Address) (*states.ContractHistory, error) {
+	if this.lightMode {
+		return nil, scom.ErrLightMode
+	}
+	return this.stateStore.GetContractHistory(contractHash)
+}
+
+//GetContractStorageSize return the storage bytes of contract. Wrap function of StateStore.GetContractStorageSize
+func (this *LedgerStoreImp) GetContractStorageSize(contractHash common.Address) (uint64, uint64, error) {
+	if this.lightMode {
+		return 0, 0, scom.ErrLightMode
+	}
+	return this.stateStore.GetContractStorageSize(contractHash)
+}
+
 //GetStorageItem return the storage value of the key in smart contract. Wrap function of StateStore.GetStorageState
 func (this *LedgerStoreImp) GetStorageItem(key *states.StorageKey) (*states.StorageItem, error) {
+	if this.lightMode {
+		return nil, scom.ErrLightMode
+	}
 	return this.stateStore.GetStorageState(key)
 }
 
@@ -1173,6 +1286,9 @@ func (this *LedgerStoreImp) PreExecuteContractBatch(txes []*types.Transaction, a
 
 //PreExecuteContract return the result of smart contract execution without commit to store
 func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, preParam PrexecuteParam) (*sstate.PreExecResult, error) {
+	if this.lightMode {
+		return nil, scom.ErrLightMode
+	}
 	height := this.GetCurrentBlockHeight()
 	// use previous block time to make it predictable for easy test
 	blockTime := uint32(time.Now().Unix())
@@ -1202,6 +1318,9 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 
 		return true
 	})
+	for key, val := range preParam.GasTable {
+		gasTable[key] = val
+	}
 
 	if tx.TxType == types.InvokeNeo || tx.TxType == types.InvokeWasm {
 		invoke := tx.Payload.(*payload.InvokeCode)
@@ -1215,6 +1334,7 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 			WasmExecStep: config.DEFAULT_WASM_MAX_STEPCOUNT,
 			JitMode:      preParam.JitMode,
 			PreExec:      true,
+			Debugger:     preParam.Debugger,
 		}
 		//start the smart contract executive function
 		engine, _ := sc.NewExecuteEngine(invoke.Code, tx.TxType)
@@ -1252,6 +1372,9 @@ func (this *LedgerStoreImp) PreExecuteContractWithParam(tx *types.Transaction, p
 
 		if deploy.VmType() == payload.WASMVM_TYPE {
 			wasmCode := deploy.GetRawCode()
+			if err := wasmvm.CheckDeployCode(sconfig.Height, wasmCode); err != nil {
+				return stf, err
+			}
 			err := wasmvm.WasmjitValidate(wasmCode)
 			if err != nil {
 				return stf, err
@@ -1303,6 +1426,10 @@ func (this *LedgerStoreImp) Close() error {
 	if err != nil {
 		return fmt.Errorf("stateStore close error %s", err)
 	}
+	err = this.crossChainStore.Close()
+	if err != nil {
+		return fmt.Errorf("crossChainStore close error %s", err)
+	}
 	return nil
 }
 
@@ -1318,6 +1445,19 @@ func (this *LedgerStoreImp) EnableBlockPrune(numBeforeCurr uint32) {
 	this.preserveBlockHistoryLength = numBeforeCurr
 }
 
+//EnableLightMode make the ledger only sync and save block headers. It should be called before init
+func (this *LedgerStoreImp) EnableLightMode() {
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	this.lightMode = true
+}
+
+//IsLightMode return whether the ledger only saves block headers
+func (this *LedgerStoreImp) IsLightMode() bool {
+	return this.lightMode
+}
+
 func (this *LedgerStoreImp) maxAllowedPruneHeight(currHeader *types.Header) uint32 {
 	if currHeader.Height <= config.GetContractApiDeprecateHeight() {
 		return 0
@@ -0,0 +1,89 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLightLedgerStore(t *testing.T) {
+	fullDir, lightDir := "test/lightfull", "test/light"
+	acc := account.NewAccount("")
+	bookkeepers := []keypair.PublicKey{acc.PublicKey}
+	genesisBlock, err := genesis.BuildGenesisBlock(bookkeepers, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+
+	fullStore, err := NewLedgerStore(fullDir, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, fullStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	block := newForkTestBlock(t, fullStore, acc)
+	result, err := fullStore.ExecuteBlock(block)
+	assert.Nil(t, err)
+	assert.Nil(t, fullStore.SubmitBlock(block, nil, result))
+	assert.Nil(t, fullStore.Close())
+
+	lightStore, err := NewLedgerStore(lightDir, 0)
+	assert.Nil(t, err)
+	lightStore.EnableLightMode()
+	assert.Nil(t, lightStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Nil(t, lightStore.AddHeaders([]*types.Header{block.Header}))
+	assert.Equal(t, uint32(1), lightStore.GetCurrentBlockHeight())
+	assert.Equal(t, block.Hash(), lightStore.GetCurrentBlockHash())
+
+	_, err = lightStore.ExecuteBlock(block)
+	assert.Equal(t, scom.ErrLightMode, err)
+	_, err = lightStore.GetStorageItem(&states.StorageKey{})
+	assert.Equal(t, scom.ErrLightMode, err)
+	assert.Nil(t, lightStore.Close())
+
+	lightStore, err = NewLedgerStore(lightDir, 0)
+	assert.Nil(t, err)
+	lightStore.EnableLightMode()
+	assert.Nil(t, lightStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Equal(t, block.Hash(), lightStore.GetCurrentBlockHash())
+	header, err := lightStore.GetHeaderByHeight(1)
+	assert.Nil(t, err)
+	assert.Equal(t, block.Header.TransactionsRoot, header.TransactionsRoot)
+	assert.Nil(t, lightStore.Close())
+
+	// light ledger data can not be used by full node, and vice versa
+	lightStore, err = NewLedgerStore(lightDir, 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, lightStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Nil(t, lightStore.Close())
+
+	fullStore, err = NewLedgerStore(fullDir, 0)
+	assert.Nil(t, err)
+	fullStore.EnableLightMode()
+	assert.NotNil(t, fullStore.InitLedgerStoreWithGenesisBlock(genesisBlock, bookkeepers))
+	assert.Nil(t, fullStore.Close())
+}
@@ -0,0 +1,332 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	//Max goroutines executing transactions of a block concurrently, parallel execution is disabled if less than 2
+	ParallelExecWorkers = runtime.NumCPU()
+	//Blocks with less transactions are executed sequentially
+	ParallelExecMinTxs = 8
+)
+
+//ong balance of governance contract, which receives the gas fee of every transaction
+var gasFeeCollectorKey = append([]byte{byte(scom.ST_STORAGE)}, ont.GenBalanceKey(utils.OngContractAddress, utils.GovernanceContractAddress)...)
+
+//gasChargeObserver is notified when the gas fee of transaction is being charged
+type gasChargeObserver interface {
+	setCharging(charging bool)
+}
+
+type recordedRead struct {
+	value []byte
+	//only read when charging gas fee
+	charging bool
+}
+
+//readRecorder is a read only view of state store which records the reads of a speculatively executed transaction
+type readRecorder struct {
+	scom.PersistStore
+	reads    map[string]*recordedRead
+	prefixes [][]byte
+	charging bool
+}
+
+func (self *readRecorder) reset() {
+	self.reads = make(map[string]*recordedRead)
+	self.prefixes = nil
+	self.charging = false
+}
+
+func (self *readRecorder) Get(key []byte) ([]byte, error) {
+	value, err := self.PersistStore.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	if read, ok := self.reads[string(key)]; ok {
+		read.charging = read.charging && self.charging
+	} else {
+		self.reads[string(key)] = &recordedRead{value: value, charging: self.charging}
+	}
+	return value, err
+}
+
+func (self *readRecorder) NewIterator(prefix []byte) scom.StoreIterator {
+	self.prefixes = append(self.prefixes, append([]byte{}, prefix...))
+	return self.PersistStore.NewIterator(prefix)
+}
+
+func (self *readRecorder) Put(key []byte, value []byte) error {
+	return fmt.Errorf("speculative state is read only")
+}
+
+func (self *readRecorder) Delete(key []byte) error {
+	return fmt.Errorf("speculative state is read only")
+}
+
+//speculativeLedger passes the gas charging phase of transaction to the read recorder, and holds the runtime logs
+//until the speculation is committed
+type speculativeLedger struct {
+	store.LedgerStore
+	reads *readRecorder
+	logs  []*event.LogEventArgs
+}
+
+func (self *speculativeLedger) setCharging(charging bool) {
+	self.reads.charging = charging
+}
+
+func (self *speculativeLedger) CollectLog(log *event.LogEventArgs) {
+	self.logs = append(self.logs, log)
+}
+
+type keyValue struct {
+	key   []byte
+	value []byte
+}
+
+//txSpeculation is the result of a transaction executed on the state before block
+type txSpeculation struct {
+	tx          *types.Transaction
+	reads       map[string]*recordedRead
+	prefixes    [][]byte
+	writes      []keyValue
+	notify      *event.ExecuteNotify
+	logs        []*event.LogEventArgs
+	crossStates []common.Uint256
+	err         error
+	done        chan struct{}
+}
+
+//txSpeculator executes transactions on the state before block, the overlay is reused between transactions
+type txSpeculator struct {
+	ledger  *speculativeLedger
+	reads   *readRecorder
+	overlay *overlaydb.OverlayDB
+	cache   *storage.CacheDB
+}
+
+func newTxSpeculator(ledger store.LedgerStore, state scom.PersistStore) *txSpeculator {
+	reads := &readRecorder{PersistStore: state}
+	overlay := overlaydb.NewOverlayDB(reads)
+	return &txSpeculator{
+		ledger:  &speculativeLedger{LedgerStore: ledger, reads: reads},
+		reads:   reads,
+		overlay: overlay,
+		cache:   storage.NewCacheDB(overlay),
+	}
+}
+
+func parallelExecEnabled(block *types.Block) bool {
+	return ParallelExecWorkers > 1 && block.Header.Height != 0 && len(block.Transactions) >= ParallelExecMinTxs
+}
+
+//executeTransactions execute the transactions of block one by one on overlay
+func (this *LedgerStoreImp) executeTransactions(ledger store.LedgerStore, overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block) (notifies []*event.ExecuteNotify, crossStates []common.Uint256, err error) {
+	cache := storage.NewCacheDB(overlay)
+	for _, tx := range block.Transactions {
+		cache.Reset()
+		notify, crossStateHashes, e := this.handleTransaction(ledger, overlay, cache, gasTable, block, tx)
+		if e != nil {
+			return nil, nil, e
+		}
+		notifies = append(notifies, notify)
+		crossStates = append(crossStates, crossStateHashes...)
+	}
+	return
+}
+
+//executeTransactionsParallel execute all transactions of block concurrently on the state before block, then commit
+//the results to overlay in block order. A transaction is executed again on overlay if it read any state written by
+//previous transactions of block, so the write set is the same as executing sequentially. Gas fee transferred to
+//governance contract is rebased instead of being a conflict, since every charged transaction writes it.
+//Runtime log events of a speculation are only pushed when it is committed, so they are pushed once per transaction.
+func (this *LedgerStoreImp) executeTransactionsParallel(ledger store.LedgerStore, state scom.PersistStore, overlay *overlaydb.OverlayDB,
+	gasTable map[string]uint64, block *types.Block) (notifies []*event.ExecuteNotify, crossStates []common.Uint256, err error) {
+	specs := make([]*txSpeculation, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		specs[i] = &txSpeculation{tx: tx, done: make(chan struct{})}
+	}
+
+	queue := make(chan *txSpeculation, len(specs))
+	for _, spec := range specs {
+		queue <- spec
+	}
+	close(queue)
+	workers := ParallelExecWorkers
+	if workers > len(specs) {
+		workers = len(specs)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			speculator := newTxSpeculator(ledger, state)
+			for spec := range queue {
+				this.speculateTransaction(speculator, gasTable, block, spec)
+				close(spec.done)
+			}
+		}()
+	}
+	defer wg.Wait()
+
+	cache := storage.NewCacheDB(overlay)
+	for _, spec := range specs {
+		<-spec.done
+		if spec.err == nil && spec.commitTo(overlay) {
+			for _, log := range spec.logs {
+				event.PushSmartCodeEvent(log.TxHash, 0, event.EVENT_LOG, log)
+			}
+			notifies = append(notifies, spec.notify)
+			crossStates = append(crossStates, spec.crossStates...)
+			continue
+		}
+		cache.Reset()
+		notify, crossStateHashes, e := this.handleTransaction(ledger, overlay, cache, gasTable, block, spec.tx)
+		if e != nil {
+			return nil, nil, e
+		}
+		notifies = append(notifies, notify)
+		crossStates = append(crossStates, crossStateHashes...)
+	}
+	return
+}
+
+func (this *LedgerStoreImp) speculateTransaction(speculator *txSpeculator, gasTable map[string]uint64,
+	block *types.Block, spec *txSpeculation) {
+	speculator.reads.reset()
+	speculator.overlay.Reset()
+	speculator.overlay.SetError(nil)
+	speculator.cache.Reset()
+	speculator.ledger.logs = nil
+	spec.notify, spec.crossStates, spec.err = this.handleTransaction(speculator.ledger, speculator.overlay,
+		speculator.cache, gasTable, block, spec.tx)
+	spec.reads = speculator.reads.reads
+	spec.prefixes = speculator.reads.prefixes
+	spec.logs = speculator.ledger.logs
+	speculator.overlay.GetWriteSet().ForEach(func(key, val []byte) {
+		spec.writes = append(spec.writes, keyValue{key: append([]byte{}, key...), value: append([]byte{}, val...)})
+	})
+}
+
+//commitTo apply the write set of speculation to overlay, return false if it conflicts with the writes in overlay
+func (self *txSpeculation) commitTo(overlay *overlaydb.OverlayDB) bool {
+	written := overlay.GetWriteSet()
+	for _, prefix := range self.prefixes {
+		iter := written.NewIterator(util.BytesPrefix(prefix))
+		conflict := iter.First()
+		iter.Release()
+		if conflict {
+			return false
+		}
+	}
+
+	var gasFee []byte
+	for key, read := range self.reads {
+		if key == string(gasFeeCollectorKey) && read.charging {
+			if fee, ok := self.rebaseGasFee(overlay, read.value); ok {
+				gasFee = fee
+				continue
+			}
+		}
+		value, unknown := written.Get([]byte(key))
+		if !unknown && !bytes.Equal(value, read.value) {
+			return false
+		}
+	}
+
+	for _, write := range self.writes {
+		if gasFee != nil && bytes.Equal(write.key, gasFeeCollectorKey) {
+			overlay.Put(write.key, gasFee)
+		} else {
+			overlay.Put(write.key, write.value)
+		}
+	}
+	return true
+}
+
+//rebaseGasFee add the gas fee received in speculation to the balance of governance contract in overlay
+func (self *txSpeculation) rebaseGasFee(overlay *overlaydb.OverlayDB, read []byte) ([]byte, bool) {
+	if self.tx.Payer == utils.GovernanceContractAddress {
+		return nil, false
+	}
+	var written []byte
+	for _, write := range self.writes {
+		if bytes.Equal(write.key, gasFeeCollectorKey) {
+			written = write.value
+			break
+		}
+	}
+	if written == nil {
+		return nil, false
+	}
+	before, ok := decodeBalance(read)
+	if !ok {
+		return nil, false
+	}
+	after, ok := decodeBalance(written)
+	if !ok {
+		return nil, false
+	}
+	fee := after - before
+	if !bytes.Equal(written, ont.GetToUInt64StorageItem(before, fee).ToArray()) {
+		return nil, false
+	}
+	raw, err := overlay.Get(gasFeeCollectorKey)
+	if err != nil {
+		return nil, false
+	}
+	current, ok := decodeBalance(raw)
+	if !ok {
+		return nil, false
+	}
+	return ont.GetToUInt64StorageItem(current, fee).ToArray(), true
+}
+
+func decodeBalance(raw []byte) (uint64, bool) {
+	if len(raw) == 0 {
+		return 0, true
+	}
+	value, err := states.GetValueFromRawStorageItem(raw)
+	if err != nil || len(value) != 8 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(value), true
+}
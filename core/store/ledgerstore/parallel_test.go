@@ -0,0 +1,238 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/signature"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	cutils "github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/events"
+	"github.com/ontio/ontology/events/message"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	svm "github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+//parallelTestEnv is a ledger with ong balances of test accounts on top of genesis state
+type parallelTestEnv struct {
+	chain    *pipelineTestChain
+	store    *LedgerStoreImp
+	state    *pendingState
+	accounts []*account.Account
+}
+
+func newParallelTestEnv(t assert.TestingT, dir string, accountNum int) *parallelTestEnv {
+	chain := newPipelineTestGenesis(t)
+	env := &parallelTestEnv{chain: chain, store: chain.newStore(t, dir)}
+	balances := overlaydb.NewMemDB(0, 0)
+	for i := 0; i < accountNum; i++ {
+		acc := account.NewAccount("")
+		env.accounts = append(env.accounts, acc)
+		key := append([]byte{byte(scom.ST_STORAGE)}, ont.GenBalanceKey(utils.OngContractAddress, acc.Address)...)
+		balances.Put(key, utils.GenUInt64StorageItem(1000000000).ToArray())
+	}
+	env.state = &pendingState{PersistStore: env.store.stateStore.store, writeSet: balances}
+	return env
+}
+
+func (env *parallelTestEnv) close(dir string) {
+	env.store.Close()
+	os.RemoveAll(dir)
+}
+
+func (env *parallelTestEnv) transferTx(t assert.TestingT, nonce uint32, from *account.Account, to common.Address, value uint64) *types.Transaction {
+	code, err := cutils.BuildNativeInvokeCode(utils.OngContractAddress, 0, "transfer",
+		[]interface{}{[]ont.State{{From: from.Address, To: to, Value: value}}})
+	assert.Nil(t, err)
+	return env.invokeTx(t, nonce, from, code)
+}
+
+//invokeTx generate an invoke transaction paid and signed by from
+func (env *parallelTestEnv) invokeTx(t assert.TestingT, nonce uint32, from *account.Account, code []byte) *types.Transaction {
+	mutable := &types.MutableTransaction{
+		GasPrice: 1,
+		GasLimit: 200000,
+		TxType:   types.InvokeNeo,
+		Nonce:    nonce,
+		Payer:    from.Address,
+		Payload:  &payload.InvokeCode{Code: code},
+	}
+	hash := mutable.Hash()
+	sig, err := signature.Sign(from, hash[:])
+	assert.Nil(t, err)
+	mutable.Sigs = []types.Sig{{PubKeys: []keypair.PublicKey{from.PublicKey}, M: 1, SigData: [][]byte{sig}}}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+//transferBlock generate a block of txNum transfers, every conflictPeriod-th transfer sends to the sender of next one
+func (env *parallelTestEnv) transferBlock(t assert.TestingT, txNum, conflictPeriod int) *types.Block {
+	var txs []*types.Transaction
+	for i := 0; i < txNum; i++ {
+		from := env.accounts[i%len(env.accounts)]
+		to := account.NewAccount("").Address
+		if conflictPeriod > 0 && i%conflictPeriod == 0 {
+			to = env.accounts[(i+1)%len(env.accounts)].Address
+		}
+		txs = append(txs, env.transferTx(t, uint32(i), from, to, uint64(i+1)))
+	}
+	return newTestBlockWithTxs(t, env.store, env.chain.acc, txs)
+}
+
+func (env *parallelTestEnv) execute(t assert.TestingT, block *types.Block, workers int) (result executeResultDump) {
+	parallelExecWorkers := ParallelExecWorkers
+	ParallelExecWorkers = workers
+	defer func() { ParallelExecWorkers = parallelExecWorkers }()
+	res, err := env.store.executeBlockWith(block, env.store, env.state)
+	assert.Nil(t, err)
+	result.hash = res.Hash
+	result.notify = res.Notify
+	result.crossStatesRoot = res.CrossStatesRoot
+	res.WriteSet.ForEach(func(key, val []byte) {
+		result.writeSet = append(result.writeSet, fmt.Sprintf("%x:%x", key, val))
+	})
+	return
+}
+
+type executeResultDump struct {
+	hash            common.Uint256
+	notify          interface{}
+	crossStatesRoot common.Uint256
+	writeSet        []string
+}
+
+func TestExecuteBlockParallel(t *testing.T) {
+	env := newParallelTestEnv(t, "test/parallel", 16)
+	defer env.close("test/parallel")
+
+	poor := account.NewAccount("")
+	var txs []*types.Transaction
+	for i, acc := range env.accounts {
+		to := account.NewAccount("").Address
+		if i%3 == 0 {
+			// received by the sender of next transfer
+			to = env.accounts[(i+1)%len(env.accounts)].Address
+		}
+		txs = append(txs, env.transferTx(t, uint32(i), acc, to, uint64(i+1)))
+		if i%4 == 0 {
+			// spend from the same account again
+			txs = append(txs, env.transferTx(t, uint32(100+i), acc, poor.Address, 1))
+		}
+	}
+	// insufficient balance for gas and transfer
+	txs = append(txs, env.transferTx(t, 200, poor, env.accounts[0].Address, 1))
+	txs = append(txs, env.transferTx(t, 201, env.accounts[1], poor.Address, 2000000000))
+	// transfer to governance contract
+	txs = append(txs, env.transferTx(t, 202, env.accounts[2], utils.GovernanceContractAddress, 10))
+	block := newTestBlockWithTxs(t, env.store, env.chain.acc, txs)
+
+	sequential := env.execute(t, block, 1)
+	for _, workers := range []int{2, 4, 16} {
+		assert.Equal(t, sequential, env.execute(t, block, workers))
+	}
+
+	block = env.transferBlock(t, 64, 0)
+	assert.Equal(t, env.execute(t, block, 1), env.execute(t, block, 8))
+}
+
+func TestParallelRuntimeLogPushedOnce(t *testing.T) {
+	env := newParallelTestEnv(t, "test/parallellog", 4)
+	defer env.close("test/parallellog")
+
+	if events.DefActorPublisher == nil {
+		events.Init()
+	}
+	logs := make(chan *event.LogEventArgs, 64)
+	pid := actor.Spawn(actor.FromFunc(func(ctx actor.Context) {
+		if msg, ok := ctx.Message().(*message.SmartCodeEventMsg); ok && msg.Event.Action == event.EVENT_LOG {
+			logs <- msg.Event.Result.(*event.LogEventArgs)
+		}
+	}))
+	defer pid.Stop()
+	subscriber := events.NewActorSubscriber(pid)
+	subscriber.Subscribe(message.TOPIC_SMART_CODE_EVENT)
+	defer subscriber.Unsubscribe(message.TOPIC_SMART_CODE_EVENT)
+
+	builder := neovm.NewParamsBuilder(new(bytes.Buffer))
+	builder.EmitPushByteArray([]byte("hello"))
+	sink := common.NewZeroCopySink(builder.ToArray())
+	sink.WriteByte(byte(neovm.SYSCALL))
+	sink.WriteString(svm.RUNTIME_LOG_NAME)
+	sink.WriteByte(byte(neovm.RET))
+	// the second transaction of a payer reads the ong balance charged by the first one, so it is executed again
+	var txs []*types.Transaction
+	for i := 0; i < 2*len(env.accounts); i++ {
+		txs = append(txs, env.invokeTx(t, uint32(i), env.accounts[i%len(env.accounts)], sink.Bytes()))
+	}
+	block := newTestBlockWithTxs(t, env.store, env.chain.acc, txs)
+
+	for _, workers := range []int{1, 4} {
+		env.execute(t, block, workers)
+		pushed := make(map[common.Uint256]int)
+		for i := 0; i < len(txs); i++ {
+			select {
+			case log := <-logs:
+				assert.Equal(t, "hello", log.Message)
+				pushed[log.TxHash] += 1
+			case <-time.After(5 * time.Second):
+				t.Fatalf("runtime log of tx not pushed, workers: %d", workers)
+			}
+		}
+		select {
+		case log := <-logs:
+			t.Fatalf("runtime log of tx %s pushed again, workers: %d", log.TxHash.ToHexString(), workers)
+		case <-time.After(100 * time.Millisecond):
+		}
+		for _, tx := range txs {
+			assert.Equal(t, 1, pushed[tx.Hash()])
+		}
+	}
+}
+
+func BenchmarkExecuteBlockParallel(b *testing.B) {
+	env := newParallelTestEnv(b, "test/parallelbench", 2000)
+	defer env.close("test/parallelbench")
+	for _, conflictPeriod := range []int{0, 10} {
+		block := env.transferBlock(b, 2000, conflictPeriod)
+		for _, workers := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("conflict=%d/workers=%d", conflictPeriod, workers), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					env.execute(b, block, workers)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,273 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/errors"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//pendingState is a read only view of state store with the write set of an unsubmitted block on top
+type pendingState struct {
+	scom.PersistStore
+	writeSet *overlaydb.MemDB
+}
+
+func (self *pendingState) Get(key []byte) ([]byte, error) {
+	value, unknown := self.writeSet.Get(key)
+	if !unknown {
+		if len(value) == 0 {
+			return nil, scom.ErrNotFound
+		}
+		return value, nil
+	}
+	return self.PersistStore.Get(key)
+}
+
+func (self *pendingState) Has(key []byte) (bool, error) {
+	value, unknown := self.writeSet.Get(key)
+	if !unknown {
+		return len(value) != 0, nil
+	}
+	return self.PersistStore.Has(key)
+}
+
+func (self *pendingState) NewIterator(prefix []byte) scom.StoreIterator {
+	memIter := self.writeSet.NewIterator(util.BytesPrefix(prefix))
+	return overlaydb.NewJoinIter(memIter, self.PersistStore.NewIterator(prefix))
+}
+
+func (self *pendingState) Put(key []byte, value []byte) error {
+	return fmt.Errorf("pending state is read only")
+}
+
+func (self *pendingState) Delete(key []byte) error {
+	return fmt.Errorf("pending state is read only")
+}
+
+//pendingLedger is the ledger view seen by the block next to an executed but unsubmitted block
+type pendingLedger struct {
+	*LedgerStoreImp
+	block *types.Block
+	hash  common.Uint256
+	state *pendingState
+}
+
+func newPendingLedger(ledger *LedgerStoreImp, block *types.Block, result store.ExecuteResult) *pendingLedger {
+	return &pendingLedger{
+		LedgerStoreImp: ledger,
+		block:          block,
+		hash:           block.Hash(),
+		state:          &pendingState{PersistStore: ledger.stateStore.store, writeSet: result.WriteSet},
+	}
+}
+
+func (self *pendingLedger) GetCurrentBlockHeight() uint32 {
+	return self.block.Header.Height
+}
+
+func (self *pendingLedger) GetCurrentBlockHash() common.Uint256 {
+	return self.hash
+}
+
+func (self *pendingLedger) GetBlockHash(height uint32) common.Uint256 {
+	if height == self.block.Header.Height {
+		return self.hash
+	}
+	return self.LedgerStoreImp.GetBlockHash(height)
+}
+
+func (self *pendingLedger) GetHeaderByHash(blockHash common.Uint256) (*types.Header, error) {
+	if blockHash == self.hash {
+		return self.block.Header, nil
+	}
+	return self.LedgerStoreImp.GetHeaderByHash(blockHash)
+}
+
+func (self *pendingLedger) GetHeaderByHeight(height uint32) (*types.Header, error) {
+	if height == self.block.Header.Height {
+		return self.block.Header, nil
+	}
+	return self.LedgerStoreImp.GetHeaderByHeight(height)
+}
+
+func (self *pendingLedger) GetBlockByHash(blockHash common.Uint256) (*types.Block, error) {
+	if blockHash == self.hash {
+		return self.block, nil
+	}
+	return self.LedgerStoreImp.GetBlockByHash(blockHash)
+}
+
+func (self *pendingLedger) GetBlockByHeight(height uint32) (*types.Block, error) {
+	if height == self.block.Header.Height {
+		return self.block, nil
+	}
+	return self.LedgerStoreImp.GetBlockByHeight(height)
+}
+
+func (self *pendingLedger) GetTransaction(txHash common.Uint256) (*types.Transaction, uint32, error) {
+	for _, tx := range self.block.Transactions {
+		if tx.Hash() == txHash {
+			return tx, self.block.Header.Height, nil
+		}
+	}
+	return self.LedgerStoreImp.GetTransaction(txHash)
+}
+
+func (self *pendingLedger) IsContainBlock(blockHash common.Uint256) (bool, error) {
+	if blockHash == self.hash {
+		return true, nil
+	}
+	return self.LedgerStoreImp.IsContainBlock(blockHash)
+}
+
+func (self *pendingLedger) IsContainTransaction(txHash common.Uint256) (bool, error) {
+	for _, tx := range self.block.Transactions {
+		if tx.Hash() == txHash {
+			return true, nil
+		}
+	}
+	return self.LedgerStoreImp.IsContainTransaction(txHash)
+}
+
+func (self *pendingLedger) GetContractState(contractHash common.Address) (*payload.DeployCode, error) {
+	key, err := self.stateStore.getContractStateKey(contractHash)
+	if err != nil {
+		return nil, err
+	}
+	value, err := self.state.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	contractState := new(payload.DeployCode)
+	err = contractState.Deserialization(common.NewZeroCopySource(value))
+	if err != nil {
+		return nil, err
+	}
+	return contractState, nil
+}
+
+//executeBlockAhead execute block on top of its previous block, which is executed but may be submitting concurrently.
+//the merkle root of result need be calculated after the previous block submitted
+func (this *LedgerStoreImp) executeBlockAhead(block, pending *types.Block, pendingResult store.ExecuteResult) (store.ExecuteResult, error) {
+	view := newPendingLedger(this, pending, pendingResult)
+	if block.Header.PrevBlockHash != view.hash {
+		return store.ExecuteResult{}, fmt.Errorf("block %d is not next to pending block %d", block.Header.Height, pending.Header.Height)
+	}
+	return this.executeBlockWith(block, view, view.state)
+}
+
+type aheadResult struct {
+	result store.ExecuteResult
+	err    error
+}
+
+//AddBlocks save consecutive blocks to ledger. The execution of each block is pipelined with the submitting
+//of its previous block. return the number of leading blocks which are in ledger after the call
+func (this *LedgerStoreImp) AddBlocks(blocks []*types.Block, ccMsgs []*types.CrossChainMsg,
+	stateMerkleRoots []common.Uint256) (int, error) {
+	if this.lightMode {
+		return 0, scom.ErrLightMode
+	}
+	if len(ccMsgs) != len(blocks) || len(stateMerkleRoots) != len(blocks) {
+		return 0, fmt.Errorf("blocks, cross chain msgs and state merkle roots length mismatch")
+	}
+	this.getSavingBlockLock()
+	defer this.releaseSavingBlockLock()
+
+	saved := 0
+	currBlockHeight := this.GetCurrentBlockHeight()
+	for saved < len(blocks) && blocks[saved].Header.Height <= currBlockHeight {
+		saved++
+	}
+	if saved == len(blocks) {
+		return saved, nil
+	}
+	for i := saved; i < len(blocks); i++ {
+		if blocks[i].Header.Height != currBlockHeight+uint32(i-saved)+1 {
+			return saved, fmt.Errorf("block height %d not equal next block height %d",
+				blocks[i].Header.Height, currBlockHeight+uint32(i-saved)+1)
+		}
+	}
+
+	result, err := this.executeBlock(blocks[saved])
+	for i := saved; i < len(blocks); i++ {
+		if err != nil {
+			return i, err
+		}
+		if this.closing {
+			return i, errors.NewErr("save block error: ledger is closing")
+		}
+		block := blocks[i]
+		var ahead chan aheadResult
+		if i+1 < len(blocks) {
+			ahead = make(chan aheadResult, 1)
+			go func(next *types.Block, pendingResult store.ExecuteResult) {
+				res, err := this.executeBlockAhead(next, block, pendingResult)
+				ahead <- aheadResult{result: res, err: err}
+			}(blocks[i+1], result)
+		}
+
+		err = this.submitPipelinedBlock(block, ccMsgs[i], stateMerkleRoots[i], result)
+		var next aheadResult
+		if ahead != nil {
+			// always wait the ahead execution, which reads the state being submitted
+			next = <-ahead
+		}
+		if err != nil {
+			return i, err
+		}
+		if ahead != nil {
+			// a failed ahead execution is returned at the next iteration, after block i is counted as saved
+			result, err = next.result, next.err
+			if err == nil {
+				result.MerkleRoot = this.stateMerkleRoot(blocks[i+1].Header.Height, result)
+			}
+		}
+	}
+	return len(blocks), nil
+}
+
+//need hold saving block lock
+func (this *LedgerStoreImp) submitPipelinedBlock(block *types.Block, ccMsg *types.CrossChainMsg,
+	stateMerkleRoot common.Uint256, result store.ExecuteResult) error {
+	err := this.verifyBlock(block, ccMsg)
+	if err != nil {
+		return err
+	}
+	//empty block does not check stateMerkleRoot
+	if len(block.Transactions) != 0 && result.MerkleRoot != stateMerkleRoot {
+		return fmt.Errorf("state merkle root mismatch. expected: %s, got: %s",
+			result.MerkleRoot.ToHexString(), stateMerkleRoot.ToHexString())
+	}
+	err = this.submitBlock(block, ccMsg, result)
+	if err != nil {
+		return fmt.Errorf("saveBlock error %s", err)
+	}
+	this.delHeaderCache(block.Hash())
+	return nil
+}
@@ -0,0 +1,289 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/genesis"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	vm "github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+//pipelineTestChain is a locally generated solo chain, each block increase a counter in contract storage
+type pipelineTestChain struct {
+	acc         *account.Account
+	genesis     *types.Block
+	contract    common.Address
+	blocks      []*types.Block
+	ccMsgs      []*types.CrossChainMsg
+	merkleRoots []common.Uint256
+}
+
+//counterContractCode increase the value stored at key "n" and notify the new value
+func counterContractCode() []byte {
+	buf := new(bytes.Buffer)
+	builder := vm.NewParamsBuilder(buf)
+	sysCall := func(name string) {
+		builder.Emit(vm.SYSCALL)
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteString(name)
+		buf.Write(sink.Bytes())
+	}
+	builder.EmitPushByteArray([]byte("n"))
+	sysCall(neovm.STORAGE_GETCONTEXT_NAME)
+	sysCall(neovm.STORAGE_GET_NAME)
+	builder.Emit(vm.INC)
+	builder.Emit(vm.DUP)
+	builder.EmitPushByteArray([]byte("n"))
+	sysCall(neovm.STORAGE_GETCONTEXT_NAME)
+	sysCall(neovm.STORAGE_PUT_NAME)
+	sysCall(neovm.RUNTIME_NOTIFY_NAME)
+	builder.Emit(vm.RET)
+	return builder.ToArray()
+}
+
+func newPipelineTestTx(t assert.TestingT, nonce uint32, txType types.TransactionType, pl types.Payload) *types.Transaction {
+	mutable := &types.MutableTransaction{
+		GasLimit: 200000,
+		TxType:   txType,
+		Nonce:    nonce,
+		Payload:  pl,
+	}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func newTestBlockWithTxs(t assert.TestingT, store *LedgerStoreImp, acc *account.Account, txs []*types.Transaction) *types.Block {
+	nextBookkeeper, err := types.AddressFromBookkeepers([]keypair.PublicKey{acc.PublicKey})
+	assert.Nil(t, err)
+	prevHeader, err := store.GetHeaderByHash(store.GetCurrentBlockHash())
+	assert.Nil(t, err)
+	txHashes := make([]common.Uint256, 0, len(txs))
+	for _, tx := range txs {
+		txHashes = append(txHashes, tx.Hash())
+	}
+	txRoot := common.ComputeMerkleRoot(txHashes)
+	header := &types.Header{
+		PrevBlockHash:    prevHeader.Hash(),
+		TransactionsRoot: txRoot,
+		BlockRoot:        store.GetBlockRootWithNewTxRoots(prevHeader.Height+1, []common.Uint256{txRoot}),
+		Timestamp:        prevHeader.Timestamp + 1,
+		Height:           prevHeader.Height + 1,
+		NextBookkeeper:   nextBookkeeper,
+	}
+	block := &types.Block{Header: header, Transactions: txs}
+	hash := block.Hash()
+	sig, err := signature.Sign(acc, hash[:])
+	assert.Nil(t, err)
+	block.Header.Bookkeepers = []keypair.PublicKey{acc.PublicKey}
+	block.Header.SigData = [][]byte{sig}
+	return block
+}
+
+//newPipelineTestGenesis need be called before switching to solo consensus
+func newPipelineTestGenesis(t assert.TestingT) *pipelineTestChain {
+	chain := &pipelineTestChain{acc: account.NewAccount("")}
+	var err error
+	chain.genesis, err = genesis.BuildGenesisBlock([]keypair.PublicKey{chain.acc.PublicKey}, config.DefConfig.Genesis)
+	assert.Nil(t, err)
+	return chain
+}
+
+//generate a chain of blockNum blocks with txNum counter invocations per block
+func (chain *pipelineTestChain) generate(t assert.TestingT, dir string, blockNum, txNum int) {
+	bookkeepers := []keypair.PublicKey{chain.acc.PublicKey}
+	store, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	defer store.Close()
+	assert.Nil(t, store.InitLedgerStoreWithGenesisBlock(chain.genesis, bookkeepers))
+
+	deploy, err := payload.NewDeployCode(counterContractCode(), payload.NEOVM_TYPE, "counter", "1", "", "", "")
+	assert.Nil(t, err)
+	chain.contract = deploy.Address()
+	invokeBuilder := vm.NewParamsBuilder(new(bytes.Buffer))
+	invokeBuilder.EmitPushCall(chain.contract[:])
+	invokeCode := invokeBuilder.ToArray()
+
+	nonce := uint32(0)
+	for i := 0; i < blockNum; i++ {
+		var txs []*types.Transaction
+		if i == 0 {
+			txs = append(txs, newPipelineTestTx(t, nonce, types.Deploy, deploy))
+			nonce++
+		}
+		for j := 0; j < txNum; j++ {
+			txs = append(txs, newPipelineTestTx(t, nonce, types.InvokeNeo, &payload.InvokeCode{Code: invokeCode}))
+			nonce++
+		}
+		block := newTestBlockWithTxs(t, store, chain.acc, txs)
+		result, err := store.ExecuteBlock(block)
+		assert.Nil(t, err)
+		assert.Nil(t, store.SubmitBlock(block, nil, result))
+		chain.blocks = append(chain.blocks, block)
+		chain.ccMsgs = append(chain.ccMsgs, nil)
+		chain.merkleRoots = append(chain.merkleRoots, result.MerkleRoot)
+	}
+}
+
+func (chain *pipelineTestChain) newStore(t assert.TestingT, dir string) *LedgerStoreImp {
+	store, err := NewLedgerStore(dir, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, store.InitLedgerStoreWithGenesisBlock(chain.genesis, []keypair.PublicKey{chain.acc.PublicKey}))
+	return store
+}
+
+func TestAddBlocksPipelined(t *testing.T) {
+	chain := newPipelineTestGenesis(t)
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+	chain.generate(t, "test/pipelinesrc", 10, 5)
+	seqStore := chain.newStore(t, "test/pipelineseq")
+	defer seqStore.Close()
+	for i, block := range chain.blocks {
+		assert.Nil(t, seqStore.AddBlock(block, nil, chain.merkleRoots[i]))
+	}
+
+	store := chain.newStore(t, "test/pipeline")
+	defer store.Close()
+	// already saved blocks are skipped
+	saved, err := store.AddBlocks(chain.blocks[:3], chain.ccMsgs[:3], chain.merkleRoots[:3])
+	assert.Nil(t, err)
+	assert.Equal(t, 3, saved)
+	saved, err = store.AddBlocks(chain.blocks, chain.ccMsgs, chain.merkleRoots)
+	assert.Nil(t, err)
+	assert.Equal(t, len(chain.blocks), saved)
+
+	assert.Equal(t, seqStore.GetCurrentBlockHash(), store.GetCurrentBlockHash())
+	for _, block := range chain.blocks {
+		height := block.Header.Height
+		expected, err := seqStore.GetStateMerkleRoot(height)
+		assert.Nil(t, err)
+		root, err := store.GetStateMerkleRoot(height)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, root)
+		for _, tx := range block.Transactions {
+			expected, err := seqStore.GetEventNotifyByTx(tx.Hash())
+			assert.Nil(t, err)
+			notify, err := store.GetEventNotifyByTx(tx.Hash())
+			assert.Nil(t, err)
+			assert.Equal(t, expected, notify)
+		}
+	}
+	key := &states.StorageKey{ContractAddress: chain.contract, Key: []byte("n")}
+	item, err := store.GetStorageItem(key)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{50}, item.Value)
+}
+
+func TestAddBlocksStopAtInvalidBlock(t *testing.T) {
+	chain := newPipelineTestGenesis(t)
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+	chain.generate(t, "test/pipelineinvalidsrc", 6, 2)
+	store := chain.newStore(t, "test/pipelineinvalid")
+	defer store.Close()
+	roots := append([]common.Uint256{}, chain.merkleRoots...)
+	roots[4] = common.UINT256_EMPTY
+	saved, err := store.AddBlocks(chain.blocks, chain.ccMsgs, roots)
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, saved)
+	assert.Equal(t, chain.blocks[3].Hash(), store.GetCurrentBlockHash())
+
+	// continue from the failed block
+	saved, err = store.AddBlocks(chain.blocks[4:], chain.ccMsgs[4:], chain.merkleRoots[4:])
+	assert.Nil(t, err)
+	assert.Equal(t, 2, saved)
+	assert.Equal(t, chain.blocks[5].Hash(), store.GetCurrentBlockHash())
+}
+
+func TestAddBlocksAheadExecutionFailed(t *testing.T) {
+	chain := newPipelineTestGenesis(t)
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+	chain.generate(t, "test/pipelineaheadsrc", 6, 2)
+	store := chain.newStore(t, "test/pipelineahead")
+	defer store.Close()
+	// block 4 is not next to block 3, so its ahead execution fails while block 3 is submitted
+	header := *chain.blocks[4].Header
+	header.PrevBlockHash = common.UINT256_EMPTY
+	blocks := append([]*types.Block{}, chain.blocks...)
+	blocks[4] = &types.Block{Header: &header, Transactions: chain.blocks[4].Transactions}
+	saved, err := store.AddBlocks(blocks, chain.ccMsgs, chain.merkleRoots)
+	assert.NotNil(t, err)
+	assert.Equal(t, 4, saved)
+	assert.Equal(t, chain.blocks[3].Hash(), store.GetCurrentBlockHash())
+}
+
+func benchmarkSaveBlocks(b *testing.B, save func(store *LedgerStoreImp, chain *pipelineTestChain) error) {
+	chain := newPipelineTestGenesis(b)
+	consensusType := config.DefConfig.Genesis.ConsensusType
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	defer func() { config.DefConfig.Genesis.ConsensusType = consensusType }()
+	chain.generate(b, "test/benchsrc", 50, 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dir := fmt.Sprintf("test/bench%d", i)
+		store := chain.newStore(b, dir)
+		b.StartTimer()
+		if err := save(store, chain); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		store.Close()
+		os.RemoveAll(dir)
+		b.StartTimer()
+	}
+}
+
+func BenchmarkAddBlock(b *testing.B) {
+	benchmarkSaveBlocks(b, func(store *LedgerStoreImp, chain *pipelineTestChain) error {
+		for i, block := range chain.blocks {
+			if err := store.AddBlock(block, nil, chain.merkleRoots[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkAddBlocks(b *testing.B) {
+	benchmarkSaveBlocks(b, func(store *LedgerStoreImp, chain *pipelineTestChain) error {
+		_, err := store.AddBlocks(chain.blocks, chain.ccMsgs, chain.merkleRoots)
+		return err
+	})
+}
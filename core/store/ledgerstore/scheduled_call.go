@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"fmt"
+	"math"
+
+	sysconfig "github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/scheduler"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/states"
+	"github.com/ontio/ontology/smartcontract/storage"
+)
+
+//executeScheduledCalls execute the due calls of scheduler contract on overlay before the transactions of block
+func (this *LedgerStoreImp) executeScheduledCalls(ledger store.LedgerStore, overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block) ([]*event.ExecuteNotify, error) {
+	height := block.Header.Height
+	if height == 0 || height < sysconfig.GetSchedulerHeight() {
+		return nil, nil
+	}
+	config := &smartcontract.Config{
+		Time:      block.Header.Timestamp,
+		Height:    height,
+		Tx:        &types.Transaction{},
+		BlockHash: block.Hash(),
+	}
+	sc := smartcontract.SmartContract{
+		Config:  config,
+		CacheDB: storage.NewCacheDB(overlay),
+		Store:   ledger,
+		Gas:     math.MaxUint64,
+	}
+	service, _ := sc.NewNativeService()
+	calls, err := scheduler.GetDueCalls(service, height, scheduler.MAX_CALLS_PER_BLOCK)
+	if overlay.Error() != nil {
+		return nil, fmt.Errorf("get scheduled calls at height %d error %s", height, overlay.Error())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get scheduled calls at height %d error %s", height, err)
+	}
+
+	notifies := make([]*event.ExecuteNotify, 0, len(calls))
+	for _, call := range calls {
+		notify, err := this.stateStore.HandleScheduledCall(ledger, overlay, gasTable, block, call)
+		if overlay.Error() != nil {
+			return nil, fmt.Errorf("HandleScheduledCall call %d error %s", call.Id, overlay.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		notifies = append(notifies, notify)
+	}
+	return notifies, nil
+}
+
+//HandleScheduledCall execute a due call of scheduler contract as a system invocation. The call is removed before
+//executed, the gas fee is paid by the prepaid gas of call and the rest is refunded to caller
+func (self *StateStore) HandleScheduledCall(store store.LedgerStore, overlay *overlaydb.OverlayDB, gasTable map[string]uint64,
+	block *types.Block, call *scheduler.ScheduledCall) (*event.ExecuteNotify, error) {
+	tx, err := call.Transaction()
+	if err != nil {
+		return nil, fmt.Errorf("HandleScheduledCall call %d error %s", call.Id, err)
+	}
+	notify := &event.ExecuteNotify{TxHash: tx.Hash(), State: event.CONTRACT_STATE_FAIL}
+	config := &smartcontract.Config{
+		Time:      block.Header.Timestamp,
+		Height:    block.Header.Height,
+		Tx:        tx,
+		BlockHash: block.Hash(),
+	}
+
+	cache := storage.NewCacheDB(overlay)
+	system := smartcontract.SmartContract{
+		Config:  config,
+		CacheDB: cache,
+		Store:   store,
+		Gas:     math.MaxUint64,
+	}
+	service, _ := system.NewNativeService()
+	scheduler.RemoveCall(service, call)
+	cache.Commit()
+
+	uintCodeGasPrice, ok := gasTable[neovm.UINT_INVOKE_CODE_LEN_NAME]
+	if !ok {
+		overlay.SetError(fmt.Errorf("[HandleScheduledCall] get UINT_INVOKE_CODE_LEN_NAME gas failed"))
+		return nil, nil
+	}
+	invoke := tx.Payload.(*payload.InvokeCode)
+	codeLenGasLimit := calcGasByCodeLen(len(invoke.Code), uintCodeGasPrice)
+
+	sc := smartcontract.SmartContract{
+		Config:       config,
+		CacheDB:      storage.NewCacheDB(overlay),
+		Store:        store,
+		GasTable:     gasTable,
+		WasmExecStep: sysconfig.DEFAULT_WASM_MAX_STEPCOUNT,
+		PreExec:      false,
+	}
+	costGasLimit := call.GasLimit
+	if call.GasLimit < codeLenGasLimit {
+		err = fmt.Errorf("gas limit insufficient: need %d actual %d", codeLenGasLimit, call.GasLimit)
+	} else {
+		sc.Gas = call.GasLimit - codeLenGasLimit
+		if call.VmType == scheduler.VM_TYPE_NATIVE {
+			err = invokeScheduledNative(&sc, gasTable, call)
+		} else {
+			engine, e := sc.NewExecuteEngine(invoke.Code, tx.TxType)
+			if e != nil {
+				err = e
+			} else {
+				_, err = engine.Invoke()
+			}
+		}
+		if sc.IsInternalErr() {
+			overlay.SetError(fmt.Errorf("[HandleScheduledCall] %s", err))
+			return nil, nil
+		}
+		costGasLimit = call.GasLimit - sc.Gas
+		if err == nil {
+			costGasLimit -= sc.GasRefund(costGasLimit)
+		}
+		if costGasLimit < neovm.MIN_TRANSACTION_GAS {
+			costGasLimit = neovm.MIN_TRANSACTION_GAS
+		}
+	}
+	if err == nil {
+		sc.CacheDB.Commit()
+		notify.Notify = append(notify.Notify, sc.Notifications...)
+		notify.State = event.CONTRACT_STATE_SUCCESS
+	} else {
+		log.Debugf("HandleScheduledCall call %d error %s", call.Id, err)
+	}
+
+	fee := costGasLimit * call.GasPrice
+	cache = storage.NewCacheDB(overlay)
+	settle := smartcontract.SmartContract{
+		Config:  config,
+		CacheDB: cache,
+		Store:   store,
+		Gas:     math.MaxUint64,
+	}
+	service, _ = settle.NewNativeService()
+	if e := scheduler.Settle(service, call, fee, err); e != nil {
+		return nil, fmt.Errorf("HandleScheduledCall call %d error %s", call.Id, e)
+	}
+	cache.Commit()
+	notify.Notify = append(notify.Notify, settle.Notifications...)
+	notify.GasConsumed = fee
+	return notify, nil
+}
+
+//invokeScheduledNative invoke native contract with the raw args of call, which can not be passed through neovm code
+func invokeScheduledNative(sc *smartcontract.SmartContract, gasTable map[string]uint64, call *scheduler.ScheduledCall) error {
+	gas, ok := gasTable[neovm.NATIVE_INVOKE_NAME]
+	if !ok {
+		gas = neovm.NATIVE_INVOKE_GAS
+	}
+	if sc.Gas < gas {
+		err := fmt.Errorf("gas insufficient: need %d actual %d", gas, sc.Gas)
+		sc.Gas = 0
+		return err
+	}
+	sc.Gas -= gas
+	service, err := sc.NewNativeService()
+	if err != nil {
+		return err
+	}
+	service.InvokeParam = states.ContractInvokeParam{Address: call.Contract, Method: call.Method, Args: call.Args}
+	_, err = service.Invoke()
+	return err
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ledgerstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	cutils "github.com/ontio/ontology/core/utils"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/scheduler"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteScheduledCalls(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	env := newParallelTestEnv(t, "test/scheduler", 1)
+	defer env.close("test/scheduler")
+	caller := env.accounts[0]
+
+	deploy, err := payload.NewDeployCode(counterContractCode(), payload.NEOVM_TYPE, "counter", "1", "", "", "")
+	assert.Nil(t, err)
+	counter := deploy.Address()
+	balanceOfArgs := common.NewZeroCopySink(nil)
+	utils.EncodeAddress(balanceOfArgs, caller.Address)
+	params := []*scheduler.ScheduleParam{
+		{Contract: counter, Method: "inc"},
+		{Contract: utils.OngContractAddress, Method: "balanceOf", Args: balanceOfArgs.Bytes()},
+		{Contract: utils.OngContractAddress, Method: "transfer", Args: []byte{1, 2, 3}},
+		{Contract: counter, Method: "inc"},
+	}
+	txs := []*types.Transaction{newPipelineTestTx(t, 0, types.Deploy, deploy)}
+	for i, param := range params {
+		param.Caller, param.Height, param.GasLimit, param.GasPrice = caller.Address, 2, 200000, 1
+		code, err := cutils.BuildNativeInvokeCode(utils.SchedulerContractAddress, 0, scheduler.SCHEDULE_NAME,
+			[]interface{}{param})
+		assert.Nil(t, err)
+		txs = append(txs, env.invokeTx(t, uint32(i+1), caller, code))
+	}
+	code, err := cutils.BuildNativeInvokeCode(utils.SchedulerContractAddress, 0, scheduler.CANCEL_NAME,
+		[]interface{}{uint64(3)})
+	assert.Nil(t, err)
+	txs = append(txs, env.invokeTx(t, 10, caller, code))
+
+	block := newTestBlockWithTxs(t, env.store, env.chain.acc, txs)
+	result, err := env.store.executeBlockWith(block, env.store, env.state)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), len(result.Notify))
+	for _, notify := range result.Notify {
+		assert.Equal(t, event.CONTRACT_STATE_SUCCESS, notify.State)
+	}
+	state := &pendingState{PersistStore: env.state, writeSet: result.WriteSet}
+	balanceKey := append([]byte{byte(scom.ST_STORAGE)}, ont.GenBalanceKey(utils.OngContractAddress, caller.Address)...)
+	balanceOf := func(state *pendingState) uint64 {
+		raw, _ := state.Get(balanceKey)
+		balance, ok := decodeBalance(raw)
+		assert.True(t, ok)
+		return balance
+	}
+	scheduled := balanceOf(state)
+
+	header := *block.Header
+	header.Height, header.PrevBlockHash = 2, block.Hash()
+	next := &types.Block{Header: &header}
+	result, err = env.store.executeBlockWith(next, env.store, state)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(result.Notify))
+	var fee uint64
+	for i, notify := range result.Notify {
+		call := &scheduler.ScheduledCall{Id: uint64(i), VmType: scheduler.VM_TYPE_NATIVE, ScheduleParam: *params[i]}
+		if i == 0 {
+			call.VmType = scheduler.VM_TYPE_NEOVM
+		}
+		tx, err := call.Transaction()
+		assert.Nil(t, err)
+		assert.Equal(t, tx.Hash(), notify.TxHash)
+		fee += notify.GasConsumed
+	}
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, result.Notify[0].State)
+	assert.Equal(t, counter, result.Notify[0].Notify[0].ContractAddress)
+	assert.Equal(t, event.CONTRACT_STATE_SUCCESS, result.Notify[1].State)
+	assert.Equal(t, event.CONTRACT_STATE_FAIL, result.Notify[2].State)
+	assert.Equal(t, 3*params[0].GasLimit*params[0].GasPrice-fee, balanceOf(&pendingState{PersistStore: state,
+		writeSet: result.WriteSet})-scheduled)
+
+	// executed calls are removed
+	header.Height = 3
+	result, err = env.store.executeBlockWith(&types.Block{Header: &header}, env.store,
+		&pendingState{PersistStore: state, writeSet: result.WriteSet})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(result.Notify))
+}
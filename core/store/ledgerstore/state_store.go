@@ -36,6 +36,7 @@ import (
 	"github.com/ontio/ontology/merkle"
 	"github.com/ontio/ontology/smartcontract/service/native/ontid"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/storage"
 )
 
 var (
@@ -60,6 +61,10 @@ func NewStateStore(dbDir, merklePath string, stateHashCheckHeight uint32) (*Stat
 	if err != nil {
 		return nil, err
 	}
+	return newStateStore(dbDir, merklePath, store, stateHashCheckHeight)
+}
+
+func newStateStore(dbDir, merklePath string, store scom.PersistStore, stateHashCheckHeight uint32) (*StateStore, error) {
 	stateStore := &StateStore{
 		dbDir:                dbDir,
 		store:                store,
@@ -260,6 +265,51 @@ func (self *StateStore) GetContractState(contractHash common.Address) (*payload.
 	return contractState, nil
 }
 
+//GetContractHistory return the upgrade history of contract, empty if the contract has never been upgraded
+func (self *StateStore) GetContractHistory(contractHash common.Address) (*states.ContractHistory, error) {
+	key := append([]byte{byte(scom.ST_CONTRACT_HISTORY)}, contractHash[:]...)
+	value, err := self.store.Get(key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return &states.ContractHistory{}, nil
+		}
+		return nil, err
+	}
+	history := new(states.ContractHistory)
+	err = history.Deserialization(common.NewZeroCopySource(value))
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+//GetContractStorageSize return the storage bytes of contract accounted since the storage accounting height, and
+//the total bytes of all its storage items including the items written before and not rewritten since
+func (self *StateStore) GetContractStorageSize(contractHash common.Address) (accounted uint64, total uint64, err error) {
+	key := append([]byte{byte(scom.ST_STORAGE_SIZE)}, contractHash[:]...)
+	value, err := self.store.Get(key)
+	if err != nil && err != scom.ErrNotFound {
+		return 0, 0, err
+	}
+	if err == nil {
+		if len(value) != 8 {
+			return 0, 0, fmt.Errorf("invalid storage size of contract %s", contractHash.ToHexString())
+		}
+		accounted = binary.LittleEndian.Uint64(value)
+	}
+
+	prefix := append([]byte{byte(scom.ST_STORAGE)}, contractHash[:]...)
+	iter := self.store.NewIterator(prefix)
+	for has := iter.First(); has; has = iter.Next() {
+		total += storage.StorageItemSize(iter.Key()[1:], iter.Value())
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, 0, err
+	}
+	return accounted, total, nil
+}
+
 //GetBookkeeperState return current book keeper states
 func (self *StateStore) GetBookkeeperState() (*states.BookkeeperState, error) {
 	key, err := self.getBookkeeperKey()
@@ -75,6 +75,9 @@ func (self *StateStore) HandleDeployTransaction(store store.LedgerStore, overlay
 	)
 
 	if deploy.VmType() == payload.WASMVM_TYPE {
+		if err = wasmvm.CheckDeployCode(block.Header.Height, deploy.GetRawCode()); err != nil {
+			return err
+		}
 		_, err = wasmvm.ReadWasmModule(deploy.GetRawCode(), sysconfig.DefConfig.Common.WasmVerifyMethod)
 		if err != nil {
 			return err
@@ -236,6 +239,9 @@ func (self *StateStore) HandleInvokeTransaction(store store.LedgerStore, overlay
 	}
 
 	costGasLimit = availableGasLimit - sc.Gas
+	if err == nil {
+		costGasLimit -= sc.GasRefund(costGasLimit)
+	}
 	if costGasLimit < neovm.MIN_TRANSACTION_GAS {
 		costGasLimit = neovm.MIN_TRANSACTION_GAS
 	}
@@ -311,6 +317,10 @@ func isBalanceSufficient(payer common.Address, cache *storage.CacheDB, config *s
 
 func chargeCostGas(payer common.Address, gas uint64, config *smartcontract.Config,
 	cache *storage.CacheDB, store store.LedgerStore) ([]*event.NotifyEventInfo, error) {
+	if observer, ok := store.(gasChargeObserver); ok {
+		observer.setCharging(true)
+		defer observer.setCharging(false)
+	}
 
 	params := genNativeTransferCode(payer, utils.GovernanceContractAddress, gas)
 
@@ -330,9 +340,10 @@ func chargeCostGas(payer common.Address, gas uint64, config *smartcontract.Confi
 }
 
 func refreshGlobalParam(config *smartcontract.Config, cache *storage.CacheDB, store store.LedgerStore) error {
+	keys := append(append([]string{}, neovm.GAS_TABLE_KEYS...), wasmvm.GasScheduleKeys()...)
 	sink := common.NewZeroCopySink(nil)
-	utils.EncodeVarUint(sink, uint64(len(neovm.GAS_TABLE_KEYS)))
-	for _, value := range neovm.GAS_TABLE_KEYS {
+	utils.EncodeVarUint(sink, uint64(len(keys)))
+	for _, value := range keys {
 		sink.WriteString(value)
 	}
 
@@ -352,8 +363,8 @@ func refreshGlobalParam(config *smartcontract.Config, cache *storage.CacheDB, st
 	if err := params.Deserialization(common.NewZeroCopySource(result)); err != nil {
 		return fmt.Errorf("deserialize global params error:%s", err)
 	}
-	neovm.GAS_TABLE.Range(func(key, value interface{}) bool {
-		n, ps := params.GetParam(key.(string))
+	for _, key := range keys {
+		n, ps := params.GetParam(key)
 		if n != -1 && ps.Value != "" {
 			pu, err := strconv.ParseUint(ps.Value, 10, 64)
 			if err != nil {
@@ -362,8 +373,7 @@ func refreshGlobalParam(config *smartcontract.Config, cache *storage.CacheDB, st
 				neovm.GAS_TABLE.Store(key, pu)
 			}
 		}
-		return true
-	})
+	}
 	return nil
 }
 
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package leveldbstore
+
+import (
+	"bytes"
+
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	forkValueDeleted = byte(0)
+	forkValueExist   = byte(1)
+)
+
+//NewForkStore turn local into a read-through overlay store of base. Data is read from the local store first and
+//then from the base store, all modifications are saved to the local store only, so the base store is never changed.
+//Closing the fork store closes base too
+func NewForkStore(base common.PersistStore, local *LevelDBStore) *LevelDBStore {
+	local.base = base
+	return local
+}
+
+func forkValue(value []byte) []byte {
+	val := make([]byte, 0, len(value)+1)
+	val = append(val, forkValueExist)
+	return append(val, value...)
+}
+
+var forkDeleted = []byte{forkValueDeleted}
+
+//get the value of a key from local store, or from base store if the key is not modified locally
+func (self *LevelDBStore) forkGet(key []byte) ([]byte, error) {
+	val, err := self.db.Get(key, nil)
+	if err == nil {
+		if val[0] == forkValueDeleted {
+			return nil, common.ErrNotFound
+		}
+		return val[1:], nil
+	}
+	if err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return self.base.Get(key)
+}
+
+func (self *LevelDBStore) forkHas(key []byte) (bool, error) {
+	_, err := self.forkGet(key)
+	if err == common.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+//return a iterator which merges the local and base store with the key prefix
+func (self *LevelDBStore) forkIterator(prefix []byte) common.StoreIterator {
+	return &forkIterator{
+		base:  self.base.NewIterator(prefix),
+		local: self.db.NewIterator(util.BytesPrefix(prefix), nil),
+	}
+}
+
+type forkIterator struct {
+	base    common.StoreIterator
+	local   common.StoreIterator
+	baseOk  bool
+	localOk bool
+	init    bool
+	key     []byte
+	value   []byte
+}
+
+//cmp compares the current keys of base and local iterator, exhausted iterator is regarded as the largest
+func (self *forkIterator) cmp() int {
+	if !self.baseOk {
+		return 1
+	}
+	if !self.localOk {
+		return -1
+	}
+	return bytes.Compare(self.base.Key(), self.local.Key())
+}
+
+//settle moves to the first valid item at or after current position, deleted keys are skipped
+func (self *forkIterator) settle() bool {
+	for self.baseOk || self.localOk {
+		c := self.cmp()
+		if c < 0 {
+			self.key, self.value = self.base.Key(), self.base.Value()
+			return true
+		}
+		val := self.local.Value()
+		if len(val) > 0 && val[0] == forkValueExist {
+			self.key, self.value = self.local.Key(), val[1:]
+			return true
+		}
+		if c == 0 {
+			self.baseOk = self.base.Next()
+		}
+		self.localOk = self.local.Next()
+	}
+	self.key, self.value = nil, nil
+	return false
+}
+
+func (self *forkIterator) Next() bool {
+	if !self.init {
+		self.init = true
+		self.baseOk = self.base.Next()
+		self.localOk = self.local.Next()
+		return self.settle()
+	}
+	if !self.baseOk && !self.localOk {
+		return false
+	}
+	c := self.cmp()
+	if c <= 0 {
+		self.baseOk = self.base.Next()
+	}
+	if c >= 0 {
+		self.localOk = self.local.Next()
+	}
+	return self.settle()
+}
+
+func (self *forkIterator) First() bool {
+	self.init = true
+	self.baseOk = self.base.First()
+	self.localOk = self.local.First()
+	return self.settle()
+}
+
+func (self *forkIterator) Key() []byte {
+	return self.key
+}
+
+func (self *forkIterator) Value() []byte {
+	return self.value
+}
+
+func (self *forkIterator) Release() {
+	self.base.Release()
+	self.local.Release()
+}
+
+func (self *forkIterator) Error() error {
+	if err := self.base.Error(); err != nil {
+		return err
+	}
+	return self.local.Error()
+}
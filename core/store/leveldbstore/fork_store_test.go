@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package leveldbstore
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/core/store/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestForkStore(t *testing.T) (*LevelDBStore, *LevelDBStore) {
+	base, err := NewMemLevelDBStore()
+	assert.Nil(t, err)
+	local, err := NewMemLevelDBStore()
+	assert.Nil(t, err)
+	for _, kv := range [][2]string{{"a1", "base1"}, {"a3", "base3"}, {"a5", "base5"}, {"b1", "other"}} {
+		assert.Nil(t, base.Put([]byte(kv[0]), []byte(kv[1])))
+	}
+	return NewForkStore(base, local), base
+}
+
+func TestForkStoreReadThrough(t *testing.T) {
+	store, base := newTestForkStore(t)
+
+	val, err := store.Get([]byte("a1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "base1", string(val))
+
+	assert.Nil(t, store.Put([]byte("a1"), []byte("local1")))
+	assert.Nil(t, store.Delete([]byte("a3")))
+
+	val, err = store.Get([]byte("a1"))
+	assert.Nil(t, err)
+	assert.Equal(t, "local1", string(val))
+	_, err = store.Get([]byte("a3"))
+	assert.Equal(t, common.ErrNotFound, err)
+	has, err := store.Has([]byte("a3"))
+	assert.Nil(t, err)
+	assert.False(t, has)
+
+	store.NewBatch()
+	store.BatchPut([]byte("a3"), []byte("local3"))
+	store.BatchDelete([]byte("a5"))
+	assert.Nil(t, store.BatchCommit())
+	val, err = store.Get([]byte("a3"))
+	assert.Nil(t, err)
+	assert.Equal(t, "local3", string(val))
+	_, err = store.Get([]byte("a5"))
+	assert.Equal(t, common.ErrNotFound, err)
+
+	// base store is never modified
+	for k, v := range map[string]string{"a1": "base1", "a3": "base3", "a5": "base5"} {
+		val, err := base.Get([]byte(k))
+		assert.Nil(t, err)
+		assert.Equal(t, v, string(val))
+	}
+}
+
+func TestForkStoreIterator(t *testing.T) {
+	store, _ := newTestForkStore(t)
+	assert.Nil(t, store.Put([]byte("a0"), []byte("local0")))
+	assert.Nil(t, store.Put([]byte("a3"), []byte("local3")))
+	assert.Nil(t, store.Delete([]byte("a5")))
+	assert.Nil(t, store.Put([]byte("a6"), []byte("local6")))
+	assert.Nil(t, store.Delete([]byte("a7")))
+
+	expected := [][2]string{{"a0", "local0"}, {"a1", "base1"}, {"a3", "local3"}, {"a6", "local6"}}
+	iter := store.NewIterator([]byte("a"))
+	var items [][2]string
+	for iter.Next() {
+		items = append(items, [2]string{string(iter.Key()), string(iter.Value())})
+	}
+	assert.Nil(t, iter.Error())
+	assert.Equal(t, expected, items)
+
+	assert.True(t, iter.First())
+	assert.Equal(t, "a0", string(iter.Key()))
+	iter.Release()
+
+	iter = store.NewIterator([]byte("c"))
+	assert.False(t, iter.Next())
+	iter.Release()
+}
@@ -33,6 +33,8 @@ import (
 type LevelDBStore struct {
 	db    *leveldb.DB // LevelDB instance
 	batch *leveldb.Batch
+	//base store read through by fork store, see NewForkStore
+	base common.PersistStore
 }
 
 // used to compute the size of bloom filter bits array .
@@ -74,6 +76,24 @@ func NewLevelDBStore(file string) (*LevelDBStore, error) {
 	}, nil
 }
 
+//NewReadOnlyLevelDBStore return LevelDBStore instance which can only be read. The db files will never be modified
+func NewReadOnlyLevelDBStore(file string) (*LevelDBStore, error) {
+	o := opt.Options{
+		ReadOnly:       true,
+		ErrorIfMissing: true,
+		Filter:         filter.NewBloomFilter(BITSPERKEY),
+	}
+	db, err := leveldb.OpenFile(file, &o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelDBStore{
+		db:    db,
+		batch: nil,
+	}, nil
+}
+
 func NewMemLevelDBStore() (*LevelDBStore, error) {
 	store := storage.NewMemStorage()
 	// default Options
@@ -94,11 +114,17 @@ func NewMemLevelDBStore() (*LevelDBStore, error) {
 
 //Put a key-value pair to leveldb
 func (self *LevelDBStore) Put(key []byte, value []byte) error {
+	if self.base != nil {
+		value = forkValue(value)
+	}
 	return self.db.Put(key, value, nil)
 }
 
 //Get the value of a key from leveldb
 func (self *LevelDBStore) Get(key []byte) ([]byte, error) {
+	if self.base != nil {
+		return self.forkGet(key)
+	}
 	dat, err := self.db.Get(key, nil)
 	if err != nil {
 		if err == leveldb.ErrNotFound {
@@ -111,11 +137,17 @@ func (self *LevelDBStore) Get(key []byte) ([]byte, error) {
 
 //Has return whether the key is exist in leveldb
 func (self *LevelDBStore) Has(key []byte) (bool, error) {
+	if self.base != nil {
+		return self.forkHas(key)
+	}
 	return self.db.Has(key, nil)
 }
 
 //Delete the the in leveldb
 func (self *LevelDBStore) Delete(key []byte) error {
+	if self.base != nil {
+		return self.db.Put(key, forkDeleted, nil)
+	}
 	return self.db.Delete(key, nil)
 }
 
@@ -126,11 +158,18 @@ func (self *LevelDBStore) NewBatch() {
 
 //BatchPut put a key-value pair to leveldb batch
 func (self *LevelDBStore) BatchPut(key []byte, value []byte) {
+	if self.base != nil {
+		value = forkValue(value)
+	}
 	self.batch.Put(key, value)
 }
 
 //BatchDelete delete a key to leveldb batch
 func (self *LevelDBStore) BatchDelete(key []byte) {
+	if self.base != nil {
+		self.batch.Put(key, forkDeleted)
+		return
+	}
 	self.batch.Delete(key)
 }
 
@@ -147,11 +186,17 @@ func (self *LevelDBStore) BatchCommit() error {
 //Close leveldb
 func (self *LevelDBStore) Close() error {
 	err := self.db.Close()
+	if err == nil && self.base != nil {
+		err = self.base.Close()
+	}
 	return err
 }
 
 //NewIterator return a iterator of leveldb with the key prefix
 func (self *LevelDBStore) NewIterator(prefix []byte) common.StoreIterator {
+	if self.base != nil {
+		return self.forkIterator(prefix)
+	}
 
 	iter := self.db.NewIterator(util.BytesPrefix(prefix), nil)
 
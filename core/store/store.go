@@ -44,6 +44,7 @@ type LedgerStore interface {
 	Close() error
 	AddHeaders(headers []*types.Header) error
 	AddBlock(block *types.Block, ccMsg *types.CrossChainMsg, stateMerkleRoot common.Uint256) error
+	AddBlocks(blocks []*types.Block, ccMsgs []*types.CrossChainMsg, stateMerkleRoots []common.Uint256) (int, error)
 	ExecuteBlock(b *types.Block) (ExecuteResult, error)                                       // called by consensus
 	SubmitBlock(b *types.Block, crossChainMsg *types.CrossChainMsg, exec ExecuteResult) error // called by consensus
 	GetStateMerkleRoot(height uint32) (result common.Uint256, err error)
@@ -63,6 +64,8 @@ type LedgerStore interface {
 	GetBlockRootWithNewTxRoots(startHeight uint32, txRoots []common.Uint256) common.Uint256
 	GetMerkleProof(m, n uint32) ([]common.Uint256, error)
 	GetContractState(contractHash common.Address) (*payload.DeployCode, error)
+	GetContractHistory(contractHash common.Address) (*states.ContractHistory, error)
+	GetContractStorageSize(contractHash common.Address) (accounted uint64, total uint64, err error)
 	GetBookkeeperState() (*states.BookkeeperState, error)
 	GetStorageItem(key *states.StorageKey) (*states.StorageItem, error)
 	PreExecuteContract(tx *types.Transaction) (*cstates.PreExecResult, error)
@@ -75,4 +78,6 @@ type LedgerStore interface {
 	GetCrossChainMsg(height uint32) (*types.CrossChainMsg, error)
 	GetCrossStatesProof(height uint32, key []byte) ([]byte, error)
 	EnableBlockPrune(numBeforeCurr uint32)
+	EnableLightMode()
+	IsLightMode() bool
 }
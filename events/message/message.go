@@ -19,18 +19,24 @@
 package message
 
 import (
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/types"
 )
 
 const (
 	TOPIC_SAVE_BLOCK_COMPLETE = "svblkcmp"
 	TOPIC_SMART_CODE_EVENT    = "scevt"
+	TOPIC_TX_POOL_ADD         = "txpooladd"
 )
 
 type SaveBlockCompleteMsg struct {
 	Block *types.Block
 }
 
+type TxPoolAddMsg struct {
+	Hash common.Uint256
+}
+
 type SmartCodeEventMsg struct {
 	Event *types.SmartCodeEvent
 }
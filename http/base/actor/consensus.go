@@ -19,10 +19,17 @@
 package actor
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
 	cactor "github.com/ontio/ontology/consensus/actor"
 )
 
+const GENERATE_BLOCKS_TIMEOUT = 60
+
 var consensusSrvPid *actor.PID
 
 func SetConsensusPid(actr *actor.PID) {
@@ -44,3 +51,39 @@ func ConsensusSrvHalt() error {
 	}
 	return nil
 }
+
+//GenerateBlocks requests solo consensus to seal count blocks immediately
+func GenerateBlocks(count uint32) ([]common.Uint256, error) {
+	if consensusSrvPid == nil {
+		return nil, errors.New("consensus service is not started")
+	}
+	future := consensusSrvPid.RequestFuture(&cactor.GenerateBlocks{Count: count}, GENERATE_BLOCKS_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return nil, err
+	}
+	rsp, ok := result.(*cactor.GenerateBlocksRsp)
+	if !ok {
+		return nil, errors.New("fail")
+	}
+	return rsp.BlockHashes, rsp.Error
+}
+
+//SetNextBlockTimestamp requests solo consensus to use timestamp for the next block
+func SetNextBlockTimestamp(timestamp uint32) error {
+	if consensusSrvPid == nil {
+		return errors.New("consensus service is not started")
+	}
+	future := consensusSrvPid.RequestFuture(&cactor.SetNextBlockTimestamp{Timestamp: timestamp}, REQ_TIMEOUT*time.Second)
+	result, err := future.Result()
+	if err != nil {
+		log.Errorf(ERR_ACTOR_COMM, err)
+		return err
+	}
+	rsp, ok := result.(*cactor.SetNextBlockTimestampRsp)
+	if !ok {
+		return errors.New("fail")
+	}
+	return rsp.Error
+}
@@ -22,8 +22,10 @@ import (
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
 	cstate "github.com/ontio/ontology/smartcontract/states"
 )
 
@@ -32,6 +34,23 @@ const (
 	ERR_ACTOR_COMM = "[http] Actor comm error: %v"
 )
 
+//LightClient fetch states and transactions from full node peers in light mode
+type LightClient interface {
+	GetStorage(contract common.Address, key []byte) ([]byte, error)
+	GetTransaction(txHash common.Uint256) (*types.Transaction, uint32, error)
+}
+
+var lightClient LightClient
+
+func SetLightClient(client LightClient) {
+	lightClient = client
+}
+
+//IsLightMode return whether the ledger only saves block headers
+func IsLightMode() bool {
+	return ledger.DefLedger.IsLightMode()
+}
+
 //GetHeaderByHeight from ledger
 func GetHeaderByHeight(height uint32) (*types.Header, error) {
 	return ledger.DefLedger.GetHeaderByHeight(height)
@@ -64,11 +83,18 @@ func GetCurrentBlockHeight() uint32 {
 
 //GetTransaction from ledger
 func GetTransaction(hash common.Uint256) (*types.Transaction, error) {
+	if lightClient != nil {
+		tx, _, err := lightClient.GetTransaction(hash)
+		return tx, err
+	}
 	return ledger.DefLedger.GetTransaction(hash)
 }
 
 //GetStorageItem from ledger
 func GetStorageItem(address common.Address, key []byte) ([]byte, error) {
+	if lightClient != nil {
+		return lightClient.GetStorage(address, key)
+	}
 	return ledger.DefLedger.GetStorageItem(address, key)
 }
 
@@ -78,8 +104,23 @@ func GetContractStateFromStore(hash common.Address) (*payload.DeployCode, error)
 	return ledger.DefLedger.GetContractState(hash)
 }
 
+//GetContractHistoryFromStore from ledger
+func GetContractHistoryFromStore(hash common.Address) (*states.ContractHistory, error) {
+	hash = updateNativeSCAddr(hash)
+	return ledger.DefLedger.GetContractHistory(hash)
+}
+
+//GetContractStorageSizeFromStore from ledger
+func GetContractStorageSizeFromStore(hash common.Address) (uint64, uint64, error) {
+	return ledger.DefLedger.GetContractStorageSize(hash)
+}
+
 //GetTxnWithHeightByTxHash from ledger
 func GetTxnWithHeightByTxHash(hash common.Uint256) (uint32, *types.Transaction, error) {
+	if lightClient != nil {
+		tx, height, err := lightClient.GetTransaction(hash)
+		return height, tx, err
+	}
 	tx, height, err := ledger.DefLedger.GetTransactionWithHeight(hash)
 	return height, tx, err
 }
@@ -89,6 +130,11 @@ func PreExecuteContract(tx *types.Transaction) (*cstate.PreExecResult, error) {
 	return ledger.DefLedger.PreExecuteContract(tx)
 }
 
+//PreExecuteContractWithDebugger from ledger
+func PreExecuteContractWithDebugger(tx *types.Transaction, debugger neovm.Debugger) (*cstate.PreExecResult, error) {
+	return ledger.DefLedger.PreExecuteContractWithDebugger(tx, debugger)
+}
+
 func PreExecuteContractBatch(tx []*types.Transaction, atomic bool) ([]*cstate.PreExecResult, uint32, error) {
 	return ledger.DefLedger.PreExecuteContractBatch(tx, atomic)
 }
@@ -19,12 +19,37 @@
 package actor
 
 import (
+	"errors"
+	"time"
+
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/p2pserver/common"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
+	"github.com/ontio/ontology/p2pserver/peer_score"
 )
 
 var netServer p2p.P2P
 
+//peerAdmin is implemented by the net server supporting peer management at runtime
+type peerAdmin interface {
+	GetPeerScores() []peer_score.ScoreInfo
+	BanPeer(addr string, duration time.Duration, reason string) (*peer_score.BanInfo, error)
+	UnbanPeer(addr string) error
+	GetBannedPeers() []peer_score.BanInfo
+	GetPeerDetails() []peer.PeerDetail
+	AddPeer(addr string) error
+	RemovePeer(addr string) error
+	DisconnectPeer(idOrAddr string) error
+	GetReservedConfig() *config.P2PRsvConfig
+	AddReservedPeer(ipOrName string) error
+	RemoveReservedPeer(ipOrName string) error
+	AddMaskPeer(ip string) error
+	RemoveMaskPeer(ip string) error
+}
+
+var errPeerAdminUnsupported = errors.New("peer management is not supported by net server")
+
 func SetNetServer(p2p p2p.P2P) {
 	netServer = p2p
 }
@@ -93,3 +118,128 @@ func GetNodeType() uint64 {
 	}
 	return netServer.GetHostInfo().Services
 }
+
+func getPeerAdmin() (peerAdmin, error) {
+	admin, ok := netServer.(peerAdmin)
+	if !ok {
+		return nil, errPeerAdminUnsupported
+	}
+	return admin, nil
+}
+
+//GetPeerScores from netSever actor
+func GetPeerScores() ([]peer_score.ScoreInfo, error) {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return admin.GetPeerScores(), nil
+}
+
+//BanPeer bans the ip for duration and disconnects its peers
+func BanPeer(addr string, duration time.Duration, reason string) (*peer_score.BanInfo, error) {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return admin.BanPeer(addr, duration, reason)
+}
+
+//UnbanPeer lifts the ban of the ip
+func UnbanPeer(addr string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.UnbanPeer(addr)
+}
+
+//GetBannedPeers from netSever actor
+func GetBannedPeers() ([]peer_score.BanInfo, error) {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return admin.GetBannedPeers(), nil
+}
+
+//GetPeerDetails from netSever actor
+func GetPeerDetails() ([]peer.PeerDetail, error) {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return admin.GetPeerDetails(), nil
+}
+
+//AddPeer connects to the address
+func AddPeer(addr string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.AddPeer(addr)
+}
+
+//RemovePeer disconnects the address and stops connecting to it automatically
+func RemovePeer(addr string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.RemovePeer(addr)
+}
+
+//DisconnectPeer closes the connection with peer of the id or address
+func DisconnectPeer(idOrAddr string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.DisconnectPeer(idOrAddr)
+}
+
+//GetReservedConfig from netSever actor
+func GetReservedConfig() (*config.P2PRsvConfig, error) {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return nil, err
+	}
+	return admin.GetReservedConfig(), nil
+}
+
+//AddReservedPeer adds the ip or domain to reserved list
+func AddReservedPeer(ipOrName string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.AddReservedPeer(ipOrName)
+}
+
+//RemoveReservedPeer removes the ip or domain from reserved list
+func RemoveReservedPeer(ipOrName string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.RemoveReservedPeer(ipOrName)
+}
+
+//AddMaskPeer adds the ip to mask list
+func AddMaskPeer(ip string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.AddMaskPeer(ip)
+}
+
+//RemoveMaskPeer removes the ip from mask list
+func RemoveMaskPeer(ip string) error {
+	admin, err := getPeerAdmin()
+	if err != nil {
+		return err
+	}
+	return admin.RemoveMaskPeer(ip)
+}
@@ -33,11 +33,14 @@ import (
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/ledger"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
 	cutils "github.com/ontio/ontology/core/utils"
 	ontErrors "github.com/ontio/ontology/errors"
 	bactor "github.com/ontio/ontology/http/base/actor"
 	common2 "github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/smartcontract/debugger"
 	"github.com/ontio/ontology/smartcontract/event"
 	"github.com/ontio/ontology/smartcontract/service/native/ont"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
@@ -178,6 +181,29 @@ type TXNEntryInfo struct {
 	State []TXNAttrInfo // the result from each validator
 }
 
+type DebugStatus struct {
+	Session  uint64
+	State    string
+	Snapshot *debugger.Snapshot `json:",omitempty"`
+	Result   *PreExecuteResult  `json:",omitempty"`
+	Error    string             `json:",omitempty"`
+}
+
+//ContractStorageSize is the storage bytes of contract, the deposit is charged for the accounted bytes only
+type ContractStorageSize struct {
+	Accounted uint64 //bytes of items written since the storage accounting height
+	Total     uint64 //bytes of all storage items
+}
+
+type ContractVersionInfo struct {
+	Version      uint32
+	CodeHash     string
+	PrevCodeHash string
+	Height       uint32
+	Upgrader     string
+	TxHash       string
+}
+
 func GetLogEvent(obj *event.LogEventArgs) (map[string]bool, LogEventArgs) {
 	hash := obj.TxHash
 	addr := obj.ContractAddress.ToHexString()
@@ -204,6 +230,35 @@ func ConvertPreExecuteResult(obj *cstate.PreExecResult) PreExecuteResult {
 	return PreExecuteResult{obj.State, obj.Gas, obj.Result, evts}
 }
 
+func ConvertDebugStatus(status *debugger.Status) DebugStatus {
+	res := DebugStatus{
+		Session:  status.Session,
+		State:    status.State,
+		Snapshot: status.Snapshot,
+		Error:    status.Error,
+	}
+	if status.Result != nil {
+		result := ConvertPreExecuteResult(status.Result)
+		res.Result = &result
+	}
+	return res
+}
+
+func ConvertContractHistory(history *states.ContractHistory) []ContractVersionInfo {
+	versions := make([]ContractVersionInfo, 0, len(history.Versions))
+	for _, v := range history.Versions {
+		versions = append(versions, ContractVersionInfo{
+			Version:      v.Version,
+			CodeHash:     v.CodeHash.ToHexString(),
+			PrevCodeHash: v.PrevCodeHash.ToHexString(),
+			Height:       v.Height,
+			Upgrader:     v.Upgrader.ToBase58(),
+			TxHash:       v.TxHash.ToHexString(),
+		})
+	}
+	return versions
+}
+
 func TransArryByteToHexString(ptx *types.Transaction) *Transactions {
 	trans := new(Transactions)
 	trans.TxType = ptx.TxType
@@ -301,6 +356,9 @@ func GetBlockInfo(block *types.Block) BlockInfo {
 }
 
 func GetBalance(address common.Address) (*BalanceOfRsp, error) {
+	if bactor.IsLightMode() {
+		return getLightBalance(address)
+	}
 	balances, height, err := GetContractBalance(0, []common.Address{utils.OntContractAddress, utils.OngContractAddress}, address, true)
 	if err != nil {
 		return nil, fmt.Errorf("get ont balance error:%s", err)
@@ -312,6 +370,32 @@ func GetBalance(address common.Address) (*BalanceOfRsp, error) {
 	}, nil
 }
 
+//getLightBalance read ont and ong balance storage from full node peers in light mode
+func getLightBalance(address common.Address) (*BalanceOfRsp, error) {
+	height := bactor.GetCurrentBlockHeight()
+	balances := make([]uint64, 0, 2)
+	for _, contract := range []common.Address{utils.OntContractAddress, utils.OngContractAddress} {
+		value, err := bactor.GetStorageItem(contract, address[:])
+		if err == scom.ErrNotFound {
+			balances = append(balances, 0)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get balance of %s error:%s", contract.ToHexString(), err)
+		}
+		balance, eof := common.NewZeroCopySource(value).NextUint64()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		balances = append(balances, balance)
+	}
+	return &BalanceOfRsp{
+		Ont:    fmt.Sprintf("%d", balances[0]),
+		Ong:    fmt.Sprintf("%d", balances[1]),
+		Height: fmt.Sprintf("%d", height),
+	}, nil
+}
+
 func GetGrantOng(addr common.Address) (string, error) {
 	key := append([]byte(ont.UNBOUND_TIME_OFFSET), addr[:]...)
 	value, err := ledger.DefLedger.GetStorageItem(utils.OntContractAddress, key)
@@ -32,6 +32,7 @@ import (
 	bcomn "github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
 	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/vm/crossvm_codec"
 )
 
 //get best block hash
@@ -365,6 +366,81 @@ func GetContractState(params []interface{}) map[string]interface{} {
 	return responseSuccess(common.ToHexString(sink.Bytes()))
 }
 
+//get wasm contract abi, return nil if contract has no abi section
+func GetContractAbi(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bactor.GetContractStateFromStore(address)
+	if err != nil || contract == nil {
+		return responsePack(berr.UNKNOWN_CONTRACT, berr.ErrMap[berr.UNKNOWN_CONTRACT])
+	}
+	if contract.VmType() != payload.WASMVM_TYPE {
+		return responseSuccess(nil)
+	}
+	abi, err := crossvm_codec.ReadWasmContractAbi(contract.GetRawCode())
+	if err != nil {
+		return responsePack(berr.SMARTCODE_ERROR, err.Error())
+	}
+	return responseSuccess(abi)
+}
+
+//get contract upgrade history, empty if the contract has never been upgraded
+func GetContractHistory(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bactor.GetContractStateFromStore(address)
+	if err != nil || contract == nil {
+		return responsePack(berr.UNKNOWN_CONTRACT, berr.ErrMap[berr.UNKNOWN_CONTRACT])
+	}
+	history, err := bactor.GetContractHistoryFromStore(address)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(bcomn.ConvertContractHistory(history))
+}
+
+//get contract storage bytes accounted since the storage accounting height
+func GetContractStorageSize(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	address, err := bcomn.GetAddress(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	contract, err := bactor.GetContractStateFromStore(address)
+	if err != nil || contract == nil {
+		return responsePack(berr.UNKNOWN_CONTRACT, berr.ErrMap[berr.UNKNOWN_CONTRACT])
+	}
+	accounted, total, err := bactor.GetContractStorageSizeFromStore(address)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, "")
+	}
+	return responseSuccess(bcomn.ContractStorageSize{Accounted: accounted, Total: total})
+}
+
 //get smartconstract event
 func GetSmartCodeEvent(params []interface{}) map[string]interface{} {
 	if !config.DefConfig.Common.EnableEventLog {
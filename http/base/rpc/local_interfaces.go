@@ -19,14 +19,24 @@
 package rpc
 
 import (
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
 	bactor "github.com/ontio/ontology/http/base/actor"
-	"github.com/ontio/ontology/http/base/common"
+	bcomn "github.com/ontio/ontology/http/base/common"
 	berr "github.com/ontio/ontology/http/base/error"
+	p2pcomm "github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/smartcontract/debugger"
+	"github.com/ontio/ontology/smartcontract/impersonation"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	cstates "github.com/ontio/ontology/smartcontract/states"
 )
 
 const (
@@ -58,7 +68,7 @@ func GetNodeState(params []interface{}) map[string]interface{} {
 	if err != nil {
 		return responsePack(berr.INTERNAL_ERROR, false)
 	}
-	n := common.NodeInfo{
+	n := bcomn.NodeInfo{
 		NodeTime:    t,
 		NodePort:    port,
 		ID:          id,
@@ -100,3 +110,365 @@ func SetDebugInfo(params []interface{}) map[string]interface{} {
 	}
 	return responsePack(berr.SUCCESS, true)
 }
+
+//GenerateBlocks seals blocks immediately in solo consensus
+func GenerateBlocks(params []interface{}) map[string]interface{} {
+	if config.DefConfig.Genesis.ConsensusType != config.CONSENSUS_TYPE_SOLO {
+		return responsePack(berr.INVALID_METHOD, "")
+	}
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	count, ok := params[0].(float64)
+	if !ok || count < 1 || count > math.MaxUint32 || count != math.Trunc(count) {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	hashes, err := bactor.GenerateBlocks(uint32(count))
+	if err != nil {
+		log.Errorf("GenerateBlocks error:%s", err)
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	blocks := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		blocks = append(blocks, hash.ToHexString())
+	}
+	return responseSuccess(blocks)
+}
+
+//SetNextBlockTimestamp sets the timestamp of next block in solo consensus
+func SetNextBlockTimestamp(params []interface{}) map[string]interface{} {
+	if config.DefConfig.Genesis.ConsensusType != config.CONSENSUS_TYPE_SOLO {
+		return responsePack(berr.INVALID_METHOD, "")
+	}
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	timestamp, ok := params[0].(float64)
+	if !ok || timestamp < 0 || timestamp > math.MaxUint32 || timestamp != math.Trunc(timestamp) {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	if err := bactor.SetNextBlockTimestamp(uint32(timestamp)); err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
+//ImpersonateAccount marks the address as impersonated in test mode, so that its witness is always checked
+//and unsigned transactions paid by it are accepted
+func ImpersonateAccount(params []interface{}) map[string]interface{} {
+	addr, errCode := impersonationParam(params)
+	if errCode != berr.SUCCESS {
+		return responsePack(errCode, "")
+	}
+	if err := impersonation.Impersonate(addr); err != nil {
+		return responsePack(berr.INVALID_METHOD, err.Error())
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
+//StopImpersonatingAccount stops impersonating the address
+func StopImpersonatingAccount(params []interface{}) map[string]interface{} {
+	addr, errCode := impersonationParam(params)
+	if errCode != berr.SUCCESS {
+		return responsePack(errCode, "")
+	}
+	if err := impersonation.StopImpersonating(addr); err != nil {
+		return responsePack(berr.INVALID_METHOD, err.Error())
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
+//GetImpersonatedAccounts return all impersonated addresses
+func GetImpersonatedAccounts(params []interface{}) map[string]interface{} {
+	if !impersonation.Enabled() {
+		return responsePack(berr.INVALID_METHOD, impersonation.ErrNotTestMode.Error())
+	}
+	addrs := impersonation.Addresses()
+	result := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		result = append(result, addr.ToBase58())
+	}
+	return responseSuccess(result)
+}
+
+func impersonationParam(params []interface{}) (common.Address, int64) {
+	if !impersonation.Enabled() {
+		return common.ADDRESS_EMPTY, berr.INVALID_METHOD
+	}
+	if len(params) < 1 {
+		return common.ADDRESS_EMPTY, berr.INVALID_PARAMS
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return common.ADDRESS_EMPTY, berr.INVALID_PARAMS
+	}
+	addr, err := common.AddressFromBase58(str)
+	if err != nil {
+		return common.ADDRESS_EMPTY, berr.INVALID_PARAMS
+	}
+	return addr, berr.SUCCESS
+}
+
+//GetPeerScores return the scores of connected peers and misbehaving peers
+func GetPeerScores(params []interface{}) map[string]interface{} {
+	scores, err := bactor.GetPeerScores()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(scores)
+}
+
+//BanPeer refuses connections with an ip for the given seconds, default one day
+func BanPeer(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	addr, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	duration := time.Duration(p2pcomm.DEFAULT_BAN_DURATION) * time.Second
+	if len(params) > 1 {
+		seconds, ok := params[1].(float64)
+		if !ok || seconds < 1 {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+	info, err := bactor.BanPeer(addr, duration, "banned by local rpc")
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+//UnbanPeer lifts the ban of an ip
+func UnbanPeer(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	addr, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	if err := bactor.UnbanPeer(addr); err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
+//GetBannedPeers return all banned ips
+func GetBannedPeers(params []interface{}) map[string]interface{} {
+	bans, err := bactor.GetBannedPeers()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(bans)
+}
+
+//GetPeerInfo return the detailed information of connected peers
+func GetPeerInfo(params []interface{}) map[string]interface{} {
+	details, err := bactor.GetPeerDetails()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(details)
+}
+
+//DebugStart pre-executes a neovm transaction in a new debug session, params are the raw transaction,
+//optional stop on entry flag (default 1) and optional breakpoints
+func DebugStart(params []interface{}) map[string]interface{} {
+	str, ok := stringParam(params)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	raw, err := common.HexToBytes(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	txn, err := types.TransactionFromRawBytes(raw)
+	if err != nil {
+		return responsePack(berr.INVALID_TRANSACTION, "")
+	}
+	if txn.TxType != types.InvokeNeo {
+		return responsePack(berr.INVALID_TRANSACTION, "only neovm invoke transaction can be debugged")
+	}
+	stopOnEntry := true
+	if len(params) > 1 {
+		flag, ok := params[1].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		stopOnEntry = flag == 1
+	}
+	var breakpoints []debugger.Breakpoint
+	if len(params) > 2 {
+		breakpoints, err = breakpointsParam(params[2])
+		if err != nil {
+			return responsePack(berr.INVALID_PARAMS, err.Error())
+		}
+	}
+	status, err := debugger.Start(func(d neovm.Debugger) (*cstates.PreExecResult, error) {
+		return bactor.PreExecuteContractWithDebugger(txn, d)
+	}, breakpoints, stopOnEntry)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(bcomn.ConvertDebugStatus(status))
+}
+
+//DebugStep executes one opcode of the debug session
+func DebugStep(params []interface{}) map[string]interface{} {
+	return debugCall(params, (*debugger.Session).Step)
+}
+
+//DebugContinue executes the debug session until a breakpoint is hit or the execution finished
+func DebugContinue(params []interface{}) map[string]interface{} {
+	return debugCall(params, (*debugger.Session).Continue)
+}
+
+//DebugStop aborts the debug session
+func DebugStop(params []interface{}) map[string]interface{} {
+	return debugCall(params, (*debugger.Session).Stop)
+}
+
+//DebugSetBreakpoints replace the breakpoints of debug session
+func DebugSetBreakpoints(params []interface{}) map[string]interface{} {
+	if len(params) < 2 {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	session, errCode, desc := debugSessionParam(params)
+	if errCode != berr.SUCCESS {
+		return responsePack(errCode, desc)
+	}
+	breakpoints, err := breakpointsParam(params[1])
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	session.SetBreakpoints(breakpoints)
+	return responsePack(berr.SUCCESS, true)
+}
+
+//GetDebugSessions return the ids of running debug sessions
+func GetDebugSessions(params []interface{}) map[string]interface{} {
+	return responseSuccess(debugger.Sessions())
+}
+
+func debugCall(params []interface{}, fn func(*debugger.Session) *debugger.Status) map[string]interface{} {
+	session, errCode, desc := debugSessionParam(params)
+	if errCode != berr.SUCCESS {
+		return responsePack(errCode, desc)
+	}
+	return responseSuccess(bcomn.ConvertDebugStatus(fn(session)))
+}
+
+func debugSessionParam(params []interface{}) (*debugger.Session, int64, string) {
+	if len(params) < 1 {
+		return nil, berr.INVALID_PARAMS, ""
+	}
+	id, ok := params[0].(float64)
+	if !ok {
+		return nil, berr.INVALID_PARAMS, ""
+	}
+	session, err := debugger.Get(uint64(id))
+	if err != nil {
+		return nil, berr.INVALID_PARAMS, err.Error()
+	}
+	return session, berr.SUCCESS, ""
+}
+
+//breakpointsParam parses breakpoints like [{"contract":"<hex address>","offset":12,"opcode":"SYSCALL"}],
+//all fields are optional
+func breakpointsParam(param interface{}) ([]debugger.Breakpoint, error) {
+	list, ok := param.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("breakpoints should be a list")
+	}
+	breakpoints := make([]debugger.Breakpoint, 0, len(list))
+	for _, item := range list {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("breakpoint should be an object")
+		}
+		bp := debugger.Breakpoint{Offset: -1}
+		if contract, ok := obj["contract"].(string); ok && contract != "" {
+			addr, err := bcomn.GetAddress(contract)
+			if err != nil {
+				return nil, fmt.Errorf("invalid breakpoint contract %s", contract)
+			}
+			bp.Contract = addr
+		}
+		if offset, ok := obj["offset"].(float64); ok {
+			bp.Offset = int(offset)
+		}
+		if opcode, ok := obj["opcode"].(string); ok {
+			bp.OpCode = opcode
+		}
+		breakpoints = append(breakpoints, bp)
+	}
+	return breakpoints, nil
+}
+
+func stringParam(params []interface{}) (string, bool) {
+	if len(params) < 1 {
+		return "", false
+	}
+	str, ok := params[0].(string)
+	return str, ok && str != ""
+}
+
+//peerAdminCall invokes fn with the first string param
+func peerAdminCall(params []interface{}, fn func(string) error) map[string]interface{} {
+	str, ok := stringParam(params)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	if err := fn(str); err != nil {
+		return responsePack(berr.INVALID_PARAMS, err.Error())
+	}
+	return responsePack(berr.SUCCESS, true)
+}
+
+//AddPeer connects to a peer address, ip:port
+func AddPeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.AddPeer)
+}
+
+//RemovePeer disconnects a peer address and stops connecting to it automatically
+func RemovePeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.RemovePeer)
+}
+
+//DisconnectPeer closes the connection with a peer of id or address
+func DisconnectPeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.DisconnectPeer)
+}
+
+//GetReservedPeers return the reserved and mask peers in use
+func GetReservedPeers(params []interface{}) map[string]interface{} {
+	cfg, err := bactor.GetReservedConfig()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(cfg)
+}
+
+//AddReservedPeer adds an ip or domain to reserved list
+func AddReservedPeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.AddReservedPeer)
+}
+
+//RemoveReservedPeer removes an ip or domain from reserved list
+func RemoveReservedPeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.RemoveReservedPeer)
+}
+
+//AddMaskPeer adds an ip to mask list
+func AddMaskPeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.AddMaskPeer)
+}
+
+//RemoveMaskPeer removes an ip from mask list
+func RemoveMaskPeer(params []interface{}) map[string]interface{} {
+	return peerAdminCall(params, bactor.RemoveMaskPeer)
+}
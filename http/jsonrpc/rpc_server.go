@@ -48,6 +48,9 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getnetworkid", rpc.GetNetworkId)
 
 	rpc.HandleFunc("getcontractstate", rpc.GetContractState)
+	rpc.HandleFunc("getcontractabi", rpc.GetContractAbi)
+	rpc.HandleFunc("getcontracthistory", rpc.GetContractHistory)
+	rpc.HandleFunc("getcontractstoragesize", rpc.GetContractStorageSize)
 	rpc.HandleFunc("getmempooltxcount", rpc.GetMemPoolTxCount)
 	rpc.HandleFunc("getmempooltxstate", rpc.GetMemPoolTxState)
 	rpc.HandleFunc("getmempooltxhashlist", rpc.GetMemPoolTxHashList)
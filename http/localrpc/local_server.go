@@ -43,6 +43,30 @@ func StartLocalServer() error {
 	rpc.HandleFunc("startconsensus", rpc.StartConsensus)
 	rpc.HandleFunc("stopconsensus", rpc.StopConsensus)
 	rpc.HandleFunc("setdebuginfo", rpc.SetDebugInfo)
+	rpc.HandleFunc("generateblocks", rpc.GenerateBlocks)
+	rpc.HandleFunc("setnextblocktimestamp", rpc.SetNextBlockTimestamp)
+	rpc.HandleFunc("impersonateaccount", rpc.ImpersonateAccount)
+	rpc.HandleFunc("stopimpersonatingaccount", rpc.StopImpersonatingAccount)
+	rpc.HandleFunc("getimpersonatedaccounts", rpc.GetImpersonatedAccounts)
+	rpc.HandleFunc("getpeerscores", rpc.GetPeerScores)
+	rpc.HandleFunc("banpeer", rpc.BanPeer)
+	rpc.HandleFunc("unbanpeer", rpc.UnbanPeer)
+	rpc.HandleFunc("getbannedpeers", rpc.GetBannedPeers)
+	rpc.HandleFunc("getpeerinfo", rpc.GetPeerInfo)
+	rpc.HandleFunc("addpeer", rpc.AddPeer)
+	rpc.HandleFunc("removepeer", rpc.RemovePeer)
+	rpc.HandleFunc("disconnectpeer", rpc.DisconnectPeer)
+	rpc.HandleFunc("getreservedpeers", rpc.GetReservedPeers)
+	rpc.HandleFunc("addreservedpeer", rpc.AddReservedPeer)
+	rpc.HandleFunc("removereservedpeer", rpc.RemoveReservedPeer)
+	rpc.HandleFunc("addmaskpeer", rpc.AddMaskPeer)
+	rpc.HandleFunc("removemaskpeer", rpc.RemoveMaskPeer)
+	rpc.HandleFunc("debugstart", rpc.DebugStart)
+	rpc.HandleFunc("debugstep", rpc.DebugStep)
+	rpc.HandleFunc("debugcontinue", rpc.DebugContinue)
+	rpc.HandleFunc("debugstop", rpc.DebugStop)
+	rpc.HandleFunc("debugsetbreakpoints", rpc.DebugSetBreakpoints)
+	rpc.HandleFunc("getdebugsessions", rpc.GetDebugSessions)
 
 	// TODO: only listen to local host
 	err := http.ListenAndServe(LOCAL_HOST+":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpLocalPort)), nil)
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/p2pserver/message/types"
 	"github.com/ontio/ontology/p2pserver/net/netserver"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
 	"github.com/ontio/ontology/p2pserver/protocols"
@@ -65,10 +66,27 @@ var (
 		Name: "ontology_p2p_reconnect_count",
 		Help: "ontology p2p reconnect count",
 	})
+
+	compressSentSavedMetric = prom.NewCounterFunc(prom.CounterOpts{
+		Name: "ontology_p2p_compress_sent_saved_bytes",
+		Help: "ontology p2p bytes saved by message compression when sending",
+	}, func() float64 {
+		sent, _ := types.CompressionSavedBytes()
+		return float64(sent)
+	})
+
+	compressRecvSavedMetric = prom.NewCounterFunc(prom.CounterOpts{
+		Name: "ontology_p2p_compress_recv_saved_bytes",
+		Help: "ontology p2p bytes saved by message compression when receiving",
+	}, func() float64 {
+		_, recv := types.CompressionSavedBytes()
+		return float64(recv)
+	})
 )
 
 var (
-	metrics = []prom.Collector{nodePortMetric, blockHeightMetric, inboundsCountMetric, outboundsCountMetric, peerStatusMetric, reserveCountMetric, reconnectCountMetric}
+	metrics = []prom.Collector{nodePortMetric, blockHeightMetric, inboundsCountMetric, outboundsCountMetric, peerStatusMetric, reserveCountMetric, reconnectCountMetric,
+		compressSentSavedMetric, compressRecvSavedMetric}
 )
 
 func initMetric() error {
@@ -40,8 +40,10 @@ import (
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/consensus"
+	"github.com/ontio/ontology/consensus/signer"
 	"github.com/ontio/ontology/core/genesis"
 	"github.com/ontio/ontology/core/ledger"
+	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/events"
 	bactor "github.com/ontio/ontology/http/base/actor"
 	hserver "github.com/ontio/ontology/http/base/actor"
@@ -80,6 +82,7 @@ func setupAPP() *cli.App {
 		cmd.MultiSigTxCommand,
 		cmd.SendTxCommand,
 		cmd.ShowTxCommand,
+		cmd.NetCrawlCommand,
 	}
 	app.Flags = []cli.Flag{
 		//common setting
@@ -89,6 +92,7 @@ func setupAPP() *cli.App {
 		utils.DisableLogFileFlag,
 		utils.DisableEventLogFlag,
 		utils.DataDirFlag,
+		utils.LightModeFlag,
 		utils.WasmVerifyMethodFlag,
 		//account setting
 		utils.WalletFileFlag,
@@ -97,6 +101,7 @@ func setupAPP() *cli.App {
 		//consensus setting
 		utils.EnableConsensusFlag,
 		utils.MaxTxInBlockFlag,
+		utils.ConsensusSignerFlag,
 		//txpool setting
 		utils.GasPriceFlag,
 		utils.GasLimitFlag,
@@ -112,9 +117,14 @@ func setupAPP() *cli.App {
 		utils.MaxConnInBoundFlag,
 		utils.MaxConnOutBoundFlag,
 		utils.MaxConnInBoundForSingleIPFlag,
+		utils.DisableP2PEncryptionFlag,
+		utils.DisableP2PCompressionFlag,
+		utils.ExternalAddrFlag,
+		utils.NATFlag,
 		//test mode setting
 		utils.EnableTestModeFlag,
 		utils.TestModeGenBlockTimeFlag,
+		utils.TestModeSealModeFlag,
 		//rpc setting
 		utils.RPCDisabledFlag,
 		utils.RPCPortFlag,
@@ -128,6 +138,10 @@ func setupAPP() *cli.App {
 		utils.WsEnabledFlag,
 		utils.WsPortFlag,
 	}
+	forkCmd := cmd.ForkCommand
+	forkCmd.Action = startForkOntology
+	forkCmd.Flags = append(forkCmd.Flags, app.Flags...)
+	app.Commands = append(app.Commands, forkCmd)
 	app.Before = func(context *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
 		return nil
@@ -142,6 +156,58 @@ func main() {
 	}
 }
 
+//forkOptions is set only when the node is started by fork command
+type forkOptions struct {
+	source string
+	dir    string
+}
+
+var forkOpts *forkOptions
+
+func startForkOntology(ctx *cli.Context) error {
+	opts := &forkOptions{
+		source: ctx.String(utils.GetFlagName(utils.ForkSourceFlag)),
+		dir:    ctx.String(utils.GetFlagName(utils.ForkDataDirFlag)),
+	}
+	if opts.source == "" {
+		cmd.PrintErrorMsg("Missing %s argument.", utils.ForkSourceFlag.Name)
+		cli.ShowSubcommandHelp(ctx)
+		return nil
+	}
+	source, err := filepath.Abs(opts.source)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(opts.dir)
+	if err != nil {
+		return err
+	}
+	if source == dir {
+		return fmt.Errorf("fork dir should be different from fork source")
+	}
+	forkOpts = opts
+
+	// node flags are read from app context, so pass them through and start node in test mode
+	app := ctx.Parent()
+	forkFlags := map[string]bool{
+		utils.GetFlagName(utils.ForkSourceFlag):  true,
+		utils.GetFlagName(utils.ForkDataDirFlag): true,
+	}
+	for _, name := range ctx.FlagNames() {
+		if forkFlags[name] || !ctx.IsSet(name) {
+			continue
+		}
+		if err := app.Set(name, ctx.String(name)); err != nil {
+			return fmt.Errorf("set flag %s error: %s", name, err)
+		}
+	}
+	if err := app.Set(utils.GetFlagName(utils.EnableTestModeFlag), "true"); err != nil {
+		return err
+	}
+	startOntology(app)
+	return nil
+}
+
 func startOntology(ctx *cli.Context) {
 	initLog(ctx)
 
@@ -154,7 +220,7 @@ func startOntology(ctx *cli.Context) {
 		log.Errorf("initConfig error: %s", err)
 		return
 	}
-	acc, err := initAccount(ctx)
+	bkSigner, err := initSigner(ctx)
 	if err != nil {
 		log.Errorf("initWallet error: %s", err)
 		return
@@ -175,7 +241,7 @@ func startOntology(ctx *cli.Context) {
 		log.Errorf("initP2PNode error: %s", err)
 		return
 	}
-	_, err = initConsensus(ctx, p2p, txpool, acc)
+	_, err = initConsensus(ctx, p2p, txpool, bkSigner)
 	if err != nil {
 		log.Errorf("initConsensus error: %s", err)
 		return
@@ -223,10 +289,54 @@ func initConfig(ctx *cli.Context) (*config.OntologyConfig, error) {
 	return cfg, nil
 }
 
-func initAccount(ctx *cli.Context) (*account.Account, error) {
+func initSigner(ctx *cli.Context) (signer.Signer, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
 	}
+	var bkSigner signer.Signer
+	endpoint := ctx.GlobalString(utils.GetFlagName(utils.ConsensusSignerFlag))
+	if endpoint != "" {
+		remote, err := initRemoteSigner(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		bkSigner = remote
+	} else {
+		acc, err := initAccount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		bkSigner = signer.NewLocalSigner(acc)
+	}
+
+	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
+		curPk := hex.EncodeToString(keypair.SerializePublicKey(bkSigner.PubKey()))
+		config.DefConfig.Genesis.SOLO.Bookkeepers = []string{curPk}
+	}
+
+	log.Infof("Account init success")
+	return bkSigner, nil
+}
+
+func initRemoteSigner(ctx *cli.Context, endpoint string) (*signer.RemoteSigner, error) {
+	address := ctx.GlobalString(utils.GetFlagName(utils.AccountAddressFlag))
+	if address == "" {
+		return nil, fmt.Errorf("Please config signer account using --%s flag", utils.GetFlagName(utils.AccountAddressFlag))
+	}
+	passwd, err := cmdcom.GetPasswd(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := signer.NewRemoteSigner(endpoint, address, string(passwd))
+	cmdcom.ClearPasswd(passwd)
+	if err != nil {
+		return nil, fmt.Errorf("init remote signer error: %s", err)
+	}
+	log.Infof("Using remote signer: %s, account: %s", endpoint, address)
+	return remote, nil
+}
+
+func initAccount(ctx *cli.Context) (*account.Account, error) {
 	walletFile := ctx.GlobalString(utils.GetFlagName(utils.WalletFileFlag))
 	if walletFile == "" {
 		return nil, fmt.Errorf("Please config wallet file using --wallet flag")
@@ -240,25 +350,25 @@ func initAccount(ctx *cli.Context) (*account.Account, error) {
 		return nil, fmt.Errorf("get account error: %s", err)
 	}
 	log.Infof("Using account: %s", acc.Address.ToBase58())
-
-	if config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO {
-		curPk := hex.EncodeToString(keypair.SerializePublicKey(acc.PublicKey))
-		config.DefConfig.Genesis.SOLO.Bookkeepers = []string{curPk}
-	}
-
-	log.Infof("Account init success")
 	return acc, nil
 }
 
 func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error) {
 	events.Init() //Init event hub
 
+	if forkOpts != nil {
+		return initForkLedger()
+	}
+
 	var err error
 	dbDir := utils.GetStoreDirPath(config.DefConfig.Common.DataDir, config.DefConfig.P2PNode.NetworkName)
 	ledger.DefLedger, err = ledger.NewLedger(dbDir, stateHashHeight)
 	if err != nil {
 		return nil, fmt.Errorf("NewLedger error: %s", err)
 	}
+	if config.DefConfig.Common.LightMode {
+		ledger.DefLedger.EnableLightMode()
+	}
 	bookKeepers, err := config.DefConfig.GetBookkeepers()
 	if err != nil {
 		return nil, fmt.Errorf("GetBookkeepers error: %s", err)
@@ -277,9 +387,34 @@ func initLedger(ctx *cli.Context, stateHashHeight uint32) (*ledger.Ledger, error
 	return ledger.DefLedger, nil
 }
 
+func initForkLedger() (*ledger.Ledger, error) {
+	var err error
+	var genesisBlock *types.Block
+	ledger.DefLedger, genesisBlock, err = ledger.NewForkLedger(forkOpts.source, forkOpts.dir)
+	if err != nil {
+		return nil, fmt.Errorf("NewForkLedger error: %s", err)
+	}
+	bookKeepers, err := config.DefConfig.GetBookkeepers()
+	if err != nil {
+		return nil, fmt.Errorf("GetBookkeepers error: %s", err)
+	}
+	err = ledger.DefLedger.Init(bookKeepers, genesisBlock)
+	if err != nil {
+		return nil, fmt.Errorf("Init ledger error: %s", err)
+	}
+
+	log.Infof("Fork ledger init success, fork dir: %s", forkOpts.dir)
+	return ledger.DefLedger, nil
+}
+
 func initTxPool(ctx *cli.Context) (*proc.TXPoolServer, error) {
 	disablePreExec := ctx.GlobalBool(utils.GetFlagName(utils.TxpoolPreExecDisableFlag))
 	bactor.DisableSyncVerifyTx = ctx.GlobalBool(utils.GetFlagName(utils.DisableSyncVerifyTxFlag))
+	//light node has no states to verify transactions
+	if config.DefConfig.Common.LightMode {
+		disablePreExec = true
+		bactor.DisableSyncVerifyTx = true
+	}
 	disableBroadcastNetTx := ctx.GlobalBool(utils.GetFlagName(utils.DisableBroadcastNetTxFlag))
 	txPoolServer, err := txnpool.StartTxnPoolServer(disablePreExec, disableBroadcastNetTx)
 	if err != nil {
@@ -315,19 +450,22 @@ func initP2PNode(ctx *cli.Context, txpoolSvr *proc.TXPoolServer) (*p2pserver.P2P
 	netreqactor.SetTxnPoolPid(txpoolSvr.GetPID(tc.TxActor))
 	txpoolSvr.Net = p2p.GetNetwork()
 	hserver.SetNetServer(p2p.GetNetwork())
+	if lightClient := p2p.GetLightClient(); lightClient != nil {
+		hserver.SetLightClient(lightClient)
+	}
 	p2p.WaitForPeersStart()
 	log.Infof("P2P init success")
 	return p2p, p2p.GetNetwork(), nil
 }
 
-func initConsensus(ctx *cli.Context, net p2p.P2P, txpoolSvr *proc.TXPoolServer, acc *account.Account) (consensus.ConsensusService, error) {
+func initConsensus(ctx *cli.Context, net p2p.P2P, txpoolSvr *proc.TXPoolServer, bkSigner signer.Signer) (consensus.ConsensusService, error) {
 	if !config.DefConfig.Consensus.EnableConsensus {
 		return nil, nil
 	}
 	pool := txpoolSvr.GetPID(tc.TxPoolActor)
 
 	consensusType := strings.ToLower(config.DefConfig.Genesis.ConsensusType)
-	consensusService, err := consensus.NewConsensusService(consensusType, acc, pool, nil, net)
+	consensusService, err := consensus.NewConsensusService(consensusType, bkSigner, pool, nil, net)
 	if err != nil {
 		return nil, fmt.Errorf("NewConsensusService %s error: %s", consensusType, err)
 	}
@@ -365,7 +503,10 @@ func initRpc(ctx *cli.Context) error {
 }
 
 func initLocalRpc(ctx *cli.Context) error {
-	if !ctx.GlobalBool(utils.GetFlagName(utils.RPCLocalEnableFlag)) {
+	// manual sealing solo node is driven by generateblocks local rpc
+	manualSeal := config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO &&
+		config.DefConfig.Genesis.SOLO.SealMode == config.SOLO_SEAL_MODE_MANUAL
+	if !ctx.GlobalBool(utils.GetFlagName(utils.RPCLocalEnableFlag)) && !manualSeal {
 		return nil
 	}
 	var err error
@@ -19,12 +19,18 @@
 package req
 
 import (
+	"errors"
+	"time"
+
 	"github.com/ontio/ontology-eventbus/actor"
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/types"
 	tc "github.com/ontio/ontology/txnpool/common"
 )
 
+const txnPoolReqTimeout = 5 * time.Second
+
 var txnPoolPid *actor.PID
 
 func SetTxnPoolPid(txnPid *actor.PID) {
@@ -44,3 +50,51 @@ func AddTransaction(transaction *types.Transaction) {
 	}
 	txnPoolPid.Tell(txReq)
 }
+
+//add txn to txnpool, the verify result is sent to the returned channel
+func AddTransactionWithResult(transaction *types.Transaction) chan *tc.TxResult {
+	if txnPoolPid == nil {
+		log.Error("[p2p]net_server AddTransactionWithResult(): txnpool pid is nil")
+		return nil
+	}
+	ch := make(chan *tc.TxResult, 1)
+	txReq := &tc.TxReq{
+		Tx:         transaction,
+		Sender:     tc.NetSender,
+		TxResultCh: ch,
+	}
+	txnPoolPid.Tell(txReq)
+	return ch
+}
+
+//get hashes of txns in txnpool, including the txns being verified
+func GetTransactionHashes() ([]common.Uint256, error) {
+	if txnPoolPid == nil {
+		return nil, errors.New("txnpool pid is nil")
+	}
+	result, err := txnPoolPid.RequestFuture(&tc.GetPendingTxnHashReq{}, txnPoolReqTimeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	rsp, ok := result.(*tc.GetPendingTxnHashRsp)
+	if !ok {
+		return nil, errors.New("unexpected response of txnpool")
+	}
+	return rsp.TxHashs, nil
+}
+
+//get txns in txnpool by hashes, the txn is nil if not in txnpool
+func GetTransactions(hashes []common.Uint256) ([]*types.Transaction, error) {
+	if txnPoolPid == nil {
+		return nil, errors.New("txnpool pid is nil")
+	}
+	result, err := txnPoolPid.RequestFuture(&tc.GetTxnsReq{Hashes: hashes}, txnPoolReqTimeout).Result()
+	if err != nil {
+		return nil, err
+	}
+	rsp, ok := result.(*tc.GetTxnsRsp)
+	if !ok || len(rsp.Txs) != len(hashes) {
+		return nil, errors.New("unexpected response of txnpool")
+	}
+	return rsp.Txs, nil
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/ontio/ontology/account"
 	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/signature"
 	"github.com/ontio/ontology/core/types"
 )
 
@@ -71,6 +72,8 @@ type PeerKeyId struct {
 	PublicKey keypair.PublicKey
 
 	Id PeerId
+
+	signer *account.Account //only available for local peer
 }
 
 func (self PeerId) GenRandPeerId(prefix uint) PeerId {
@@ -179,7 +182,21 @@ func RandPeerKeyId() *PeerKeyId {
 	return &PeerKeyId{
 		PublicKey: acc.PublicKey,
 		Id:        kid,
+		signer:    acc,
+	}
+}
+
+//Sign signs data with the private key of local peer
+func (this *PeerKeyId) Sign(data []byte) ([]byte, error) {
+	if this.signer == nil {
+		return nil, errors.New("private key of peer key id is not available")
 	}
+	return signature.Sign(this.signer, data)
+}
+
+//Verify verifies the signature of data signed by this peer
+func (this *PeerKeyId) Verify(data, sig []byte) error {
+	return signature.Verify(this.PublicKey, data, sig)
 }
 
 func validatePublicKey(pubKey keypair.PublicKey) bool {
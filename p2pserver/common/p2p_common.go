@@ -28,6 +28,7 @@ import (
 const (
 	VERIFY_NODE  = 1 //peer involved in consensus
 	SERVICE_NODE = 2 //peer only sync with consensus peer
+	LIGHT_NODE   = 3 //peer only sync block headers
 )
 
 const MIN_VERSION_FOR_DHT = "1.9.1-beta"
@@ -77,7 +78,11 @@ const (
 )
 
 //cap flag
-const HTTP_INFO_FLAG = 0 //peer`s http info bit in cap field
+const HTTP_INFO_FLAG = 0     //peer`s http info bit in cap field
+const ENCRYPT_FLAG = 1       //peer`s encrypted transport support bit in cap field
+const COMPRESS_FLAG = 2      //peer`s message compression support bit in cap field
+const COMPACT_BLOCK_FLAG = 3 //peer`s compact block relay support bit in cap field
+const TX_INV_FLAG = 4        //peer`s transaction announcement support bit in cap field
 
 //recent contact const
 const (
@@ -85,6 +90,12 @@ const (
 	RECENT_FILE_NAME = "peers.recent"
 )
 
+//banned peers const
+const (
+	BANNED_FILE_NAME     = "peers.banned"
+	DEFAULT_BAN_DURATION = 24 * 60 * 60 //default ban duration in second
+)
+
 //PeerAddr represent peer`s net information
 type PeerAddr struct {
 	Time     int64    //latest timestamp
@@ -116,6 +127,23 @@ const (
 	FINDNODE_TYPE      = "findnode"    // find node using dht
 	FINDNODE_RESP_TYPE = "findnodeack" // find node using dht
 	UPDATE_KADID_TYPE  = "updatekadid" //update node kadid
+	GET_STORAGE_TYPE   = "getstorage"  //req contract storage for light node
+	STORAGE_TYPE       = "storage"     //contract storage
+	GET_TX_PROOF_TYPE  = "gettxproof"  //req transaction with proof for light node
+	TX_PROOF_TYPE      = "txproof"     //transaction with proof
+	ENC_HELLO_TYPE     = "enchello"    //ephemeral key for encrypted transport
+	ENC_AUTH_TYPE      = "encauth"     //signature of encrypted transport handshake
+	COMPRESSED_TYPE    = "compressed"  //compressed message
+	COMPACT_BLOCK_TYPE = "cmpctblock"  //block with short transaction ids
+	GET_BLOCK_TXN_TYPE = "getblocktxn" //req missing transactions of compact block
+	BLOCK_TXN_TYPE     = "blocktxn"    //missing transactions of compact block
+	SUBPROTOCOL_TYPE   = "subproto"    //message of registered sub protocol
+)
+
+//sub protocol const
+const (
+	MAX_PROTOCOL_NAME_LEN = 32 //the maximum length of sub protocol name
+	MAX_PROTOCOL_CNT      = 64 //the maximum sub protocols advertised in version
 )
 
 //ParseIPAddr return ip address
@@ -18,6 +18,7 @@
 package connect_controller
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"sort"
@@ -28,6 +29,7 @@ import (
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/p2pserver/common"
 	"github.com/ontio/ontology/p2pserver/handshake"
+	"github.com/ontio/ontology/p2pserver/nat"
 	"github.com/ontio/ontology/p2pserver/peer"
 	"github.com/scylladb/go-set/strset"
 )
@@ -55,6 +57,7 @@ type ConnectController struct {
 
 	ownAddr       string
 	nextConnectId uint64
+	reservedOnly  bool // reserved list can be changed at runtime only if it is enabled at start
 }
 
 func NewConnectController(peerInfo *peer.PeerInfo, keyid *common.PeerKeyId,
@@ -68,14 +71,28 @@ func NewConnectController(peerInfo *peer.PeerInfo, keyid *common.PeerKeyId,
 		connecting:           strset.New(),
 		peers:                make(map[common.PeerId]*connectedPeer),
 	}
+	if control.externalAddr == nil {
+		control.externalAddr, _ = nat.NewExternalAddr("", peerInfo.Port)
+	}
+	control.reservedOnly = len(control.ReservedPeers) > 0
 	// put domain to the end
-	sort.Slice(control.ReservedPeers, func(i, j int) bool {
-		return net.ParseIP(control.ReservedPeers[i]) != nil
-	})
+	sortReservedPeers(control.ReservedPeers)
 
 	return control
 }
 
+//ExternalAddr return the public address advertised to peers
+func (self *ConnectController) ExternalAddr() *nat.ExternalAddr {
+	return self.externalAddr
+}
+
+//localInfo return the host info sent in handshake, which advertises the public address
+func (self *ConnectController) localInfo() *peer.PeerInfo {
+	info := *self.peerInfo
+	info.ExternalIP, info.Port = self.externalAddr.Resolve()
+	return &info
+}
+
 func (self *ConnectController) OwnAddress() string {
 	return self.ownAddr
 }
@@ -143,8 +160,66 @@ func (self *ConnectController) removeConnecting(addr string) {
 	self.connecting.Remove(addr)
 }
 
+func sortReservedPeers(peers []string) {
+	sort.SliceStable(peers, func(i, j int) bool {
+		return net.ParseIP(peers[i]) != nil && net.ParseIP(peers[j]) == nil
+	})
+}
+
 func (self *ConnectController) reserveEnabled() bool {
-	return len(self.ReservedPeers) > 0
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return self.reservedOnly || len(self.ReservedPeers) > 0
+}
+
+//GetReservedPeers return the ips and domains allowed to connect in reserved peers only mode
+func (self *ConnectController) GetReservedPeers() []string {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	return append([]string{}, self.ReservedPeers...)
+}
+
+//AddReservedPeer adds the ip or domain to reserved list
+func (self *ConnectController) AddReservedPeer(ipOrName string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if !self.reservedOnly {
+		return errors.New("reserved peers only mode is not enabled")
+	}
+	for _, p := range self.ReservedPeers {
+		if p == ipOrName {
+			return nil
+		}
+	}
+	peers := append(append([]string{}, self.ReservedPeers...), ipOrName)
+	sortReservedPeers(peers)
+	self.ReservedPeers = peers
+	return nil
+}
+
+//RemoveReservedPeer removes the ip or domain from reserved list, connected peers are not affected
+func (self *ConnectController) RemoveReservedPeer(ipOrName string) error {
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+	if !self.reservedOnly {
+		return errors.New("reserved peers only mode is not enabled")
+	}
+	peers := make([]string, 0, len(self.ReservedPeers))
+	for _, p := range self.ReservedPeers {
+		if p != ipOrName {
+			peers = append(peers, p)
+		}
+	}
+	if len(peers) == len(self.ReservedPeers) {
+		return fmt.Errorf("%s is not in reserved list", ipOrName)
+	}
+	self.ReservedPeers = peers
+	return nil
+}
+
+//IsAllowed reports whether connection with the address is allowed by reserved list
+func (self *ConnectController) IsAllowed(remoteAddr string) bool {
+	return self.checkReservedPeers(remoteAddr) == nil
 }
 
 // remoteAddr format 192.168.1.1:61234
@@ -156,7 +231,7 @@ func (self *ConnectController) inReserveList(remoteIPPort string) bool {
 		return false
 	}
 	// we don't load domain in start because we consider domain's A/AAAA record may change sometimes
-	for _, curIPOrName := range self.ReservedPeers {
+	for _, curIPOrName := range self.GetReservedPeers() {
 		curIPs, err := net.LookupHost(curIPOrName)
 		if err != nil {
 			continue
@@ -201,7 +276,7 @@ func (self *ConnectController) AcceptConnect(conn net.Conn) (*peer.PeerInfo, net
 		return nil, nil, err
 	}
 
-	peerInfo, err := handshake.HandshakeServer(self.peerInfo, self.selfId, conn)
+	peerInfo, transport, err := handshake.HandshakeServer(self.localInfo(), self.selfId, conn, self.EnableEncryption)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -211,7 +286,7 @@ func (self *ConnectController) AcceptConnect(conn net.Conn) (*peer.PeerInfo, net
 		return nil, nil, err
 	}
 
-	wrapped := self.savePeer(conn, peerInfo, INBOUND_INDEX)
+	wrapped := self.savePeer(transport, peerInfo, INBOUND_INDEX)
 
 	log.Infof("inbound peer %s connected, %s", conn.RemoteAddr().String(), peerInfo)
 	return peerInfo, wrapped, nil
@@ -235,7 +310,7 @@ func (self *ConnectController) Connect(addr string) (*peer.PeerInfo, net.Conn, e
 		return nil, nil, err
 	}
 
-	peerInfo, err := handshake.HandshakeClient(self.peerInfo, self.selfId, conn)
+	peerInfo, transport, err := handshake.HandshakeClient(self.localInfo(), self.selfId, conn, self.EnableEncryption)
 	if err != nil {
 		_ = conn.Close()
 		return nil, nil, err
@@ -247,7 +322,7 @@ func (self *ConnectController) Connect(addr string) (*peer.PeerInfo, net.Conn, e
 		return nil, nil, err
 	}
 
-	wrapped := self.savePeer(conn, peerInfo, OUTBOUND_INDEX)
+	wrapped := self.savePeer(transport, peerInfo, OUTBOUND_INDEX)
 
 	log.Infof("outbound peer %s connected. %s", conn.RemoteAddr().String(), peerInfo)
 	return peerInfo, wrapped, nil
@@ -267,6 +342,10 @@ func (self *ConnectController) beforeHandshakeCheck(addr string, index int) erro
 		return err
 	}
 
+	if self.banChecker != nil && self.banChecker.IsBanned(addr) {
+		return fmt.Errorf("peer %s is banned", addr)
+	}
+
 	if self.hasBoundAddr(addr) {
 		return fmt.Errorf("peer %s already in connection records", addr)
 	}
@@ -326,6 +405,8 @@ func (self *ConnectController) savePeer(conn net.Conn, p *peer.PeerInfo, index i
 		self.inboundListenAddress.Add(listen)
 	}
 
+	self.externalAddr.Vote(p.Id, p.ObservedIP)
+
 	cid := self.getConnectId()
 	self.peers[p.Id] = &connectedPeer{
 		connectId: cid,
@@ -358,6 +439,7 @@ func (self *ConnectController) removePeer(conn *Conn) {
 		log.Fatalf("connection %s not in controller", conn.kid.ToHexString())
 	} else if p.connectId == conn.connectId { // connection not replaced
 		delete(self.peers, conn.kid)
+		self.externalAddr.RemoveVote(conn.kid)
 	}
 }
 
@@ -108,7 +108,7 @@ func TestConnectController_CanDetectSelfAddress(t *testing.T) {
 
 		c, s := trans.Pipe()
 		go func() {
-			_, _ = handshake.HandshakeClient(server.peerInfo, server.Key, c)
+			_, _, _ = handshake.HandshakeClient(server.peerInfo, server.Key, c, server.EnableEncryption)
 		}()
 
 		_, _, err := server.AcceptConnect(s)
@@ -132,7 +132,7 @@ func TestConnectController_AcceptConnect_MaxInBound(t *testing.T) {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			_, err := handshake.HandshakeClient(client.peerInfo, client.Key, conn1)
+			_, _, err := handshake.HandshakeClient(client.peerInfo, client.Key, conn1, client.EnableEncryption)
 			if i < int(maxInboud) {
 				assert.Nil(t, err)
 			} else {
@@ -194,7 +194,7 @@ func TestConnCtrlOption_MaxInBoundPerIp(t *testing.T) {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			_, err := handshake.HandshakeClient(client.peerInfo, client.Key, conn1)
+			_, _, err := handshake.HandshakeClient(client.peerInfo, client.Key, conn1, client.EnableEncryption)
 			if i < int(maxInBoundPerIp) {
 				assert.Nil(t, err)
 			} else {
@@ -214,6 +214,26 @@ func TestConnCtrlOption_MaxInBoundPerIp(t *testing.T) {
 	assert.Equal(t, server.inoutbounds[INBOUND_INDEX].Size(), 0)
 }
 
+type banAll struct{}
+
+func (banAll) IsBanned(addr string) bool { return true }
+
+func TestConnectController_RejectBanned(t *testing.T) {
+	trans := NewTransport(t)
+	server := NewNode(NewConnCtrlOption().WithBanChecker(banAll{}))
+	client := NewNode(NewConnCtrlOption().WithBanChecker(banAll{}))
+
+	_, s := trans.Pipe()
+	_, _, err := server.AcceptConnect(s)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "banned")
+	assert.Equal(t, server.boundsCount(INBOUND_INDEX), uint(0))
+
+	_, _, err = client.Connect(trans.listenAddr)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "banned")
+}
+
 func checkServer(t *testing.T, client, server *Node, clientConns chan<- net.Conn, i int, conn2 net.Conn, maxLimit int, isCheck bool) {
 	info, conn, err := server.AcceptConnect(conn2)
 	if i >= maxLimit && isCheck == false {
@@ -223,7 +243,8 @@ func checkServer(t *testing.T, client, server *Node, clientConns chan<- net.Conn
 		return
 	}
 	assert.Nil(t, err)
-	info.Addr = "" // client.Info is not set
+	info.Addr = ""        // client.Info is not set
+	info.ObservedIP = nil // the address of server observed by client
 	assert.Equal(t, info, client.Info)
 
 	clientConns <- conn
@@ -17,14 +17,25 @@
  */
 package connect_controller
 
-import "github.com/ontio/ontology/common/config"
+import (
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/p2pserver/nat"
+)
 
 type ConnCtrlOption struct {
 	MaxConnOutBound     uint
 	MaxConnInBound      uint
 	MaxConnInBoundPerIP uint
 	ReservedPeers       []string // enabled if not empty
+	EnableEncryption    bool     // encrypt transport with peers which support it
 	dialer              Dialer
+	banChecker          BanChecker
+	externalAddr        *nat.ExternalAddr // resolved from ip observed by peers if nil
+}
+
+//BanChecker reports whether connections with the address are refused
+type BanChecker interface {
+	IsBanned(addr string) bool
 }
 
 func NewConnCtrlOption() ConnCtrlOption {
@@ -32,6 +43,7 @@ func NewConnCtrlOption() ConnCtrlOption {
 		MaxConnInBound:      config.DEFAULT_MAX_CONN_IN_BOUND,
 		MaxConnOutBound:     config.DEFAULT_MAX_CONN_OUT_BOUND,
 		MaxConnInBoundPerIP: config.DEFAULT_MAX_CONN_IN_BOUND_FOR_SINGLE_IP,
+		EnableEncryption:    true,
 		dialer:              &noTlsDialer{},
 	}
 }
@@ -56,11 +68,26 @@ func (self ConnCtrlOption) ReservedOnly(peers []string) ConnCtrlOption {
 	return self
 }
 
+func (self ConnCtrlOption) Encryption(enable bool) ConnCtrlOption {
+	self.EnableEncryption = enable
+	return self
+}
+
 func (self ConnCtrlOption) WithDialer(dialer Dialer) ConnCtrlOption {
 	self.dialer = dialer
 	return self
 }
 
+func (self ConnCtrlOption) WithBanChecker(checker BanChecker) ConnCtrlOption {
+	self.banChecker = checker
+	return self
+}
+
+func (self ConnCtrlOption) WithExternalAddr(addr *nat.ExternalAddr) ConnCtrlOption {
+	self.externalAddr = addr
+	return self
+}
+
 func ConnCtrlOptionFromConfig(config *config.P2PNodeConfig) (option ConnCtrlOption, err error) {
 	var rsv []string
 	if config.ReservedPeersOnly && config.ReservedCfg != nil {
@@ -71,12 +98,19 @@ func ConnCtrlOptionFromConfig(config *config.P2PNodeConfig) (option ConnCtrlOpti
 		err = e
 		return
 	}
+	externalAddr, e := nat.NewExternalAddr(config.ExternalAddr, config.NodePort)
+	if e != nil {
+		err = e
+		return
+	}
 	return ConnCtrlOption{
 		MaxConnOutBound:     config.MaxConnOutBound,
 		MaxConnInBound:      config.MaxConnInBound,
 		MaxConnInBoundPerIP: config.MaxConnInBoundForSingleIP,
 		ReservedPeers:       rsv,
+		EnableEncryption:    !config.DisableEncryption,
 
-		dialer: dialer,
+		dialer:       dialer,
+		externalAddr: externalAddr,
 	}, nil
 }
@@ -0,0 +1,280 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/handshake"
+	"github.com/ontio/ontology/p2pserver/message/msg_pack"
+	"github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/peer"
+)
+
+const (
+	DEFAULT_TIMEOUT     = 5 * time.Second
+	DEFAULT_CONCURRENCY = 16
+	FIND_NODE_REQUESTS  = 8 // find random ids in different buckets to enumerate the routing table of node
+)
+
+//Node is the information of a reachable node
+type Node struct {
+	Id          string
+	Addr        string
+	Version     uint32
+	SoftVersion string
+	Services    uint64
+	Relay       bool
+	Height      uint64
+	Latency     int64    //milliseconds of ping round trip
+	Neighbors   []string //addresses in the routing table of node
+}
+
+//Result of crawling, sorted by address
+type Result struct {
+	Nodes       []*Node
+	Unreachable []string
+}
+
+//Crawler walks the network through the addresses and dht peers reported by nodes
+type Crawler struct {
+	Timeout     time.Duration // timeout of dialing and querying each node
+	Concurrency int
+	MaxNodes    int // stop crawling new addresses once reached, zero means unlimited
+	Encrypt     bool
+	Dial        func(addr string, timeout time.Duration) (net.Conn, error)
+
+	keyId *common.PeerKeyId
+	info  *peer.PeerInfo
+}
+
+func NewCrawler() *Crawler {
+	keyId := common.RandPeerKeyId()
+	softVersion := config.Version
+	if _, err := semver.ParseTolerant(softVersion); err != nil {
+		// dht is required to query the routing table
+		softVersion = common.MIN_VERSION_FOR_DHT
+	}
+	// crawler is a light node which does not listen, so remote nodes will not sync blocks from it
+	info := peer.NewPeerInfo(keyId.Id, common.PROTOCOL_VERSION, common.LIGHT_NODE, false, 0,
+		0, 0, softVersion, "")
+	return &Crawler{
+		Timeout:     DEFAULT_TIMEOUT,
+		Concurrency: DEFAULT_CONCURRENCY,
+		Encrypt:     true,
+		Dial: func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("tcp", addr, timeout)
+		},
+		keyId: keyId,
+		info:  info,
+	}
+}
+
+//Crawl visits all nodes reachable from seeds
+func (self *Crawler) Crawl(seeds []string) *Result {
+	result := &Result{}
+	concurrency := self.Concurrency
+	if concurrency <= 0 {
+		concurrency = DEFAULT_CONCURRENCY
+	}
+	sem := make(chan struct{}, concurrency)
+	visited := make(map[string]bool)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	var visit func(addr string)
+	visit = func(addr string) {
+		lock.Lock()
+		if visited[addr] || (self.MaxNodes > 0 && len(visited) >= self.MaxNodes) {
+			lock.Unlock()
+			return
+		}
+		visited[addr] = true
+		lock.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			node, err := self.Probe(addr)
+			<-sem
+
+			lock.Lock()
+			if err != nil {
+				log.Debugf("[crawler]probe %s failed: %s", addr, err)
+				result.Unreachable = append(result.Unreachable, addr)
+			} else {
+				result.Nodes = append(result.Nodes, node)
+			}
+			lock.Unlock()
+
+			if node != nil {
+				for _, nbr := range node.Neighbors {
+					visit(nbr)
+				}
+			}
+		}()
+	}
+
+	for _, seed := range seeds {
+		addrs, err := resolve(seed)
+		if err != nil {
+			log.Warnf("[crawler]resolve seed %s failed: %s", seed, err)
+			lock.Lock()
+			result.Unreachable = append(result.Unreachable, seed)
+			lock.Unlock()
+			continue
+		}
+		for _, addr := range addrs {
+			visit(addr)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(result.Nodes, func(i, j int) bool {
+		return result.Nodes[i].Addr < result.Nodes[j].Addr
+	})
+	sort.Strings(result.Unreachable)
+	return result
+}
+
+//resolve the domain of seed to ip addresses, so that nodes are identified by ip
+func resolve(seed string) ([]string, error) {
+	host, port, err := net.SplitHostPort(seed)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return []string{seed}, nil
+	}
+	ips, err := net.LookupHost(host)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip, port))
+	}
+	return addrs, nil
+}
+
+//Probe handshakes with the node at addr and queries its information and neighbors
+func (self *Crawler) Probe(addr string) (*Node, error) {
+	conn, err := self.Dial(addr, self.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	info, transport, err := handshake.HandshakeClient(self.info, self.keyId, conn, self.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{
+		Id:          info.Id.ToHexString(),
+		Addr:        addr,
+		Version:     info.Version,
+		SoftVersion: info.SoftVersion,
+		Services:    info.Services,
+		Relay:       info.Relay,
+		Height:      info.Height,
+	}
+	if err := conn.SetDeadline(time.Now().Add(self.Timeout)); err != nil {
+		return nil, err
+	}
+
+	// requests are answered in any order, count the responses to stop early
+	reqs := []types.Message{msgpack.NewAddrReq()}
+	if !info.Id.IsPseudoPeerId() {
+		for i := 0; i < FIND_NODE_REQUESTS; i++ {
+			reqs = append(reqs, msgpack.NewFindNodeReq(info.Id.GenRandPeerId(uint(i))))
+		}
+	}
+	reqs = append(reqs, msgpack.NewPingMsg(0))
+	for _, req := range reqs {
+		if err := sendMsg(transport, req); err != nil {
+			return nil, err
+		}
+	}
+	pingTime := time.Now()
+
+	neighbors := make(map[string]bool)
+	addNeighbor := func(nbr string) {
+		_, port, err := net.SplitHostPort(nbr)
+		if err != nil || port == "0" || nbr == addr {
+			return
+		}
+		neighbors[nbr] = true
+	}
+	for pending := len(reqs); pending > 0; {
+		msg, _, err := types.ReadMessage(transport)
+		if err != nil {
+			// timeout, the node may not support some requests
+			log.Debugf("[crawler]read from %s: %s", addr, err)
+			break
+		}
+		switch m := msg.(type) {
+		case *types.Addr:
+			for _, na := range m.NodeAddrs {
+				ip := net.IP(na.IpAddr[:])
+				addNeighbor(net.JoinHostPort(ip.String(), strconv.Itoa(int(na.Port))))
+			}
+			pending--
+		case *types.FindNodeResp:
+			for _, pair := range m.CloserPeers {
+				addNeighbor(pair.Address)
+			}
+			pending--
+		case *types.Pong:
+			node.Latency = int64(time.Since(pingTime) / time.Millisecond)
+			if m.Height > node.Height {
+				node.Height = m.Height
+			}
+			pending--
+		case *types.Ping:
+			_ = sendMsg(transport, msgpack.NewPongMsg(0))
+		}
+	}
+
+	for nbr := range neighbors {
+		node.Neighbors = append(node.Neighbors, nbr)
+	}
+	sort.Strings(node.Neighbors)
+	return node, nil
+}
+
+func sendMsg(conn net.Conn, msg types.Message) error {
+	sink := comm.NewZeroCopySink(nil)
+	types.WriteMessage(sink, msg)
+	_, err := conn.Write(sink.Bytes())
+	if err != nil {
+		return fmt.Errorf("send %s message to %s: %s", msg.CmdType(), conn.RemoteAddr(), err)
+	}
+	return nil
+}
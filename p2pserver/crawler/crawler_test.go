@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/handshake"
+	"github.com/ontio/ontology/p2pserver/message/msg_pack"
+	"github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	// lower the difficulty
+	common.Difficulty = 1
+}
+
+type fakeNode struct {
+	keyId    *common.PeerKeyId
+	info     *peer.PeerInfo
+	listener net.Listener
+	addrs    []string // answer of addr request
+	closer   []string // answer of find node request
+}
+
+func newFakeNode(t *testing.T, height uint64) *fakeNode {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	keyId := common.RandPeerKeyId()
+	port := uint16(listener.Addr().(*net.TCPAddr).Port)
+	info := peer.NewPeerInfo(keyId.Id, common.PROTOCOL_VERSION, common.SERVICE_NODE, true, 0,
+		port, height, common.MIN_VERSION_FOR_DHT, "")
+	node := &fakeNode{keyId: keyId, info: info, listener: listener}
+	go node.serve()
+	return node
+}
+
+func (self *fakeNode) Addr() string {
+	return self.listener.Addr().String()
+}
+
+func (self *fakeNode) serve() {
+	for {
+		conn, err := self.listener.Accept()
+		if err != nil {
+			return
+		}
+		go self.handle(conn)
+	}
+}
+
+func (self *fakeNode) handle(conn net.Conn) {
+	defer conn.Close()
+	_, transport, err := handshake.HandshakeServer(self.info, self.keyId, conn, true)
+	if err != nil {
+		return
+	}
+	for {
+		msg, _, err := types.ReadMessage(transport)
+		if err != nil {
+			return
+		}
+		var resp types.Message
+		switch m := msg.(type) {
+		case *types.AddrReq:
+			var addrs []common.PeerAddr
+			for _, addr := range self.addrs {
+				host, port, _ := net.SplitHostPort(addr)
+				p, _ := strconv.Atoi(port)
+				pa := common.PeerAddr{Port: uint16(p)}
+				copy(pa.IpAddr[:], net.ParseIP(host).To16())
+				addrs = append(addrs, pa)
+			}
+			resp = msgpack.NewAddrs(addrs)
+		case *types.FindNodeReq:
+			fresp := &types.FindNodeResp{TargetID: m.TargetID}
+			for _, addr := range self.closer {
+				fresp.CloserPeers = append(fresp.CloserPeers, common.PeerIDAddressPair{Address: addr})
+			}
+			resp = fresp
+		case *types.Ping:
+			resp = msgpack.NewPongMsg(self.info.Height + 1)
+		}
+		if resp != nil {
+			if err := sendMsg(transport, resp); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestCrawl(t *testing.T) {
+	a := newFakeNode(t, 10)
+	b := newFakeNode(t, 20)
+	c := newFakeNode(t, 30)
+	dead := newFakeNode(t, 0)
+	dead.listener.Close()
+	defer a.listener.Close()
+	defer b.listener.Close()
+	defer c.listener.Close()
+
+	a.addrs = []string{b.Addr()}
+	b.addrs = []string{a.Addr()}
+	b.closer = []string{c.Addr(), a.Addr()}
+	c.addrs = []string{dead.Addr(), "127.0.0.1:0"}
+
+	crawler := NewCrawler()
+	result := crawler.Crawl([]string{a.Addr()})
+	assert.Equal(t, 3, len(result.Nodes))
+	assert.Equal(t, []string{dead.Addr()}, result.Unreachable)
+
+	nodes := make(map[string]*Node)
+	for _, node := range result.Nodes {
+		nodes[node.Addr] = node
+	}
+	nodeB := nodes[b.Addr()]
+	assert.NotNil(t, nodeB)
+	assert.Equal(t, b.keyId.Id.ToHexString(), nodeB.Id)
+	assert.Equal(t, uint64(21), nodeB.Height)
+	assert.Equal(t, uint64(common.SERVICE_NODE), nodeB.Services)
+	assert.Equal(t, common.MIN_VERSION_FOR_DHT, nodeB.SoftVersion)
+	assert.Equal(t, 2, len(nodeB.Neighbors))
+	assert.Equal(t, []string{dead.Addr()}, nodes[c.Addr()].Neighbors)
+
+	crawler.MaxNodes = 2
+	result = crawler.Crawl([]string{a.Addr()})
+	assert.Equal(t, 2, len(result.Nodes)+len(result.Unreachable))
+}
+
+func TestOutput(t *testing.T) {
+	result := &Result{
+		Nodes: []*Node{
+			{Id: "01", Addr: "1.1.1.1:20338", SoftVersion: "v1.10", Height: 10, Neighbors: []string{"2.2.2.2:20338", "3.3.3.3:20338"}},
+			{Id: "02", Addr: "2.2.2.2:20338", SoftVersion: "v1.10", Height: 11, Latency: 5},
+		},
+		Unreachable: []string{"3.3.3.3:20338"},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteJSON(buf, result))
+	decoded := &Result{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), decoded))
+	assert.Equal(t, result, decoded)
+
+	buf.Reset()
+	assert.Nil(t, WriteCSV(buf, result))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Equal(t, "01,1.1.1.1:20338,0,v1.10,0,false,10,0,2.2.2.2:20338 3.3.3.3:20338", lines[1])
+
+	buf.Reset()
+	assert.Nil(t, WriteDOT(buf, result))
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph ontology {\n"))
+	assert.Contains(t, dot, "\"1.1.1.1:20338\" -> \"3.3.3.3:20338\";")
+	assert.Contains(t, dot, "\"3.3.3.3:20338\" [style=dashed];")
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crawler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//WriteJSON writes the result in indented json
+func WriteJSON(w io.Writer, result *Result) error {
+	data, err := json.MarshalIndent(result, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+//WriteCSV writes a line for each reachable node, neighbors are separated by space
+func WriteCSV(w io.Writer, result *Result) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"id", "address", "version", "soft_version", "services", "relay", "height",
+		"latency_ms", "neighbors"})
+	if err != nil {
+		return err
+	}
+	for _, node := range result.Nodes {
+		err = writer.Write([]string{
+			node.Id,
+			node.Addr,
+			strconv.FormatUint(uint64(node.Version), 10),
+			node.SoftVersion,
+			strconv.FormatUint(node.Services, 10),
+			strconv.FormatBool(node.Relay),
+			strconv.FormatUint(node.Height, 10),
+			strconv.FormatInt(node.Latency, 10),
+			strings.Join(node.Neighbors, " "),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+//WriteDOT writes the topology in graphviz dot format, an edge means the node has the neighbor in its routing table
+func WriteDOT(w io.Writer, result *Result) error {
+	var b strings.Builder
+	b.WriteString("digraph ontology {\n")
+	for _, node := range result.Nodes {
+		fmt.Fprintf(&b, "\t%q [label=\"%s\\n%s\\nheight %d\"];\n", node.Addr, node.Addr, node.SoftVersion, node.Height)
+	}
+	for _, addr := range result.Unreachable {
+		fmt.Fprintf(&b, "\t%q [style=dashed];\n", addr)
+	}
+	for _, node := range result.Nodes {
+		for _, nbr := range node.Neighbors {
+			fmt.Fprintf(&b, "\t%q -> %q;\n", node.Addr, nbr)
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
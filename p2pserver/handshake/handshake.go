@@ -20,21 +20,23 @@ package handshake
 import (
 	"fmt"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/blang/semver"
 	common2 "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/p2pserver/common"
 	"github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/nat"
 	"github.com/ontio/ontology/p2pserver/peer"
 )
 
 var HANDSHAKE_DURATION = 10 * time.Second // handshake time can not exceed this duration, or will treat as attack.
 
-func HandshakeClient(info *peer.PeerInfo, selfId *common.PeerKeyId, conn net.Conn) (*peer.PeerInfo, error) {
-	version := newVersion(info)
+func HandshakeClient(info *peer.PeerInfo, selfId *common.PeerKeyId, conn net.Conn, encrypt bool) (*peer.PeerInfo, net.Conn, error) {
+	version := newVersion(info, encrypt, conn.RemoteAddr())
 	if err := conn.SetDeadline(time.Now().Add(HANDSHAKE_DURATION)); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		_ = conn.SetDeadline(time.Time{}) //reset back
@@ -43,62 +45,73 @@ func HandshakeClient(info *peer.PeerInfo, selfId *common.PeerKeyId, conn net.Con
 	// 1. sendMsg version
 	err := sendMsg(conn, version)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 2. read version
 	msg, _, err := types.ReadMessage(conn)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	receivedVersion, ok := msg.(*types.Version)
 	if !ok {
-		return nil, fmt.Errorf("expected version message, but got message type: %s", msg.CmdType())
+		return nil, nil, fmt.Errorf("expected version message, but got message type: %s", msg.CmdType())
 	}
 
 	// 3. update kadId
 	kid := common.PseudoPeerIdFromUint64(receivedVersion.P.Nonce)
+	var remoteId *common.PeerKeyId
 	if useDHT(receivedVersion.P.SoftVersion, info.SoftVersion) {
 		err = sendMsg(conn, &types.UpdatePeerKeyId{KadKeyId: selfId})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		// 4. read kadkeyid
 		msg, _, err = types.ReadMessage(conn)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		kadKeyId, ok := msg.(*types.UpdatePeerKeyId)
 		if !ok {
-			return nil, fmt.Errorf("handshake failed, expect kad id message, got %s", msg.CmdType())
+			return nil, nil, fmt.Errorf("handshake failed, expect kad id message, got %s", msg.CmdType())
 		}
 
-		kid = kadKeyId.KadKeyId.Id
+		remoteId = kadKeyId.KadKeyId
+		kid = remoteId.Id
 	}
 
-	// 5. sendMsg ack
-	err = sendMsg(conn, &types.VerACK{})
+	// 5. switch to encrypted transport bound to kad id
+	transport := conn
+	if remoteId != nil && useEncryption(encrypt, receivedVersion) {
+		transport, err = secureClient(selfId, remoteId, conn)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// 6. sendMsg ack
+	err = sendMsg(transport, &types.VerACK{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	msg, _, err = types.ReadMessage(conn)
+	msg, _, err = types.ReadMessage(transport)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// 6. receive verack
+	// 7. receive verack
 	if _, ok := msg.(*types.VerACK); !ok {
-		return nil, fmt.Errorf("handshake failed, expect verack message, got %s", msg.CmdType())
+		return nil, nil, fmt.Errorf("handshake failed, expect verack message, got %s", msg.CmdType())
 	}
 
-	return createPeerInfo(receivedVersion, kid, conn.RemoteAddr().String()), nil
+	return createPeerInfo(info, receivedVersion, kid, conn.RemoteAddr().String()), transport, nil
 }
 
-func HandshakeServer(info *peer.PeerInfo, selfId *common.PeerKeyId, conn net.Conn) (*peer.PeerInfo, error) {
-	ver := newVersion(info)
+func HandshakeServer(info *peer.PeerInfo, selfId *common.PeerKeyId, conn net.Conn, encrypt bool) (*peer.PeerInfo, net.Conn, error) {
+	ver := newVersion(info, encrypt, conn.RemoteAddr())
 	if err := conn.SetDeadline(time.Now().Add(HANDSHAKE_DURATION)); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		_ = conn.SetDeadline(time.Time{}) //reset back
@@ -107,54 +120,65 @@ func HandshakeServer(info *peer.PeerInfo, selfId *common.PeerKeyId, conn net.Con
 	// 1. read version
 	msg, _, err := types.ReadMessage(conn)
 	if err != nil {
-		return nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
+		return nil, nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
 	}
 	if msg.CmdType() != common.VERSION_TYPE {
-		return nil, fmt.Errorf("[HandshakeServer] expected version message")
+		return nil, nil, fmt.Errorf("[HandshakeServer] expected version message")
 	}
 	version := msg.(*types.Version)
 
 	// 2. sendMsg version
 	err = sendMsg(conn, ver)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 3. read update kadkey id
 	kid := common.PseudoPeerIdFromUint64(version.P.Nonce)
+	var remoteId *common.PeerKeyId
 	if useDHT(version.P.SoftVersion, info.SoftVersion) {
 		msg, _, err := types.ReadMessage(conn)
 		if err != nil {
-			return nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
+			return nil, nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
 		}
 		kadkeyId, ok := msg.(*types.UpdatePeerKeyId)
 		if !ok {
-			return nil, fmt.Errorf("[HandshakeServer] expected update kadkeyid message")
+			return nil, nil, fmt.Errorf("[HandshakeServer] expected update kadkeyid message")
 		}
-		kid = kadkeyId.KadKeyId.Id
+		remoteId = kadkeyId.KadKeyId
+		kid = remoteId.Id
 		// 4. sendMsg update kadkey id
 		err = sendMsg(conn, &types.UpdatePeerKeyId{KadKeyId: selfId})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+	}
+
+	// 5. switch to encrypted transport bound to kad id
+	transport := conn
+	if remoteId != nil && useEncryption(encrypt, version) {
+		transport, err = secureServer(selfId, remoteId, conn)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
-	// 5. read version ack
-	msg, _, err = types.ReadMessage(conn)
+	// 6. read version ack
+	msg, _, err = types.ReadMessage(transport)
 	if err != nil {
-		return nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
+		return nil, nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
 	}
 	if msg.CmdType() != common.VERACK_TYPE {
-		return nil, fmt.Errorf("[HandshakeServer] expected version ack message")
+		return nil, nil, fmt.Errorf("[HandshakeServer] expected version ack message")
 	}
 
-	// 6. sendMsg ack
-	err = sendMsg(conn, &types.VerACK{})
+	// 7. sendMsg ack
+	err = sendMsg(transport, &types.VerACK{})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return createPeerInfo(version, kid, conn.RemoteAddr().String()), nil
+	return createPeerInfo(info, version, kid, conn.RemoteAddr().String()), transport, nil
 }
 
 func sendMsg(conn net.Conn, msg types.Message) error {
@@ -168,12 +192,46 @@ func sendMsg(conn net.Conn, msg types.Message) error {
 	return nil
 }
 
-func createPeerInfo(version *types.Version, kid common.PeerId, addr string) *peer.PeerInfo {
-	return peer.NewPeerInfo(kid, version.P.Version, version.P.Services, version.P.Relay != 0, version.P.HttpInfoPort,
+func createPeerInfo(local *peer.PeerInfo, version *types.Version, kid common.PeerId, addr string) *peer.PeerInfo {
+	info := peer.NewPeerInfo(kid, version.P.Version, version.P.Services, version.P.Relay != 0, version.P.HttpInfoPort,
 		version.P.SyncPort, version.P.StartHeight, version.P.SoftVersion, addr)
+	// compression is used only if both side support it, old peers never set the cap flag
+	info.Compress = local.Compress && version.P.Cap[common.COMPRESS_FLAG] == 0x01
+	info.CompactBlock = local.CompactBlock && version.P.Cap[common.COMPACT_BLOCK_FLAG] == 0x01
+	info.TxInv = local.TxInv && version.P.Cap[common.TX_INV_FLAG] == 0x01
+	info.ExternalIP = checkExternalIP(versionIP(version.P.ExternalIP), addr)
+	info.ObservedIP = versionIP(version.P.ObservedIP)
+	if len(version.P.Protocols) > 0 {
+		info.Protocols = make(map[string]uint32, len(version.P.Protocols))
+		for _, c := range version.P.Protocols {
+			info.Protocols[c.Name] = c.Version
+		}
+	}
+	return info
+}
+
+//checkExternalIP return the ip advertised by peer only if it is public and the same as the observed remote address,
+//since it is relayed to other peers as the listen address of peer
+func checkExternalIP(ip net.IP, addr string) net.IP {
+	if ip == nil || !nat.IsPublicIP(ip) {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || !ip.Equal(net.ParseIP(host)) {
+		return nil
+	}
+	return ip
 }
 
-func newVersion(peerInfo *peer.PeerInfo) *types.Version {
+//versionIP return nil if the optional ip field of version is not set
+func versionIP(ip [16]byte) net.IP {
+	if ip == [16]byte{} {
+		return nil
+	}
+	return net.IP(append([]byte{}, ip[:]...))
+}
+
+func newVersion(peerInfo *peer.PeerInfo, encrypt bool, remoteAddr net.Addr) *types.Version {
 	var version types.Version
 	version.P = types.VersionPayload{
 		Version:      peerInfo.Version,
@@ -197,10 +255,40 @@ func newVersion(peerInfo *peer.PeerInfo) *types.Version {
 	} else {
 		version.P.Cap[common.HTTP_INFO_FLAG] = 0x00
 	}
+	if encrypt {
+		version.P.Cap[common.ENCRYPT_FLAG] = 0x01
+	}
+	if peerInfo.Compress {
+		version.P.Cap[common.COMPRESS_FLAG] = 0x01
+	}
+	if peerInfo.CompactBlock {
+		version.P.Cap[common.COMPACT_BLOCK_FLAG] = 0x01
+	}
+	if peerInfo.TxInv {
+		version.P.Cap[common.TX_INV_FLAG] = 0x01
+	}
+	if peerInfo.ExternalIP != nil {
+		copy(version.P.ExternalIP[:], peerInfo.ExternalIP.To16())
+	}
+	for name, ver := range peerInfo.Protocols {
+		version.P.Protocols = append(version.P.Protocols, types.ProtocolCap{Name: name, Version: ver})
+	}
+	sort.Slice(version.P.Protocols, func(i, j int) bool {
+		return version.P.Protocols[i].Name < version.P.Protocols[j].Name
+	})
+	// tell the peer which ip it connects from, so it can learn its public ip behind NAT
+	if tcpAddr, ok := remoteAddr.(*net.TCPAddr); ok {
+		copy(version.P.ObservedIP[:], tcpAddr.IP.To16())
+	}
 
 	return &version
 }
 
+// encrypted transport is used only if both side enabled it, old peers never set the cap flag
+func useEncryption(encrypt bool, remote *types.Version) bool {
+	return encrypt && remote.P.Cap[common.ENCRYPT_FLAG] == 0x01
+}
+
 func useDHT(client, server string) bool {
 	// we make this symmetric, because config.Version is depend on compile option, so to avoid the case:
 	// remote version is 1.9.0 and we support DHT, but the config.Version is not valid.
@@ -18,6 +18,7 @@
 package handshake
 
 import (
+	"io"
 	"math/rand"
 	"net"
 	"sync"
@@ -76,13 +77,13 @@ func TestHandshakeNormal(t *testing.T) {
 			err  error
 		}, 2)
 		go func() {
-			info, err := HandshakeClient(client.Info, client.Id, client.Conn)
+			info, _, err := HandshakeClient(client.Info, client.Id, client.Conn, true)
 			result[0].err = err
 			result[0].info = [2]*peer.PeerInfo{info, server.Info}
 			wg.Done()
 		}()
 		go func() {
-			info, err := HandshakeServer(server.Info, server.Id, server.Conn)
+			info, _, err := HandshakeServer(server.Info, server.Id, server.Conn, true)
 			result[1].err = err
 			result[1].info = [2]*peer.PeerInfo{info, client.Info}
 			wg.Done()
@@ -99,7 +100,7 @@ func TestHandshakeNormal(t *testing.T) {
 func TestHandshakeTimeout(t *testing.T) {
 	client, _ := NewPair()
 
-	_, err := HandshakeClient(client.Info, client.Id, client.Conn)
+	_, _, err := HandshakeClient(client.Info, client.Id, client.Conn, true)
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "deadline exceeded")
 }
@@ -111,11 +112,167 @@ func TestHandshakeWrongMsg(t *testing.T) {
 		assert.Nil(t, err)
 	}()
 
-	_, err := HandshakeServer(server.Info, server.Id, server.Conn)
+	_, _, err := HandshakeServer(server.Info, server.Id, server.Conn, true)
 	assert.NotNil(t, err)
 	assert.Contains(t, err.Error(), "expected version message")
 }
 
+func handshakePair(t *testing.T, client, server Node, clientEnc, serverEnc bool) (net.Conn, net.Conn) {
+	var clientConn, serverConn net.Conn
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		_, clientConn, err = HandshakeClient(client.Info, client.Id, client.Conn, clientEnc)
+		assert.Nil(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		_, serverConn, err = HandshakeServer(server.Info, server.Id, server.Conn, serverEnc)
+		assert.Nil(t, err)
+	}()
+	wg.Wait()
+	return clientConn, serverConn
+}
+
+func TestHandshakeEncryption(t *testing.T) {
+	cases := []struct {
+		clientEnc, serverEnc bool
+		clientVer, serverVer string
+		encrypted            bool
+	}{
+		{true, true, "v1.10.0", "v1.10.0", true},
+		{true, false, "v1.10.0", "v1.10.0", false},
+		{false, true, "v1.10.0", "v1.10.0", false},
+		{true, true, "v1.8.0", "v1.10.0", false},
+	}
+	for _, c := range cases {
+		client, server := NewPair()
+		client.Info.SoftVersion = c.clientVer
+		server.Info.SoftVersion = c.serverVer
+		clientConn, serverConn := handshakePair(t, client, server, c.clientEnc, c.serverEnc)
+		_, ok := clientConn.(*secureConn)
+		assert.Equal(t, c.encrypted, ok)
+		_, ok = serverConn.(*secureConn)
+		assert.Equal(t, c.encrypted, ok)
+
+		data := make([]byte, 3*SECURE_MAX_FRAME_LEN+100)
+		rand.Read(data)
+		go func() {
+			_, err := clientConn.Write(data)
+			assert.Nil(t, err)
+		}()
+		buf := make([]byte, len(data))
+		_, err := io.ReadFull(serverConn, buf)
+		assert.Nil(t, err)
+		assert.Equal(t, data, buf)
+	}
+}
+
+//compression, compact block and transaction announcement are used only if both side support them
+func TestHandshakeCapabilities(t *testing.T) {
+	cases := []struct {
+		clientCap, serverCap, negotiated bool
+	}{
+		{true, true, true},
+		{true, false, false},
+		{false, true, false},
+		{false, false, false},
+	}
+	for _, c := range cases {
+		client, server := NewPair()
+		client.Info.Compress = c.clientCap
+		server.Info.Compress = c.serverCap
+		client.Info.CompactBlock = c.clientCap
+		server.Info.CompactBlock = c.serverCap
+		client.Info.TxInv = c.clientCap
+		server.Info.TxInv = c.serverCap
+
+		var clientRes, serverRes *peer.PeerInfo
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var err error
+			clientRes, _, err = HandshakeClient(client.Info, client.Id, client.Conn, false)
+			assert.Nil(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			var err error
+			serverRes, _, err = HandshakeServer(server.Info, server.Id, server.Conn, false)
+			assert.Nil(t, err)
+		}()
+		wg.Wait()
+		assert.Equal(t, c.negotiated, clientRes.Compress)
+		assert.Equal(t, c.negotiated, serverRes.Compress)
+		assert.Equal(t, c.negotiated, clientRes.CompactBlock)
+		assert.Equal(t, c.negotiated, serverRes.CompactBlock)
+		assert.Equal(t, c.negotiated, clientRes.TxInv)
+		assert.Equal(t, c.negotiated, serverRes.TxInv)
+	}
+}
+
+func TestHandshakeProtocols(t *testing.T) {
+	client, server := NewPair()
+	client.Info.Protocols = map[string]uint32{"ontfs": 1}
+	server.Info.Protocols = map[string]uint32{"ontfs": 2, "gossip": 1}
+
+	var clientRes, serverRes *peer.PeerInfo
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		var err error
+		clientRes, _, err = HandshakeClient(client.Info, client.Id, client.Conn, false)
+		assert.Nil(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		serverRes, _, err = HandshakeServer(server.Info, server.Id, server.Conn, false)
+		assert.Nil(t, err)
+	}()
+	wg.Wait()
+	assert.Equal(t, server.Info.Protocols, clientRes.Protocols)
+	assert.Equal(t, client.Info.Protocols, serverRes.Protocols)
+	version, ok := clientRes.ProtocolVersion("ontfs")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(2), version)
+	_, ok = serverRes.ProtocolVersion("gossip")
+	assert.False(t, ok)
+}
+
+func TestSecureConnTampered(t *testing.T) {
+	c, s := net.Pipe()
+	key := make([]byte, 32)
+	clientConn, _ := newSecureConn(c, key, key)
+	go func() {
+		// send a frame which is not encrypted with the session key
+		frame := []byte{20, 0}
+		frame = append(frame, make([]byte, 20)...)
+		_, _ = s.Write(frame)
+	}()
+	_, err := clientConn.Read(make([]byte, 10))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "decrypt frame failed")
+}
+
+func TestHandshakeEncAuthFailed(t *testing.T) {
+	client, server := NewPair()
+	client.Info.SoftVersion = "v1.10.0"
+	server.Info.SoftVersion = "v1.10.0"
+	// server claims the kad id of another peer, which it can not sign for
+	fake := common.RandPeerKeyId()
+	go func() {
+		_, _, _ = HandshakeServer(server.Info, &common.PeerKeyId{PublicKey: fake.PublicKey, Id: fake.Id}, server.Conn, true)
+	}()
+	_, _, err := HandshakeClient(client.Info, client.Id, client.Conn, true)
+	assert.NotNil(t, err)
+}
+
 func TestVersion(t *testing.T) {
 	assert.True(t, supportDHT(common.MIN_VERSION_FOR_DHT))
 	assert.True(t, supportDHT("1.9.1"))
@@ -132,3 +289,51 @@ func TestVersion(t *testing.T) {
 	assert.False(t, supportDHT("1.8.0-beta-9-geeaeewwf"))
 	assert.False(t, supportDHT("1.8.0"))
 }
+
+//public ip of peer and observed ip of local node are exchanged in version
+func TestHandshakeAddresses(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	c, err := net.Dial("tcp", listener.Addr().String())
+	assert.Nil(t, err)
+	s, err := listener.Accept()
+	assert.Nil(t, err)
+	client, server := NewNode(c), NewNode(s)
+	client.Info.Port = 30338
+	client.Info.ExternalIP = net.ParseIP("203.0.113.7")
+
+	var clientRes, serverRes *peer.PeerInfo
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		clientRes, _, err = HandshakeClient(client.Info, client.Id, client.Conn, false)
+		assert.Nil(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		var err error
+		serverRes, _, err = HandshakeServer(server.Info, server.Id, server.Conn, false)
+		assert.Nil(t, err)
+	}()
+	wg.Wait()
+
+	assert.Nil(t, clientRes.ExternalIP)
+	assert.Equal(t, "127.0.0.1", clientRes.ObservedIP.String())
+	//advertised ip not matching the remote address is dropped
+	assert.Nil(t, serverRes.ExternalIP)
+	assert.Equal(t, "127.0.0.1", serverRes.ObservedIP.String())
+	assert.Equal(t, "127.0.0.1:30338", serverRes.RemoteListenAddress())
+}
+
+func TestCheckExternalIP(t *testing.T) {
+	ip := net.ParseIP("203.0.113.7")
+	assert.Equal(t, ip, checkExternalIP(ip, "203.0.113.7:20338"))
+	assert.Equal(t, ip, checkExternalIP(ip, "[::ffff:203.0.113.7]:20338"))
+	assert.Nil(t, checkExternalIP(nil, "203.0.113.7:20338"))
+	assert.Nil(t, checkExternalIP(ip, "198.51.100.1:20338"))
+	assert.Nil(t, checkExternalIP(ip, "203.0.113.7"))
+	assert.Nil(t, checkExternalIP(net.ParseIP("10.0.0.1"), "10.0.0.1:20338"))
+	assert.Nil(t, checkExternalIP(net.ParseIP("127.0.0.1"), "127.0.0.1:20338"))
+}
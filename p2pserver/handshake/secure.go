@@ -0,0 +1,281 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package handshake
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sync"
+
+	common2 "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/message/types"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+const SECURE_MAX_FRAME_LEN = 16 * 1024 // max plain text length of one encrypted frame
+
+const (
+	secureFrameHdrLen = 2
+	secureClientRole  = "client"
+	secureServerRole  = "server"
+)
+
+var secureProtocolName = []byte("ontology-p2p-secure-v1")
+
+// secureConn encrypts and authenticates all data on the underlying connection with
+// chacha20poly1305, each frame is prefixed with 2 bytes cipher text length, and the
+// nonce is a counter increased by one per frame in each direction.
+type secureConn struct {
+	net.Conn
+
+	sendLock  sync.Mutex
+	sendAead  cipher.AEAD
+	sendNonce uint64
+
+	recvLock  sync.Mutex
+	recvAead  cipher.AEAD
+	recvNonce uint64
+	recvFrame []byte
+	recvBuf   []byte // decrypted data not read yet
+}
+
+func newSecureConn(conn net.Conn, sendKey, recvKey []byte) (net.Conn, error) {
+	sendAead, err := chacha20poly1305.New(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	recvAead, err := chacha20poly1305.New(recvKey)
+	if err != nil {
+		return nil, err
+	}
+	return &secureConn{
+		Conn:      conn,
+		sendAead:  sendAead,
+		recvAead:  recvAead,
+		recvFrame: make([]byte, SECURE_MAX_FRAME_LEN+recvAead.Overhead()),
+	}, nil
+}
+
+func secureNonce(counter uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce[chacha20poly1305.NonceSize-8:], counter)
+	return nonce
+}
+
+// Write overwrite net.Conn
+func (self *secureConn) Write(b []byte) (int, error) {
+	self.sendLock.Lock()
+	defer self.sendLock.Unlock()
+
+	written := 0
+	for len(b) > 0 {
+		if self.sendNonce == math.MaxUint64 {
+			return written, errors.New("[secureConn] nonce exhausted")
+		}
+		n := len(b)
+		if n > SECURE_MAX_FRAME_LEN {
+			n = SECURE_MAX_FRAME_LEN
+		}
+		frame := make([]byte, secureFrameHdrLen, secureFrameHdrLen+n+self.sendAead.Overhead())
+		binary.LittleEndian.PutUint16(frame, uint16(n+self.sendAead.Overhead()))
+		frame = self.sendAead.Seal(frame, secureNonce(self.sendNonce), b[:n], nil)
+		self.sendNonce += 1
+		if _, err := self.Conn.Write(frame); err != nil {
+			return written, err
+		}
+		written += n
+		b = b[n:]
+	}
+
+	return written, nil
+}
+
+// Read overwrite net.Conn
+func (self *secureConn) Read(b []byte) (int, error) {
+	self.recvLock.Lock()
+	defer self.recvLock.Unlock()
+
+	for len(self.recvBuf) == 0 {
+		var hdr [secureFrameHdrLen]byte
+		if _, err := io.ReadFull(self.Conn, hdr[:]); err != nil {
+			return 0, err
+		}
+		length := int(binary.LittleEndian.Uint16(hdr[:]))
+		if length < self.recvAead.Overhead() || length > len(self.recvFrame) {
+			return 0, fmt.Errorf("[secureConn] invalid frame length %d", length)
+		}
+		frame := self.recvFrame[:length]
+		if _, err := io.ReadFull(self.Conn, frame); err != nil {
+			return 0, err
+		}
+		if self.recvNonce == math.MaxUint64 {
+			return 0, errors.New("[secureConn] nonce exhausted")
+		}
+		plain, err := self.recvAead.Open(frame[:0], secureNonce(self.recvNonce), frame, nil)
+		if err != nil {
+			return 0, fmt.Errorf("[secureConn] decrypt frame failed: %s", err)
+		}
+		self.recvNonce += 1
+		self.recvBuf = plain
+	}
+
+	n := copy(b, self.recvBuf)
+	self.recvBuf = self.recvBuf[n:]
+	return n, nil
+}
+
+func newEphemeralKey() (priv, pub [32]byte, err error) {
+	if _, err = rand.Read(priv[:]); err != nil {
+		return
+	}
+	curve25519.ScalarBaseMult(&pub, &priv)
+	return
+}
+
+// transcriptHash binds the ephemeral keys to the peer key ids of both sides
+func transcriptHash(clientEph, serverEph [32]byte, clientId, serverId *common.PeerKeyId) [32]byte {
+	sink := common2.NewZeroCopySink(nil)
+	sink.WriteVarBytes(secureProtocolName)
+	sink.WriteBytes(clientEph[:])
+	sink.WriteBytes(serverEph[:])
+	clientId.Serialization(sink)
+	serverId.Serialization(sink)
+	return sha256.Sum256(sink.Bytes())
+}
+
+func authData(role string, transcript [32]byte) []byte {
+	return append([]byte(role), transcript[:]...)
+}
+
+// deriveKeys returns the keys used to encrypt data from client to server and from server to client
+func deriveKeys(priv, remotePub, transcript [32]byte) ([]byte, []byte, error) {
+	var shared [32]byte
+	curve25519.ScalarMult(&shared, &priv, &remotePub)
+	if shared == [32]byte{} {
+		return nil, nil, errors.New("invalid ephemeral key")
+	}
+	keys := make([]byte, 2*chacha20poly1305.KeySize)
+	reader := hkdf.New(sha256.New, shared[:], transcript[:], secureProtocolName)
+	if _, err := io.ReadFull(reader, keys); err != nil {
+		return nil, nil, err
+	}
+	return keys[:chacha20poly1305.KeySize], keys[chacha20poly1305.KeySize:], nil
+}
+
+func secureClient(selfId, remoteId *common.PeerKeyId, conn net.Conn) (net.Conn, error) {
+	priv, pub, err := newEphemeralKey()
+	if err != nil {
+		return nil, err
+	}
+	err = sendMsg(conn, &types.EncHello{EphemeralKey: pub})
+	if err != nil {
+		return nil, err
+	}
+	msg, _, err := types.ReadMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	hello, ok := msg.(*types.EncHello)
+	if !ok {
+		return nil, fmt.Errorf("handshake failed, expect enc hello message, got %s", msg.CmdType())
+	}
+
+	transcript := transcriptHash(pub, hello.EphemeralKey, selfId, remoteId)
+	sig, err := selfId.Sign(authData(secureClientRole, transcript))
+	if err != nil {
+		return nil, err
+	}
+	err = sendMsg(conn, &types.EncAuth{Signature: sig})
+	if err != nil {
+		return nil, err
+	}
+	msg, _, err = types.ReadMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	auth, ok := msg.(*types.EncAuth)
+	if !ok {
+		return nil, fmt.Errorf("handshake failed, expect enc auth message, got %s", msg.CmdType())
+	}
+	if err = remoteId.Verify(authData(secureServerRole, transcript), auth.Signature); err != nil {
+		return nil, fmt.Errorf("handshake failed, verify enc auth signature error: %s", err)
+	}
+
+	sendKey, recvKey, err := deriveKeys(priv, hello.EphemeralKey, transcript)
+	if err != nil {
+		return nil, err
+	}
+	return newSecureConn(conn, sendKey, recvKey)
+}
+
+func secureServer(selfId, remoteId *common.PeerKeyId, conn net.Conn) (net.Conn, error) {
+	msg, _, err := types.ReadMessage(conn)
+	if err != nil {
+		return nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
+	}
+	hello, ok := msg.(*types.EncHello)
+	if !ok {
+		return nil, fmt.Errorf("[HandshakeServer] expected enc hello message")
+	}
+	priv, pub, err := newEphemeralKey()
+	if err != nil {
+		return nil, err
+	}
+	err = sendMsg(conn, &types.EncHello{EphemeralKey: pub})
+	if err != nil {
+		return nil, err
+	}
+
+	transcript := transcriptHash(hello.EphemeralKey, pub, remoteId, selfId)
+	msg, _, err = types.ReadMessage(conn)
+	if err != nil {
+		return nil, fmt.Errorf("[HandshakeServer] ReadMessage failed, error: %s", err)
+	}
+	auth, ok := msg.(*types.EncAuth)
+	if !ok {
+		return nil, fmt.Errorf("[HandshakeServer] expected enc auth message")
+	}
+	if err = remoteId.Verify(authData(secureClientRole, transcript), auth.Signature); err != nil {
+		return nil, fmt.Errorf("[HandshakeServer] verify enc auth signature error: %s", err)
+	}
+	sig, err := selfId.Sign(authData(secureServerRole, transcript))
+	if err != nil {
+		return nil, err
+	}
+	err = sendMsg(conn, &types.EncAuth{Signature: sig})
+	if err != nil {
+		return nil, err
+	}
+
+	recvKey, sendKey, err := deriveKeys(priv, hello.EphemeralKey, transcript)
+	if err != nil {
+		return nil, err
+	}
+	return newSecureConn(conn, sendKey, recvKey)
+}
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
 	comm "github.com/ontio/ontology/common"
@@ -33,12 +34,17 @@ import (
 
 //Link used to establish
 type Link struct {
+	bytesIn   uint64 // bytes received on the connection, accessed atomically
+	bytesOut  uint64 // bytes sent on the connection, accessed atomically
 	id        common.PeerId
 	addr      string                 // The address of the node
 	conn      net.Conn               // Connect socket with the peer node
 	time      time.Time              // The latest time the node activity
+	connTime  time.Time              // The time the connection is established
 	recvChan  chan *types.MsgPayload //msgpayload channel
 	reqRecord map[string]int64       //Map RequestId to Timestamp, using for rejecting duplicate request in specific time
+
+	onMalformed func(id common.PeerId, addr string, err error) //called when the peer sends an invalid message
 }
 
 func NewLink() *Link {
@@ -68,6 +74,11 @@ func (this *Link) SetChan(msgchan chan *types.MsgPayload) {
 	this.recvChan = msgchan
 }
 
+//set the callback of receiving malformed message
+func (this *Link) SetMalformedMsgHandler(handler func(id common.PeerId, addr string, err error)) {
+	this.onMalformed = handler
+}
+
 //get address
 func (this *Link) GetAddr() string {
 	return this.addr
@@ -86,6 +97,22 @@ func (this *Link) GetConn() net.Conn {
 //set connection
 func (this *Link) SetConn(conn net.Conn) {
 	this.conn = conn
+	this.connTime = time.Now()
+}
+
+//GetConnTime return the time the connection is established
+func (this *Link) GetConnTime() time.Time {
+	return this.connTime
+}
+
+//GetBytesIn return the bytes received on the connection
+func (this *Link) GetBytesIn() uint64 {
+	return atomic.LoadUint64(&this.bytesIn)
+}
+
+//GetBytesOut return the bytes sent on the connection
+func (this *Link) GetBytesOut() uint64 {
+	return atomic.LoadUint64(&this.bytesOut)
 }
 
 //record latest message time
@@ -108,13 +135,22 @@ func (this *Link) Rx() {
 
 	for {
 		msg, payloadSize, err := types.ReadMessage(reader)
+		if types.IsUnknownMsgError(err) {
+			log.Debugf("[p2p]skip message from %s: %s", this.GetAddr(), err)
+			atomic.AddUint64(&this.bytesIn, uint64(common.MSG_HDR_LEN+payloadSize))
+			continue
+		}
 		if err != nil {
 			log.Infof("[p2p]error read from %s :%s", this.GetAddr(), err.Error())
+			if this.onMalformed != nil && types.IsMalformedMsgError(err) {
+				this.onMalformed(this.id, this.addr, err)
+			}
 			break
 		}
 
 		t := time.Now()
 		this.UpdateRXTime(t)
+		atomic.AddUint64(&this.bytesIn, uint64(common.MSG_HDR_LEN+payloadSize))
 
 		if !this.needSendMsg(msg) {
 			log.Debugf("skip handle msgType:%s from:%d", msg.CmdType(), this.id)
@@ -168,6 +204,7 @@ func (this *Link) SendRaw(rawPacket []byte) error {
 		this.CloseConn()
 		return err
 	}
+	atomic.AddUint64(&this.bytesOut, uint64(nByteCnt))
 
 	return nil
 }
@@ -53,6 +53,34 @@ func NewBlock(bk *ct.Block, ccMsg *ct.CrossChainMsg, merkleRoot common.Uint256)
 	return &blk
 }
 
+//compact block package
+func NewCompactBlock(bk *ct.Block, ccMsg *ct.CrossChainMsg, merkleRoot common.Uint256) mt.Message {
+	log.Trace()
+	blockHash := bk.Hash()
+	shortIds := make([]uint64, 0, len(bk.Transactions))
+	for _, tx := range bk.Transactions {
+		shortIds = append(shortIds, mt.ShortTxId(blockHash, tx.Hash()))
+	}
+	return &mt.CompactBlock{
+		Header:     bk.Header,
+		ShortIds:   shortIds,
+		MerkleRoot: merkleRoot,
+		CCMsg:      ccMsg,
+	}
+}
+
+//missing txs of compact block req package
+func NewGetBlockTxn(blockHash common.Uint256, indexes []uint32) mt.Message {
+	log.Trace()
+	return &mt.GetBlockTxn{BlockHash: blockHash, Indexes: indexes}
+}
+
+//missing txs of compact block package
+func NewBlockTxn(blockHash common.Uint256, txs []*ct.Transaction) mt.Message {
+	log.Trace()
+	return &mt.BlockTxn{BlockHash: blockHash, Txs: txs}
+}
+
 //blk hdr package
 func NewHeaders(headers []*ct.RawHeader) mt.Message {
 	log.Trace()
@@ -156,6 +184,15 @@ func NewBlkDataReq(hash common.Uint256) mt.Message {
 	return &dataReq
 }
 
+//NewFullBlkDataReq return the request of full block, which is never replied as compact block
+func NewFullBlkDataReq(hash common.Uint256) mt.Message {
+	var dataReq mt.DataReq
+	dataReq.DataType = common.FULL_BLOCK
+	dataReq.Hash = hash
+
+	return &dataReq
+}
+
 //consensus request package
 func NewConsensusDataReq(hash common.Uint256) mt.Message {
 	log.Trace()
@@ -173,3 +210,24 @@ func NewFindNodeReq(id msgCommon.PeerId) mt.Message {
 
 	return &req
 }
+
+//storage request package for light node
+func NewStorageReq(reqId uint64, contract common.Address, key []byte) mt.Message {
+	req := mt.StorageReq{
+		ReqId:    reqId,
+		Contract: contract,
+		Key:      key,
+	}
+
+	return &req
+}
+
+//transaction proof request package for light node
+func NewTxProofReq(reqId uint64, txHash common.Uint256) mt.Message {
+	req := mt.TxProofReq{
+		ReqId:  reqId,
+		TxHash: txHash,
+	}
+
+	return &req
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	ncomm "github.com/ontio/ontology/p2pserver/common"
+)
+
+//ShortTxId return the short id of transaction in a compact block, salted by block hash
+func ShortTxId(blockHash, txHash common.Uint256) uint64 {
+	sum := sha256.Sum256(append(blockHash[:], txHash[:]...))
+	return binary.LittleEndian.Uint64(sum[:8])
+}
+
+//CompactBlock is a block with short transaction ids, the receiver reconstructs it from txpool
+type CompactBlock struct {
+	Header     *types.Header
+	ShortIds   []uint64
+	MerkleRoot common.Uint256
+	CCMsg      *types.CrossChainMsg
+}
+
+// Serialization message payload
+func (this *CompactBlock) Serialization(sink *common.ZeroCopySink) {
+	this.Header.Serialization(sink)
+	sink.WriteVarUint(uint64(len(this.ShortIds)))
+	for _, id := range this.ShortIds {
+		sink.WriteUint64(id)
+	}
+	sink.WriteHash(this.MerkleRoot)
+	sink.WriteBool(this.CCMsg != nil)
+	if this.CCMsg != nil {
+		this.CCMsg.Serialization(sink)
+	}
+}
+
+// CmdType return this message type
+func (this *CompactBlock) CmdType() string {
+	return ncomm.COMPACT_BLOCK_TYPE
+}
+
+// Deserialization message payload
+func (this *CompactBlock) Deserialization(source *common.ZeroCopySource) error {
+	this.Header = new(types.Header)
+	err := this.Header.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	n, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof || n > source.Len()/8 {
+		return io.ErrUnexpectedEOF
+	}
+	this.ShortIds = make([]uint64, 0, n)
+	for i := uint64(0); i < n; i++ {
+		id, _ := source.NextUint64()
+		this.ShortIds = append(this.ShortIds, id)
+	}
+	this.MerkleRoot, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	hasCCM, irregular, eof := source.NextBool()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.CCMsg = nil
+	if hasCCM {
+		this.CCMsg = new(types.CrossChainMsg)
+		if err := this.CCMsg.Deserialization(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//GetBlockTxn request the transactions of compact block which are missing in txpool
+type GetBlockTxn struct {
+	BlockHash common.Uint256
+	Indexes   []uint32
+}
+
+// Serialization message payload
+func (this *GetBlockTxn) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarUint(uint64(len(this.Indexes)))
+	for _, index := range this.Indexes {
+		sink.WriteUint32(index)
+	}
+}
+
+// CmdType return this message type
+func (this *GetBlockTxn) CmdType() string {
+	return ncomm.GET_BLOCK_TXN_TYPE
+}
+
+// Deserialization message payload
+func (this *GetBlockTxn) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	n, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof || n > source.Len()/4 {
+		return io.ErrUnexpectedEOF
+	}
+	this.Indexes = make([]uint32, 0, n)
+	for i := uint64(0); i < n; i++ {
+		index, _ := source.NextUint32()
+		this.Indexes = append(this.Indexes, index)
+	}
+	return nil
+}
+
+//BlockTxn response the requested transactions of compact block
+type BlockTxn struct {
+	BlockHash common.Uint256
+	Txs       []*types.Transaction
+}
+
+// Serialization message payload
+func (this *BlockTxn) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteHash(this.BlockHash)
+	sink.WriteVarUint(uint64(len(this.Txs)))
+	for _, tx := range this.Txs {
+		tx.Serialization(sink)
+	}
+}
+
+// CmdType return this message type
+func (this *BlockTxn) CmdType() string {
+	return ncomm.BLOCK_TXN_TYPE
+}
+
+// Deserialization message payload
+func (this *BlockTxn) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	this.BlockHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	n, _, irregular, eof := source.NextVarUint()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if n > source.Len() {
+		return errors.New("too many transactions in block txn")
+	}
+	this.Txs = make([]*types.Transaction, 0, n)
+	for i := uint64(0); i < n; i++ {
+		tx := new(types.Transaction)
+		if err := tx.Deserialization(source); err != nil {
+			return err
+		}
+		this.Txs = append(this.Txs, tx)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactBlockSerializationDeserialization(t *testing.T) {
+	header := &types.Header{Height: 10, Bookkeepers: nil, SigData: [][]byte{}}
+	sink := common.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	header = new(types.Header)
+	assert.Nil(t, header.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+
+	MessageTest(t, &CompactBlock{Header: header, ShortIds: []uint64{1, 2, 3}, MerkleRoot: common.UINT256_EMPTY})
+	MessageTest(t, &GetBlockTxn{BlockHash: header.Hash(), Indexes: []uint32{0, 5}})
+
+	mutable := &types.MutableTransaction{TxType: types.InvokeNeo, Payload: &payload.InvokeCode{Code: []byte{1}},
+		Sigs: []types.Sig{}}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	MessageTest(t, &BlockTxn{BlockHash: header.Hash(), Txs: []*types.Transaction{tx}})
+}
+
+func TestShortTxId(t *testing.T) {
+	blockHash, txHash := common.Uint256{1}, common.Uint256{2}
+	assert.Equal(t, ShortTxId(blockHash, txHash), ShortTxId(blockHash, txHash))
+	assert.NotEqual(t, ShortTxId(blockHash, txHash), ShortTxId(common.Uint256{3}, txHash))
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+const COMPRESS_MIN_PAYLOAD_LEN = 1024 //messages with smaller payload are always sent uncompressed
+
+var compressStats struct {
+	sentSaved uint64
+	recvSaved uint64
+}
+
+//CompressionSavedBytes return the total bytes saved by message compression when sending and receiving
+func CompressionSavedBytes() (sent uint64, recv uint64) {
+	return atomic.LoadUint64(&compressStats.sentSaved), atomic.LoadUint64(&compressStats.recvSaved)
+}
+
+//CompressMessage wraps a serialized message into a compressed message when it saves bandwidth,
+//otherwise the serialized message is returned unchanged
+func CompressMessage(raw []byte) []byte {
+	if len(raw) < common.MSG_HDR_LEN+COMPRESS_MIN_PAYLOAD_LEN || isCompressedMessage(raw) {
+		return raw
+	}
+	data := snappy.Encode(nil, raw)
+	if len(data)+common.MSG_HDR_LEN >= len(raw) {
+		return raw
+	}
+
+	sink := comm.NewZeroCopySink(make([]byte, 0, common.MSG_HDR_LEN+len(data)))
+	writeMessageHeaderInto(sink, newMessageHeader(common.COMPRESSED_TYPE, uint32(len(data)), common.Checksum(data)))
+	sink.WriteBytes(data)
+	atomic.AddUint64(&compressStats.sentSaved, uint64(len(raw)-int(sink.Size())))
+
+	return sink.Bytes()
+}
+
+func isCompressedMessage(raw []byte) bool {
+	if len(raw) < common.MSG_HDR_LEN {
+		return false
+	}
+	cmd := raw[comm.UINT32_SIZE : comm.UINT32_SIZE+common.MSG_CMD_LEN]
+	return string(bytes.TrimRight(cmd, "\x00")) == common.COMPRESSED_TYPE
+}
+
+//decompressMessage decodes the payload of compressed message, which is a whole serialized message
+func decompressMessage(data []byte) (Message, error) {
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+	if size > common.MAX_MSG_LEN {
+		return nil, fmt.Errorf("decompressed msg length:%d exceed max message size: %d", size, common.MAX_MSG_LEN)
+	}
+	raw, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, err
+	}
+	if isCompressedMessage(raw) {
+		return nil, fmt.Errorf("nested compressed message is not allowed")
+	}
+	msg, _, err := ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if saved := len(raw) - common.MSG_HDR_LEN - len(data); saved > 0 {
+		atomic.AddUint64(&compressStats.recvSaved, uint64(saved))
+	}
+
+	return msg, nil
+}
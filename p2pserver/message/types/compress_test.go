@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/snappy"
+	common2 "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func serializeMessage(msg Message) []byte {
+	sink := common2.NewZeroCopySink(nil)
+	WriteMessage(sink, msg)
+	return sink.Bytes()
+}
+
+func TestCompressMessage(t *testing.T) {
+	msg := &StorageResp{ReqId: 1, Height: 100, Found: true, Value: bytes.Repeat([]byte("value"), 1000)}
+	raw := serializeMessage(msg)
+	sent, recv := CompressionSavedBytes()
+
+	compressed := CompressMessage(raw)
+	assert.True(t, len(compressed) < len(raw))
+	assert.True(t, isCompressedMessage(compressed))
+	assert.Equal(t, compressed, CompressMessage(compressed))
+
+	demsg, size, err := ReadMessage(bytes.NewReader(compressed))
+	assert.Nil(t, err)
+	assert.Equal(t, msg, demsg)
+	assert.Equal(t, uint32(len(compressed)-common.MSG_HDR_LEN), size)
+
+	sent2, recv2 := CompressionSavedBytes()
+	assert.Equal(t, sent+uint64(len(raw)-len(compressed)), sent2)
+	assert.Equal(t, recv+uint64(len(raw)-len(compressed)), recv2)
+}
+
+func TestCompressSmallMessage(t *testing.T) {
+	raw := serializeMessage(&Ping{Height: 100})
+	assert.Equal(t, raw, CompressMessage(raw))
+}
+
+func TestNestedCompressedMessage(t *testing.T) {
+	raw := serializeMessage(&StorageResp{ReqId: 1, Found: true, Value: bytes.Repeat([]byte("value"), 1000)})
+	compressed := CompressMessage(raw)
+
+	data := snappy.Encode(nil, compressed)
+	sink := common2.NewZeroCopySink(nil)
+	writeMessageHeaderInto(sink, newMessageHeader(common.COMPRESSED_TYPE, uint32(len(data)), common.Checksum(data)))
+	sink.WriteBytes(data)
+
+	_, _, err := ReadMessage(bytes.NewReader(sink.Bytes()))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "nested compressed message")
+}
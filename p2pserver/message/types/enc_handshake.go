@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"fmt"
+	"io"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+const MAX_ENC_AUTH_SIG_LEN = 1024
+
+//EncHello carries the ephemeral key for encrypted transport key agreement
+type EncHello struct {
+	EphemeralKey [32]byte
+}
+
+//Serialize message payload
+func (this *EncHello) Serialization(sink *comm.ZeroCopySink) {
+	sink.WriteBytes(this.EphemeralKey[:])
+}
+
+//Deserialize message payload
+func (this *EncHello) Deserialization(source *comm.ZeroCopySource) error {
+	buf, eof := source.NextBytes(uint64(len(this.EphemeralKey)))
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	copy(this.EphemeralKey[:], buf)
+	return nil
+}
+
+func (this *EncHello) CmdType() string {
+	return common.ENC_HELLO_TYPE
+}
+
+//EncAuth carries the signature of handshake transcript signed by peer key id
+type EncAuth struct {
+	Signature []byte
+}
+
+//Serialize message payload
+func (this *EncAuth) Serialization(sink *comm.ZeroCopySink) {
+	sink.WriteVarBytes(this.Signature)
+}
+
+//Deserialize message payload
+func (this *EncAuth) Deserialization(source *comm.ZeroCopySource) error {
+	sig, _, irregular, eof := source.NextVarBytes()
+	if irregular {
+		return comm.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if len(sig) > MAX_ENC_AUTH_SIG_LEN {
+		return fmt.Errorf("signature length %d exceed max length %d", len(sig), MAX_ENC_AUTH_SIG_LEN)
+	}
+	this.Signature = sig
+	return nil
+}
+
+func (this *EncAuth) CmdType() string {
+	return common.ENC_AUTH_TYPE
+}
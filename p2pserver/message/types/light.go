@@ -0,0 +1,225 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"errors"
+	"io"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	ncomm "github.com/ontio/ontology/p2pserver/common"
+)
+
+//max depth of transaction merkle path
+const MAX_TX_PROOF_PATH_LEN = 32
+
+//StorageReq request contract storage from full node for light node
+type StorageReq struct {
+	ReqId    uint64
+	Contract common.Address
+	Key      []byte
+}
+
+// Serialization message payload
+func (req StorageReq) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(req.ReqId)
+	sink.WriteAddress(req.Contract)
+	sink.WriteVarBytes(req.Key)
+}
+
+// CmdType return this message type
+func (req *StorageReq) CmdType() string {
+	return ncomm.GET_STORAGE_TYPE
+}
+
+// Deserialization message payload
+func (req *StorageReq) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	req.ReqId, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	req.Contract, eof = source.NextAddress()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	req.Key, _, _, eof = source.NextVarBytes()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//StorageResp response contract storage at block height to light node
+type StorageResp struct {
+	ReqId  uint64
+	Height uint32
+	Found  bool
+	Value  []byte
+}
+
+// Serialization message payload
+func (resp StorageResp) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(resp.ReqId)
+	sink.WriteUint32(resp.Height)
+	sink.WriteBool(resp.Found)
+	sink.WriteVarBytes(resp.Value)
+}
+
+// CmdType return this message type
+func (resp *StorageResp) CmdType() string {
+	return ncomm.STORAGE_TYPE
+}
+
+// Deserialization message payload
+func (resp *StorageResp) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	resp.ReqId, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	resp.Height, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	resp.Found, irregular, eof = source.NextBool()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	resp.Value, _, _, eof = source.NextVarBytes()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//TxProofReq request transaction and its merkle path in block from full node for light node
+type TxProofReq struct {
+	ReqId  uint64
+	TxHash common.Uint256
+}
+
+// Serialization message payload
+func (req TxProofReq) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(req.ReqId)
+	sink.WriteHash(req.TxHash)
+}
+
+// CmdType return this message type
+func (req *TxProofReq) CmdType() string {
+	return ncomm.GET_TX_PROOF_TYPE
+}
+
+// Deserialization message payload
+func (req *TxProofReq) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	req.ReqId, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	req.TxHash, eof = source.NextHash()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+//TxProofResp response transaction with its index and merkle path in block to light node
+type TxProofResp struct {
+	ReqId  uint64
+	Found  bool
+	Height uint32
+	Index  uint32
+	Tx     *types.Transaction
+	Path   []common.Uint256
+}
+
+// Serialization message payload
+func (resp TxProofResp) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(resp.ReqId)
+	sink.WriteBool(resp.Found)
+	if !resp.Found {
+		return
+	}
+	sink.WriteUint32(resp.Height)
+	sink.WriteUint32(resp.Index)
+	resp.Tx.Serialization(sink)
+	sink.WriteUint32(uint32(len(resp.Path)))
+	for _, hash := range resp.Path {
+		sink.WriteHash(hash)
+	}
+}
+
+// CmdType return this message type
+func (resp *TxProofResp) CmdType() string {
+	return ncomm.TX_PROOF_TYPE
+}
+
+// Deserialization message payload
+func (resp *TxProofResp) Deserialization(source *common.ZeroCopySource) error {
+	var eof, irregular bool
+	resp.ReqId, eof = source.NextUint64()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	resp.Found, irregular, eof = source.NextBool()
+	if irregular {
+		return common.ErrIrregularData
+	}
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if !resp.Found {
+		return nil
+	}
+	resp.Height, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	resp.Index, eof = source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	tx := &types.Transaction{}
+	err := tx.Deserialization(source)
+	if err != nil {
+		return err
+	}
+	resp.Tx = tx
+	n, eof := source.NextUint32()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	if n > MAX_TX_PROOF_PATH_LEN {
+		return errors.New("tx proof path is too long")
+	}
+	resp.Path = make([]common.Uint256, 0, n)
+	for i := uint32(0); i < n; i++ {
+		hash, eof := source.NextHash()
+		if eof {
+			return io.ErrUnexpectedEOF
+		}
+		resp.Path = append(resp.Path, hash)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+)
+
+func TestLightMessageSerializationDeserialization(t *testing.T) {
+	MessageTest(t, &StorageReq{ReqId: 1, Contract: common.ADDRESS_EMPTY, Key: []byte("key")})
+	MessageTest(t, &StorageResp{ReqId: 1, Height: 100, Found: true, Value: []byte("value")})
+	MessageTest(t, &TxProofReq{ReqId: 2, TxHash: common.UINT256_EMPTY})
+	MessageTest(t, &TxProofResp{ReqId: 2})
+}
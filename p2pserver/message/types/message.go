@@ -102,6 +102,45 @@ func WriteMessage(sink *comm.ZeroCopySink, msg Message) {
 	sink.NextBytes(payLen)
 }
 
+//MalformedMsgError is returned by ReadMessage when the peer sends an invalid message,
+//as opposed to a network error
+type MalformedMsgError struct {
+	Err error
+}
+
+func (self *MalformedMsgError) Error() string {
+	return "malformed message: " + self.Err.Error()
+}
+
+//IsMalformedMsgError return whether err is caused by an invalid message
+func IsMalformedMsgError(err error) bool {
+	_, ok := err.(*MalformedMsgError)
+	return ok
+}
+
+//UnknownMsgError is returned by ReadMessage when the message type is not supported, which may be introduced by
+//newer version of peer, the message is skipped without punishing the peer
+type UnknownMsgError struct {
+	CmdType string
+}
+
+func (self *UnknownMsgError) Error() string {
+	return "unsupported cmd type:" + self.CmdType
+}
+
+//IsUnknownMsgError return whether err is caused by a message of unsupported type
+func IsUnknownMsgError(err error) bool {
+	_, ok := err.(*UnknownMsgError)
+	return ok
+}
+
+func malformed(err error) error {
+	if IsMalformedMsgError(err) || IsUnknownMsgError(err) {
+		return err
+	}
+	return &MalformedMsgError{Err: err}
+}
+
 func ReadMessage(reader io.Reader) (Message, uint32, error) {
 	hdr, err := readMessageHeader(reader)
 	if err != nil {
@@ -110,12 +149,12 @@ func ReadMessage(reader io.Reader) (Message, uint32, error) {
 
 	magic := config.DefConfig.P2PNode.NetworkMagic
 	if hdr.Magic != magic {
-		return nil, 0, fmt.Errorf("unmatched magic number %d, expected %d", hdr.Magic, magic)
+		return nil, 0, malformed(fmt.Errorf("unmatched magic number %d, expected %d", hdr.Magic, magic))
 	}
 
 	if hdr.Length > common.MAX_PAYLOAD_LEN {
-		return nil, 0, fmt.Errorf("msg payload length:%d exceed max payload size: %d",
-			hdr.Length, common.MAX_PAYLOAD_LEN)
+		return nil, 0, malformed(fmt.Errorf("msg payload length:%d exceed max payload size: %d",
+			hdr.Length, common.MAX_PAYLOAD_LEN))
 	}
 
 	buf := make([]byte, hdr.Length)
@@ -126,20 +165,27 @@ func ReadMessage(reader io.Reader) (Message, uint32, error) {
 
 	checksum := common.Checksum(buf)
 	if checksum != hdr.Checksum {
-		return nil, 0, fmt.Errorf("message checksum mismatch: %x != %x ", hdr.Checksum, checksum)
+		return nil, 0, malformed(fmt.Errorf("message checksum mismatch: %x != %x ", hdr.Checksum, checksum))
 	}
 
 	cmdType := string(bytes.TrimRight(hdr.CMD[:], string(0)))
+	if cmdType == common.COMPRESSED_TYPE {
+		msg, err := decompressMessage(buf)
+		if err != nil {
+			return nil, 0, malformed(err)
+		}
+		return msg, hdr.Length, nil
+	}
 	msg, err := MakeEmptyMessage(cmdType)
 	if err != nil {
-		return nil, 0, err
+		return nil, hdr.Length, &UnknownMsgError{CmdType: cmdType}
 	}
 
 	// the buf is referenced by msg to avoid reallocation, so can not reused
 	source := comm.NewZeroCopySource(buf)
 	err = msg.Deserialization(source)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, malformed(err)
 	}
 
 	return msg, hdr.Length, nil
@@ -183,6 +229,26 @@ func MakeEmptyMessage(cmdType string) (Message, error) {
 		return &FindNodeResp{}, nil
 	case common.UPDATE_KADID_TYPE:
 		return &UpdatePeerKeyId{}, nil
+	case common.GET_STORAGE_TYPE:
+		return &StorageReq{}, nil
+	case common.STORAGE_TYPE:
+		return &StorageResp{}, nil
+	case common.GET_TX_PROOF_TYPE:
+		return &TxProofReq{}, nil
+	case common.TX_PROOF_TYPE:
+		return &TxProofResp{}, nil
+	case common.ENC_HELLO_TYPE:
+		return &EncHello{}, nil
+	case common.ENC_AUTH_TYPE:
+		return &EncAuth{}, nil
+	case common.COMPACT_BLOCK_TYPE:
+		return &CompactBlock{}, nil
+	case common.GET_BLOCK_TXN_TYPE:
+		return &GetBlockTxn{}, nil
+	case common.BLOCK_TXN_TYPE:
+		return &BlockTxn{}, nil
+	case common.SUBPROTOCOL_TYPE:
+		return &SubProtocolMsg{}, nil
 	default:
 		return nil, errors.New("unsupported cmd type:" + cmdType)
 	}
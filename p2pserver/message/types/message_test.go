@@ -90,3 +90,33 @@ func BenchmarkReadMessageBinaryRead(b *testing.B) {
 		readMessageHeader_old(bytes.NewBuffer(sink.Bytes()))
 	}
 }
+
+func TestReadMalformedMessage(t *testing.T) {
+	sink := common2.NewZeroCopySink(nil)
+	WriteMessage(sink, &Ping{Height: 1})
+	buf := sink.Bytes()
+
+	_, _, err := ReadMessage(bytes.NewReader(buf[:len(buf)-1]))
+	assert.NotNil(t, err)
+	assert.False(t, IsMalformedMsgError(err))
+
+	corrupted := append([]byte{}, buf...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, _, err = ReadMessage(bytes.NewReader(corrupted))
+	assert.True(t, IsMalformedMsgError(err))
+
+	data := []byte{1, 2, 3}
+	sink = common2.NewZeroCopySink(nil)
+	writeMessageHeaderInto(sink, newMessageHeader("unknown", uint32(len(data)), common.Checksum(data)))
+	sink.WriteBytes(data)
+	// unknown message is skipped, and the following message can be read
+	WriteMessage(sink, &Ping{Height: 1})
+	reader := bytes.NewReader(sink.Bytes())
+	_, size, err := ReadMessage(reader)
+	assert.True(t, IsUnknownMsgError(err))
+	assert.False(t, IsMalformedMsgError(err))
+	assert.Equal(t, uint32(len(data)), size)
+	msg, _, err := ReadMessage(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), msg.(*Ping).Height)
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+//ProtocolCap is a sub protocol and its version advertised in version message
+type ProtocolCap struct {
+	Name    string
+	Version uint32
+}
+
+func writeProtocolCaps(sink *comm.ZeroCopySink, caps []ProtocolCap) {
+	sink.WriteVarUint(uint64(len(caps)))
+	for _, c := range caps {
+		sink.WriteString(c.Name)
+		sink.WriteUint32(c.Version)
+	}
+}
+
+func readProtocolCaps(source *comm.ZeroCopySource) ([]ProtocolCap, error) {
+	num, _, irregular, eof := source.NextVarUint()
+	if eof || irregular {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if num > common.MAX_PROTOCOL_CNT {
+		return nil, fmt.Errorf("too many sub protocols: %d", num)
+	}
+	caps := make([]ProtocolCap, 0, num)
+	for i := uint64(0); i < num; i++ {
+		var c ProtocolCap
+		c.Name, _, irregular, eof = source.NextString()
+		if eof || irregular {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if len(c.Name) > common.MAX_PROTOCOL_NAME_LEN {
+			return nil, errors.New("sub protocol name too long")
+		}
+		c.Version, eof = source.NextUint32()
+		if eof {
+			return nil, io.ErrUnexpectedEOF
+		}
+		caps = append(caps, c)
+	}
+	return caps, nil
+}
+
+//SubProtocolMsg carries a message of registered sub protocol, the data is encoded by the sub protocol
+type SubProtocolMsg struct {
+	Protocol string
+	Code     uint16 //message type defined by the sub protocol
+	Data     []byte
+}
+
+// Serialization message payload
+func (this *SubProtocolMsg) Serialization(sink *comm.ZeroCopySink) {
+	sink.WriteString(this.Protocol)
+	sink.WriteUint16(this.Code)
+	sink.WriteVarBytes(this.Data)
+}
+
+// CmdType return this message type
+func (this *SubProtocolMsg) CmdType() string {
+	return common.SUBPROTOCOL_TYPE
+}
+
+// Deserialization message payload
+func (this *SubProtocolMsg) Deserialization(source *comm.ZeroCopySource) error {
+	var eof, irregular bool
+	this.Protocol, _, irregular, eof = source.NextString()
+	if eof || irregular {
+		return io.ErrUnexpectedEOF
+	}
+	if len(this.Protocol) == 0 || len(this.Protocol) > common.MAX_PROTOCOL_NAME_LEN {
+		return fmt.Errorf("invalid sub protocol name length: %d", len(this.Protocol))
+	}
+	this.Code, eof = source.NextUint16()
+	if eof {
+		return io.ErrUnexpectedEOF
+	}
+	this.Data, _, irregular, eof = source.NextVarBytes()
+	if eof || irregular {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	ncomm "github.com/ontio/ontology/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubProtocolMsgSerializationDeserialization(t *testing.T) {
+	msg := &SubProtocolMsg{
+		Protocol: "ontfs",
+		Code:     3,
+		Data:     []byte("file chunk"),
+	}
+	MessageTest(t, msg)
+}
+
+func TestSubProtocolMsgInvalidName(t *testing.T) {
+	for _, name := range []string{"", string(make([]byte, ncomm.MAX_PROTOCOL_NAME_LEN+1))} {
+		msg := &SubProtocolMsg{Protocol: name}
+		sink := common.NewZeroCopySink(nil)
+		msg.Serialization(sink)
+		var decoded SubProtocolMsg
+		assert.NotNil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	}
+}
@@ -39,6 +39,10 @@ type VersionPayload struct {
 	Relay       uint8
 	IsConsensus bool
 	SoftVersion string
+	//optional fields, absent in version of old peers
+	ExternalIP [16]byte      //public ip of sender, zero if unknown
+	ObservedIP [16]byte      //ip of receiver observed by sender
+	Protocols  []ProtocolCap //sub protocols supported by sender
 }
 
 type Version struct {
@@ -59,6 +63,11 @@ func (this *Version) Serialization(sink *comm.ZeroCopySink) {
 	sink.WriteUint8(this.P.Relay)
 	sink.WriteBool(this.P.IsConsensus)
 	sink.WriteString(this.P.SoftVersion)
+	sink.WriteBytes(this.P.ExternalIP[:])
+	sink.WriteBytes(this.P.ObservedIP[:])
+	if len(this.P.Protocols) > 0 {
+		writeProtocolCaps(sink, this.P.Protocols)
+	}
 }
 
 func (this *Version) CmdType() string {
@@ -128,7 +137,25 @@ func (this *Version) Deserialization(source *comm.ZeroCopySource) error {
 	this.P.SoftVersion, _, irregular, eof = source.NextString()
 	if eof || irregular {
 		this.P.SoftVersion = ""
+		return nil
+	}
+
+	// old peers do not send the optional fields
+	if source.Len() < uint64(len(this.P.ExternalIP)+len(this.P.ObservedIP)) {
+		return nil
 	}
+	buf, _ = source.NextBytes(uint64(len(this.P.ExternalIP)))
+	copy(this.P.ExternalIP[:], buf)
+	buf, _ = source.NextBytes(uint64(len(this.P.ObservedIP)))
+	copy(this.P.ObservedIP[:], buf)
 
+	if source.Len() == 0 {
+		return nil
+	}
+	protocols, err := readProtocolCaps(source)
+	if err != nil {
+		return err
+	}
+	this.P.Protocols = protocols
 	return nil
 }
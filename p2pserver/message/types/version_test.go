@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionSerializationDeserialization(t *testing.T) {
+	var msg Version
+	msg.P.Version = 1
+	msg.P.Services = 1
+	msg.P.SyncPort = 20338
+	msg.P.Nonce = 12345
+	msg.P.SoftVersion = "v1.9.0"
+	copy(msg.P.ExternalIP[:], net.ParseIP("203.0.113.7").To16())
+	copy(msg.P.ObservedIP[:], net.ParseIP("192.168.0.1").To16())
+
+	MessageTest(t, &msg)
+}
+
+func TestVersionWithProtocols(t *testing.T) {
+	var msg Version
+	msg.P.SyncPort = 20338
+	msg.P.SoftVersion = "v1.9.0"
+	msg.P.Protocols = []ProtocolCap{{Name: "ontfs", Version: 1}, {Name: "gossip", Version: 2}}
+
+	MessageTest(t, &msg)
+}
+
+//version of old peers has no optional fields
+func TestVersionWithoutOptionalFields(t *testing.T) {
+	var msg Version
+	msg.P.SyncPort = 20338
+	msg.P.SoftVersion = "v1.9.0"
+	copy(msg.P.ExternalIP[:], net.ParseIP("203.0.113.7").To16())
+	sink := common.NewZeroCopySink(nil)
+	msg.Serialization(sink)
+	old := sink.Bytes()[:sink.Size()-32]
+
+	var decoded Version
+	err := decoded.Deserialization(common.NewZeroCopySource(old))
+	assert.Nil(t, err)
+	assert.Equal(t, msg.P.SoftVersion, decoded.P.SoftVersion)
+	assert.Equal(t, [16]byte{}, decoded.P.ExternalIP)
+	assert.Equal(t, [16]byte{}, decoded.P.ObservedIP)
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mock
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/p2pserver/net/netserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitConnectionCnt(t *testing.T, node *netserver.NetServer, cnt uint32) {
+	assert.Eventually(t, func() bool {
+		return node.GetConnectionCnt() == cnt
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestPeerAdmin(t *testing.T) {
+	net := NewNetwork()
+	a := NewReservedNode(nil, net, nil)
+	b := NewReservedNode(nil, net, nil)
+	net.AllowConnect(a.GetID(), b.GetID())
+	go a.Start()
+	go b.Start()
+	bAddr := b.GetHostInfo().Addr
+
+	assert.NotNil(t, a.AddPeer("invalid"))
+	assert.Nil(t, a.AddPeer(bAddr))
+	waitConnectionCnt(t, a, 1)
+	waitConnectionCnt(t, b, 1)
+
+	details := a.GetPeerDetails()
+	assert.Equal(t, 1, len(details))
+	bId := b.GetID()
+	assert.Equal(t, bId.ToHexString(), details[0].Id)
+	assert.Equal(t, bAddr, details[0].ListenAddr)
+	assert.False(t, details[0].Inbound)
+	assert.Equal(t, b.GetHostInfo().SoftVersion, details[0].SoftVersion)
+	details = b.GetPeerDetails()
+	assert.Equal(t, 1, len(details))
+	assert.True(t, details[0].Inbound)
+
+	assert.NotNil(t, a.DisconnectPeer("1.2.3.4:20338"))
+	assert.Nil(t, a.DisconnectPeer(bId.ToHexString()))
+	waitConnectionCnt(t, a, 0)
+
+	assert.Nil(t, a.AddPeer(bAddr))
+	waitConnectionCnt(t, a, 1)
+	assert.Nil(t, a.RemovePeer(bAddr))
+	waitConnectionCnt(t, a, 0)
+
+	// removed peer is neither dialed nor accepted
+	a.Connect(bAddr)
+	b.Connect(a.GetHostInfo().Addr)
+	time.Sleep(time.Millisecond * 500)
+	assert.Equal(t, uint32(0), a.GetConnectionCnt())
+
+	assert.Nil(t, a.AddPeer(bAddr))
+	waitConnectionCnt(t, a, 1)
+}
+
+func TestMaskPeerAdmin(t *testing.T) {
+	net := NewNetwork()
+	node := NewReservedNode(nil, net, nil)
+	go node.Start()
+	time.Sleep(time.Millisecond * 100)
+
+	assert.NotNil(t, node.AddMaskPeer("invalid"))
+	assert.Nil(t, node.AddMaskPeer("10.0.0.1"))
+	assert.Equal(t, []string{"10.0.0.1"}, node.GetReservedConfig().MaskPeers)
+	assert.Nil(t, node.RemoveMaskPeer("10.0.0.1"))
+	assert.NotNil(t, node.RemoveMaskPeer("10.0.0.1"))
+	assert.Equal(t, 0, len(node.GetReservedConfig().MaskPeers))
+
+	// reserved list can not be changed if reserved peers only mode is disabled
+	assert.NotNil(t, node.AddReservedPeer("10.0.0.1"))
+}
+
+func TestReservedPeerAdmin(t *testing.T) {
+	net := NewNetwork()
+	b := NewReservedNode(nil, net, nil)
+	c := NewReservedNode(nil, net, nil)
+	bAddr, cAddr := b.GetHostInfo().Addr, c.GetHostInfo().Addr
+	bIP, cIP := strings.Split(bAddr, ":")[0], strings.Split(cAddr, ":")[0]
+	a := NewReservedNode(nil, net, []string{bIP})
+	net.AllowConnect(a.GetID(), b.GetID())
+	net.AllowConnect(a.GetID(), c.GetID())
+	go a.Start()
+	go b.Start()
+	go c.Start()
+
+	assert.Nil(t, a.AddPeer(bAddr))
+	assert.NotNil(t, a.AddPeer(cAddr))
+	assert.Nil(t, a.AddReservedPeer(cIP))
+	assert.Equal(t, []string{bIP, cIP}, a.GetReservedConfig().ReservedPeers)
+	assert.Nil(t, a.AddPeer(cAddr))
+	waitConnectionCnt(t, a, 2)
+
+	assert.Nil(t, a.RemoveReservedPeer(cIP))
+	assert.NotNil(t, a.RemoveReservedPeer(cIP))
+	waitConnectionCnt(t, a, 1)
+	assert.Equal(t, bAddr, a.GetPeerDetails()[0].ListenAddr)
+}
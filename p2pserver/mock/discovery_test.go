@@ -89,6 +89,18 @@ func (self *DiscoveryProtocol) HandlePeerMessage(ctx *p2p.Context, msg msgTypes.
 	}
 }
 
+func (self *DiscoveryProtocol) AddMaskPeer(ip string) {
+	self.discovery.AddMaskPeer(ip)
+}
+
+func (self *DiscoveryProtocol) RemoveMaskPeer(ip string) bool {
+	return self.discovery.RemoveMaskPeer(ip)
+}
+
+func (self *DiscoveryProtocol) GetMaskPeers() []string {
+	return self.discovery.GetMaskPeers()
+}
+
 func TestDiscoveryNode(t *testing.T) {
 	N := 5
 	net := NewNetwork()
@@ -50,5 +50,8 @@ func NewNode(keyId *common.PeerKeyId, localInfo *peer.PeerInfo, proto p2p.Protoc
 	localInfo.Port = uint16(iport)
 	opt := connect_controller.NewConnCtrlOption().MaxInBoundPerIp(10).
 		MaxInBound(20).MaxOutBound(20).WithDialer(dialer).ReservedOnly(reservedPeers)
-	return netserver.NewCustomNetServer(keyId, localInfo, proto, listener, opt)
+	ns := netserver.NewCustomNetServer(keyId, localInfo, proto, listener, opt)
+	// protocols run with shortened intervals in mock network
+	ns.DisableRateLimit()
+	return ns
 }
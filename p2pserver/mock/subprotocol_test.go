@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mock
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/p2pserver/common"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoMsg struct {
+	from common.PeerId
+	code uint16
+	data string
+}
+
+type echoProtocol struct {
+	sync.Mutex
+	received  []echoMsg
+	connected int
+}
+
+func (self *echoProtocol) Name() string {
+	return "echo"
+}
+
+func (self *echoProtocol) Version() uint32 {
+	return 1
+}
+
+func (self *echoProtocol) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage) {
+	self.Lock()
+	defer self.Unlock()
+	switch m := msg.(type) {
+	case p2p.PeerConnected:
+		if _, ok := m.Info.ProtocolVersion(self.Name()); ok {
+			self.connected++
+		}
+	case p2p.PeerDisConnected:
+		if _, ok := m.Info.ProtocolVersion(self.Name()); ok {
+			self.connected--
+		}
+	}
+}
+
+func (self *echoProtocol) HandleProtocolMessage(ctx *p2p.Context, code uint16, data []byte) {
+	self.Lock()
+	defer self.Unlock()
+	self.received = append(self.received, echoMsg{from: ctx.Sender().GetID(), code: code, data: string(data)})
+}
+
+func (self *echoProtocol) Received() []echoMsg {
+	self.Lock()
+	defer self.Unlock()
+	return append([]echoMsg{}, self.received...)
+}
+
+func TestSubProtocol(t *testing.T) {
+	net := NewNetwork()
+	a := NewReservedNode(nil, net, nil)
+	b := NewReservedNode(nil, net, nil)
+	c := NewReservedNode(nil, net, nil)
+	net.AllowConnect(a.GetID(), b.GetID())
+	net.AllowConnect(a.GetID(), c.GetID())
+
+	protoA, protoB := &echoProtocol{}, &echoProtocol{}
+	senderA, err := a.RegisterProtocol(protoA)
+	assert.Nil(t, err)
+	_, err = b.RegisterProtocol(protoB)
+	assert.Nil(t, err)
+	_, err = b.RegisterProtocol(&echoProtocol{})
+	assert.NotNil(t, err)
+
+	go a.Start()
+	go b.Start()
+	go c.Start()
+	assert.Nil(t, a.AddPeer(b.GetHostInfo().Addr))
+	assert.Nil(t, a.AddPeer(c.GetHostInfo().Addr))
+	waitConnectionCnt(t, a, 2)
+	assert.Eventually(t, func() bool {
+		protoB.Lock()
+		defer protoB.Unlock()
+		return protoB.connected == 1
+	}, time.Second*5, time.Millisecond*10)
+
+	peers := senderA.Peers()
+	assert.Equal(t, 1, len(peers))
+	assert.Equal(t, b.GetID(), peers[0].GetID())
+	assert.NotNil(t, senderA.SendTo(c.GetID(), 1, []byte("hello")))
+
+	senderA.Broadcast(2, []byte("ping"))
+	assert.Nil(t, senderA.SendTo(b.GetID(), 3, []byte("pong")))
+	assert.Eventually(t, func() bool {
+		return len(protoB.Received()) == 2
+	}, time.Second*5, time.Millisecond*10)
+	// messages are handled concurrently
+	assert.ElementsMatch(t, []echoMsg{
+		{from: a.GetID(), code: 2, data: "ping"},
+		{from: a.GetID(), code: 3, data: "pong"},
+	}, protoB.Received())
+
+	time.Sleep(time.Millisecond * 100)
+	_, err = c.RegisterProtocol(&echoProtocol{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+//MIN_ADDR_VOTES is the number of peers which should report the same observed ip before it is advertised
+const MIN_ADDR_VOTES = 3
+
+//ExternalAddr resolves the public address advertised to peers, from the configured address, the port mapping
+//of NAT gateway, or the ip of local node observed by peers in order
+type ExternalAddr struct {
+	lock       sync.Mutex
+	staticIP   net.IP
+	staticPort uint16
+	listenPort uint16
+	mappedIP   net.IP
+	mappedPort uint16
+	votes      map[common.PeerId]string //peer -> observed ip
+}
+
+//NewExternalAddr return the external address of node listening on the port, static is the configured address
+//in format "ip" or "ip:port", empty if not configured
+func NewExternalAddr(static string, listenPort uint16) (*ExternalAddr, error) {
+	addr := &ExternalAddr{
+		listenPort: listenPort,
+		votes:      make(map[common.PeerId]string),
+	}
+	if static == "" {
+		return addr, nil
+	}
+	host := static
+	if h, port, err := net.SplitHostPort(static); err == nil {
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil || p == 0 {
+			return nil, fmt.Errorf("invalid external address port: %s", static)
+		}
+		host = h
+		addr.staticPort = uint16(p)
+	}
+	addr.staticIP = net.ParseIP(host)
+	if addr.staticIP == nil {
+		return nil, fmt.Errorf("invalid external address ip: %s", static)
+	}
+	return addr, nil
+}
+
+//SetMapping updates the port mapping on NAT gateway, ip is nil if the gateway does not report it
+func (self *ExternalAddr) SetMapping(ip net.IP, port uint16) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.mappedIP = ip
+	self.mappedPort = port
+}
+
+//Vote records the ip of local node observed by the peer
+func (self *ExternalAddr) Vote(id common.PeerId, ip net.IP) {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.votes[id] = ip.String()
+}
+
+//RemoveVote removes the vote of disconnected peer
+func (self *ExternalAddr) RemoveVote(id common.PeerId) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	delete(self.votes, id)
+}
+
+//Resolve return the public ip and port advertised to peers, the ip is nil if unknown
+func (self *ExternalAddr) Resolve() (net.IP, uint16) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	port := self.listenPort
+	if self.staticPort != 0 {
+		port = self.staticPort
+	} else if self.mappedPort != 0 {
+		port = self.mappedPort
+	}
+
+	if self.staticIP != nil {
+		return self.staticIP, port
+	}
+	// gateway behind another NAT reports a private ip
+	if IsPublicIP(self.mappedIP) {
+		return self.mappedIP, port
+	}
+	return self.votedIP(), port
+}
+
+//votedIP return the ip reported by most peers if enough peers agree
+func (self *ExternalAddr) votedIP() net.IP {
+	counts := make(map[string]int)
+	best, bestCount := "", 0
+	for _, ip := range self.votes {
+		counts[ip] += 1
+		if counts[ip] > bestCount || (counts[ip] == bestCount && ip < best) {
+			best, bestCount = ip, counts[ip]
+		}
+	}
+	if bestCount < MIN_ADDR_VOTES {
+		return nil
+	}
+	return net.ParseIP(best)
+}
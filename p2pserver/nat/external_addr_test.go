@@ -0,0 +1,100 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalAddrVotes(t *testing.T) {
+	addr, err := NewExternalAddr("", 20338)
+	assert.Nil(t, err)
+	ip, port := addr.Resolve()
+	assert.Nil(t, ip)
+	assert.Equal(t, uint16(20338), port)
+
+	public := net.ParseIP("203.0.113.7")
+	for i := uint64(1); i < MIN_ADDR_VOTES; i++ {
+		addr.Vote(common.PseudoPeerIdFromUint64(i), public)
+	}
+	addr.Vote(common.PseudoPeerIdFromUint64(100), net.ParseIP("198.51.100.1"))
+	addr.Vote(common.PseudoPeerIdFromUint64(101), net.ParseIP("127.0.0.1"))
+	ip, _ = addr.Resolve()
+	assert.Nil(t, ip)
+
+	addr.Vote(common.PseudoPeerIdFromUint64(MIN_ADDR_VOTES), public)
+	ip, _ = addr.Resolve()
+	assert.Equal(t, public.String(), ip.String())
+
+	addr.RemoveVote(common.PseudoPeerIdFromUint64(1))
+	ip, _ = addr.Resolve()
+	assert.Nil(t, ip)
+}
+
+func TestExternalAddrPriority(t *testing.T) {
+	addr, err := NewExternalAddr("", 20338)
+	assert.Nil(t, err)
+	for i := uint64(0); i < MIN_ADDR_VOTES; i++ {
+		addr.Vote(common.PseudoPeerIdFromUint64(i), net.ParseIP("203.0.113.7"))
+	}
+
+	// gateway behind another NAT reports private ip, only the mapped port is used
+	addr.SetMapping(net.ParseIP("192.168.1.1"), 30338)
+	ip, port := addr.Resolve()
+	assert.Equal(t, "203.0.113.7", ip.String())
+	assert.Equal(t, uint16(30338), port)
+
+	addr.SetMapping(net.ParseIP("198.51.100.9"), 30338)
+	ip, port = addr.Resolve()
+	assert.Equal(t, "198.51.100.9", ip.String())
+	assert.Equal(t, uint16(30338), port)
+
+	static, err := NewExternalAddr("192.0.2.1:40338", 20338)
+	assert.Nil(t, err)
+	static.SetMapping(net.ParseIP("198.51.100.9"), 30338)
+	ip, port = static.Resolve()
+	assert.Equal(t, "192.0.2.1", ip.String())
+	assert.Equal(t, uint16(40338), port)
+
+	static, err = NewExternalAddr("192.0.2.1", 20338)
+	assert.Nil(t, err)
+	ip, port = static.Resolve()
+	assert.Equal(t, "192.0.2.1", ip.String())
+	assert.Equal(t, uint16(20338), port)
+
+	for _, invalid := range []string{"host", "192.0.2.1:0", "192.0.2.1:port", ":20338"} {
+		_, err = NewExternalAddr(invalid, 20338)
+		assert.NotNil(t, err, invalid)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for _, ip := range []string{"203.0.113.7", "8.8.8.8", "2001:db8::1"} {
+		assert.True(t, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"10.0.0.1", "172.16.0.1", "192.168.1.1", "100.64.0.1", "127.0.0.1", "0.0.0.0",
+		"169.254.1.1", "fd00::1", "::1"} {
+		assert.False(t, IsPublicIP(net.ParseIP(ip)), ip)
+	}
+	assert.False(t, IsPublicIP(nil))
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common/log"
+)
+
+const (
+	MAPPING_LIFETIME = 20 * time.Minute //lifetime of port mapping requested from gateway
+	MAPPING_REFRESH  = 15 * time.Minute //interval of renewing port mapping before it expires
+	MAPPING_DESC     = "ontology p2p"   //description of port mapping shown by gateway
+)
+
+//Interface maps ports of local node on the NAT gateway
+type Interface interface {
+	//ExternalIP return the public ip of gateway
+	ExternalIP() (net.IP, error)
+	//AddMapping maps the external port to the internal port, the actually mapped external port is returned
+	AddMapping(protocol string, extPort, intPort uint16, desc string, lifetime time.Duration) (uint16, error)
+	DeleteMapping(protocol string, extPort, intPort uint16) error
+	String() string
+}
+
+//Parse return the NAT traversal mechanism of spec:
+//	"none" or ""    no port mapping
+//	"any"           try UPnP and NAT-PMP
+//	"upnp"          UPnP only
+//	"pmp"           NAT-PMP with auto detected gateway
+//	"pmp:<ip>"      NAT-PMP with the gateway ip
+func Parse(spec string) (Interface, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	mech := strings.ToLower(parts[0])
+	switch mech {
+	case "", "none", "off":
+		return nil, nil
+	case "any", "auto", "on":
+		return &autoDisc{what: "UPnP or NAT-PMP", discover: func() Interface {
+			if nat := discoverUPnP(); nat != nil {
+				return nat
+			}
+			return discoverPMP()
+		}}, nil
+	case "upnp":
+		return &autoDisc{what: "UPnP", discover: func() Interface {
+			if nat := discoverUPnP(); nat != nil {
+				return nat
+			}
+			return nil
+		}}, nil
+	case "pmp", "natpmp", "nat-pmp":
+		if len(parts) == 1 {
+			return &autoDisc{what: "NAT-PMP", discover: func() Interface {
+				if nat := discoverPMP(); nat != nil {
+					return nat
+				}
+				return nil
+			}}, nil
+		}
+		ip := net.ParseIP(parts[1])
+		if ip == nil {
+			return nil, fmt.Errorf("invalid NAT-PMP gateway ip: %s", parts[1])
+		}
+		return NewPMP(&net.UDPAddr{IP: ip, Port: NATPMP_PORT}), nil
+	default:
+		return nil, fmt.Errorf("unknown NAT mechanism: %s", parts[0])
+	}
+}
+
+//autoDisc discovers the gateway at the first use, since discovery takes time
+type autoDisc struct {
+	what     string
+	discover func() Interface
+	once     sync.Once
+	found    Interface
+}
+
+func (self *autoDisc) wait() (Interface, error) {
+	self.once.Do(func() {
+		self.found = self.discover()
+	})
+	if self.found == nil {
+		return nil, fmt.Errorf("no %s gateway found", self.what)
+	}
+	return self.found, nil
+}
+
+func (self *autoDisc) ExternalIP() (net.IP, error) {
+	nat, err := self.wait()
+	if err != nil {
+		return nil, err
+	}
+	return nat.ExternalIP()
+}
+
+func (self *autoDisc) AddMapping(protocol string, extPort, intPort uint16, desc string,
+	lifetime time.Duration) (uint16, error) {
+	nat, err := self.wait()
+	if err != nil {
+		return 0, err
+	}
+	return nat.AddMapping(protocol, extPort, intPort, desc, lifetime)
+}
+
+func (self *autoDisc) DeleteMapping(protocol string, extPort, intPort uint16) error {
+	nat, err := self.wait()
+	if err != nil {
+		return err
+	}
+	return nat.DeleteMapping(protocol, extPort, intPort)
+}
+
+func (self *autoDisc) String() string {
+	if self.found != nil {
+		return self.found.String()
+	}
+	return self.what
+}
+
+//Map adds the port mapping on gateway and keeps renewing it until quit is closed, then the mapping is deleted.
+//The public ip and mapped port are reported to onMapped every time the mapping is renewed
+func Map(nat Interface, quit chan bool, protocol string, port uint16, onMapped func(ip net.IP, port uint16)) {
+	refresh := time.NewTimer(0)
+	var mapped uint16
+	defer func() {
+		refresh.Stop()
+		if mapped != 0 {
+			if err := nat.DeleteMapping(protocol, mapped, port); err != nil {
+				log.Debugf("[nat]failed to delete port mapping %d on %s: %s", mapped, nat, err)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-refresh.C:
+			extPort, err := nat.AddMapping(protocol, port, port, MAPPING_DESC, MAPPING_LIFETIME)
+			if err != nil {
+				log.Warnf("[nat]failed to map port %d on %s: %s", port, nat, err)
+			} else {
+				if mapped != extPort {
+					log.Infof("[nat]mapped %s port %d to external port %d on %s", protocol, port, extPort, nat)
+				}
+				mapped = extPort
+				ip, err := nat.ExternalIP()
+				if err != nil {
+					log.Debugf("[nat]failed to get external ip from %s: %s", nat, err)
+				}
+				onMapped(ip, extPort)
+			}
+			refresh.Reset(MAPPING_REFRESH)
+		case <-quit:
+			return
+		}
+	}
+}
+
+//IsPublicIP reports whether the ip is reachable from internet
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || !ip.IsGlobalUnicast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return !(ip4[0] == 10 ||
+			(ip4[0] == 172 && ip4[1]&0xf0 == 16) ||
+			(ip4[0] == 192 && ip4[1] == 168) ||
+			(ip4[0] == 100 && ip4[1]&0xc0 == 64)) //carrier-grade NAT
+	}
+	return ip[0]&0xfe != 0xfc //unique local address
+}
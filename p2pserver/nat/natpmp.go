@@ -0,0 +1,182 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	NATPMP_PORT             = 5351 //port of NAT-PMP service on gateway
+	NATPMP_RETRIES          = 4    //number of request retransmissions
+	NATPMP_INITIAL_TIMEOUT  = 250 * time.Millisecond
+	NATPMP_DISCOVER_TIMEOUT = 2 * time.Second
+)
+
+const (
+	natpmpOpExternalAddr = 0
+	natpmpOpMapUDP       = 1
+	natpmpOpMapTCP       = 2
+)
+
+//pmp is the NAT-PMP(RFC 6886) client of a gateway
+type pmp struct {
+	gateway *net.UDPAddr
+	timeout time.Duration
+}
+
+//NewPMP return the NAT-PMP client of gateway
+func NewPMP(gateway *net.UDPAddr) Interface {
+	return &pmp{gateway: gateway, timeout: NATPMP_INITIAL_TIMEOUT}
+}
+
+func (self *pmp) String() string {
+	return "NAT-PMP(" + self.gateway.String() + ")"
+}
+
+//request sends the request to gateway and waits the response, the request is retransmitted with doubled timeout
+func (self *pmp) request(req []byte, respLen int) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, self.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := self.timeout
+	buf := make([]byte, 16)
+	for i := 0; i < NATPMP_RETRIES; i++ {
+		if _, err = conn.Write(req); err != nil {
+			return nil, err
+		}
+		if err = conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(buf)
+		if err != nil {
+			if e, ok := err.(net.Error); ok && e.Timeout() {
+				timeout *= 2
+				continue
+			}
+			return nil, err
+		}
+		if n < respLen || buf[0] != 0 || buf[1] != req[1]|0x80 {
+			return nil, errors.New("invalid NAT-PMP response")
+		}
+		if code := binary.BigEndian.Uint16(buf[2:4]); code != 0 {
+			return nil, fmt.Errorf("NAT-PMP request failed with result code %d", code)
+		}
+		return buf[:n], nil
+	}
+	return nil, errors.New("NAT-PMP gateway no response")
+}
+
+func (self *pmp) ExternalIP() (net.IP, error) {
+	resp, err := self.request([]byte{0, natpmpOpExternalAddr}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+func (self *pmp) mapPort(protocol string, extPort, intPort uint16, lifetime time.Duration) (uint16, error) {
+	req := make([]byte, 12)
+	switch strings.ToUpper(protocol) {
+	case "TCP":
+		req[1] = natpmpOpMapTCP
+	case "UDP":
+		req[1] = natpmpOpMapUDP
+	default:
+		return 0, fmt.Errorf("unsupported protocol %s", protocol)
+	}
+	binary.BigEndian.PutUint16(req[4:6], intPort)
+	binary.BigEndian.PutUint16(req[6:8], extPort)
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+	resp, err := self.request(req, 16)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(resp[10:12]), nil
+}
+
+func (self *pmp) AddMapping(protocol string, extPort, intPort uint16, desc string,
+	lifetime time.Duration) (uint16, error) {
+	return self.mapPort(protocol, extPort, intPort, lifetime)
+}
+
+func (self *pmp) DeleteMapping(protocol string, extPort, intPort uint16) error {
+	// mapping is deleted by requesting zero lifetime and zero external port
+	_, err := self.mapPort(protocol, 0, intPort, 0)
+	return err
+}
+
+//discoverPMP probes the potential gateways and returns the first one responding
+func discoverPMP() Interface {
+	gateways := potentialGateways()
+	found := make(chan Interface, len(gateways))
+	for _, ip := range gateways {
+		go func(ip net.IP) {
+			nat := NewPMP(&net.UDPAddr{IP: ip, Port: NATPMP_PORT})
+			if _, err := nat.ExternalIP(); err != nil {
+				found <- nil
+				return
+			}
+			found <- nat
+		}(ip)
+	}
+	timeout := time.After(NATPMP_DISCOVER_TIMEOUT)
+	for range gateways {
+		select {
+		case nat := <-found:
+			if nat != nil {
+				return nat
+			}
+		case <-timeout:
+			return nil
+		}
+	}
+	return nil
+}
+
+//potentialGateways guesses the gateway is the first address of private networks of local interfaces
+func potentialGateways() []net.IP {
+	var gateways []net.IP
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipnet.IP.To4()
+		if ip == nil || ip.IsLoopback() || IsPublicIP(ip) {
+			continue
+		}
+		gateway := ip.Mask(ipnet.Mask)
+		gateway[3] |= 0x01
+		gateways = append(gateways, gateway)
+	}
+	return gateways
+}
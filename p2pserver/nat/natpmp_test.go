@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//fakePMPGateway serves NAT-PMP requests, ports are mapped to port+1000
+type fakePMPGateway struct {
+	conn     *net.UDPConn
+	lock     sync.Mutex
+	mappings map[uint16]uint16 //internal port -> external port
+	result   uint16
+}
+
+func newFakePMPGateway(t *testing.T) *fakePMPGateway {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	gw := &fakePMPGateway{conn: conn, mappings: make(map[uint16]uint16)}
+	go gw.serve()
+	return gw
+}
+
+func (self *fakePMPGateway) serve() {
+	buf := make([]byte, 64)
+	for {
+		n, addr, err := self.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if n < 2 {
+			continue
+		}
+		self.lock.Lock()
+		resp := make([]byte, 16)
+		resp[1] = buf[1] | 0x80
+		binary.BigEndian.PutUint16(resp[2:4], self.result)
+		switch buf[1] {
+		case natpmpOpExternalAddr:
+			copy(resp[8:12], net.IPv4(203, 0, 113, 7).To4())
+			resp = resp[:12]
+		case natpmpOpMapTCP, natpmpOpMapUDP:
+			intPort := binary.BigEndian.Uint16(buf[4:6])
+			lifetime := binary.BigEndian.Uint32(buf[8:12])
+			extPort := intPort + 1000
+			if lifetime == 0 {
+				delete(self.mappings, intPort)
+				extPort = 0
+			} else {
+				self.mappings[intPort] = extPort
+			}
+			copy(resp[8:10], buf[4:6])
+			binary.BigEndian.PutUint16(resp[10:12], extPort)
+			copy(resp[12:16], buf[8:12])
+		}
+		self.lock.Unlock()
+		_, _ = self.conn.WriteToUDP(resp, addr)
+	}
+}
+
+func (self *fakePMPGateway) mapped(intPort uint16) (uint16, bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	port, ok := self.mappings[intPort]
+	return port, ok
+}
+
+func (self *fakePMPGateway) addr() *net.UDPAddr {
+	return self.conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestPMP(t *testing.T) {
+	gw := newFakePMPGateway(t)
+	defer gw.conn.Close()
+	nat := NewPMP(gw.addr())
+
+	ip, err := nat.ExternalIP()
+	assert.Nil(t, err)
+	assert.Equal(t, "203.0.113.7", ip.String())
+
+	port, err := nat.AddMapping("tcp", 20338, 20338, MAPPING_DESC, MAPPING_LIFETIME)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(21338), port)
+	mapped, _ := gw.mapped(20338)
+	assert.Equal(t, uint16(21338), mapped)
+
+	assert.Nil(t, nat.DeleteMapping("tcp", port, 20338))
+	_, ok := gw.mapped(20338)
+	assert.False(t, ok)
+
+	gw.lock.Lock()
+	gw.result = 3
+	gw.lock.Unlock()
+	_, err = nat.AddMapping("tcp", 20338, 20338, MAPPING_DESC, MAPPING_LIFETIME)
+	assert.NotNil(t, err)
+}
+
+func TestPMPNoResponse(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	defer conn.Close()
+	nat := &pmp{gateway: conn.LocalAddr().(*net.UDPAddr), timeout: 10 * time.Millisecond}
+	_, err = nat.ExternalIP()
+	assert.NotNil(t, err)
+}
+
+func TestParse(t *testing.T) {
+	for _, spec := range []string{"", "none"} {
+		nat, err := Parse(spec)
+		assert.Nil(t, err)
+		assert.Nil(t, nat)
+	}
+	for _, spec := range []string{"any", "upnp", "pmp", "pmp:192.168.1.1"} {
+		nat, err := Parse(spec)
+		assert.Nil(t, err)
+		assert.NotNil(t, nat)
+	}
+	nat, err := Parse("pmp:192.168.1.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "NAT-PMP(192.168.1.1:5351)", nat.String())
+	_, err = Parse("pmp:gateway")
+	assert.NotNil(t, err)
+	_, err = Parse("stun")
+	assert.NotNil(t, err)
+}
+
+func TestMap(t *testing.T) {
+	gw := newFakePMPGateway(t)
+	defer gw.conn.Close()
+
+	quit := make(chan bool)
+	mapped := make(chan uint16, 1)
+	done := make(chan bool)
+	go func() {
+		Map(NewPMP(gw.addr()), quit, "tcp", 20338, func(ip net.IP, port uint16) {
+			assert.Equal(t, "203.0.113.7", ip.String())
+			mapped <- port
+		})
+		close(done)
+	}()
+	select {
+	case port := <-mapped:
+		assert.Equal(t, uint16(21338), port)
+	case <-time.After(5 * time.Second):
+		t.Fatal("port not mapped")
+	}
+	close(quit)
+	<-done
+	// mapping is deleted after quit
+	_, ok := gw.mapped(20338)
+	assert.False(t, ok)
+}
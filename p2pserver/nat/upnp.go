@@ -0,0 +1,299 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	UPNP_DISCOVER_TIMEOUT = 3 * time.Second
+	UPNP_REQUEST_TIMEOUT  = 5 * time.Second
+	SSDP_ADDR             = "239.255.255.250:1900"
+)
+
+//wan connection services supporting port mapping, in preferred order
+var upnpServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+//upnp is the client of WAN connection service of internet gateway device
+type upnp struct {
+	controlURL  string
+	serviceType string
+	localIP     net.IP //ip of local node in the gateway's network
+	client      *http.Client
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+type upnpDevice struct {
+	Services []upnpService `xml:"serviceList>service"`
+	Devices  []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+func (self *upnpDevice) findService(serviceType string) *upnpService {
+	for i := range self.Services {
+		if self.Services[i].ServiceType == serviceType {
+			return &self.Services[i]
+		}
+	}
+	for i := range self.Devices {
+		if s := self.Devices[i].findService(serviceType); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+//NewUPnP return the UPnP client of gateway described by the device description at location
+func NewUPnP(location string) (Interface, error) {
+	client := &http.Client{Timeout: UPNP_REQUEST_TIMEOUT}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get device description failed: %s", resp.Status)
+	}
+	var root upnpRoot
+	if err = xml.NewDecoder(resp.Body).Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid device description: %s", err)
+	}
+
+	var service *upnpService
+	for _, serviceType := range upnpServiceTypes {
+		if service = root.Device.findService(serviceType); service != nil {
+			break
+		}
+	}
+	if service == nil {
+		return nil, errors.New("no WAN connection service in device")
+	}
+	base := location
+	if root.URLBase != "" {
+		base = root.URLBase
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	controlURL, err := baseURL.Parse(service.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+	localIP, err := localIPTo(controlURL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &upnp{
+		controlURL:  controlURL.String(),
+		serviceType: service.ServiceType,
+		localIP:     localIP,
+		client:      client,
+	}, nil
+}
+
+//localIPTo return the local ip used to reach the host
+func localIPTo(host string) (net.IP, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "80")
+	}
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func (self *upnp) String() string {
+	return "UPnP(" + self.controlURL + ")"
+}
+
+type soapArg struct {
+	name, value string
+}
+
+//call invokes the soap action of WAN connection service and returns the response
+func (self *upnp) call(action string, args []soapArg) ([]byte, error) {
+	body := bytes.NewBufferString(`<?xml version="1.0"?>` +
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" ` +
+		`s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(body, `<u:%s xmlns:u="%s">`, action, self.serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(body, "<%s>", arg.name)
+		if err := xml.EscapeText(body, []byte(arg.value)); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(body, "</%s>", arg.name)
+	}
+	fmt.Fprintf(body, "</u:%s></s:Body></s:Envelope>", action)
+
+	req, err := http.NewRequest("POST", self.controlURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s#%s"`, self.serviceType, action))
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		desc, _ := xmlValue(data, "errorDescription")
+		return nil, fmt.Errorf("UPnP action %s failed: %s %s", action, resp.Status, desc)
+	}
+	return data, nil
+}
+
+//xmlValue return the text of first element with the name in data
+func xmlValue(data []byte, name string) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("element %s not found", name)
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			var value string
+			if err = decoder.DecodeElement(&value, &start); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(value), nil
+		}
+	}
+}
+
+func (self *upnp) ExternalIP() (net.IP, error) {
+	resp, err := self.call("GetExternalIPAddress", nil)
+	if err != nil {
+		return nil, err
+	}
+	value, err := xmlValue(resp, "NewExternalIPAddress")
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid external ip: %s", value)
+	}
+	return ip, nil
+}
+
+func (self *upnp) AddMapping(protocol string, extPort, intPort uint16, desc string,
+	lifetime time.Duration) (uint16, error) {
+	_, err := self.call("AddPortMapping", []soapArg{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(int(extPort))},
+		{"NewProtocol", strings.ToUpper(protocol)},
+		{"NewInternalPort", strconv.Itoa(int(intPort))},
+		{"NewInternalClient", self.localIP.String()},
+		{"NewEnabled", "1"},
+		{"NewPortMappingDescription", desc},
+		{"NewLeaseDuration", strconv.Itoa(int(lifetime / time.Second))},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return extPort, nil
+}
+
+func (self *upnp) DeleteMapping(protocol string, extPort, intPort uint16) error {
+	_, err := self.call("DeletePortMapping", []soapArg{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(int(extPort))},
+		{"NewProtocol", strings.ToUpper(protocol)},
+	})
+	return err
+}
+
+//discoverUPnP searches internet gateway devices by SSDP and returns the first one usable
+func discoverUPnP() Interface {
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+	ssdp, err := net.ResolveUDPAddr("udp4", SSDP_ADDR)
+	if err != nil {
+		return nil
+	}
+	req := "M-SEARCH * HTTP/1.1\r\n" +
+		"HOST: " + SSDP_ADDR + "\r\n" +
+		"ST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\n" +
+		"MAN: \"ssdp:discover\"\r\n" +
+		"MX: 2\r\n\r\n"
+	if _, err = conn.WriteTo([]byte(req), ssdp); err != nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(UPNP_DISCOVER_TIMEOUT)
+	if err = conn.SetReadDeadline(deadline); err != nil {
+		return nil
+	}
+	tried := make(map[string]bool)
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		location := resp.Header.Get("Location")
+		if location == "" || tried[location] {
+			continue
+		}
+		tried[location] = true
+		if nat, err := NewUPnP(location); err == nil {
+			return nat
+		}
+	}
+}
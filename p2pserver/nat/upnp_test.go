@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fakeDeviceDesc = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+<device>
+  <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+  <deviceList>
+    <device>
+      <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+      <deviceList>
+        <device>
+          <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+          <serviceList>
+            <service>
+              <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+              <controlURL>/ctl/IPConn</controlURL>
+            </service>
+          </serviceList>
+        </device>
+      </deviceList>
+    </device>
+  </deviceList>
+</device>
+</root>`
+
+//fakeIGD serves the device description and WANIPConnection actions
+type fakeIGD struct {
+	lock    sync.Mutex
+	actions []string
+	bodies  []string
+}
+
+func (self *fakeIGD) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/rootDesc.xml":
+		fmt.Fprint(w, fakeDeviceDesc)
+	case "/ctl/IPConn":
+		body, _ := ioutil.ReadAll(r.Body)
+		action := r.Header.Get("SOAPAction")
+		action = strings.Trim(action[strings.Index(action, "#")+1:], `"`)
+		self.lock.Lock()
+		self.actions = append(self.actions, action)
+		self.bodies = append(self.bodies, string(body))
+		self.lock.Unlock()
+		switch action {
+		case "GetExternalIPAddress":
+			fmt.Fprint(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body>`+
+				`<u:GetExternalIPAddressResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">`+
+				`<NewExternalIPAddress>198.51.100.9</NewExternalIPAddress>`+
+				`</u:GetExternalIPAddressResponse></s:Body></s:Envelope>`)
+		case "AddPortMapping", "DeletePortMapping":
+			if strings.Contains(string(body), "<NewExternalPort>1</NewExternalPort>") {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault>`+
+					`<detail><UPnPError><errorCode>718</errorCode><errorDescription>ConflictInMappingEntry`+
+					`</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`)
+				return
+			}
+			fmt.Fprint(w, `<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body></s:Body></s:Envelope>`)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestUPnP(t *testing.T) {
+	igd := &fakeIGD{}
+	server := httptest.NewServer(igd)
+	defer server.Close()
+
+	nat, err := NewUPnP(server.URL + "/rootDesc.xml")
+	assert.Nil(t, err)
+	assert.Equal(t, "UPnP("+server.URL+"/ctl/IPConn)", nat.String())
+
+	ip, err := nat.ExternalIP()
+	assert.Nil(t, err)
+	assert.Equal(t, "198.51.100.9", ip.String())
+
+	port, err := nat.AddMapping("tcp", 20338, 20338, MAPPING_DESC, MAPPING_LIFETIME)
+	assert.Nil(t, err)
+	assert.Equal(t, uint16(20338), port)
+	assert.Nil(t, nat.DeleteMapping("tcp", 20338, 20338))
+	assert.Equal(t, []string{"GetExternalIPAddress", "AddPortMapping", "DeletePortMapping"}, igd.actions)
+	assert.Contains(t, igd.bodies[1], "<NewInternalClient>127.0.0.1</NewInternalClient>")
+	assert.Contains(t, igd.bodies[1], "<NewProtocol>TCP</NewProtocol>")
+	assert.Contains(t, igd.bodies[1], "<NewLeaseDuration>1200</NewLeaseDuration>")
+
+	_, err = nat.AddMapping("tcp", 1, 1, MAPPING_DESC, MAPPING_LIFETIME)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "ConflictInMappingEntry")
+}
+
+func TestUPnPNoWANService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<root><device><serviceList></serviceList></device></root>`)
+	}))
+	defer server.Close()
+	_, err := NewUPnP(server.URL + "/rootDesc.xml")
+	assert.NotNil(t, err)
+}
@@ -90,11 +90,20 @@ func (this *NbrPeers) Broadcast(msg types.Message) {
 	sink := comm.NewZeroCopySink(nil)
 	types.WriteMessage(sink, msg)
 
+	// compress once for all peers supporting compression
+	var compressed []byte
 	this.RLock()
 	defer this.RUnlock()
 	for _, node := range this.List {
 		if node.Peer.GetRelay() {
-			go node.Peer.SendRaw(msg.CmdType(), sink.Bytes())
+			raw := sink.Bytes()
+			if node.Peer.Info.Compress {
+				if compressed == nil {
+					compressed = types.CompressMessage(raw)
+				}
+				raw = compressed
+			}
+			go node.Peer.SendRaw(msg.CmdType(), raw)
 		}
 	}
 }
@@ -146,6 +155,9 @@ func (this *NbrPeers) GetNeighborAddrs() []common.PeerAddr {
 		p := node.Peer
 		var addr common.PeerAddr
 		addr.IpAddr, _ = p.GetAddr16()
+		if p.Info.ExternalIP != nil {
+			copy(addr.IpAddr[:], p.Info.ExternalIP.To16())
+		}
 		addr.Time = p.GetTimeStamp()
 		addr.Services = p.GetServices()
 		addr.Port = p.GetPort()
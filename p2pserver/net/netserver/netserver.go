@@ -20,16 +20,21 @@ package netserver
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"sync"
 	"time"
 
+	common2 "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/p2pserver/common"
 	"github.com/ontio/ontology/p2pserver/connect_controller"
 	"github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/nat"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
 	"github.com/ontio/ontology/p2pserver/peer"
+	"github.com/ontio/ontology/p2pserver/peer_score"
 )
 
 //NewNetServer return the net object in p2p
@@ -39,6 +44,9 @@ func NewNetServer(protocol p2p.Protocol, conf *config.P2PNodeConfig) (*NetServer
 		base:       &peer.PeerInfo{},
 		Np:         NewNbrPeers(),
 		protocol:   protocol,
+		score:      peer_score.NewPeerScore(common.BANNED_FILE_NAME),
+		removed:    make(map[string]bool),
+		subProtos:  p2p.NewRegistry(),
 		stopRecvCh: make(chan bool),
 	}
 
@@ -57,9 +65,12 @@ func NewCustomNetServer(id *common.PeerKeyId, info *peer.PeerInfo, proto p2p.Pro
 		protocol:   proto,
 		NetChan:    make(chan *types.MsgPayload, common.CHAN_CAPABILITY),
 		Np:         NewNbrPeers(),
+		score:      peer_score.NewPeerScore(""),
+		removed:    make(map[string]bool),
+		subProtos:  p2p.NewRegistry(),
 		stopRecvCh: make(chan bool),
 	}
-	n.connCtrl = connect_controller.NewConnectController(info, id, opt)
+	n.connCtrl = connect_controller.NewConnectController(info, id, opt.WithBanChecker(n.score))
 
 	return n
 }
@@ -73,6 +84,13 @@ type NetServer struct {
 	Np       *NbrPeers
 
 	connCtrl *connect_controller.ConnectController
+	score    *peer_score.PeerScore
+	nat      nat.Interface // port mapping on NAT gateway, nil if disabled
+
+	removedLock sync.Mutex
+	removed     map[string]bool // addresses removed by admin, not connected automatically
+
+	subProtos *p2p.Registry
 
 	stopRecvCh chan bool // To stop sync channel
 }
@@ -90,7 +108,17 @@ func (this *NetServer) processMessage(channel chan *types.MsgPayload,
 					continue
 				}
 
+				if !this.score.Allow(data.Id, data.Payload.CmdType()) {
+					log.Debugf("[p2p]peer %s exceeds rate limit of %s msg", data.Addr, data.Payload.CmdType())
+					this.AdjustPeerScore(data.Id, peer_score.SCORE_RATE_LIMITED, "rate limit exceeded")
+					continue
+				}
+
 				ctx := p2p.NewContext(sender, this, data.PayloadSize)
+				if msg, ok := data.Payload.(*types.SubProtocolMsg); ok {
+					go this.handleSubProtocolMsg(ctx, msg)
+					continue
+				}
 				go this.protocol.HandlePeerMessage(ctx, data.Payload)
 			}
 		case <-stopCh:
@@ -110,14 +138,21 @@ func (this *NetServer) init(conf *config.P2PNodeConfig) error {
 		return errors.New("[p2p]invalid link port")
 	}
 
-	this.base = peer.NewPeerInfo(keyId.Id, common.PROTOCOL_VERSION, common.SERVICE_NODE, true, httpInfo,
+	var services uint64 = common.SERVICE_NODE
+	if config.DefConfig.Common.LightMode {
+		services = common.LIGHT_NODE
+	}
+	this.base = peer.NewPeerInfo(keyId.Id, common.PROTOCOL_VERSION, services, true, httpInfo,
 		nodePort, 0, config.Version, "")
+	this.base.Compress = !conf.DisableCompression
+	this.base.CompactBlock = !config.DefConfig.Common.LightMode
+	this.base.TxInv = !config.DefConfig.Common.LightMode
 
 	option, err := connect_controller.ConnCtrlOptionFromConfig(conf)
 	if err != nil {
 		return err
 	}
-	this.connCtrl = connect_controller.NewConnectController(this.base, keyId, option)
+	this.connCtrl = connect_controller.NewConnectController(this.base, keyId, option.WithBanChecker(this.score))
 
 	syncPort := this.base.Port
 	if syncPort == 0 {
@@ -129,6 +164,10 @@ func (this *NetServer) init(conf *config.P2PNodeConfig) error {
 		log.Error("[p2p]failed to create sync listener")
 		return errors.New("[p2p]failed to create sync listener")
 	}
+	this.nat, err = nat.Parse(conf.NAT)
+	if err != nil {
+		return err
+	}
 
 	log.Infof("[p2p]init peer ID to %s", this.base.Id.ToHexString())
 
@@ -137,9 +176,13 @@ func (this *NetServer) init(conf *config.P2PNodeConfig) error {
 
 //InitListen start listening on the config port
 func (this *NetServer) Start() error {
-	this.protocol.HandleSystemMessage(this, p2p.NetworkStart{})
+	this.subProtos.Freeze()
+	this.notifySystemMessage(p2p.NetworkStart{})
 	go this.startNetAccept(this.listener)
 	log.Infof("[p2p]start listen on sync port %d", this.base.Port)
+	if this.nat != nil {
+		go nat.Map(this.nat, this.stopRecvCh, "TCP", this.base.Port, this.connCtrl.ExternalAddr().SetMapping)
+	}
 	go this.processMessage(this.NetChan, this.stopRecvCh)
 
 	log.Debug("[p2p]MessageRouter start to parse p2p message...")
@@ -200,6 +243,20 @@ func (this *NetServer) GetNeighbors() []*peer.Peer {
 
 //Broadcast called by actor, broadcast msg
 func (this *NetServer) Broadcast(msg types.Message) {
+	// transactions are announced to peers if the protocol supports
+	if trn, ok := msg.(*types.Trn); ok {
+		if relayer, ok := this.protocol.(p2p.TransactionRelayer); ok {
+			relayer.RelayTransaction(trn.Txn)
+			return
+		}
+	}
+	// new blocks announced by consensus are relayed as compact blocks to peers supporting it
+	if inv, ok := msg.(*types.Inv); ok && common2.InventoryType(inv.P.InvType) == common2.BLOCK {
+		if relayer, ok := this.protocol.(p2p.BlockRelayer); ok {
+			relayer.RelayBlocks(inv.P.Blk)
+			return
+		}
+	}
 	this.Np.Broadcast(msg)
 }
 
@@ -214,6 +271,10 @@ func (this *NetServer) Send(p *peer.Peer, msg types.Message) error {
 
 //Connect used to connect net address under sync or cons mode
 func (this *NetServer) Connect(addr string) {
+	if this.isRemoved(addr) {
+		log.Debugf("[p2p]skip connecting to removed peer %s", addr)
+		return
+	}
 	err := this.connect(addr)
 	if err != nil {
 		log.Debugf("%s connecting to %s failed, err: %s", this.base.Addr, addr, err)
@@ -227,21 +288,51 @@ func (this *NetServer) connect(addr string) error {
 		return err
 	}
 	remotePeer := createPeer(peerInfo, conn)
+	remotePeer.Link.SetMalformedMsgHandler(this.onMalformedMsg)
 
 	remotePeer.AttachChan(this.NetChan)
 	this.ReplacePeer(remotePeer)
 	go remotePeer.Link.Rx()
 
-	this.protocol.HandleSystemMessage(this, p2p.PeerConnected{Info: remotePeer.Info})
+	this.notifySystemMessage(p2p.PeerConnected{Info: remotePeer.Info})
 	return nil
 }
 
+//notifySystemMessage passes the network event to the core protocol and sub protocols
+func (this *NetServer) notifySystemMessage(msg p2p.SystemMessage) {
+	this.protocol.HandleSystemMessage(this, msg)
+	for _, proto := range this.subProtos.Protocols() {
+		proto.HandleSystemMessage(this, msg)
+	}
+}
+
+//RegisterProtocol adds the sub protocol before network starts, and return the sender of its messages
+func (this *NetServer) RegisterProtocol(proto p2p.SubProtocol) (*p2p.Sender, error) {
+	err := this.subProtos.Register(proto)
+	if err != nil {
+		return nil, err
+	}
+	// advertised in version message of handshake
+	this.base.Protocols = this.subProtos.Capabilities()
+	return p2p.NewSender(proto.Name(), this), nil
+}
+
+func (this *NetServer) handleSubProtocolMsg(ctx *p2p.Context, msg *types.SubProtocolMsg) {
+	proto := this.subProtos.Get(msg.Protocol)
+	if proto == nil {
+		log.Debugf("[p2p]receive message of unknown sub protocol %s from %s", msg.Protocol, ctx.Sender().GetAddr())
+		return
+	}
+	proto.HandleProtocolMessage(ctx, msg.Code, msg.Data)
+}
+
 func (this *NetServer) notifyPeerConnected(p *peer.PeerInfo) {
-	this.protocol.HandleSystemMessage(this, p2p.PeerConnected{Info: p})
+	this.notifySystemMessage(p2p.PeerConnected{Info: p})
 }
 
 func (this *NetServer) notifyPeerDisconnected(p *peer.PeerInfo) {
-	this.protocol.HandleSystemMessage(this, p2p.PeerDisConnected{Info: p})
+	this.score.RemovePeer(p.Id)
+	this.notifySystemMessage(p2p.PeerDisConnected{Info: p})
 }
 
 //Stop stop all net layer logic
@@ -255,7 +346,7 @@ func (this *NetServer) Stop() {
 		_ = this.listener.Close()
 	}
 	close(this.stopRecvCh)
-	this.protocol.HandleSystemMessage(this, p2p.NetworkStop{})
+	this.notifySystemMessage(p2p.NetworkStop{})
 }
 
 func (this *NetServer) handleClientConnection(conn net.Conn) error {
@@ -263,12 +354,18 @@ func (this *NetServer) handleClientConnection(conn net.Conn) error {
 	if err != nil {
 		return err
 	}
+	if this.isRemoved(peerInfo.RemoteListenAddress()) {
+		_ = conn.Close()
+		return fmt.Errorf("peer %s is removed", peerInfo.RemoteListenAddress())
+	}
 	remotePeer := createPeer(peerInfo, conn)
+	remotePeer.SetInbound(true)
+	remotePeer.Link.SetMalformedMsgHandler(this.onMalformedMsg)
 	remotePeer.AttachChan(this.NetChan)
 	this.ReplacePeer(remotePeer)
 
 	go remotePeer.Link.Rx()
-	this.protocol.HandleSystemMessage(this, p2p.PeerConnected{Info: remotePeer.Info})
+	this.notifySystemMessage(p2p.PeerConnected{Info: remotePeer.Info})
 	return nil
 }
 
@@ -326,3 +423,220 @@ func (this *NetServer) SendTo(p common.PeerId, msg types.Message) {
 		this.Send(peer, msg)
 	}
 }
+
+//AdjustPeerScore add delta to the score of the peer, the peer's ip is banned if the score is too low
+func (this *NetServer) AdjustPeerScore(id common.PeerId, delta int, reason string) {
+	p := this.GetPeer(id)
+	if p == nil {
+		return
+	}
+	this.adjustScore(id, p.Link.GetAddr(), delta, reason)
+}
+
+func (this *NetServer) onMalformedMsg(id common.PeerId, addr string, err error) {
+	this.adjustScore(id, addr, peer_score.SCORE_MALFORMED_MSG, err.Error())
+}
+
+func (this *NetServer) adjustScore(id common.PeerId, addr string, delta int, reason string) {
+	score, ban, err := this.score.Adjust(id, addr, delta)
+	if err != nil {
+		log.Warnf("[p2p]adjust score of peer %s error: %s", addr, err)
+		return
+	}
+	log.Debugf("[p2p]score of peer %s changed by %d to %d: %s", addr, delta, score, reason)
+	if ban {
+		_, err = this.BanPeer(addr, common.DEFAULT_BAN_DURATION*time.Second, "score too low, last: "+reason)
+		if err != nil {
+			log.Warnf("[p2p]ban peer %s error: %s", addr, err)
+		}
+	}
+}
+
+//DisableRateLimit stop limiting message rate of peers
+func (this *NetServer) DisableRateLimit() {
+	this.score.DisableRateLimit()
+}
+
+//BanPeer refuse connections with the ip of addr for duration and disconnect its peers
+func (this *NetServer) BanPeer(addr string, duration time.Duration, reason string) (*peer_score.BanInfo, error) {
+	info, err := this.score.Ban(addr, duration, reason)
+	if err != nil {
+		return nil, err
+	}
+	log.Warnf("[p2p]ban peer %s until %s: %s", info.Addr, time.Unix(info.Expire, 0), reason)
+	for _, p := range this.GetNeighbors() {
+		ip, err := peer_score.ParseIP(p.Link.GetAddr())
+		if err == nil && ip == info.Addr {
+			p.Close()
+		}
+	}
+	return info, nil
+}
+
+//UnbanPeer lift the ban of the ip
+func (this *NetServer) UnbanPeer(addr string) error {
+	return this.score.Unban(addr)
+}
+
+//GetBannedPeers return the ips currently banned
+func (this *NetServer) GetBannedPeers() []peer_score.BanInfo {
+	return this.score.BannedPeers()
+}
+
+//GetPeerScores return the scores of connected peers and misbehaving peers disconnected
+func (this *NetServer) GetPeerScores() []peer_score.ScoreInfo {
+	var infos []peer_score.ScoreInfo
+	connected := make(map[string]bool)
+	for _, p := range this.GetNeighbors() {
+		id := p.GetID()
+		infos = append(infos, peer_score.ScoreInfo{
+			Id:    id.ToHexString(),
+			Addr:  p.Link.GetAddr(),
+			Score: this.score.Score(id),
+		})
+		connected[id.ToHexString()] = true
+	}
+	for _, info := range this.score.Scores() {
+		if !connected[info.Id] {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+//GetPeerDetails return the detailed information of connected peers
+func (this *NetServer) GetPeerDetails() []peer.PeerDetail {
+	var details []peer.PeerDetail
+	for _, p := range this.GetNeighbors() {
+		details = append(details, p.Detail())
+	}
+	return details
+}
+
+func (this *NetServer) isRemoved(addr string) bool {
+	this.removedLock.Lock()
+	defer this.removedLock.Unlock()
+	return this.removed[addr]
+}
+
+//AddPeer connects to the address, it is allowed to be connected automatically again if removed before
+func (this *NetServer) AddPeer(addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return err
+	}
+	this.removedLock.Lock()
+	delete(this.removed, addr)
+	this.removedLock.Unlock()
+
+	for _, p := range this.GetNeighbors() {
+		if p.Link.GetAddr() == addr || p.Info.RemoteListenAddress() == addr {
+			return nil
+		}
+	}
+	return this.connect(addr)
+}
+
+//RemovePeer disconnects peers with the address and stops connecting to it automatically
+func (this *NetServer) RemovePeer(addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return err
+	}
+	this.removedLock.Lock()
+	this.removed[addr] = true
+	this.removedLock.Unlock()
+
+	for _, p := range this.GetNeighbors() {
+		if p.Link.GetAddr() == addr || p.Info.RemoteListenAddress() == addr {
+			p.Close()
+		}
+	}
+	return nil
+}
+
+//DisconnectPeer closes the connection with peer of the id or address, the peer may be connected again
+func (this *NetServer) DisconnectPeer(idOrAddr string) error {
+	found := false
+	for _, p := range this.GetNeighbors() {
+		id := p.GetID()
+		if id.ToHexString() == idOrAddr || p.Link.GetAddr() == idOrAddr ||
+			p.Info.RemoteListenAddress() == idOrAddr {
+			p.Close()
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("peer %s is not connected", idOrAddr)
+	}
+	return nil
+}
+
+//GetReservedConfig return the reserved and mask peers in use
+func (this *NetServer) GetReservedConfig() *config.P2PRsvConfig {
+	cfg := &config.P2PRsvConfig{
+		ReservedPeers: this.connCtrl.GetReservedPeers(),
+	}
+	if mask, ok := this.protocol.(p2p.MaskPeerManager); ok {
+		cfg.MaskPeers = mask.GetMaskPeers()
+	}
+	return cfg
+}
+
+//AddReservedPeer allows connections with the ip or domain in reserved peers only mode
+func (this *NetServer) AddReservedPeer(ipOrName string) error {
+	if ipOrName == "" {
+		return errors.New("empty reserved peer")
+	}
+	return this.connCtrl.AddReservedPeer(ipOrName)
+}
+
+//RemoveReservedPeer removes the ip or domain from reserved list and disconnects peers no longer allowed
+func (this *NetServer) RemoveReservedPeer(ipOrName string) error {
+	err := this.connCtrl.RemoveReservedPeer(ipOrName)
+	if err != nil {
+		return err
+	}
+	for _, p := range this.GetNeighbors() {
+		if !this.connCtrl.IsAllowed(p.Link.GetAddr()) {
+			p.Close()
+		}
+	}
+	return nil
+}
+
+func (this *NetServer) maskPeerManager() (p2p.MaskPeerManager, error) {
+	mask, ok := this.protocol.(p2p.MaskPeerManager)
+	if !ok {
+		return nil, errors.New("mask peers is not supported by protocol")
+	}
+	return mask, nil
+}
+
+//AddMaskPeer hides the ip from peers not in mask list
+func (this *NetServer) AddMaskPeer(ip string) error {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return fmt.Errorf("invalid ip: %s", ip)
+	}
+	mask, err := this.maskPeerManager()
+	if err != nil {
+		return err
+	}
+	mask.AddMaskPeer(addr.String())
+	return nil
+}
+
+//RemoveMaskPeer removes the ip from mask list
+func (this *NetServer) RemoveMaskPeer(ip string) error {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return fmt.Errorf("invalid ip: %s", ip)
+	}
+	mask, err := this.maskPeerManager()
+	if err != nil {
+		return err
+	}
+	if !mask.RemoveMaskPeer(addr.String()) {
+		return fmt.Errorf("%s is not in mask list", ip)
+	}
+	return nil
+}
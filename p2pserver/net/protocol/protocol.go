@@ -18,6 +18,8 @@
 package p2p
 
 import (
+	"github.com/ontio/ontology/common"
+	ct "github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/p2pserver/message/types"
 	"github.com/ontio/ontology/p2pserver/peer"
 )
@@ -45,6 +47,23 @@ type Protocol interface {
 	HandleSystemMessage(net P2P, msg SystemMessage)
 }
 
+//TransactionRelayer is implemented by protocol which relays transactions instead of full broadcast
+type TransactionRelayer interface {
+	RelayTransaction(tx *ct.Transaction)
+}
+
+//BlockRelayer is implemented by protocol which relays the new blocks announced by consensus as compact blocks
+type BlockRelayer interface {
+	RelayBlocks(hashes []common.Uint256)
+}
+
+//MaskPeerManager is implemented by protocol which hides mask peers from others
+type MaskPeerManager interface {
+	AddMaskPeer(ip string)
+	RemoveMaskPeer(ip string) bool
+	GetMaskPeers() []string
+}
+
 type SystemMessage interface {
 	systemMessage()
 }
@@ -41,4 +41,5 @@ type P2P interface {
 	GetOutConnRecordLen() uint
 	Broadcast(msg types.Message)
 	IsOwnAddress(addr string) bool
+	AdjustPeerScore(id common.PeerId, delta int, reason string)
 }
@@ -0,0 +1,156 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/peer"
+)
+
+//SubProtocol is a node-to-node feature registered on network besides the core protocol. Its messages are carried
+//in the namespace of its name, and it is advertised to peers in version message
+type SubProtocol interface {
+	Name() string
+	Version() uint32
+	//HandleSystemMessage receives the network start/stop and all peer connection events
+	HandleSystemMessage(net P2P, msg SystemMessage)
+	HandleProtocolMessage(ctx *Context, code uint16, data []byte)
+}
+
+//Registry keeps the sub protocols by name, sub protocols can only be registered before network starts
+type Registry struct {
+	lock      sync.RWMutex
+	protocols map[string]SubProtocol
+	frozen    bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{protocols: make(map[string]SubProtocol)}
+}
+
+//Register adds the sub protocol, its name should be unique
+func (self *Registry) Register(proto SubProtocol) error {
+	name := proto.Name()
+	if len(name) == 0 || len(name) > common.MAX_PROTOCOL_NAME_LEN {
+		return fmt.Errorf("invalid sub protocol name length: %d", len(name))
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.frozen {
+		return errors.New("sub protocol can not be registered after network started")
+	}
+	if _, ok := self.protocols[name]; ok {
+		return fmt.Errorf("sub protocol %s already registered", name)
+	}
+	if len(self.protocols) >= common.MAX_PROTOCOL_CNT {
+		return errors.New("too many sub protocols")
+	}
+	self.protocols[name] = proto
+	return nil
+}
+
+//Freeze rejects further registration
+func (self *Registry) Freeze() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.frozen = true
+}
+
+//Get return the sub protocol of name, nil if not registered
+func (self *Registry) Get(name string) SubProtocol {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.protocols[name]
+}
+
+//Protocols return all sub protocols sorted by name
+func (self *Registry) Protocols() []SubProtocol {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	protos := make([]SubProtocol, 0, len(self.protocols))
+	for _, proto := range self.protocols {
+		protos = append(protos, proto)
+	}
+	sort.Slice(protos, func(i, j int) bool {
+		return protos[i].Name() < protos[j].Name()
+	})
+	return protos
+}
+
+//Capabilities return the versions of sub protocols by name
+func (self *Registry) Capabilities() map[string]uint32 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	caps := make(map[string]uint32, len(self.protocols))
+	for name, proto := range self.protocols {
+		caps[name] = proto.Version()
+	}
+	return caps
+}
+
+//Sender sends messages of a sub protocol to peers supporting it
+type Sender struct {
+	name string
+	net  P2P
+}
+
+func NewSender(name string, net P2P) *Sender {
+	return &Sender{name: name, net: net}
+}
+
+func (self *Sender) newMsg(code uint16, data []byte) *types.SubProtocolMsg {
+	return &types.SubProtocolMsg{Protocol: self.name, Code: code, Data: data}
+}
+
+//Peers return the connected peers supporting the sub protocol
+func (self *Sender) Peers() []*peer.Peer {
+	var peers []*peer.Peer
+	for _, p := range self.net.GetNeighbors() {
+		if _, ok := p.Info.ProtocolVersion(self.name); ok {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+//SendTo sends the message to the peer, fails if the peer does not support the sub protocol
+func (self *Sender) SendTo(id common.PeerId, code uint16, data []byte) error {
+	p := self.net.GetPeer(id)
+	if p == nil {
+		return fmt.Errorf("peer %s is not connected", id.ToHexString())
+	}
+	if _, ok := p.Info.ProtocolVersion(self.name); !ok {
+		return fmt.Errorf("peer %s does not support sub protocol %s", id.ToHexString(), self.name)
+	}
+	return self.net.Send(p, self.newMsg(code, data))
+}
+
+//Broadcast sends the message to all connected peers supporting the sub protocol
+func (self *Sender) Broadcast(code uint16, data []byte) {
+	msg := self.newMsg(code, data)
+	for _, p := range self.Peers() {
+		_ = self.net.Send(p, msg)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/ontio/ontology/p2pserver/net/netserver"
 	p2pnet "github.com/ontio/ontology/p2pserver/net/protocol"
 	"github.com/ontio/ontology/p2pserver/protocols"
+	"github.com/ontio/ontology/p2pserver/protocols/light_client"
 )
 
 //P2PServer control all network activities
@@ -63,11 +64,25 @@ func (this *P2PServer) Stop() {
 	this.network.Stop()
 }
 
+//RegisterProtocol adds a sub protocol to network before the server starts
+func (this *P2PServer) RegisterProtocol(proto p2pnet.SubProtocol) (*p2pnet.Sender, error) {
+	return this.network.RegisterProtocol(proto)
+}
+
 // GetNetwork returns the low level netserver
 func (this *P2PServer) GetNetwork() p2pnet.P2P {
 	return this.network
 }
 
+// GetLightClient returns the light client, which is nil if the node is not in light mode
+func (this *P2PServer) GetLightClient() *light_client.LightClient {
+	mh, ok := this.network.Protocol().(*protocols.MsgHandler)
+	if !ok {
+		return nil
+	}
+	return mh.LightClient()
+}
+
 //WaitForPeersStart check whether enough peer linked in loop
 func (this *P2PServer) WaitForPeersStart() {
 	periodTime := config.DEFAULT_GEN_BLOCK_TIME / common.UPDATE_RATE_PER_BLOCK
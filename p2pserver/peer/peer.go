@@ -25,6 +25,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	comm "github.com/ontio/ontology/common"
@@ -45,6 +46,12 @@ type PeerInfo struct {
 	Height       uint64
 	SoftVersion  string
 	Addr         string
+	Compress     bool              // whether messages on the link are compressed
+	CompactBlock bool              // whether recent blocks are relayed as compact blocks on the link
+	TxInv        bool              // whether transactions are relayed by inventory announcement on the link
+	ExternalIP   net.IP            // public ip advertised by the peer, nil if not advertised or not matching remote address
+	ObservedIP   net.IP            // ip of local node observed by the peer, nil if not reported
+	Protocols    map[string]uint32 // sub protocols supported by the peer and their versions
 }
 
 func NewPeerInfo(id common.PeerId, version uint32, services uint64, relay bool, httpInfoPort uint16,
@@ -62,12 +69,22 @@ func NewPeerInfo(id common.PeerId, version uint32, services uint64, relay bool,
 	}
 }
 
+//ProtocolVersion return the version of sub protocol supported by the peer
+func (pi *PeerInfo) ProtocolVersion(name string) (uint32, bool) {
+	version, ok := pi.Protocols[name]
+	return version, ok
+}
+
 // RemoteListen get remote service port
 func (pi *PeerInfo) RemoteListenAddress() string {
 	host, _, err := net.SplitHostPort(pi.Addr)
 	if err != nil {
 		return ""
 	}
+	// peer behind NAT advertises its public ip
+	if pi.ExternalIP != nil {
+		host = pi.ExternalIP.String()
+	}
 
 	sb := strings.Builder{}
 	sb.WriteString(host)
@@ -79,9 +96,29 @@ func (pi *PeerInfo) RemoteListenAddress() string {
 
 //Peer represent the node in p2p
 type Peer struct {
+	latency  int64 // round trip time of last ping in nanoseconds, accessed atomically
 	Info     *PeerInfo
 	Link     *conn.Link
 	connLock sync.RWMutex
+	inbound  bool
+}
+
+//PeerDetail describe a connected peer for management
+type PeerDetail struct {
+	Id          string
+	Addr        string //address of the connection
+	ListenAddr  string
+	Inbound     bool
+	Version     uint32
+	SoftVersion string
+	Services    uint64
+	Relay       bool
+	Height      uint64
+	Latency     int64 //milliseconds of last ping round trip, zero if unknown
+	BytesIn     uint64
+	BytesOut    uint64
+	ConnTime    int64 //unix time the connection is established
+	LastRecv    int64 //unix time of the latest message received
 }
 
 //NewPeer return new peer without publickey initial
@@ -97,6 +134,47 @@ func (self *Peer) SetInfo(info *PeerInfo) {
 	self.Info = info
 }
 
+//SetInbound set whether the connection is accepted from peer
+func (this *Peer) SetInbound(inbound bool) {
+	this.inbound = inbound
+}
+
+//IsInbound return whether the connection is accepted from peer
+func (this *Peer) IsInbound() bool {
+	return this.inbound
+}
+
+//SetLatency set the round trip time of ping
+func (this *Peer) SetLatency(latency time.Duration) {
+	atomic.StoreInt64(&this.latency, int64(latency))
+}
+
+//GetLatency return the round trip time of last ping, zero if unknown
+func (this *Peer) GetLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&this.latency))
+}
+
+//Detail return the detailed information of peer
+func (this *Peer) Detail() PeerDetail {
+	id := this.GetID()
+	return PeerDetail{
+		Id:          id.ToHexString(),
+		Addr:        this.Link.GetAddr(),
+		ListenAddr:  this.Info.RemoteListenAddress(),
+		Inbound:     this.IsInbound(),
+		Version:     this.GetVersion(),
+		SoftVersion: this.GetSoftVersion(),
+		Services:    this.GetServices(),
+		Relay:       this.GetRelay(),
+		Height:      this.GetHeight(),
+		Latency:     int64(this.GetLatency() / time.Millisecond),
+		BytesIn:     this.Link.GetBytesIn(),
+		BytesOut:    this.Link.GetBytesOut(),
+		ConnTime:    this.Link.GetConnTime().Unix(),
+		LastRecv:    this.Link.GetRXTime().Unix(),
+	}
+}
+
 func (self *PeerInfo) String() string {
 	return fmt.Sprintf("id=%s, version=%s", self.Id.ToHexString(), self.SoftVersion)
 }
@@ -137,6 +215,9 @@ func (this *Peer) GetPort() uint16 {
 //SendTo call sync link to send buffer
 func (this *Peer) SendRaw(msgType string, msgPayload []byte) error {
 	if this.Link != nil && this.Link.Valid() {
+		if this.Info.Compress {
+			msgPayload = types.CompressMessage(msgPayload)
+		}
 		return this.Link.SendRaw(msgPayload)
 	}
 	return errors.New("[p2p]sync link invalid")
@@ -0,0 +1,378 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer_score
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	common2 "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+//score adjustment of peer behaviors
+const (
+	SCORE_VALID_BLOCK       = 1
+	SCORE_RATE_LIMITED      = -1
+	SCORE_UNSOLICITED_BLOCK = -5
+	SCORE_INVALID_TX        = -10
+	SCORE_INVALID_CONSENSUS = -10
+	SCORE_INVALID_BLOCK     = -20
+	SCORE_MALFORMED_MSG     = -20
+)
+
+const (
+	SCORE_MAX              = 100              //upper bound of peer score
+	SCORE_BAN_THRESHOLD    = -100             //peer is banned when its score drops to this value
+	SCORE_RECOVER_INTERVAL = 10 * time.Second //negative score recovers one point every interval
+)
+
+//BanInfo describe a banned ip
+type BanInfo struct {
+	Addr   string //banned ip
+	Reason string
+	Expire int64 //unix time the ban is lifted
+}
+
+//ScoreInfo describe the score of a peer
+type ScoreInfo struct {
+	Id    string //peer id
+	Addr  string //ip of the peer when its score changes, or the address of connected peer
+	Score int
+}
+
+type score struct {
+	value   int
+	updated time.Time
+	ip      string //last ip of the peer
+}
+
+//PeerScore keeps the reputation of peers by peer id, the message rate limiters of connected peers
+//and the ip ban list
+type PeerScore struct {
+	lock    sync.Mutex
+	scores  map[common.PeerId]*score
+	buckets map[common.PeerId]map[string]*tokenBucket
+	bans    map[string]*BanInfo
+	limits  map[string]RateLimit
+	noLimit bool
+	banFile string // ban list is not persisted if empty
+	now     func() time.Time
+}
+
+//NewPeerScore return a PeerScore and load the persisted ban list from banFile
+func NewPeerScore(banFile string) *PeerScore {
+	self := &PeerScore{
+		scores:  make(map[common.PeerId]*score),
+		buckets: make(map[common.PeerId]map[string]*tokenBucket),
+		bans:    make(map[string]*BanInfo),
+		limits:  DefaultRateLimits,
+		banFile: banFile,
+		now:     time.Now,
+	}
+	self.loadBans()
+	return self
+}
+
+//ParseIP return the ip of a "ip:port" address or a plain ip
+func ParseIP(addr string) (string, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", fmt.Errorf("invalid ip address: %s", addr)
+	}
+	return ip.String(), nil
+}
+
+//Allow consume a token of the message type from the peer's bucket,
+//return false if the peer exceeds the rate limit
+func (self *PeerScore) Allow(id common.PeerId, cmdType string) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.noLimit {
+		return true
+	}
+	buckets := self.buckets[id]
+	if buckets == nil {
+		buckets = make(map[string]*tokenBucket)
+		self.buckets[id] = buckets
+	}
+	bucket := buckets[cmdType]
+	if bucket == nil {
+		limit, ok := self.limits[cmdType]
+		if !ok {
+			limit = DEFAULT_RATE_LIMIT
+		}
+		bucket = newTokenBucket(limit, self.now())
+		buckets[cmdType] = bucket
+	}
+
+	return bucket.take(self.now())
+}
+
+//DisableRateLimit let Allow always pass
+func (self *PeerScore) DisableRateLimit() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.noLimit = true
+}
+
+//RemovePeer release the rate limiters of a disconnected peer
+func (self *PeerScore) RemovePeer(id common.PeerId) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	delete(self.buckets, id)
+}
+
+//Adjust add delta to the score of peer id connected from addr, return the new score and whether it reaches
+//the ban threshold
+func (self *PeerScore) Adjust(id common.PeerId, addr string, delta int) (int, bool, error) {
+	ip, err := ParseIP(addr)
+	if err != nil {
+		return 0, false, err
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	s := self.getScore(id)
+	s.ip = ip
+	s.value += delta
+	if s.value > SCORE_MAX {
+		s.value = SCORE_MAX
+	}
+	if s.value <= SCORE_BAN_THRESHOLD {
+		delete(self.scores, id)
+		return s.value, true, nil
+	}
+	if s.value == 0 {
+		delete(self.scores, id)
+	}
+
+	return s.value, false, nil
+}
+
+//Score return the current score of the peer
+func (self *PeerScore) Score(id common.PeerId) int {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	s, ok := self.scores[id]
+	if !ok {
+		return 0
+	}
+	self.recover(s)
+	return s.value
+}
+
+//Scores return the non-zero scores of all recorded peers
+func (self *PeerScore) Scores() []ScoreInfo {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	infos := make([]ScoreInfo, 0, len(self.scores))
+	for id, s := range self.scores {
+		self.recover(s)
+		if s.value == 0 {
+			delete(self.scores, id)
+			continue
+		}
+		infos = append(infos, ScoreInfo{Id: id.ToHexString(), Addr: s.ip, Score: s.value})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Id < infos[j].Id })
+	return infos
+}
+
+//get the score of peer with recovery applied, create it if not exist. need hold lock
+func (self *PeerScore) getScore(id common.PeerId) *score {
+	s, ok := self.scores[id]
+	if !ok {
+		s = &score{updated: self.now()}
+		self.scores[id] = s
+		return s
+	}
+	self.recover(s)
+	return s
+}
+
+//negative score recovers toward zero over time. need hold lock
+func (self *PeerScore) recover(s *score) {
+	now := self.now()
+	if s.value < 0 {
+		points := int(now.Sub(s.updated) / SCORE_RECOVER_INTERVAL)
+		if points <= 0 {
+			return
+		}
+		s.value += points
+		if s.value > 0 {
+			s.value = 0
+		}
+		s.updated = s.updated.Add(time.Duration(points) * SCORE_RECOVER_INTERVAL)
+		return
+	}
+	s.updated = now
+}
+
+//Ban reject the ip for duration and persist the ban list
+func (self *PeerScore) Ban(addr string, duration time.Duration, reason string) (*BanInfo, error) {
+	ip, err := ParseIP(addr)
+	if err != nil {
+		return nil, err
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid ban duration: %s", duration)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	info := &BanInfo{
+		Addr:   ip,
+		Reason: reason,
+		Expire: self.now().Add(duration).Unix(),
+	}
+	self.bans[ip] = info
+	for id, s := range self.scores {
+		if s.ip == ip {
+			delete(self.scores, id)
+		}
+	}
+	self.saveBans()
+
+	return info, nil
+}
+
+//Unban lift the ban of the ip
+func (self *PeerScore) Unban(addr string) error {
+	ip, err := ParseIP(addr)
+	if err != nil {
+		return err
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if _, ok := self.bans[ip]; !ok {
+		return fmt.Errorf("ip %s is not banned", ip)
+	}
+	delete(self.bans, ip)
+	self.saveBans()
+
+	return nil
+}
+
+//IsBanned return whether the ip of the address is banned
+func (self *PeerScore) IsBanned(addr string) bool {
+	ip, err := ParseIP(addr)
+	if err != nil {
+		return false
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	info, ok := self.bans[ip]
+	if !ok {
+		return false
+	}
+	if info.Expire <= self.now().Unix() {
+		delete(self.bans, ip)
+		return false
+	}
+	return true
+}
+
+//BannedPeers return all unexpired bans
+func (self *PeerScore) BannedPeers() []BanInfo {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := self.now().Unix()
+	infos := make([]BanInfo, 0, len(self.bans))
+	for ip, info := range self.bans {
+		if info.Expire <= now {
+			delete(self.bans, ip)
+			continue
+		}
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Addr < infos[j].Addr })
+	return infos
+}
+
+//need hold lock
+func (self *PeerScore) saveBans() {
+	if self.banFile == "" {
+		return
+	}
+	now := self.now().Unix()
+	bans := make([]*BanInfo, 0, len(self.bans))
+	for _, info := range self.bans {
+		if info.Expire > now {
+			bans = append(bans, info)
+		}
+	}
+	buf, err := json.Marshal(bans)
+	if err != nil {
+		log.Warn("[p2p]package banned peers fail: ", err)
+		return
+	}
+	err = ioutil.WriteFile(self.banFile, buf, 0600)
+	if err != nil {
+		log.Warn("[p2p]write banned peers fail: ", err)
+		return
+	}
+	// file written by old version is accessible by everyone
+	err = os.Chmod(self.banFile, 0600)
+	if err != nil {
+		log.Warn("[p2p]change mode of banned peers file fail: ", err)
+	}
+}
+
+func (self *PeerScore) loadBans() {
+	if self.banFile == "" || !common2.FileExisted(self.banFile) {
+		return
+	}
+	buf, err := ioutil.ReadFile(self.banFile)
+	if err != nil {
+		log.Warnf("[p2p]read %s fail:%s", self.banFile, err)
+		return
+	}
+	var bans []*BanInfo
+	err = json.Unmarshal(buf, &bans)
+	if err != nil {
+		log.Warn("[p2p]parse banned peers file fail: ", err)
+		return
+	}
+	now := self.now().Unix()
+	for _, info := range bans {
+		if info.Expire > now {
+			self.bans[info.Addr] = info
+		}
+	}
+}
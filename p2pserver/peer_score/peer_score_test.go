@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer_score
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (self *fakeClock) now() time.Time {
+	return self.t
+}
+
+func newTestScore(banFile string) (*PeerScore, *fakeClock) {
+	clock := &fakeClock{t: time.Now()}
+	score := NewPeerScore(banFile)
+	score.now = clock.now
+	return score, clock
+}
+
+func TestTokenBucket(t *testing.T) {
+	score, clock := newTestScore("")
+	score.limits = map[string]RateLimit{common.PING_TYPE: {Rate: 2, Burst: 3}}
+	id := common.PseudoPeerIdFromUint64(1)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, score.Allow(id, common.PING_TYPE))
+	}
+	assert.False(t, score.Allow(id, common.PING_TYPE))
+	// other peers have their own buckets
+	assert.True(t, score.Allow(common.PseudoPeerIdFromUint64(2), common.PING_TYPE))
+
+	clock.t = clock.t.Add(500 * time.Millisecond)
+	assert.True(t, score.Allow(id, common.PING_TYPE))
+	assert.False(t, score.Allow(id, common.PING_TYPE))
+
+	clock.t = clock.t.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, score.Allow(id, common.PING_TYPE))
+	}
+	assert.False(t, score.Allow(id, common.PING_TYPE))
+
+	score.RemovePeer(id)
+	assert.True(t, score.Allow(id, common.PING_TYPE))
+
+	score.DisableRateLimit()
+	for i := 0; i < 10; i++ {
+		assert.True(t, score.Allow(id, common.PING_TYPE))
+	}
+}
+
+func TestScoreDecay(t *testing.T) {
+	score, clock := newTestScore("")
+	id := common.PseudoPeerIdFromUint64(1)
+	addr := "127.0.0.1:20338"
+
+	val, ban, err := score.Adjust(id, addr, SCORE_INVALID_BLOCK)
+	assert.Nil(t, err)
+	assert.False(t, ban)
+	assert.Equal(t, SCORE_INVALID_BLOCK, val)
+	// score is kept by peer id, peers behind the same ip are scored separately
+	assert.Equal(t, 0, score.Score(common.PseudoPeerIdFromUint64(2)))
+	infos := score.Scores()
+	assert.Equal(t, 1, len(infos))
+	assert.Equal(t, id.ToHexString(), infos[0].Id)
+	assert.Equal(t, "127.0.0.1", infos[0].Addr)
+
+	clock.t = clock.t.Add(5 * SCORE_RECOVER_INTERVAL)
+	assert.Equal(t, SCORE_INVALID_BLOCK+5, score.Score(id))
+	clock.t = clock.t.Add(time.Hour)
+	assert.Equal(t, 0, score.Score(id))
+	assert.Equal(t, 0, len(score.Scores()))
+
+	for i := 0; i < 200; i++ {
+		score.Adjust(id, addr, SCORE_VALID_BLOCK)
+	}
+	assert.Equal(t, SCORE_MAX, score.Score(id))
+	// positive score does not decay
+	clock.t = clock.t.Add(time.Hour)
+	assert.Equal(t, SCORE_MAX, score.Score(id))
+
+	_, _, err = score.Adjust(id, "invalid", -1)
+	assert.NotNil(t, err)
+}
+
+func TestScoreReachBanThreshold(t *testing.T) {
+	score, _ := newTestScore("")
+	id := common.PseudoPeerIdFromUint64(1)
+	other := common.PseudoPeerIdFromUint64(2)
+	_, _, err := score.Adjust(other, "10.0.0.1:30000", SCORE_INVALID_TX)
+	assert.Nil(t, err)
+	addr := "10.0.0.1:20338"
+	banned := false
+	for i := 0; i < 10 && !banned; i++ {
+		_, ban, err := score.Adjust(id, addr, SCORE_MALFORMED_MSG)
+		assert.Nil(t, err)
+		banned = ban
+	}
+	assert.True(t, banned)
+	assert.Equal(t, 0, score.Score(id))
+	assert.Equal(t, SCORE_INVALID_TX, score.Score(other))
+	// scores of peers behind the banned ip are cleared
+	_, err = score.Ban(addr, time.Hour, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, score.Score(other))
+}
+
+func TestBanPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peer_score")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, common.BANNED_FILE_NAME)
+
+	score, clock := newTestScore(file)
+	_, err = score.Ban("10.0.0.1:20338", time.Hour, "test")
+	assert.Nil(t, err)
+	_, err = score.Ban("10.0.0.2", 2*time.Hour, "test")
+	assert.Nil(t, err)
+	_, err = score.Ban("10.0.0.3", 0, "test")
+	assert.NotNil(t, err)
+	assert.True(t, score.IsBanned("10.0.0.1:30000"))
+	assert.False(t, score.IsBanned("10.0.0.3:20338"))
+	stat, err := os.Stat(file)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	loaded := NewPeerScore(file)
+	loaded.now = clock.now
+	bans := loaded.BannedPeers()
+	assert.Equal(t, 2, len(bans))
+	assert.Equal(t, "10.0.0.1", bans[0].Addr)
+	assert.Equal(t, "test", bans[0].Reason)
+
+	assert.Nil(t, loaded.Unban("10.0.0.1"))
+	assert.NotNil(t, loaded.Unban("10.0.0.1"))
+	assert.False(t, loaded.IsBanned("10.0.0.1:20338"))
+
+	clock.t = clock.t.Add(90 * time.Minute)
+	assert.True(t, loaded.IsBanned("10.0.0.2:20338"))
+	clock.t = clock.t.Add(time.Hour)
+	assert.False(t, loaded.IsBanned("10.0.0.2:20338"))
+
+	reloaded := NewPeerScore(file)
+	reloaded.now = clock.now
+	assert.Equal(t, 0, len(reloaded.BannedPeers()))
+}
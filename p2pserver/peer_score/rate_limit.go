@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package peer_score
+
+import (
+	"time"
+
+	"github.com/ontio/ontology/p2pserver/common"
+)
+
+//RateLimit is the token bucket parameter of a message type
+type RateLimit struct {
+	Rate  float64 //tokens refilled per second
+	Burst float64 //capacity of the bucket
+}
+
+//DEFAULT_RATE_LIMIT applies to message types not in DefaultRateLimits
+var DEFAULT_RATE_LIMIT = RateLimit{Rate: 100, Burst: 500}
+
+//DefaultRateLimits is the per peer rate limit of each message type
+var DefaultRateLimits = map[string]RateLimit{
+	common.PING_TYPE:          {Rate: 1, Burst: 10},
+	common.PONG_TYPE:          {Rate: 1, Burst: 10},
+	common.GetADDR_TYPE:       {Rate: 1, Burst: 5},
+	common.ADDR_TYPE:          {Rate: 5, Burst: 20},
+	common.FINDNODE_TYPE:      {Rate: 5, Burst: 20},
+	common.FINDNODE_RESP_TYPE: {Rate: 5, Burst: 20},
+	common.GET_HEADERS_TYPE:   {Rate: 10, Burst: 50},
+	common.GET_BLOCKS_TYPE:    {Rate: 10, Burst: 50},
+	common.HEADERS_TYPE:       {Rate: 20, Burst: 100},
+	common.GET_DATA_TYPE:      {Rate: 1000, Burst: 5000}, //transactions announced by inv are requested one by one
+	common.BLOCK_TYPE:         {Rate: 500, Burst: 2000},
+	common.TX_TYPE:            {Rate: 1000, Burst: 5000},
+	common.CONSENSUS_TYPE:     {Rate: 200, Burst: 1000},
+}
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		limit:  limit,
+		tokens: limit.Burst,
+		last:   now,
+	}
+}
+
+//take refill the bucket and consume one token if available
+func (self *tokenBucket) take(now time.Time) bool {
+	if elapsed := now.Sub(self.last).Seconds(); elapsed > 0 {
+		self.tokens += elapsed * self.limit.Rate
+		if self.tokens > self.limit.Burst {
+			self.tokens = self.limit.Burst
+		}
+	}
+	self.last = now
+	if self.tokens < 1 {
+		return false
+	}
+	self.tokens -= 1
+	return true
+}
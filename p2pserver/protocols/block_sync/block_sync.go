@@ -32,12 +32,13 @@ import (
 	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
 	"github.com/ontio/ontology/p2pserver/peer"
+	"github.com/ontio/ontology/p2pserver/peer_score"
 )
 
 const (
 	SYNC_MAX_HEADER_FORWARD_SIZE = 5000       //keep CurrentHeaderHeight - CurrentBlockHeight <= SYNC_MAX_HEADER_FORWARD_SIZE
 	SYNC_MAX_FLIGHT_HEADER_SIZE  = 1          //Number of headers on flight
-	SYNC_MAX_FLIGHT_BLOCK_SIZE   = 50         //Number of blocks on flight
+	SYNC_MAX_FLIGHT_BLOCK_SIZE   = 500        //Number of blocks on flight
 	SYNC_MAX_BLOCK_CACHE_SIZE    = 500        //Cache size of block wait to commit to ledger
 	SYNC_HEADER_REQUEST_TIMEOUT  = 2          //s, Request header timeout time. If header haven't receive after SYNC_HEADER_REQUEST_TIMEOUT second, retry
 	SYNC_BLOCK_REQUEST_TIMEOUT   = 2          //s, Request block timeout time. If block haven't received after SYNC_BLOCK_REQUEST_TIMEOUT second, retry
@@ -48,6 +49,7 @@ const (
 	SYNC_NODE_SPEED_INIT         = 100 * 1024 //Init a big speed (100MB/s) for every node in first round
 	SYNC_MAX_ERROR_RESP_TIMES    = 5          //Max error headers/blocks response times, if reaches, delete it
 	SYNC_MAX_HEIGHT_OFFSET       = 5          //Offset of the max height and current height
+	SYNC_MAX_SAVE_BATCH_SIZE     = 50         //Max number of blocks saved to ledger in a batch
 )
 
 //NodeWeight record some params of node, using for sort
@@ -57,6 +59,7 @@ type NodeWeight struct {
 	timeoutCnt   int            //Node response timeout count
 	errorRespCnt int            //Node response error data count
 	reqTime      []int64        //Record request time, using for calc the avg req time interval, unit millisecond
+	window       *SyncWindow    //Adaptive number of blocks on flight to the node
 }
 
 //NewNodeWeight new a nodeweight
@@ -76,6 +79,7 @@ func NewNodeWeight(id p2pComm.PeerId) *NodeWeight {
 		timeoutCnt:   0,
 		errorRespCnt: 0,
 		reqTime:      r,
+		window:       NewSyncWindow(),
 	}
 }
 
@@ -391,6 +395,7 @@ func (this *BlockSyncMgr) checkTimeout() {
 	for blockHash, flightInfos := range blockTimeoutFlights {
 		for _, flightInfo := range flightInfos {
 			this.addTimeoutCnt(flightInfo.GetNodeId())
+			this.shrinkWindow(flightInfo.GetNodeId())
 			if flightInfo.Height <= curBlockHeight {
 				this.delFlightBlock(blockHash)
 				continue
@@ -459,6 +464,8 @@ func (this *BlockSyncMgr) syncHeader() {
 	log.Infof("Header sync request height:%d", NextHeaderId)
 }
 
+//syncBlock request the blocks following current block height. Consecutive heights are assigned to nodes
+//by the free window of each node, so block ranges are downloaded from many nodes concurrently
 func (this *BlockSyncMgr) syncBlock() {
 	if this.tryGetSyncBlockLock() {
 		return
@@ -483,53 +490,102 @@ func (this *BlockSyncMgr) syncBlock() {
 		count = cacheCap
 	}
 
-	counter := 1
-	i := uint32(0)
-	reqTimes := 1
-	for {
-		if counter > count {
-			break
-		}
-		i++
-		nextBlockHeight := curBlockHeight + i
+	heights := make([]uint32, 0, count)
+	hashes := make(map[uint32]common.Uint256)
+	for nextBlockHeight := curBlockHeight + 1; nextBlockHeight <= curHeaderHeight && len(heights) < count; nextBlockHeight++ {
 		nextBlockHash := this.ledger.GetBlockHash(nextBlockHeight)
 		if nextBlockHash == common.UINT256_EMPTY {
-			return
-		}
-		if this.isBlockOnFlight(nextBlockHash) {
-			if nextBlockHeight <= curBlockHeight+SYNC_NEXT_BLOCKS_HEIGHT {
-				//request more nodes for next block height
-				reqTimes = SYNC_NEXT_BLOCK_TIMES
-			} else {
-				continue
-			}
+			break
 		}
 		if this.isInBlockCache(nextBlockHeight) {
 			continue
 		}
 		if nextBlockHeight <= curBlockHeight+SYNC_NEXT_BLOCKS_HEIGHT {
-			reqTimes = SYNC_NEXT_BLOCK_TIMES
+			//request more nodes for next block height
+			this.syncNextBlock(nextBlockHeight, nextBlockHash)
+			continue
 		}
-		for t := 0; t < reqTimes; t++ {
-			reqNode := this.getNextNode(nextBlockHeight)
-			if reqNode == nil {
-				return
-			}
-			this.addFlightBlock(reqNode.GetID(), nextBlockHeight, nextBlockHash)
-			msg := msgpack.NewBlkDataReq(nextBlockHash)
-			err := this.server.Send(reqNode, msg)
+		if this.isBlockOnFlight(nextBlockHash) {
+			continue
+		}
+		heights = append(heights, nextBlockHeight)
+		hashes[nextBlockHeight] = nextBlockHash
+	}
+	if len(heights) == 0 {
+		return
+	}
+
+	ranges := assignRanges(heights, this.getSyncSlots())
+	for id, blockHeights := range ranges {
+		reqNode := this.server.GetPeer(id)
+		if reqNode == nil {
+			continue
+		}
+		log.Tracef("[block-sync] syncBlock request height:%d - %d from node:%s", blockHeights[0],
+			blockHeights[len(blockHeights)-1], id.ToHexString())
+		for _, height := range blockHeights {
+			this.addFlightBlock(id, height, hashes[height])
+			err := this.server.Send(reqNode, msgpack.NewBlkDataReq(hashes[height]))
 			if err != nil {
-				log.Warnf("[block-sync] syncBlock Height:%d ReqBlkData error:%s", nextBlockHeight, err)
-				return
-			} else {
-				this.appendReqTime(reqNode.GetID())
+				log.Warnf("[block-sync] syncBlock Height:%d ReqBlkData error:%s", height, err)
+				this.delFlightBlockOfNode(hashes[height], id)
+				break
 			}
+			this.appendReqTime(id)
+		}
+	}
+}
+
+//syncNextBlock request the block next to current block from several nodes, the slowest block blocks the saving
+func (this *BlockSyncMgr) syncNextBlock(height uint32, blockHash common.Uint256) {
+	flightInfos := this.getFlightBlocks(blockHash)
+	requested := make(map[p2pComm.PeerId]bool)
+	for _, info := range flightInfos {
+		requested[info.GetNodeId()] = true
+	}
+	for _, slot := range this.getSyncSlots() {
+		if len(requested) >= SYNC_NEXT_BLOCK_TIMES {
+			return
+		}
+		if requested[slot.id] || slot.height < height {
+			continue
+		}
+		reqNode := this.server.GetPeer(slot.id)
+		if reqNode == nil {
+			continue
+		}
+		requested[slot.id] = true
+		this.addFlightBlock(slot.id, height, blockHash)
+		err := this.server.Send(reqNode, msgpack.NewBlkDataReq(blockHash))
+		if err != nil {
+			log.Warnf("[block-sync] syncNextBlock Height:%d ReqBlkData error:%s", height, err)
+			this.delFlightBlockOfNode(blockHash, slot.id)
+			continue
 		}
-		counter++
-		reqTimes = 1
+		this.appendReqTime(slot.id)
 	}
 }
 
+//getSyncSlots return the free window of nodes, sorted by node weight
+func (this *BlockSyncMgr) getSyncSlots() []*syncSlot {
+	weights := this.getAllNodeWeights()
+	sort.Sort(sort.Reverse(weights))
+	flights := this.getFlightBlockCountByNode()
+	slots := make([]*syncSlot, 0, len(weights))
+	for _, w := range weights {
+		n := this.server.GetPeer(w.id)
+		if n == nil {
+			continue
+		}
+		free := w.window.Size() - flights[w.id]
+		if free <= 0 {
+			continue
+		}
+		slots = append(slots, &syncSlot{id: w.id, height: uint32(n.GetHeight()), free: free})
+	}
+	return slots
+}
+
 //OnHeaderReceive receive header from net
 func (this *BlockSyncMgr) OnHeaderReceive(fromID p2pComm.PeerId, headers []*types.Header) {
 	if len(headers) == 0 {
@@ -550,6 +606,7 @@ func (this *BlockSyncMgr) OnHeaderReceive(fromID p2pComm.PeerId, headers []*type
 	this.delFlightHeader(height)
 	if err != nil {
 		this.addErrorRespCnt(fromID)
+		this.server.AdjustPeerScore(fromID, peer_score.SCORE_INVALID_BLOCK, "invalid headers")
 		n := this.getNodeWeight(fromID)
 		if n != nil && n.GetErrorRespCnt() >= SYNC_MAX_ERROR_RESP_TIMES {
 			this.delNode(fromID)
@@ -597,12 +654,16 @@ func (this *BlockSyncMgr) OnBlockReceive(fromID p2pComm.PeerId, blockSize uint32
 		t := (time.Now().UnixNano() - flightInfo.GetStartTime().UnixNano()) / int64(time.Millisecond)
 		s := float32(blockSize) / float32(t) * 1000.0 / 1024.0
 		this.addNewSpeed(fromID, s)
+		this.growWindow(fromID)
 	}
 
 	this.delFlightBlock(blockHash)
 	curHeaderHeight := this.ledger.GetCurrentHeaderHeight()
 	nextHeader := curHeaderHeight + 1
 	if height > nextHeader {
+		if flightInfo == nil {
+			this.server.AdjustPeerScore(fromID, peer_score.SCORE_UNSOLICITED_BLOCK, "unsolicited block")
+		}
 		return
 	}
 	curBlockHeight := this.ledger.GetCurrentBlockHeight()
@@ -686,6 +747,28 @@ func (this *BlockSyncMgr) getBlockCache(blockHeight uint32) (p2pComm.PeerId, *ty
 	return this.blocksCache.getBlock(blockHeight)
 }
 
+//getBlockCacheRange return the consecutive cached blocks from blockHeight, at most count blocks
+func (this *BlockSyncMgr) getBlockCacheRange(blockHeight uint32, count int) ([]p2pComm.PeerId, []*types.Block,
+	[]*types.CrossChainMsg, []common.Uint256) {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	var fromIDs []p2pComm.PeerId
+	var blocks []*types.Block
+	var ccMsgs []*types.CrossChainMsg
+	var merkleRoots []common.Uint256
+	for height := blockHeight; len(blocks) < count; height++ {
+		fromID, block, ccMsg, merkleRoot := this.blocksCache.getBlock(height)
+		if block == nil {
+			break
+		}
+		fromIDs = append(fromIDs, fromID)
+		blocks = append(blocks, block)
+		ccMsgs = append(ccMsgs, ccMsg)
+		merkleRoots = append(merkleRoots, merkleRoot)
+	}
+	return fromIDs, blocks, ccMsgs, merkleRoots
+}
+
 func (this *BlockSyncMgr) delBlockCache(blockHeight uint32) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -708,6 +791,8 @@ func (this *BlockSyncMgr) releaseSaveBlockLock() {
 	this.saveBlockLock = false
 }
 
+//saveBlock save the consecutive cached blocks in batch, the execution of a block is pipelined
+//with the persisting of its previous block by ledger
 func (this *BlockSyncMgr) saveBlock() {
 	if this.tryGetSaveBlockLock() {
 		return
@@ -717,36 +802,48 @@ func (this *BlockSyncMgr) saveBlock() {
 	nextBlockHeight := curBlockHeight + 1
 	this.clearBlocks(curBlockHeight)
 	for {
-		fromID, nextBlock, ccMsg, merkleRoot := this.getBlockCache(nextBlockHeight)
-		if nextBlock == nil {
+		fromIDs, blocks, ccMsgs, merkleRoots := this.getBlockCacheRange(nextBlockHeight, SYNC_MAX_SAVE_BATCH_SIZE)
+		if len(blocks) == 0 {
+			return
+		}
+		saved, err := this.ledger.AddBlocks(blocks, ccMsgs, merkleRoots)
+		for i := 0; i < saved; i++ {
+			this.delBlockCache(blocks[i].Header.Height)
+			this.server.AdjustPeerScore(fromIDs[i], peer_score.SCORE_VALID_BLOCK, "valid block")
+		}
+		nextBlockHeight += uint32(saved)
+		if saved > 0 {
+			this.pingOutsyncNodes(nextBlockHeight - 1)
+		}
+		if err == nil {
+			continue
+		}
+		if saved >= len(blocks) {
 			return
 		}
-		err := this.ledger.AddBlock(nextBlock, ccMsg, merkleRoot)
+		fromID, nextBlock := fromIDs[saved], blocks[saved]
 		this.delBlockCache(nextBlockHeight)
+		this.addErrorRespCnt(fromID)
+		this.server.AdjustPeerScore(fromID, peer_score.SCORE_INVALID_BLOCK, "invalid block")
+		n := this.getNodeWeight(fromID)
+		if n != nil && n.GetErrorRespCnt() >= SYNC_MAX_ERROR_RESP_TIMES {
+			this.delNode(fromID)
+		}
+		log.Warnf("[block-sync] saveBlock Height:%d AddBlocks error:%s", nextBlockHeight, err)
+		reqNode := this.getNextNode(nextBlockHeight)
+		if reqNode == nil {
+			return
+		}
+		this.addFlightBlock(reqNode.GetID(), nextBlockHeight, nextBlock.Hash())
+		msg := msgpack.NewBlkDataReq(nextBlock.Hash())
+		err = this.server.Send(reqNode, msg)
 		if err != nil {
-			this.addErrorRespCnt(fromID)
-			n := this.getNodeWeight(fromID)
-			if n != nil && n.GetErrorRespCnt() >= SYNC_MAX_ERROR_RESP_TIMES {
-				this.delNode(fromID)
-			}
-			log.Warnf("[block-sync] saveBlock Height:%d AddBlock error:%s", nextBlockHeight, err)
-			reqNode := this.getNextNode(nextBlockHeight)
-			if reqNode == nil {
-				return
-			}
-			this.addFlightBlock(reqNode.GetID(), nextBlockHeight, nextBlock.Hash())
-			msg := msgpack.NewBlkDataReq(nextBlock.Hash())
-			err := this.server.Send(reqNode, msg)
-			if err != nil {
-				log.Warn("[block-sync] require new block error:", err)
-				return
-			} else {
-				this.appendReqTime(reqNode.GetID())
-			}
+			log.Warn("[block-sync] require new block error:", err)
 			return
+		} else {
+			this.appendReqTime(reqNode.GetID())
 		}
-		nextBlockHeight++
-		this.pingOutsyncNodes(nextBlockHeight - 1)
+		return
 	}
 }
 
@@ -836,6 +933,37 @@ func (this *BlockSyncMgr) delFlightBlock(blockHash common.Uint256) bool {
 	return true
 }
 
+//delFlightBlockOfNode remove the flight of block to the node
+func (this *BlockSyncMgr) delFlightBlockOfNode(blockHash common.Uint256, nodeId p2pComm.PeerId) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	infos := this.flightBlocks[blockHash]
+	for i, info := range infos {
+		if info.GetNodeId() == nodeId {
+			infos = append(infos[:i], infos[i+1:]...)
+			break
+		}
+	}
+	if len(infos) == 0 {
+		delete(this.flightBlocks, blockHash)
+	} else {
+		this.flightBlocks[blockHash] = infos
+	}
+}
+
+//getFlightBlockCountByNode return the number of blocks on flight to each node
+func (this *BlockSyncMgr) getFlightBlockCountByNode() map[p2pComm.PeerId]int {
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	counts := make(map[p2pComm.PeerId]int)
+	for _, infos := range this.flightBlocks {
+		for _, info := range infos {
+			counts[info.GetNodeId()]++
+		}
+	}
+	return counts
+}
+
 func (this *BlockSyncMgr) getFlightBlockCount() int {
 	this.lock.RLock()
 	defer this.lock.RUnlock()
@@ -962,6 +1090,22 @@ func (this *BlockSyncMgr) addNewSpeed(nodeId p2pComm.PeerId, speed float32) {
 	}
 }
 
+//growWindow grow a node's window after a requested block received
+func (this *BlockSyncMgr) growWindow(nodeId p2pComm.PeerId) {
+	n := this.getNodeWeight(nodeId)
+	if n != nil {
+		n.window.OnReceive()
+	}
+}
+
+//shrinkWindow shrink a node's window after a request timeout
+func (this *BlockSyncMgr) shrinkWindow(nodeId p2pComm.PeerId) {
+	n := this.getNodeWeight(nodeId)
+	if n != nil {
+		n.window.OnTimeout()
+	}
+}
+
 //pingOutsyncNodes send ping msg to lower height nodes for syncing
 func (this *BlockSyncMgr) pingOutsyncNodes(curHeight uint32) {
 	peers := make([]*peer.Peer, 0)
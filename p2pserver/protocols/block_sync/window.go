@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package block_sync
+
+import (
+	"sync"
+
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+)
+
+const (
+	SYNC_WINDOW_INIT = 8   //Initial number of blocks on flight to a node
+	SYNC_WINDOW_MIN  = 1   //Min number of blocks on flight to a node
+	SYNC_WINDOW_MAX  = 128 //Max number of blocks on flight to a node
+)
+
+//SyncWindow is the adaptive number of blocks on flight to a node.
+//It grows by one when a requested block received and halves when a request timeout
+type SyncWindow struct {
+	size int
+	lock sync.Mutex
+}
+
+//NewSyncWindow return a window with initial size
+func NewSyncWindow() *SyncWindow {
+	return &SyncWindow{size: SYNC_WINDOW_INIT}
+}
+
+//Size return the current window size
+func (this *SyncWindow) Size() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	return this.size
+}
+
+//OnReceive grow the window after a requested block received
+func (this *SyncWindow) OnReceive() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.size < SYNC_WINDOW_MAX {
+		this.size++
+	}
+}
+
+//OnTimeout shrink the window after a request timeout
+func (this *SyncWindow) OnTimeout() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.size /= 2
+	if this.size < SYNC_WINDOW_MIN {
+		this.size = SYNC_WINDOW_MIN
+	}
+}
+
+//syncSlot is the free window of a node
+type syncSlot struct {
+	id     p2pComm.PeerId
+	height uint32
+	free   int
+}
+
+//assignRanges split the ascending heights into consecutive ranges by the free window of slots.
+//the slots in front are assigned first, heights beyond the height of all nodes are left unassigned
+func assignRanges(heights []uint32, slots []*syncSlot) map[p2pComm.PeerId][]uint32 {
+	ranges := make(map[p2pComm.PeerId][]uint32)
+	next := 0
+	for _, slot := range slots {
+		for next < len(heights) && slot.free > 0 && heights[next] <= slot.height {
+			ranges[slot.id] = append(ranges[slot.id], heights[next])
+			slot.free--
+			next++
+		}
+		if next == len(heights) {
+			break
+		}
+	}
+	return ranges
+}
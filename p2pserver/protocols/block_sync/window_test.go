@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package block_sync
+
+import (
+	"testing"
+
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncWindow(t *testing.T) {
+	w := NewSyncWindow()
+	assert.Equal(t, SYNC_WINDOW_INIT, w.Size())
+	w.OnReceive()
+	assert.Equal(t, SYNC_WINDOW_INIT+1, w.Size())
+	w.OnTimeout()
+	assert.Equal(t, (SYNC_WINDOW_INIT+1)/2, w.Size())
+	for i := 0; i < 10; i++ {
+		w.OnTimeout()
+	}
+	assert.Equal(t, SYNC_WINDOW_MIN, w.Size())
+	for i := 0; i < 2*SYNC_WINDOW_MAX; i++ {
+		w.OnReceive()
+	}
+	assert.Equal(t, SYNC_WINDOW_MAX, w.Size())
+}
+
+func TestAssignRanges(t *testing.T) {
+	a, b, c := p2pComm.PseudoPeerIdFromUint64(1), p2pComm.PseudoPeerIdFromUint64(2), p2pComm.PseudoPeerIdFromUint64(3)
+	heights := []uint32{11, 12, 13, 14, 15, 16, 17}
+	slots := []*syncSlot{
+		{id: a, height: 100, free: 3},
+		{id: b, height: 14, free: 10},
+		{id: c, height: 100, free: 1},
+	}
+	ranges := assignRanges(heights, slots)
+	assert.Equal(t, []uint32{11, 12, 13}, ranges[a])
+	assert.Equal(t, []uint32{14}, ranges[b])
+	assert.Equal(t, []uint32{15}, ranges[c])
+	assert.Equal(t, 0, slots[2].free)
+}
@@ -0,0 +1,219 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package compact_block
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/p2pserver/actor/req"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+)
+
+const (
+	COMPACT_BLOCK_MAX_AGE         = 10 //blocks higher than current height minus the offset are relayed as compact block
+	COMPACT_BLOCK_MAX_PENDING     = 16 //max number of compact blocks waiting for missing transactions
+	COMPACT_BLOCK_PENDING_TIMEOUT = 10 //s, compact block waiting for missing transactions longer than it is dropped
+)
+
+//TxPool lookup transactions to reconstruct compact block
+type TxPool interface {
+	GetTransactionHashes() ([]common.Uint256, error)
+	GetTransactions(hashes []common.Uint256) ([]*types.Transaction, error)
+}
+
+type actorTxPool struct{}
+
+func (actorTxPool) GetTransactionHashes() ([]common.Uint256, error) {
+	return req.GetTransactionHashes()
+}
+
+func (actorTxPool) GetTransactions(hashes []common.Uint256) ([]*types.Transaction, error) {
+	return req.GetTransactions(hashes)
+}
+
+type pendingBlock struct {
+	from      p2pComm.PeerId
+	block     *msgTypes.CompactBlock
+	txs       []*types.Transaction
+	missing   []uint32
+	startTime time.Time
+}
+
+//CompactBlockRelay reconstruct compact blocks from txpool, and request missing transactions from peer
+type CompactBlockRelay struct {
+	txPool  TxPool
+	lock    sync.Mutex
+	pending map[common.Uint256]*pendingBlock
+}
+
+//NewCompactBlockRelay return a relay which lookup transactions in txnpool actor
+func NewCompactBlockRelay() *CompactBlockRelay {
+	return newCompactBlockRelay(actorTxPool{})
+}
+
+func newCompactBlockRelay(txPool TxPool) *CompactBlockRelay {
+	return &CompactBlockRelay{
+		txPool:  txPool,
+		pending: make(map[common.Uint256]*pendingBlock),
+	}
+}
+
+//OnCompactBlock reconstruct the block with transactions in txpool. If some transactions are missing, the request
+//of them is returned and the block is completed by OnBlockTxn. If too many blocks are waiting for transactions,
+//the request of full block is returned instead
+func (self *CompactBlockRelay) OnCompactBlock(from p2pComm.PeerId, cmpct *msgTypes.CompactBlock) (*msgTypes.Block,
+	msgTypes.Message, error) {
+	blockHash := cmpct.Header.Hash()
+	self.lock.Lock()
+	self.expirePending()
+	_, ok := self.pending[blockHash]
+	full := len(self.pending) >= COMPACT_BLOCK_MAX_PENDING
+	self.lock.Unlock()
+	if ok {
+		return nil, nil, nil
+	}
+
+	txs := make([]*types.Transaction, len(cmpct.ShortIds))
+	if len(cmpct.ShortIds) != 0 {
+		// all transactions are requested from peer if txpool is unavailable
+		if err := self.lookupTxs(blockHash, cmpct.ShortIds, txs); err != nil {
+			log.Debugf("[p2p]lookup transactions of compact block %d error: %s", cmpct.Header.Height, err)
+		}
+	}
+	var missing []uint32
+	for i, tx := range txs {
+		if tx == nil {
+			missing = append(missing, uint32(i))
+		}
+	}
+	if len(missing) == 0 {
+		block := &types.Block{Header: cmpct.Header, Transactions: txs}
+		if checkTxRoot(block) {
+			return &msgTypes.Block{Blk: block, MerkleRoot: cmpct.MerkleRoot, CCMsg: cmpct.CCMsg}, nil, nil
+		}
+		// short id collision, fetch all transactions
+		for i := range txs {
+			txs[i] = nil
+			missing = append(missing, uint32(i))
+		}
+	}
+	if full {
+		return nil, msgpack.NewFullBlkDataReq(blockHash), nil
+	}
+
+	self.lock.Lock()
+	self.pending[blockHash] = &pendingBlock{
+		from:      from,
+		block:     cmpct,
+		txs:       txs,
+		missing:   missing,
+		startTime: time.Now(),
+	}
+	self.lock.Unlock()
+	return nil, &msgTypes.GetBlockTxn{BlockHash: blockHash, Indexes: missing}, nil
+}
+
+//OnBlockTxn complete the pending compact block with the requested transactions
+func (self *CompactBlockRelay) OnBlockTxn(from p2pComm.PeerId, blockTxn *msgTypes.BlockTxn) (*msgTypes.Block, error) {
+	self.lock.Lock()
+	pending, ok := self.pending[blockTxn.BlockHash]
+	if ok && pending.from == from {
+		delete(self.pending, blockTxn.BlockHash)
+	}
+	self.lock.Unlock()
+	if !ok || pending.from != from {
+		return nil, nil
+	}
+
+	if len(blockTxn.Txs) != len(pending.missing) {
+		return nil, fmt.Errorf("block txn of %s expect %d transactions, got %d", blockTxn.BlockHash.ToHexString(),
+			len(pending.missing), len(blockTxn.Txs))
+	}
+	for i, index := range pending.missing {
+		pending.txs[index] = blockTxn.Txs[i]
+	}
+	block := &types.Block{Header: pending.block.Header, Transactions: pending.txs}
+	if !checkTxRoot(block) {
+		return nil, fmt.Errorf("transactions root of block %s mismatch", blockTxn.BlockHash.ToHexString())
+	}
+	return &msgTypes.Block{Blk: block, MerkleRoot: pending.block.MerkleRoot, CCMsg: pending.block.CCMsg}, nil
+}
+
+//lookupTxs fill txs with the transactions in txpool matching the short ids
+func (self *CompactBlockRelay) lookupTxs(blockHash common.Uint256, shortIds []uint64, txs []*types.Transaction) error {
+	poolHashes, err := self.txPool.GetTransactionHashes()
+	if err != nil {
+		return err
+	}
+	byShortId := make(map[uint64]common.Uint256, len(poolHashes))
+	ambiguous := make(map[uint64]bool)
+	for _, hash := range poolHashes {
+		id := msgTypes.ShortTxId(blockHash, hash)
+		if _, ok := byShortId[id]; ok {
+			ambiguous[id] = true
+		}
+		byShortId[id] = hash
+	}
+
+	var hashes []common.Uint256
+	var indexes []int
+	for i, id := range shortIds {
+		hash, ok := byShortId[id]
+		if ok && !ambiguous[id] {
+			hashes = append(hashes, hash)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	found, err := self.txPool.GetTransactions(hashes)
+	if err != nil {
+		return err
+	}
+	for i, tx := range found {
+		txs[indexes[i]] = tx
+	}
+	return nil
+}
+
+//need hold lock
+func (self *CompactBlockRelay) expirePending() {
+	now := time.Now()
+	for hash, pending := range self.pending {
+		if now.Sub(pending.startTime) >= COMPACT_BLOCK_PENDING_TIMEOUT*time.Second {
+			delete(self.pending, hash)
+		}
+	}
+}
+
+func checkTxRoot(block *types.Block) bool {
+	hashes := make([]common.Uint256, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		hashes = append(hashes, tx.Hash())
+	}
+	return common.ComputeMerkleRoot(hashes) == block.Header.TransactionsRoot
+}
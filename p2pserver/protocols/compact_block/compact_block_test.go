@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package compact_block
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTxPool map[common.Uint256]*types.Transaction
+
+func (self fakeTxPool) GetTransactionHashes() ([]common.Uint256, error) {
+	var hashes []common.Uint256
+	for hash := range self {
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func (self fakeTxPool) GetTransactions(hashes []common.Uint256) ([]*types.Transaction, error) {
+	var txs []*types.Transaction
+	for _, hash := range hashes {
+		txs = append(txs, self[hash])
+	}
+	return txs, nil
+}
+
+func newTestBlock(t *testing.T, txNum int) *types.Block {
+	var txs []*types.Transaction
+	var hashes []common.Uint256
+	for i := 0; i < txNum; i++ {
+		mutable := &types.MutableTransaction{TxType: types.InvokeNeo, Nonce: uint32(i),
+			Payload: &payload.InvokeCode{Code: []byte{1}}, Sigs: []types.Sig{}}
+		tx, err := mutable.IntoImmutable()
+		assert.Nil(t, err)
+		txs = append(txs, tx)
+		hashes = append(hashes, tx.Hash())
+	}
+	header := &types.Header{Height: 10, TransactionsRoot: common.ComputeMerkleRoot(hashes)}
+	return &types.Block{Header: header, Transactions: txs}
+}
+
+func TestReconstructFromTxPool(t *testing.T) {
+	block := newTestBlock(t, 4)
+	pool := fakeTxPool{}
+	for _, tx := range block.Transactions {
+		pool[tx.Hash()] = tx
+	}
+	relay := newCompactBlockRelay(pool)
+	cmpct := msgpack.NewCompactBlock(block, nil, common.UINT256_EMPTY).(*msgTypes.CompactBlock)
+	full, req, err := relay.OnCompactBlock(p2pComm.PseudoPeerIdFromUint64(1), cmpct)
+	assert.Nil(t, err)
+	assert.Nil(t, req)
+	assert.Equal(t, block.Hash(), full.Blk.Hash())
+	assert.Equal(t, block.Transactions, full.Blk.Transactions)
+}
+
+func TestRequestMissingTxs(t *testing.T) {
+	block := newTestBlock(t, 4)
+	pool := fakeTxPool{}
+	pool[block.Transactions[0].Hash()] = block.Transactions[0]
+	pool[block.Transactions[2].Hash()] = block.Transactions[2]
+	relay := newCompactBlockRelay(pool)
+	from := p2pComm.PseudoPeerIdFromUint64(1)
+	cmpct := msgpack.NewCompactBlock(block, nil, common.UINT256_EMPTY).(*msgTypes.CompactBlock)
+	full, req, err := relay.OnCompactBlock(from, cmpct)
+	assert.Nil(t, err)
+	assert.Nil(t, full)
+	assert.Equal(t, []uint32{1, 3}, req.(*msgTypes.GetBlockTxn).Indexes)
+
+	// response from other peer is ignored
+	txn := &msgTypes.BlockTxn{BlockHash: block.Hash(), Txs: []*types.Transaction{block.Transactions[1], block.Transactions[3]}}
+	full, err = relay.OnBlockTxn(p2pComm.PseudoPeerIdFromUint64(2), txn)
+	assert.Nil(t, err)
+	assert.Nil(t, full)
+
+	full, err = relay.OnBlockTxn(from, txn)
+	assert.Nil(t, err)
+	assert.Equal(t, block.Transactions, full.Blk.Transactions)
+}
+
+func TestInvalidBlockTxn(t *testing.T) {
+	block := newTestBlock(t, 2)
+	relay := newCompactBlockRelay(fakeTxPool{})
+	from := p2pComm.PseudoPeerIdFromUint64(1)
+	cmpct := msgpack.NewCompactBlock(block, nil, common.UINT256_EMPTY).(*msgTypes.CompactBlock)
+	_, req, err := relay.OnCompactBlock(from, cmpct)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint32{0, 1}, req.(*msgTypes.GetBlockTxn).Indexes)
+
+	txn := &msgTypes.BlockTxn{BlockHash: block.Hash(), Txs: []*types.Transaction{block.Transactions[1], block.Transactions[0]}}
+	_, err = relay.OnBlockTxn(from, txn)
+	assert.NotNil(t, err)
+}
+
+func TestPendingFullRequestFullBlock(t *testing.T) {
+	relay := newCompactBlockRelay(fakeTxPool{})
+	from := p2pComm.PseudoPeerIdFromUint64(1)
+	for i := 0; i < COMPACT_BLOCK_MAX_PENDING; i++ {
+		block := newTestBlock(t, 1)
+		block.Header.Height = uint32(i)
+		_, req, err := relay.OnCompactBlock(from, msgpack.NewCompactBlock(block, nil, common.UINT256_EMPTY).(*msgTypes.CompactBlock))
+		assert.Nil(t, err)
+		_, ok := req.(*msgTypes.GetBlockTxn)
+		assert.True(t, ok)
+	}
+
+	block := newTestBlock(t, 1)
+	block.Header.Height = COMPACT_BLOCK_MAX_PENDING
+	full, req, err := relay.OnCompactBlock(from, msgpack.NewCompactBlock(block, nil, common.UINT256_EMPTY).(*msgTypes.CompactBlock))
+	assert.Nil(t, err)
+	assert.Nil(t, full)
+	dataReq, ok := req.(*msgTypes.DataReq)
+	assert.True(t, ok)
+	assert.Equal(t, common.FULL_BLOCK, dataReq.DataType)
+	assert.Equal(t, block.Hash(), dataReq.Hash)
+}
@@ -21,6 +21,7 @@ package discovery
 import (
 	"net"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/ontio/ontology/common/log"
@@ -34,11 +35,12 @@ import (
 )
 
 type Discovery struct {
-	dht     *dht.DHT
-	net     p2p.P2P
-	id      common.PeerId
-	quit    chan bool
-	maskSet *strset.Set
+	dht      *dht.DHT
+	net      p2p.P2P
+	id       common.PeerId
+	quit     chan bool
+	maskLock sync.RWMutex
+	maskSet  *strset.Set
 }
 
 func NewDiscovery(net p2p.P2P, maskLst []string, refleshInterval time.Duration) *Discovery {
@@ -64,6 +66,39 @@ func (self *Discovery) Stop() {
 	close(self.quit)
 }
 
+//AddMaskPeer hides the ip from peers not in mask list
+func (self *Discovery) AddMaskPeer(ip string) {
+	self.maskLock.Lock()
+	defer self.maskLock.Unlock()
+	self.maskSet.Add(ip)
+}
+
+//RemoveMaskPeer removes the ip from mask list
+func (self *Discovery) RemoveMaskPeer(ip string) bool {
+	self.maskLock.Lock()
+	defer self.maskLock.Unlock()
+	if !self.maskSet.Has(ip) {
+		return false
+	}
+	self.maskSet.Remove(ip)
+	return true
+}
+
+//GetMaskPeers return the mask list
+func (self *Discovery) GetMaskPeers() []string {
+	self.maskLock.RLock()
+	defer self.maskLock.RUnlock()
+	return self.maskSet.List()
+}
+
+//masked reports whether the ip is hidden from the remote ip
+func (self *Discovery) masked(remoteIP, ip string) bool {
+	self.maskLock.RLock()
+	defer self.maskLock.RUnlock()
+	// mask peer see everyone, but other's will not see mask node
+	return self.maskSet.Size() > 0 && !self.maskSet.Has(remoteIP) && self.maskSet.Has(ip)
+}
+
 func (self *Discovery) OnAddPeer(info *peer.PeerInfo) {
 	self.dht.Update(info.Id, info.RemoteListenAddress())
 }
@@ -155,23 +190,21 @@ func (self *Discovery) FindNodeHandle(ctx *p2p.Context, freq *types.FindNodeReq)
 	// mask peer see everyone, but other's will not see mask node
 	// if remotePeer is in msk-list, give them everything
 	// not in mask set means they are in the other side
-	if self.maskSet.Size() > 0 && !self.maskSet.Has(remoteIP.String()) {
-		mskedAddrs := make([]common.PeerIDAddressPair, 0)
-		// filter out the masked node
-		for _, pair := range fresp.CloserPeers {
-			ip, _, err := net.SplitHostPort(pair.Address)
-			if err != nil {
-				continue
-			}
-			// hide mask node
-			if self.maskSet.Has(ip) {
-				continue
-			}
-			mskedAddrs = append(mskedAddrs, pair)
+	mskedAddrs := make([]common.PeerIDAddressPair, 0)
+	// filter out the masked node
+	for _, pair := range fresp.CloserPeers {
+		ip, _, err := net.SplitHostPort(pair.Address)
+		if err != nil {
+			continue
 		}
-		// replace with masked nodes
-		fresp.CloserPeers = mskedAddrs
+		// hide mask node
+		if self.masked(remoteIP.String(), ip) {
+			continue
+		}
+		mskedAddrs = append(mskedAddrs, pair)
 	}
+	// replace with masked nodes
+	fresp.CloserPeers = mskedAddrs
 
 	log.Debugf("[dht] find %d more closer peers:", len(fresp.CloserPeers))
 	for _, curpa := range fresp.CloserPeers {
@@ -249,20 +282,18 @@ func (self *Discovery) AddrReqHandle(ctx *p2p.Context) {
 	// mask peer see everyone, but other's will not see mask node
 	// if remotePeer is in msk-list, give them everthing
 	// not in mask set means they are in the other side
-	if self.maskSet.Size() > 0 && !self.maskSet.Has(remoteIP.String()) {
-		mskedAddrs := make([]common.PeerAddr, 0)
-		for _, addr := range addrs {
-			ip := net.IP(addr.IpAddr[:])
-			address := ip.To16().String()
-			// hide mask node
-			if self.maskSet.Has(address) {
-				continue
-			}
-			mskedAddrs = append(mskedAddrs, addr)
+	mskedAddrs := make([]common.PeerAddr, 0)
+	for _, addr := range addrs {
+		ip := net.IP(addr.IpAddr[:])
+		address := ip.To16().String()
+		// hide mask node
+		if self.masked(remoteIP.String(), address) {
+			continue
 		}
-		// replace with mskedAddrs
-		addrs = mskedAddrs
+		mskedAddrs = append(mskedAddrs, addr)
 	}
+	// replace with mskedAddrs
+	addrs = mskedAddrs
 
 	msg := msgpack.NewAddrs(addrs)
 	err := remotePeer.Send(msg)
@@ -18,6 +18,7 @@
 package heatbeat
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/ontio/ontology/common/config"
@@ -30,10 +31,11 @@ import (
 )
 
 type HeartBeat struct {
-	net    p2p.P2P
-	id     common.PeerId
-	quit   chan bool
-	ledger *ledger.Ledger //ledger
+	lastPing int64 // unix nano time of last ping, accessed atomically
+	net      p2p.P2P
+	id       common.PeerId
+	quit     chan bool
+	ledger   *ledger.Ledger //ledger
 }
 
 func NewHeartBeat(net p2p.P2P, ld *ledger.Ledger) *HeartBeat {
@@ -71,6 +73,7 @@ func (this *HeartBeat) heartBeatService() {
 func (this *HeartBeat) ping() {
 	height := this.ledger.GetCurrentBlockHeight()
 	ping := msgpack.NewPingMsg(uint64(height))
+	atomic.StoreInt64(&this.lastPing, time.Now().UnixNano())
 	go this.net.Broadcast(ping)
 }
 
@@ -104,6 +107,9 @@ func (this *HeartBeat) PingHandle(ctx *p2p.Context, ping *types.Ping) {
 }
 
 func (this *HeartBeat) PongHandle(ctx *p2p.Context, pong *types.Pong) {
-	remotePeer := ctx.Network()
+	remotePeer := ctx.Sender()
 	remotePeer.SetHeight(pong.Height)
+	if lastPing := atomic.LoadInt64(&this.lastPing); lastPing != 0 {
+		remotePeer.SetLatency(time.Since(time.Unix(0, lastPing)))
+	}
 }
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package light_client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/log"
+	"github.com/ontio/ontology/core/ledger"
+	scom "github.com/ontio/ontology/core/store/common"
+	"github.com/ontio/ontology/core/types"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
+)
+
+const (
+	LIGHT_REQ_TIMEOUT    = 10 //timeout in second of light node request
+	LIGHT_REQ_PEER_NUM   = 3  //max number of full node peers a request is sent to
+	LIGHT_STORAGE_QUORUM = 2  //min number of full node peers which must agree on a storage value
+)
+
+var (
+	ErrNoFullPeer          = errors.New("no full node peer available")
+	ErrRequestTimeout      = errors.New("request to full node peers timeout")
+	ErrInconsistentStorage = errors.New("storage from full node peers is inconsistent")
+	ErrStorageNoQuorum     = errors.New("storage is not confirmed by enough full node peers")
+)
+
+type pendingRequest struct {
+	peers  map[p2pComm.PeerId]bool //peers which have not responded
+	respCh chan msgTypes.Message
+}
+
+//LightClient fetch states and transactions from full node peers for light node
+type LightClient struct {
+	net     p2p.P2P
+	ledger  *ledger.Ledger
+	lock    sync.Mutex
+	nextId  uint64
+	pending map[uint64]*pendingRequest
+}
+
+func NewLightClient(ld *ledger.Ledger) *LightClient {
+	return &LightClient{
+		ledger:  ld,
+		pending: make(map[uint64]*pendingRequest),
+	}
+}
+
+//Start make the light client send requests through net
+func (self *LightClient) Start(net p2p.P2P) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.net = net
+}
+
+//OnResponse pass the response from peer to the pending request
+func (self *LightClient) OnResponse(from p2pComm.PeerId, reqId uint64, msg msgTypes.Message) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	req, ok := self.pending[reqId]
+	if !ok || !req.peers[from] {
+		log.Debugf("[light] unexpected response %d from peer %s", reqId, from.ToHexString())
+		return
+	}
+	delete(req.peers, from)
+	req.respCh <- msg
+}
+
+//GetStorage return the storage value of contract agreed by all responding full node peers, at least
+//LIGHT_STORAGE_QUORUM peers must respond. Storage can not be proved against block header since the
+//ledger has no state trie, so it is cross checked among peers instead
+func (self *LightClient) GetStorage(contract common.Address, key []byte) ([]byte, error) {
+	var first *msgTypes.StorageResp
+	agreed := 0
+	consistent := true
+	err := self.query(func(reqId uint64) msgTypes.Message {
+		return msgpack.NewStorageReq(reqId, contract, key)
+	}, func(msg msgTypes.Message) bool {
+		resp, ok := msg.(*msgTypes.StorageResp)
+		if !ok {
+			return false
+		}
+		if first == nil {
+			first = resp
+			agreed = 1
+			return false
+		}
+		if resp.Found != first.Found || !bytes.Equal(resp.Value, first.Value) {
+			consistent = false
+			return true
+		}
+		agreed++
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, ErrRequestTimeout
+	}
+	if !consistent {
+		return nil, ErrInconsistentStorage
+	}
+	if agreed < LIGHT_STORAGE_QUORUM {
+		return nil, ErrStorageNoQuorum
+	}
+	if !first.Found {
+		return nil, scom.ErrNotFound
+	}
+	return first.Value, nil
+}
+
+//GetTransaction return the transaction and its block height. The merkle path of transaction
+//is verified against the transactions root of local block header
+func (self *LightClient) GetTransaction(txHash common.Uint256) (*types.Transaction, uint32, error) {
+	var tx *types.Transaction
+	var height uint32
+	err := self.query(func(reqId uint64) msgTypes.Message {
+		return msgpack.NewTxProofReq(reqId, txHash)
+	}, func(msg msgTypes.Message) bool {
+		resp, ok := msg.(*msgTypes.TxProofResp)
+		if !ok || !resp.Found {
+			return false
+		}
+		if err := self.verifyTxProof(txHash, resp); err != nil {
+			log.Warnf("[light] verify proof of tx %s error: %s", txHash.ToHexString(), err)
+			return false
+		}
+		tx, height = resp.Tx, resp.Height
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if tx == nil {
+		return nil, 0, scom.ErrNotFound
+	}
+	return tx, height, nil
+}
+
+func (self *LightClient) verifyTxProof(txHash common.Uint256, resp *msgTypes.TxProofResp) error {
+	if resp.Tx.Hash() != txHash {
+		return fmt.Errorf("transaction hash mismatch")
+	}
+	header, err := self.ledger.GetHeaderByHeight(resp.Height)
+	if err != nil || header == nil {
+		return fmt.Errorf("can not get header at height %d", resp.Height)
+	}
+	if !common.VerifyMerklePath(txHash, resp.Index, resp.Path, header.TransactionsRoot) {
+		return fmt.Errorf("merkle path does not match transactions root at height %d", resp.Height)
+	}
+	return nil
+}
+
+//query send request to full node peers and pass the responses to handle until it returns true,
+//all peers have responded or timeout. ErrRequestTimeout is returned if no peer responds
+func (self *LightClient) query(newReq func(reqId uint64) msgTypes.Message, handle func(msgTypes.Message) bool) error {
+	self.lock.Lock()
+	net := self.net
+	if net == nil {
+		self.lock.Unlock()
+		return ErrNoFullPeer
+	}
+	peers := self.fullPeers(net)
+	if len(peers) == 0 {
+		self.lock.Unlock()
+		return ErrNoFullPeer
+	}
+	self.nextId++
+	reqId := self.nextId
+	req := &pendingRequest{
+		peers:  make(map[p2pComm.PeerId]bool),
+		respCh: make(chan msgTypes.Message, len(peers)),
+	}
+	for _, p := range peers {
+		req.peers[p.GetID()] = true
+	}
+	self.pending[reqId] = req
+	self.lock.Unlock()
+
+	defer func() {
+		self.lock.Lock()
+		delete(self.pending, reqId)
+		self.lock.Unlock()
+	}()
+
+	msg := newReq(reqId)
+	sent := 0
+	for _, p := range peers {
+		if err := net.Send(p, msg); err != nil {
+			log.Warnf("[light] send request to peer %s error: %s", p.GetAddr(), err)
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return ErrNoFullPeer
+	}
+
+	timer := time.NewTimer(LIGHT_REQ_TIMEOUT * time.Second)
+	defer timer.Stop()
+	for received := 0; received < sent; received++ {
+		select {
+		case resp := <-req.respCh:
+			if handle(resp) {
+				return nil
+			}
+		case <-timer.C:
+			if received == 0 {
+				return ErrRequestTimeout
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+//fullPeers return the highest full node peers
+func (self *LightClient) fullPeers(net p2p.P2P) []*peer.Peer {
+	var peers []*peer.Peer
+	for _, p := range net.GetNeighbors() {
+		if p.GetServices() != p2pComm.LIGHT_NODE {
+			peers = append(peers, p)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].GetHeight() > peers[j].GetHeight()
+	})
+	if len(peers) > LIGHT_REQ_PEER_NUM {
+		peers = peers[:LIGHT_REQ_PEER_NUM]
+	}
+	return peers
+}
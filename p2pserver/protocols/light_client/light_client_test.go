@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package light_client
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeNetwork answers storage requests with the value of each peer
+type fakeNetwork struct {
+	p2p.P2P
+	client *LightClient
+	peers  []*peer.Peer
+	values map[p2pComm.PeerId][]byte
+}
+
+func newFakeNetwork(client *LightClient, values ...string) *fakeNetwork {
+	net := &fakeNetwork{client: client, values: make(map[p2pComm.PeerId][]byte)}
+	for i, value := range values {
+		p := peer.NewPeer()
+		p.Info.Id = p2pComm.PseudoPeerIdFromUint64(uint64(i + 1))
+		net.peers = append(net.peers, p)
+		net.values[p.Info.Id] = []byte(value)
+	}
+	return net
+}
+
+func (self *fakeNetwork) GetNeighbors() []*peer.Peer {
+	return self.peers
+}
+
+func (self *fakeNetwork) Send(p *peer.Peer, msg msgTypes.Message) error {
+	req := msg.(*msgTypes.StorageReq)
+	resp := &msgTypes.StorageResp{ReqId: req.ReqId, Found: true, Value: self.values[p.GetID()]}
+	go self.client.OnResponse(p.GetID(), req.ReqId, resp)
+	return nil
+}
+
+func TestGetStorageQuorum(t *testing.T) {
+	client := NewLightClient(nil)
+	client.Start(newFakeNetwork(client, "value"))
+	_, err := client.GetStorage(common.Address{1}, []byte("key"))
+	assert.Equal(t, ErrStorageNoQuorum, err)
+
+	client.Start(newFakeNetwork(client, "value", "value"))
+	value, err := client.GetStorage(common.Address{1}, []byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	client.Start(newFakeNetwork(client, "value", "value", "other"))
+	_, err = client.GetStorage(common.Address{1}, []byte("key"))
+	assert.Equal(t, ErrInconsistentStorage, err)
+}
@@ -21,26 +21,36 @@ package protocols
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/common/config"
 	"github.com/ontio/ontology/common/log"
 	"github.com/ontio/ontology/core/ledger"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/types"
+	ontErrors "github.com/ontio/ontology/errors"
 	actor "github.com/ontio/ontology/p2pserver/actor/req"
 	msgCommon "github.com/ontio/ontology/p2pserver/common"
 	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
 	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
+	"github.com/ontio/ontology/p2pserver/peer_score"
 	"github.com/ontio/ontology/p2pserver/protocols/block_sync"
 	"github.com/ontio/ontology/p2pserver/protocols/bootstrap"
+	"github.com/ontio/ontology/p2pserver/protocols/compact_block"
 	"github.com/ontio/ontology/p2pserver/protocols/discovery"
 	"github.com/ontio/ontology/p2pserver/protocols/heatbeat"
+	"github.com/ontio/ontology/p2pserver/protocols/light_client"
 	"github.com/ontio/ontology/p2pserver/protocols/recent_peers"
 	"github.com/ontio/ontology/p2pserver/protocols/reconnect"
+	"github.com/ontio/ontology/p2pserver/protocols/tx_relay"
 )
 
+//TX_RESULT_TIMEOUT is the max time waiting the txnpool verify a tx from peer
+const TX_RESULT_TIMEOUT = 10 * time.Second
+
 //respCache cache for some response data
 var respCache *lru.ARCCache
 
@@ -55,14 +65,25 @@ type MsgHandler struct {
 	heatBeat                 *heatbeat.HeartBeat
 	bootstrap                *bootstrap.BootstrapService
 	persistRecentPeerService *recent_peers.PersistRecentPeerService
+	lightClient              *light_client.LightClient
+	compactBlock             *compact_block.CompactBlockRelay
+	txRelay                  *tx_relay.TxRelay
 	ledger                   *ledger.Ledger
+	net                      p2p.P2P
 }
 
 func NewMsgHandler(ld *ledger.Ledger) *MsgHandler {
-	return &MsgHandler{ledger: ld}
+	handler := &MsgHandler{ledger: ld}
+	if ld.IsLightMode() {
+		handler.lightClient = light_client.NewLightClient(ld)
+	} else {
+		handler.compactBlock = compact_block.NewCompactBlockRelay()
+	}
+	return handler
 }
 
 func (self *MsgHandler) start(net p2p.P2P) {
+	self.net = net
 	self.blockSync = block_sync.NewBlockSyncMgr(net, self.ledger)
 	self.reconnect = reconnect.NewReconectService(net)
 	self.discovery = discovery.NewDiscovery(net, config.DefConfig.P2PNode.ReservedCfg.MaskPeers, 0)
@@ -70,6 +91,11 @@ func (self *MsgHandler) start(net p2p.P2P) {
 	self.bootstrap = bootstrap.NewBootstrapService(net, seeds)
 	self.heatBeat = heatbeat.NewHeartBeat(net, self.ledger)
 	self.persistRecentPeerService = recent_peers.NewPersistRecentPeerService(net)
+	self.txRelay = tx_relay.NewTxRelay(net)
+	if self.lightClient != nil {
+		self.lightClient.Start(net)
+	}
+	self.txRelay.Start()
 	go self.persistRecentPeerService.Start()
 	go self.blockSync.Start()
 	go self.reconnect.Start()
@@ -85,6 +111,58 @@ func (self *MsgHandler) stop() {
 	self.persistRecentPeerService.Stop()
 	self.heatBeat.Stop()
 	self.bootstrap.Stop()
+	self.txRelay.Stop()
+}
+
+//AddMaskPeer hides the ip from peers not in mask list
+func (self *MsgHandler) AddMaskPeer(ip string) {
+	self.discovery.AddMaskPeer(ip)
+}
+
+//RemoveMaskPeer removes the ip from mask list
+func (self *MsgHandler) RemoveMaskPeer(ip string) bool {
+	return self.discovery.RemoveMaskPeer(ip)
+}
+
+//GetMaskPeers return the mask list
+func (self *MsgHandler) GetMaskPeers() []string {
+	return self.discovery.GetMaskPeers()
+}
+
+//RelayTransaction announces the transaction to neighbors
+func (self *MsgHandler) RelayTransaction(tx *types.Transaction) {
+	if self.txRelay != nil {
+		self.txRelay.Relay(tx)
+	}
+}
+
+//RelayBlocks sends the new blocks to neighbors as compact blocks if they support it, others are announced the blocks
+func (self *MsgHandler) RelayBlocks(hashes []common.Uint256) {
+	if self.net == nil {
+		return
+	}
+	inv := msgpack.NewInv(msgpack.NewInvPayload(common.BLOCK, hashes))
+	cmpcts := make([]msgTypes.Message, 0, len(hashes))
+	for _, hash := range hashes {
+		blk, err := getBlockMessage(hash)
+		if err != nil {
+			log.Debugf("[p2p]relay compact block: %s", err)
+			break
+		}
+		cmpcts = append(cmpcts, msgpack.NewCompactBlock(blk.Blk, blk.CCMsg, blk.MerkleRoot))
+	}
+	for _, nbr := range self.net.GetNeighbors() {
+		if !nbr.GetRelay() {
+			continue
+		}
+		if !nbr.Info.CompactBlock || len(cmpcts) != len(hashes) {
+			go self.net.SendTo(nbr.GetID(), inv)
+			continue
+		}
+		for _, cmpct := range cmpcts {
+			go self.net.SendTo(nbr.GetID(), cmpct)
+		}
+	}
 }
 
 func (self *MsgHandler) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage) {
@@ -92,16 +170,21 @@ func (self *MsgHandler) HandleSystemMessage(net p2p.P2P, msg p2p.SystemMessage)
 	case p2p.NetworkStart:
 		self.start(net)
 	case p2p.PeerConnected:
-		self.blockSync.OnAddNode(m.Info.Id)
+		//light node has no block to sync from
+		if m.Info.Services != msgCommon.LIGHT_NODE {
+			self.blockSync.OnAddNode(m.Info.Id)
+		}
 		self.reconnect.OnAddPeer(m.Info)
 		self.discovery.OnAddPeer(m.Info)
 		self.bootstrap.OnAddPeer(m.Info)
+		self.txRelay.OnAddPeer(m.Info)
 		self.persistRecentPeerService.AddNodeAddr(m.Info.RemoteListenAddress())
 	case p2p.PeerDisConnected:
 		self.blockSync.OnDelNode(m.Info.Id)
 		self.reconnect.OnDelPeer(m.Info)
 		self.discovery.OnDelPeer(m.Info)
 		self.bootstrap.OnDelPeer(m.Info)
+		self.txRelay.OnDelPeer(m.Info)
 		self.persistRecentPeerService.DelNodeAddr(m.Info.RemoteListenAddress())
 	case p2p.NetworkStop:
 		self.stop()
@@ -127,18 +210,64 @@ func (self *MsgHandler) HandlePeerMessage(ctx *p2p.Context, msg msgTypes.Message
 		self.blockSync.OnHeaderReceive(ctx.Sender().GetID(), m.BlkHdr)
 	case *msgTypes.Block:
 		self.blockHandle(ctx, m)
+	case *msgTypes.CompactBlock:
+		if self.compactBlock != nil {
+			self.compactBlockHandle(ctx, m)
+		}
+	case *msgTypes.GetBlockTxn:
+		if !self.ledger.IsLightMode() {
+			GetBlockTxnHandle(ctx, m)
+		}
+	case *msgTypes.BlockTxn:
+		if self.compactBlock != nil {
+			self.blockTxnHandle(ctx, m)
+		}
 	case *msgTypes.Consensus:
 		ConsensusHandle(ctx, m)
 	case *msgTypes.Trn:
-		TransactionHandle(ctx, m)
+		//light node does not keep transactions of others since they will never be cleaned by blocks
+		if !self.ledger.IsLightMode() {
+			self.txRelay.MarkKnown(ctx.Sender().GetID(), m.Txn.Hash())
+			TransactionHandle(ctx, m)
+		}
 	case *msgTypes.Addr:
 		self.discovery.AddrHandle(ctx, m)
 	case *msgTypes.DataReq:
-		DataReqHandle(ctx, m)
+		if self.ledger.IsLightMode() {
+			//light node has no block and transaction data to share
+			err := ctx.Sender().Send(msgpack.NewNotFound(m.Hash))
+			if err != nil {
+				log.Warn(err)
+			}
+		} else {
+			DataReqHandle(ctx, m)
+		}
 	case *msgTypes.Inv:
-		InvHandle(ctx, m)
+		if common.InventoryType(m.P.InvType) == common.TRANSACTION {
+			if !self.ledger.IsLightMode() {
+				self.txInvHandle(ctx, m)
+			}
+		} else {
+			InvHandle(ctx, m)
+		}
 	case *msgTypes.NotFound:
 		log.Debug("[p2p]receive notFound message, hash is ", m.Hash)
+	case *msgTypes.StorageReq:
+		if !self.ledger.IsLightMode() {
+			StorageReqHandle(ctx, m)
+		}
+	case *msgTypes.TxProofReq:
+		if !self.ledger.IsLightMode() {
+			TxProofReqHandle(ctx, m)
+		}
+	case *msgTypes.StorageResp:
+		if self.lightClient != nil {
+			self.lightClient.OnResponse(ctx.Sender().GetID(), m.ReqId, m)
+		}
+	case *msgTypes.TxProofResp:
+		if self.lightClient != nil {
+			self.lightClient.OnResponse(ctx.Sender().GetID(), m.ReqId, m)
+		}
 	default:
 		msgType := msg.CmdType()
 		if msgType == msgCommon.VERACK_TYPE || msgType == msgCommon.VERSION_TYPE {
@@ -173,6 +302,7 @@ func (self *MsgHandler) blockHandle(ctx *p2p.Context, block *msgTypes.Block) {
 	stateHashHeight := config.GetStateHashCheckHeight(config.DefConfig.P2PNode.NetworkId)
 	if block.Blk.Header.Height >= stateHashHeight && block.MerkleRoot == common.UINT256_EMPTY {
 		remotePeer := ctx.Sender()
+		ctx.Network().AdjustPeerScore(remotePeer.GetID(), peer_score.SCORE_INVALID_BLOCK, "block without state merkle root")
 		remotePeer.Close()
 		return
 	}
@@ -180,11 +310,92 @@ func (self *MsgHandler) blockHandle(ctx *p2p.Context, block *msgTypes.Block) {
 	self.blockSync.OnBlockReceive(ctx.Sender().GetID(), ctx.MsgSize, block.Blk, block.CCMsg, block.MerkleRoot)
 }
 
+// compactBlockHandle reconstructs the compact block from txpool, and requests the missing transactions
+func (self *MsgHandler) compactBlockHandle(ctx *p2p.Context, cmpct *msgTypes.CompactBlock) {
+	remotePeer := ctx.Sender()
+	// new block is relayed by consensus nodes which may have it already
+	if cmpct.Header.Height <= self.ledger.GetCurrentBlockHeight() {
+		return
+	}
+	block, req, err := self.compactBlock.OnCompactBlock(remotePeer.GetID(), cmpct)
+	if err != nil {
+		log.Debugf("[p2p]failed to reconstruct compact block %d: %s", cmpct.Header.Height, err)
+		return
+	}
+	if block != nil {
+		self.blockHandle(ctx, block)
+		return
+	}
+	if req != nil {
+		err = remotePeer.Send(req)
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+}
+
+// blockTxnHandle completes the compact block with the missing transactions from peer
+func (self *MsgHandler) blockTxnHandle(ctx *p2p.Context, blockTxn *msgTypes.BlockTxn) {
+	block, err := self.compactBlock.OnBlockTxn(ctx.Sender().GetID(), blockTxn)
+	if err != nil {
+		ctx.Network().AdjustPeerScore(ctx.Sender().GetID(), peer_score.SCORE_INVALID_BLOCK, err.Error())
+		return
+	}
+	if block != nil {
+		self.blockHandle(ctx, block)
+	}
+}
+
+// GetBlockTxnHandle handles the missing transactions req of compact block from peer
+func GetBlockTxnHandle(ctx *p2p.Context, req *msgTypes.GetBlockTxn) {
+	remotePeer := ctx.Sender()
+	block, err := ledger.DefLedger.GetBlockByHash(req.BlockHash)
+	if err != nil || block == nil {
+		err = remotePeer.Send(msgpack.NewNotFound(req.BlockHash))
+		if err != nil {
+			log.Warn(err)
+		}
+		return
+	}
+	txs := make([]*types.Transaction, 0, len(req.Indexes))
+	for _, index := range req.Indexes {
+		if int(index) >= len(block.Transactions) {
+			ctx.Network().AdjustPeerScore(remotePeer.GetID(), peer_score.SCORE_MALFORMED_MSG, "invalid block txn index")
+			return
+		}
+		txs = append(txs, block.Transactions[index])
+	}
+	err = remotePeer.Send(msgpack.NewBlockTxn(req.BlockHash, txs))
+	if err != nil {
+		log.Warn(err)
+	}
+}
+
+// txInvHandle requests the announced transactions which are unknown from peer
+func (self *MsgHandler) txInvHandle(ctx *p2p.Context, inv *msgTypes.Inv) {
+	remotePeer := ctx.Sender()
+	reqs := self.txRelay.OnInv(remotePeer.GetID(), inv.P.Blk, func(hash common.Uint256) bool {
+		if txCache.Contains(hash) {
+			return true
+		}
+		tx, err := ledger.DefLedger.GetTransaction(hash)
+		return err == nil && tx != nil
+	})
+	for _, hash := range reqs {
+		err := remotePeer.Send(msgpack.NewTxnDataReq(hash))
+		if err != nil {
+			log.Warn(err)
+			return
+		}
+	}
+}
+
 // ConsensusHandle handles the consensus message from peer
 func ConsensusHandle(ctx *p2p.Context, consensus *msgTypes.Consensus) {
 	if actor.ConsensusPid != nil {
 		if err := consensus.Cons.Verify(); err != nil {
 			log.Warn(err)
+			ctx.Network().AdjustPeerScore(ctx.Sender().GetID(), peer_score.SCORE_INVALID_CONSENSUS, err.Error())
 			return
 		}
 		consensus.Cons.PeerId = ctx.Sender().GetID()
@@ -196,7 +407,22 @@ func ConsensusHandle(ctx *p2p.Context, consensus *msgTypes.Consensus) {
 func TransactionHandle(ctx *p2p.Context, trn *msgTypes.Trn) {
 	if !txCache.Contains(trn.Txn.Hash()) {
 		txCache.Add(trn.Txn.Hash(), nil)
-		actor.AddTransaction(trn.Txn)
+		result := actor.AddTransactionWithResult(trn.Txn)
+		if result == nil {
+			return
+		}
+		// wait the verify result in background, so the message loop of the peer is not blocked
+		net, id, hash := ctx.Network(), ctx.Sender().GetID(), trn.Txn.Hash()
+		go func() {
+			select {
+			case res := <-result:
+				if res.Err == ontErrors.ErrVerifySignature || res.Err == ontErrors.ErrTransactionPayload {
+					net.AdjustPeerScore(id, peer_score.SCORE_INVALID_TX, res.Desc)
+				}
+			case <-time.After(TX_RESULT_TIMEOUT):
+				log.Debugf("[p2p]wait verify result of tx %x timeout", hash)
+			}
+		}()
 	} else {
 		log.Tracef("[p2p]receive duplicate Transaction message, txHash: %x\n", trn.Txn.Hash())
 	}
@@ -208,73 +434,46 @@ func DataReqHandle(ctx *p2p.Context, dataReq *msgTypes.DataReq) {
 	reqType := common.InventoryType(dataReq.DataType)
 	hash := dataReq.Hash
 	switch reqType {
-	case common.BLOCK:
-		reqID := fmt.Sprintf("%x%s", reqType, hash.ToHexString())
-		data := getRespCacheValue(reqID)
-		var msg msgTypes.Message
-		if data != nil {
-			switch data.(type) {
-			case *msgTypes.Block:
-				msg = data.(*msgTypes.Block)
-			}
-		}
-		if msg == nil {
-			var merkleRoot common.Uint256
-			block, err := ledger.DefLedger.GetBlockByHash(hash)
-			if err != nil || block == nil || block.Header == nil {
-				log.Debug("[p2p]can't get block by hash: ", hash, " ,send not found message")
-				msg := msgpack.NewNotFound(hash)
-				err := remotePeer.Send(msg)
-				if err != nil {
-					log.Warn(err)
-					return
-				}
-				return
-			}
-			ccMsg, err := ledger.DefLedger.GetCrossChainMsg(block.Header.Height - 1)
-			if err != nil {
-				log.Debugf("[p2p]failed to get cross chain message at height %v, err %v",
-					block.Header.Height-1, err)
-				msg := msgpack.NewNotFound(hash)
-				err := remotePeer.Send(msg)
-				if err != nil {
-					log.Warn(err)
-					return
-				}
-				return
-			}
-			merkleRoot, err = ledger.DefLedger.GetStateMerkleRoot(block.Header.Height)
+	case common.BLOCK, common.FULL_BLOCK:
+		blk, err := getBlockMessage(hash)
+		if err != nil {
+			log.Debugf("[p2p]%s, send not found message", err)
+			err := remotePeer.Send(msgpack.NewNotFound(hash))
 			if err != nil {
-				log.Debugf("[p2p]failed to get state merkel root at height %v, err %v",
-					block.Header.Height, err)
-				msg := msgpack.NewNotFound(hash)
-				err := remotePeer.Send(msg)
-				if err != nil {
-					log.Warn(err)
-					return
-				}
-				return
+				log.Warn(err)
 			}
-			msg = msgpack.NewBlock(block, ccMsg, merkleRoot)
-			saveRespCache(reqID, msg)
+			return
 		}
-		err := remotePeer.Send(msg)
+		// recent block is relayed as compact block, since the transactions are likely in the txpool of peer
+		var msg msgTypes.Message = blk
+		if reqType == common.BLOCK && remotePeer.Info.CompactBlock &&
+			blk.Blk.Header.Height+compact_block.COMPACT_BLOCK_MAX_AGE > ledger.DefLedger.GetCurrentBlockHeight() {
+			msg = msgpack.NewCompactBlock(blk.Blk, blk.CCMsg, blk.MerkleRoot)
+		}
+		err = remotePeer.Send(msg)
 		if err != nil {
 			log.Warn(err)
 			return
 		}
 
 	case common.TRANSACTION:
-		txn, err := ledger.DefLedger.GetTransaction(hash)
-		if err != nil {
+		// announced transactions are most likely still in the txpool
+		var txn *types.Transaction
+		txs, err := actor.GetTransactions([]common.Uint256{hash})
+		if err == nil && len(txs) == 1 && txs[0] != nil {
+			txn = txs[0]
+		} else {
+			txn, err = ledger.DefLedger.GetTransaction(hash)
+		}
+		if err != nil || txn == nil {
 			log.Debug("[p2p]Can't get transaction by hash: ",
 				hash, " ,send not found message")
 			msg := msgpack.NewNotFound(hash)
 			err = remotePeer.Send(msg)
 			if err != nil {
 				log.Warn(err)
-				return
 			}
+			return
 		}
 		msg := msgpack.NewTxn(txn)
 		err = remotePeer.Send(msg)
@@ -285,6 +484,81 @@ func DataReqHandle(ctx *p2p.Context, dataReq *msgTypes.DataReq) {
 	}
 }
 
+// StorageReqHandle handles the contract storage req from light node
+func StorageReqHandle(ctx *p2p.Context, req *msgTypes.StorageReq) {
+	resp := &msgTypes.StorageResp{
+		ReqId:  req.ReqId,
+		Height: ledger.DefLedger.GetCurrentBlockHeight(),
+	}
+	value, err := ledger.DefLedger.GetStorageItem(req.Contract, req.Key)
+	if err != nil && err != scom.ErrNotFound {
+		log.Debugf("[p2p]failed to get storage of contract %s, err %v", req.Contract.ToHexString(), err)
+		return
+	}
+	if err == nil {
+		resp.Found = true
+		resp.Value = value
+	}
+	err = ctx.Sender().Send(resp)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+// TxProofReqHandle handles the transaction proof req from light node
+func TxProofReqHandle(ctx *p2p.Context, req *msgTypes.TxProofReq) {
+	resp := &msgTypes.TxProofResp{ReqId: req.ReqId}
+	tx, height, err := ledger.DefLedger.GetTransactionWithHeight(req.TxHash)
+	if err == nil && tx != nil {
+		block, err := ledger.DefLedger.GetBlockByHeight(height)
+		if err == nil && block != nil {
+			hashes := make([]common.Uint256, 0, len(block.Transactions))
+			for i, t := range block.Transactions {
+				hash := t.Hash()
+				if hash == req.TxHash {
+					resp.Found = true
+					resp.Index = uint32(i)
+				}
+				hashes = append(hashes, hash)
+			}
+			if resp.Found {
+				resp.Height = height
+				resp.Tx = tx
+				resp.Path = common.ComputeMerklePath(hashes, resp.Index)
+			}
+		}
+	}
+	err = ctx.Sender().Send(resp)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+}
+
+//getBlockMessage return the block message of hash with cross chain message and state merkle root
+func getBlockMessage(hash common.Uint256) (*msgTypes.Block, error) {
+	reqID := fmt.Sprintf("%x%s", common.BLOCK, hash.ToHexString())
+	if blk, ok := getRespCacheValue(reqID).(*msgTypes.Block); ok {
+		return blk, nil
+	}
+	block, err := ledger.DefLedger.GetBlockByHash(hash)
+	if err != nil || block == nil || block.Header == nil {
+		return nil, fmt.Errorf("can't get block by hash: %s", hash.ToHexString())
+	}
+	ccMsg, err := ledger.DefLedger.GetCrossChainMsg(block.Header.Height - 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cross chain message at height %v, err %v", block.Header.Height-1, err)
+	}
+	merkleRoot, err := ledger.DefLedger.GetStateMerkleRoot(block.Header.Height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state merkel root at height %v, err %v", block.Header.Height, err)
+	}
+	blk := msgpack.NewBlock(block, ccMsg, merkleRoot).(*msgTypes.Block)
+	saveRespCache(reqID, blk)
+	return blk, nil
+}
+
 // InvHandle handles the inventory message(block,
 // transaction and consensus) from peer.
 func InvHandle(ctx *p2p.Context, inv *msgTypes.Inv) {
@@ -458,3 +732,8 @@ func saveRespCache(key string, value interface{}) bool {
 func (mh *MsgHandler) ReconnectService() *reconnect.ReconnectService {
 	return mh.reconnect
 }
+
+//LightClient return the light client, which is nil if ledger is not in light mode
+func (mh *MsgHandler) LightClient() *light_client.LightClient {
+	return mh.lightClient
+}
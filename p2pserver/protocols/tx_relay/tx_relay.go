@@ -0,0 +1,278 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tx_relay
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/peer"
+)
+
+const (
+	TX_INV_INTERVAL       = 100 * time.Millisecond //interval of flushing the queued transaction announcements
+	TX_REQ_TIMEOUT        = 10 * time.Second       //announced transaction not received in time can be requested from other peer
+	TX_RETRY_INTERVAL     = time.Second            //interval of checking the timeout transaction requests
+	MAX_KNOWN_TX_PER_PEER = 10000                  //max number of transactions remembered as known by a peer
+	MAX_REQUESTED_TX      = 10000                  //max number of transaction requests remembered
+	MAX_TX_ANNOUNCERS     = 8                      //max number of other announcers remembered for a requested transaction
+)
+
+//Network is the part of p2p network used by transaction relay
+type Network interface {
+	GetNeighbors() []*peer.Peer
+	SendTo(id p2pComm.PeerId, msg msgTypes.Message)
+}
+
+type txPeer struct {
+	known *lru.Cache
+	queue []common.Uint256
+}
+
+func newTxPeer() *txPeer {
+	known, _ := lru.New(MAX_KNOWN_TX_PER_PEER)
+	return &txPeer{known: known}
+}
+
+//txRequest is an announced transaction in flight, the other peers announced it are requested in order on timeout
+type txRequest struct {
+	peer       p2pComm.PeerId
+	time       time.Time
+	announcers []p2pComm.PeerId
+}
+
+func (self *txRequest) announce(id p2pComm.PeerId) {
+	if id == self.peer || len(self.announcers) >= MAX_TX_ANNOUNCERS {
+		return
+	}
+	for _, announcer := range self.announcers {
+		if announcer == id {
+			return
+		}
+	}
+	self.announcers = append(self.announcers, id)
+}
+
+//TxRelay announces transactions to neighbors by batched inventory messages instead of pushing the whole
+//transactions, so each peer downloads a transaction only once
+type TxRelay struct {
+	net       Network
+	lock      sync.Mutex
+	peers     map[p2pComm.PeerId]*txPeer
+	requested *lru.Cache //tx hash -> *txRequest
+	quit      chan bool
+}
+
+func NewTxRelay(net Network) *TxRelay {
+	requested, _ := lru.New(MAX_REQUESTED_TX)
+	return &TxRelay{
+		net:       net,
+		peers:     make(map[p2pComm.PeerId]*txPeer),
+		requested: requested,
+		quit:      make(chan bool),
+	}
+}
+
+func (self *TxRelay) Start() {
+	go self.flushService()
+}
+
+func (self *TxRelay) Stop() {
+	close(self.quit)
+}
+
+func (self *TxRelay) flushService() {
+	t := time.NewTicker(TX_INV_INTERVAL)
+	retry := time.NewTicker(TX_RETRY_INTERVAL)
+	for {
+		select {
+		case <-t.C:
+			self.flush()
+		case now := <-retry.C:
+			self.retry(now)
+		case <-self.quit:
+			t.Stop()
+			retry.Stop()
+			return
+		}
+	}
+}
+
+func (self *TxRelay) OnAddPeer(info *peer.PeerInfo) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if _, ok := self.peers[info.Id]; !ok {
+		self.peers[info.Id] = newTxPeer()
+	}
+}
+
+func (self *TxRelay) OnDelPeer(info *peer.PeerInfo) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	delete(self.peers, info.Id)
+}
+
+//MarkKnown records the peer has the transaction received from it, so it is never announced to the peer, and the
+//request of it is finished
+func (self *TxRelay) MarkKnown(id p2pComm.PeerId, hash common.Uint256) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if p, ok := self.peers[id]; ok {
+		p.known.Add(hash, nil)
+	}
+	self.requested.Remove(hash)
+}
+
+//Relay queues the announcement of transaction to neighbors which do not know it yet. Neighbors not supporting
+//announcement receive the whole transaction immediately
+func (self *TxRelay) Relay(tx *types.Transaction) {
+	hash := tx.Hash()
+	neighbors := self.net.GetNeighbors()
+	var full []p2pComm.PeerId
+	invs := make(map[p2pComm.PeerId][]common.Uint256)
+	self.lock.Lock()
+	for _, nbr := range neighbors {
+		//light node does not keep transactions of others
+		if !nbr.GetRelay() || nbr.GetServices() == p2pComm.LIGHT_NODE {
+			continue
+		}
+		id := nbr.GetID()
+		p, ok := self.peers[id]
+		if !ok {
+			p = newTxPeer()
+			self.peers[id] = p
+		}
+		if p.known.Contains(hash) {
+			continue
+		}
+		p.known.Add(hash, nil)
+		if !nbr.Info.TxInv {
+			full = append(full, id)
+			continue
+		}
+		p.queue = append(p.queue, hash)
+		if len(p.queue) >= p2pComm.MAX_INV_BLK_CNT {
+			invs[id] = p.queue
+			p.queue = nil
+		}
+	}
+	self.lock.Unlock()
+
+	if len(full) != 0 {
+		msg := msgpack.NewTxn(tx)
+		for _, id := range full {
+			go self.net.SendTo(id, msg)
+		}
+	}
+	self.sendInvs(invs)
+}
+
+//OnInv records the peer knows the announced transactions, and returns the ones should be requested from it.
+//Transactions already had are not requested again, and the ones requested from other peer recently are requested
+//from the peer only if they are not received in time
+func (self *TxRelay) OnInv(from p2pComm.PeerId, hashes []common.Uint256, have func(common.Uint256) bool) []common.Uint256 {
+	now := time.Now()
+	var reqs []common.Uint256
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	p := self.peers[from]
+	for _, hash := range hashes {
+		if p != nil {
+			p.known.Add(hash, nil)
+		}
+		var req *txRequest
+		if v, ok := self.requested.Get(hash); ok {
+			req = v.(*txRequest)
+			if now.Sub(req.time) < TX_REQ_TIMEOUT {
+				req.announce(from)
+				continue
+			}
+		}
+		if have(hash) {
+			continue
+		}
+		if req == nil {
+			req = &txRequest{}
+			self.requested.Add(hash, req)
+		}
+		req.peer, req.time = from, now
+		reqs = append(reqs, hash)
+	}
+	return reqs
+}
+
+//retry requests the transactions not received in time from the next peer announced them
+func (self *TxRelay) retry(now time.Time) {
+	reqs := make(map[p2pComm.PeerId][]common.Uint256)
+	self.lock.Lock()
+	for _, key := range self.requested.Keys() {
+		v, ok := self.requested.Peek(key)
+		if !ok {
+			continue
+		}
+		req := v.(*txRequest)
+		if now.Sub(req.time) < TX_REQ_TIMEOUT {
+			continue
+		}
+		for len(req.announcers) != 0 {
+			next := req.announcers[0]
+			req.announcers = req.announcers[1:]
+			if _, ok := self.peers[next]; ok {
+				req.peer, req.time = next, now
+				reqs[next] = append(reqs[next], key.(common.Uint256))
+				break
+			}
+		}
+	}
+	self.lock.Unlock()
+
+	for id, hashes := range reqs {
+		go func(id p2pComm.PeerId, hashes []common.Uint256) {
+			for _, hash := range hashes {
+				self.net.SendTo(id, msgpack.NewTxnDataReq(hash))
+			}
+		}(id, hashes)
+	}
+}
+
+func (self *TxRelay) flush() {
+	invs := make(map[p2pComm.PeerId][]common.Uint256)
+	self.lock.Lock()
+	for id, p := range self.peers {
+		if len(p.queue) != 0 {
+			invs[id] = p.queue
+			p.queue = nil
+		}
+	}
+	self.lock.Unlock()
+	self.sendInvs(invs)
+}
+
+func (self *TxRelay) sendInvs(invs map[p2pComm.PeerId][]common.Uint256) {
+	for id, hashes := range invs {
+		msg := msgpack.NewInv(msgpack.NewInvPayload(common.TRANSACTION, hashes))
+		go self.net.SendTo(id, msg)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package tx_relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	p2pComm "github.com/ontio/ontology/p2pserver/common"
+	msgTypes "github.com/ontio/ontology/p2pserver/message/types"
+	"github.com/ontio/ontology/p2pserver/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNetwork struct {
+	peers []*peer.Peer
+	sent  map[p2pComm.PeerId]chan msgTypes.Message
+}
+
+func newFakeNetwork(txInv ...bool) *fakeNetwork {
+	net := &fakeNetwork{sent: make(map[p2pComm.PeerId]chan msgTypes.Message)}
+	for i, inv := range txInv {
+		p := peer.NewPeer()
+		p.Info.Id = p2pComm.PseudoPeerIdFromUint64(uint64(i + 1))
+		p.Info.Relay = true
+		p.Info.TxInv = inv
+		net.peers = append(net.peers, p)
+		net.sent[p.Info.Id] = make(chan msgTypes.Message, 100)
+	}
+	return net
+}
+
+func (self *fakeNetwork) GetNeighbors() []*peer.Peer {
+	return self.peers
+}
+
+func (self *fakeNetwork) SendTo(id p2pComm.PeerId, msg msgTypes.Message) {
+	self.sent[id] <- msg
+}
+
+func (self *fakeNetwork) recv(t *testing.T, id p2pComm.PeerId) msgTypes.Message {
+	select {
+	case msg := <-self.sent[id]:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message sent to peer")
+		return nil
+	}
+}
+
+func (self *fakeNetwork) assertNothingSent(t *testing.T, id p2pComm.PeerId) {
+	select {
+	case msg := <-self.sent[id]:
+		t.Fatalf("unexpected message %s sent to peer", msg.CmdType())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestTx(t *testing.T, nonce uint32) *types.Transaction {
+	mutable := &types.MutableTransaction{TxType: types.InvokeNeo, Nonce: nonce,
+		Payload: &payload.InvokeCode{Code: []byte{1}}, Sigs: []types.Sig{}}
+	tx, err := mutable.IntoImmutable()
+	assert.Nil(t, err)
+	return tx
+}
+
+func TestRelayBatchesAnnouncements(t *testing.T) {
+	net := newFakeNetwork(true, false)
+	invPeer, fullPeer := net.peers[0].GetID(), net.peers[1].GetID()
+	relay := NewTxRelay(net)
+
+	var hashes []common.Uint256
+	for i := 0; i < 3; i++ {
+		tx := newTestTx(t, uint32(i))
+		relay.Relay(tx)
+		relay.Relay(tx)
+		hashes = append(hashes, tx.Hash())
+	}
+	// peer without announcement support receives each transaction once
+	for i := 0; i < 3; i++ {
+		trn, ok := net.recv(t, fullPeer).(*msgTypes.Trn)
+		assert.True(t, ok)
+		assert.Contains(t, hashes, trn.Txn.Hash())
+	}
+	net.assertNothingSent(t, fullPeer)
+	net.assertNothingSent(t, invPeer)
+
+	relay.flush()
+	inv, ok := net.recv(t, invPeer).(*msgTypes.Inv)
+	assert.True(t, ok)
+	assert.Equal(t, common.TRANSACTION, common.InventoryType(inv.P.InvType))
+	assert.Equal(t, hashes, inv.P.Blk)
+	relay.flush()
+	net.assertNothingSent(t, invPeer)
+}
+
+func TestRelayFullBatch(t *testing.T) {
+	net := newFakeNetwork(true)
+	id := net.peers[0].GetID()
+	relay := NewTxRelay(net)
+	for i := 0; i < p2pComm.MAX_INV_BLK_CNT; i++ {
+		relay.Relay(newTestTx(t, uint32(i)))
+	}
+	inv, ok := net.recv(t, id).(*msgTypes.Inv)
+	assert.True(t, ok)
+	assert.Equal(t, p2pComm.MAX_INV_BLK_CNT, len(inv.P.Blk))
+}
+
+func TestRelaySkipKnownPeer(t *testing.T) {
+	net := newFakeNetwork(false, false)
+	relay := NewTxRelay(net)
+	for _, p := range net.peers {
+		relay.OnAddPeer(p.Info)
+	}
+	tx := newTestTx(t, 1)
+	relay.MarkKnown(net.peers[0].GetID(), tx.Hash())
+	relay.Relay(tx)
+	net.recv(t, net.peers[1].GetID())
+	net.assertNothingSent(t, net.peers[0].GetID())
+}
+
+func TestOnInvRequestOnce(t *testing.T) {
+	net := newFakeNetwork(true, true)
+	first, second := net.peers[0].GetID(), net.peers[1].GetID()
+	relay := NewTxRelay(net)
+	for _, p := range net.peers {
+		relay.OnAddPeer(p.Info)
+	}
+	had, tx1, tx2 := newTestTx(t, 1), newTestTx(t, 2), newTestTx(t, 3)
+	have := func(hash common.Uint256) bool { return hash == had.Hash() }
+	hashes := []common.Uint256{had.Hash(), tx1.Hash(), tx2.Hash()}
+
+	reqs := relay.OnInv(first, hashes, have)
+	assert.Equal(t, []common.Uint256{tx1.Hash(), tx2.Hash()}, reqs)
+	// the same transactions announced by other peer are in flight already
+	reqs = relay.OnInv(second, hashes, have)
+	assert.Empty(t, reqs)
+
+	// transactions announced by the peers are not announced back
+	relay.Relay(tx1)
+	relay.flush()
+	net.assertNothingSent(t, first)
+	net.assertNothingSent(t, second)
+}
+
+func TestRetryNextAnnouncer(t *testing.T) {
+	net := newFakeNetwork(true, true, true)
+	first, second, third := net.peers[0].GetID(), net.peers[1].GetID(), net.peers[2].GetID()
+	relay := NewTxRelay(net)
+	for _, p := range net.peers {
+		relay.OnAddPeer(p.Info)
+	}
+	received, lost := newTestTx(t, 1), newTestTx(t, 2)
+	have := func(hash common.Uint256) bool { return false }
+	hashes := []common.Uint256{received.Hash(), lost.Hash()}
+
+	assert.Equal(t, hashes, relay.OnInv(first, hashes, have))
+	assert.Empty(t, relay.OnInv(second, hashes, have))
+	assert.Empty(t, relay.OnInv(third, hashes, have))
+	relay.MarkKnown(first, received.Hash())
+
+	// not timeout yet
+	relay.retry(time.Now())
+	net.assertNothingSent(t, second)
+
+	// disconnected announcer is skipped
+	relay.OnDelPeer(net.peers[1].Info)
+	now := time.Now().Add(TX_REQ_TIMEOUT)
+	relay.retry(now)
+	req, ok := net.recv(t, third).(*msgTypes.DataReq)
+	assert.True(t, ok)
+	assert.Equal(t, lost.Hash(), req.Hash)
+	net.assertNothingSent(t, third)
+	net.assertNothingSent(t, second)
+
+	// all announcers requested
+	relay.retry(now.Add(TX_REQ_TIMEOUT))
+	net.assertNothingSent(t, first)
+	net.assertNothingSent(t, third)
+
+	// announced again while in flight
+	assert.Empty(t, relay.OnInv(first, []common.Uint256{lost.Hash()}, have))
+	relay.retry(now.Add(2 * TX_REQ_TIMEOUT))
+	req, ok = net.recv(t, first).(*msgTypes.DataReq)
+	assert.True(t, ok)
+	assert.Equal(t, lost.Hash(), req.Hash)
+}
@@ -30,6 +30,7 @@ import (
 // when need to check authorization, use CheckWitness
 // when smart contract execute trigger event, use PushNotifications push it to smart contract notifications
 // when need to invoke a smart contract, use AppCall to invoke it
+// when smart contract write storage, use PutStorage to account the storage size and charge the deposit
 type ContextRef interface {
 	PushContext(context *Context)
 	CurrentContext() *Context
@@ -45,6 +46,7 @@ type ContextRef interface {
 	SetInternalErr()
 	IsInternalErr() bool
 	PutCrossStateHashes(hashes []common.Uint256)
+	PutStorage(key []byte, value []byte) error
 }
 
 type Engine interface {
@@ -0,0 +1,335 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package debugger provides neovm debug sessions. A session pre-executes a transaction and pauses before
+// opcodes which are stepped into or hit by breakpoints, so that the stacks and storage can be inspected.
+package debugger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	sstates "github.com/ontio/ontology/smartcontract/states"
+	vm "github.com/ontio/ontology/vm/neovm"
+)
+
+const (
+	//max running debug sessions
+	MAX_SESSIONS = 16
+	//a paused session is aborted if no command is received in time
+	SESSION_IDLE_TIMEOUT = 10 * time.Minute
+	//max storage items returned in a snapshot
+	STORAGE_SNAPSHOT_LIMIT = 100
+
+	STATE_PAUSED   = "paused"
+	STATE_FINISHED = "finished"
+)
+
+var (
+	ErrTooManySessions = errors.New("too many debug sessions")
+	ErrSessionNotFound = errors.New("debug session not found")
+	ErrSessionStopped  = errors.New("debug session stopped")
+	ErrSessionTimeout  = errors.New("debug session idle timeout")
+)
+
+type command int
+
+const (
+	cmdStep command = iota
+	cmdContinue
+	cmdStop
+)
+
+//Breakpoint pauses the execution before the matched opcode. An empty contract matches any contract,
+//a negative offset matches any offset and an empty opcode matches any opcode
+type Breakpoint struct {
+	Contract common.Address
+	Offset   int
+	OpCode   string
+}
+
+func (self Breakpoint) match(contract common.Address, offset int, opName string) bool {
+	if self.Contract != common.ADDRESS_EMPTY && self.Contract != contract {
+		return false
+	}
+	if self.Offset >= 0 && self.Offset != offset {
+		return false
+	}
+	if self.OpCode != "" && !strings.EqualFold(self.OpCode, opName) {
+		return false
+	}
+	return true
+}
+
+type StorageItem struct {
+	Key   string
+	Value string
+}
+
+//Snapshot is the state of a paused execution, stack items are listed from top to bottom
+type Snapshot struct {
+	Contract  string
+	Offset    int
+	OpCode    string
+	CallDepth int
+	EvalStack []interface{}
+	AltStack  []interface{}
+	Storage   []StorageItem
+}
+
+//Status is reported after every command, Snapshot is set when paused and Result when finished
+type Status struct {
+	Session  uint64
+	State    string
+	Snapshot *Snapshot              `json:",omitempty"`
+	Result   *sstates.PreExecResult `json:",omitempty"`
+	Error    string                 `json:",omitempty"`
+}
+
+//Runner pre-executes a transaction with the debugger
+type Runner func(debugger neovm.Debugger) (*sstates.PreExecResult, error)
+
+//Session is the debug session of one transaction, it implements neovm.Debugger
+type Session struct {
+	ID uint64
+
+	cmdLock     sync.Mutex
+	lock        sync.Mutex
+	breakpoints []Breakpoint
+	stepping    bool
+	commands    chan command
+	events      chan *Status
+	done        chan struct{}
+	final       *Status
+}
+
+var (
+	lock        sync.Mutex
+	sessions           = make(map[uint64]*Session)
+	nextSession uint64 = 1
+)
+
+//Start runs a new debug session. The execution pauses at the first opcode if stopOnEntry is set,
+//otherwise at the first breakpoint hit
+func Start(run Runner, breakpoints []Breakpoint, stopOnEntry bool) (*Status, error) {
+	lock.Lock()
+	if len(sessions) >= MAX_SESSIONS {
+		lock.Unlock()
+		return nil, ErrTooManySessions
+	}
+	session := &Session{
+		ID:          nextSession,
+		breakpoints: breakpoints,
+		stepping:    stopOnEntry,
+		commands:    make(chan command),
+		events:      make(chan *Status, 1),
+		done:        make(chan struct{}),
+	}
+	sessions[session.ID] = session
+	nextSession++
+	lock.Unlock()
+
+	go func() {
+		result, err := run(session)
+		final := &Status{Session: session.ID, State: STATE_FINISHED, Result: result}
+		if err != nil {
+			final.Error = err.Error()
+		}
+		session.final = final
+		close(session.done)
+
+		lock.Lock()
+		delete(sessions, session.ID)
+		lock.Unlock()
+	}()
+
+	return session.wait(), nil
+}
+
+//Get return the running session
+func Get(id uint64) (*Session, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	session, ok := sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+//Sessions return the ids of running sessions in order
+func Sessions() []uint64 {
+	lock.Lock()
+	defer lock.Unlock()
+	ids := make([]uint64, 0, len(sessions))
+	for id := range sessions {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+//Step executes one opcode and pauses again, called contracts are stepped into
+func (self *Session) Step() *Status {
+	return self.send(cmdStep)
+}
+
+//Continue executes until a breakpoint is hit or the execution finished
+func (self *Session) Continue() *Status {
+	return self.send(cmdContinue)
+}
+
+//Stop aborts the execution
+func (self *Session) Stop() *Status {
+	return self.send(cmdStop)
+}
+
+//SetBreakpoints replace the breakpoints of session
+func (self *Session) SetBreakpoints(breakpoints []Breakpoint) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.breakpoints = breakpoints
+}
+
+//Breakpoints return the breakpoints of session
+func (self *Session) Breakpoints() []Breakpoint {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return append([]Breakpoint{}, self.breakpoints...)
+}
+
+func (self *Session) send(cmd command) *Status {
+	self.cmdLock.Lock()
+	defer self.cmdLock.Unlock()
+	select {
+	case self.commands <- cmd:
+	case <-self.done:
+		return self.final
+	}
+	return self.wait()
+}
+
+func (self *Session) wait() *Status {
+	select {
+	case status := <-self.events:
+		return status
+	case <-self.done:
+		return self.final
+	}
+}
+
+//OnStep pauses the execution and waits for next command if stepping or a breakpoint is hit
+func (self *Session) OnStep(service *neovm.NeoVmService, offset int, opCode vm.OpCode) error {
+	contract := service.ContextRef.CurrentContext().ContractAddress
+	opName := vm.OpExecList[opCode].Name
+	if !self.shouldPause(contract, offset, opName) {
+		return nil
+	}
+
+	self.events <- &Status{
+		Session:  self.ID,
+		State:    STATE_PAUSED,
+		Snapshot: newSnapshot(service, contract, offset, opName),
+	}
+
+	select {
+	case cmd := <-self.commands:
+		switch cmd {
+		case cmdStep:
+			self.setStepping(true)
+		case cmdContinue:
+			self.setStepping(false)
+		default:
+			return ErrSessionStopped
+		}
+	case <-time.After(SESSION_IDLE_TIMEOUT):
+		return ErrSessionTimeout
+	}
+	return nil
+}
+
+func (self *Session) shouldPause(contract common.Address, offset int, opName string) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.stepping {
+		return true
+	}
+	for _, bp := range self.breakpoints {
+		if bp.match(contract, offset, opName) {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *Session) setStepping(stepping bool) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.stepping = stepping
+}
+
+func newSnapshot(service *neovm.NeoVmService, contract common.Address, offset int, opName string) *Snapshot {
+	snapshot := &Snapshot{
+		Contract:  contract.ToHexString(),
+		Offset:    offset,
+		OpCode:    opName,
+		CallDepth: len(service.Engine.Callers),
+		EvalStack: dumpStack(service.Engine.EvalStack),
+		AltStack:  dumpStack(service.Engine.AltStack),
+	}
+
+	iter := service.CacheDB.NewIterator(contract[:])
+	for has := iter.First(); has && len(snapshot.Storage) < STORAGE_SNAPSHOT_LIMIT; has = iter.Next() {
+		key := iter.Key()
+		value, err := states.GetValueFromRawStorageItem(iter.Value())
+		if err != nil {
+			value = iter.Value()
+		}
+		snapshot.Storage = append(snapshot.Storage, StorageItem{
+			Key:   common.ToHexString(key[common.ADDR_LEN:]),
+			Value: common.ToHexString(value),
+		})
+	}
+	iter.Release()
+
+	return snapshot
+}
+
+//dumpStack return the stack items from top to bottom
+func dumpStack(stack *vm.ValueStack) []interface{} {
+	items := make([]interface{}, 0, stack.Count())
+	for i := 0; i < stack.Count(); i++ {
+		val, err := stack.Peek(int64(i))
+		if err != nil {
+			break
+		}
+		item, err := val.ConvertNeoVmValueHexString()
+		if err != nil {
+			item = fmt.Sprintf("<%s>", val.Dump())
+		}
+		items = append(items, item)
+	}
+	return items
+}
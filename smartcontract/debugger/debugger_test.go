@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package debugger
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	sstates "github.com/ontio/ontology/smartcontract/states"
+	"github.com/ontio/ontology/smartcontract/storage"
+	vm "github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+var testCode = []byte{byte(vm.PUSH1), byte(vm.PUSH2), byte(vm.ADD), byte(vm.RET)}
+
+func testRunner(debugger neovm.Debugger) (*sstates.PreExecResult, error) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := storage.NewCacheDB(overlaydb.NewOverlayDB(memback))
+	address := common.AddressFromVmCode(testCode)
+	cache.Put(append(address[:], []byte("key")...), states.GenRawStorageItem([]byte("value")))
+
+	sc := smartcontract.SmartContract{
+		Config:   &smartcontract.Config{Time: 10, Height: 10},
+		CacheDB:  cache,
+		GasTable: map[string]uint64{},
+		Gas:      100000,
+		Debugger: debugger,
+	}
+	engine, err := sc.NewExecuteEngine(testCode, types.InvokeNeo)
+	if err != nil {
+		return nil, err
+	}
+	result, err := engine.Invoke()
+	if err != nil {
+		return nil, err
+	}
+	return &sstates.PreExecResult{State: 1, Result: result}, nil
+}
+
+func TestSessionStep(t *testing.T) {
+	status, err := Start(testRunner, nil, true)
+	assert.Nil(t, err)
+	assert.Equal(t, STATE_PAUSED, status.State)
+	assert.Equal(t, 0, status.Snapshot.Offset)
+	assert.Equal(t, "PUSH1", status.Snapshot.OpCode)
+	assert.Equal(t, []StorageItem{{Key: common.ToHexString([]byte("key")), Value: common.ToHexString([]byte("value"))}},
+		status.Snapshot.Storage)
+	assert.Contains(t, Sessions(), status.Session)
+
+	session, err := Get(status.Session)
+	assert.Nil(t, err)
+	status = session.Step()
+	assert.Equal(t, 1, status.Snapshot.Offset)
+	status = session.Step()
+	assert.Equal(t, "ADD", status.Snapshot.OpCode)
+	assert.Equal(t, []interface{}{"02", "01"}, status.Snapshot.EvalStack)
+
+	status = session.Continue()
+	assert.Equal(t, STATE_FINISHED, status.State)
+	assert.Equal(t, "", status.Error)
+	assert.NotNil(t, status.Result)
+
+	//finished session is unregistered
+	status = session.Step()
+	assert.Equal(t, STATE_FINISHED, status.State)
+	_, err = Get(status.Session)
+	assert.Equal(t, ErrSessionNotFound, err)
+}
+
+func TestSessionBreakpoint(t *testing.T) {
+	status, err := Start(testRunner, []Breakpoint{{Offset: -1, OpCode: "add"}}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, STATE_PAUSED, status.State)
+	assert.Equal(t, 2, status.Snapshot.Offset)
+
+	session, err := Get(status.Session)
+	assert.Nil(t, err)
+	session.SetBreakpoints([]Breakpoint{{Contract: common.AddressFromVmCode(testCode), Offset: 3}})
+	status = session.Continue()
+	assert.Equal(t, STATE_PAUSED, status.State)
+	assert.Equal(t, "RET", status.Snapshot.OpCode)
+	assert.Equal(t, []interface{}{"03"}, status.Snapshot.EvalStack)
+
+	status = session.Stop()
+	assert.Equal(t, STATE_FINISHED, status.State)
+	assert.Equal(t, ErrSessionStopped.Error(), status.Error)
+}
+
+func TestBreakpointMatch(t *testing.T) {
+	contract := common.AddressFromVmCode(testCode)
+	assert.True(t, Breakpoint{Offset: -1}.match(contract, 5, "ADD"))
+	assert.True(t, Breakpoint{Contract: contract, Offset: 5}.match(contract, 5, "ADD"))
+	assert.False(t, Breakpoint{Contract: contract, Offset: 5}.match(common.ADDRESS_EMPTY, 5, "ADD"))
+	assert.False(t, Breakpoint{Offset: 4}.match(contract, 5, "ADD"))
+	assert.False(t, Breakpoint{Offset: -1, OpCode: "SUB"}.match(contract, 5, "ADD"))
+}
@@ -28,3 +28,9 @@ type LogEventArgs struct {
 	ContractAddress common.Address
 	Message         string
 }
+
+//LogCollector is implemented by the ledger store of an execution whose result may be discarded, the runtime logs
+//are collected by it instead of being pushed immediately
+type LogCollector interface {
+	CollectLog(log *LogEventArgs)
+}
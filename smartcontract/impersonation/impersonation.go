@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package impersonation records the addresses impersonated in test mode. Witness of impersonated addresses is
+// regarded as checked, so that transactions can be sent without holding their keys.
+package impersonation
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+)
+
+var ErrNotTestMode = errors.New("account impersonation is only available in test mode")
+
+var (
+	lock      sync.RWMutex
+	addresses = make(map[common.Address]bool)
+)
+
+//Enabled return whether account impersonation is allowed. It's only allowed by the solo consensus of test mode network
+func Enabled() bool {
+	return config.DefConfig.Genesis.ConsensusType == config.CONSENSUS_TYPE_SOLO &&
+		config.DefConfig.P2PNode.NetworkId == config.NETWORK_ID_SOLO_NET
+}
+
+//Impersonate marks the address as impersonated
+func Impersonate(addr common.Address) error {
+	if !Enabled() {
+		return ErrNotTestMode
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	addresses[addr] = true
+	return nil
+}
+
+//StopImpersonating removes the address from impersonated addresses
+func StopImpersonating(addr common.Address) error {
+	if !Enabled() {
+		return ErrNotTestMode
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	delete(addresses, addr)
+	return nil
+}
+
+//IsImpersonated return whether the address is impersonated. It's always false out of test mode
+func IsImpersonated(addr common.Address) bool {
+	if !Enabled() {
+		return false
+	}
+	lock.RLock()
+	defer lock.RUnlock()
+	return addresses[addr]
+}
+
+//Addresses return all impersonated addresses in order. It's always empty out of test mode
+func Addresses() []common.Address {
+	if !Enabled() {
+		return nil
+	}
+	lock.RLock()
+	addrs := make([]common.Address, 0, len(addresses))
+	for addr := range addresses {
+		addrs = append(addrs, addr)
+	}
+	lock.RUnlock()
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package impersonation
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpersonation(t *testing.T) {
+	addr1 := common.AddressFromVmCode([]byte("addr1"))
+	addr2 := common.AddressFromVmCode([]byte("addr2"))
+
+	assert.False(t, Enabled())
+	assert.Equal(t, ErrNotTestMode, Impersonate(addr1))
+	assert.False(t, IsImpersonated(addr1))
+
+	consensusType, networkId := config.DefConfig.Genesis.ConsensusType, config.DefConfig.P2PNode.NetworkId
+	defer func() {
+		config.DefConfig.Genesis.ConsensusType, config.DefConfig.P2PNode.NetworkId = consensusType, networkId
+	}()
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_SOLO
+	// solo consensus on other network is not test mode
+	assert.False(t, Enabled())
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	assert.True(t, Enabled())
+
+	assert.Nil(t, Impersonate(addr1))
+	assert.Nil(t, Impersonate(addr2))
+	assert.True(t, IsImpersonated(addr1))
+	assert.Equal(t, 2, len(Addresses()))
+
+	assert.Nil(t, StopImpersonating(addr1))
+	assert.False(t, IsImpersonated(addr1))
+	assert.Equal(t, []common.Address{addr2}, Addresses())
+
+	// impersonated addresses take no effect once out of test mode
+	config.DefConfig.Genesis.ConsensusType = config.CONSENSUS_TYPE_VBFT
+	assert.False(t, IsImpersonated(addr2))
+	assert.Nil(t, Addresses())
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package crypto implements the cryptographic primitives exposed to neovm and wasmvm contracts
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ripemd160"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	HASH_LEN             = 32
+	ECRECOVER_SIG_LEN    = 65
+	ECRECOVER_PUBKEY_LEN = 65
+	ED25519_PUBKEY_LEN   = ed25519.PublicKeySize
+	ED25519_SIG_LEN      = ed25519.SignatureSize
+
+	BLS12381_G1_LEN   = 96
+	BLS12381_G2_LEN   = 192
+	BLS12381_PAIR_LEN = BLS12381_G1_LEN + BLS12381_G2_LEN
+)
+
+//Keccak256 returns the keccak-256 digest as used by ethereum
+func Keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func Ripemd160(data []byte) []byte {
+	h := ripemd160.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func Blake2b256(data []byte) []byte {
+	h := blake2b.Sum256(data)
+	return h[:]
+}
+
+//Ecrecover returns the uncompressed secp256k1 public key which created sig over hash.
+//sig is in the [R || S || V] format, V can be 0/1 or 27/28
+func Ecrecover(hash, sig []byte) ([]byte, error) {
+	if len(hash) != HASH_LEN {
+		return nil, fmt.Errorf("invalid hash length %d", len(hash))
+	}
+	if len(sig) != ECRECOVER_SIG_LEN {
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+
+	rsv := make([]byte, ECRECOVER_SIG_LEN)
+	copy(rsv, sig)
+	if rsv[64] >= 27 {
+		rsv[64] -= 27
+	}
+	r := new(big.Int).SetBytes(rsv[:32])
+	s := new(big.Int).SetBytes(rsv[32:64])
+	if !crypto.ValidateSignatureValues(rsv[64], r, s, true) {
+		return nil, errors.New("invalid signature values")
+	}
+
+	return crypto.Ecrecover(hash, rsv)
+}
+
+//Ed25519Verify checks the raw 64 bytes signature of msg against the raw 32 bytes public key
+func Ed25519Verify(pubKey, msg, sig []byte) bool {
+	if len(pubKey) != ED25519_PUBKEY_LEN || len(sig) != ED25519_SIG_LEN {
+		return false
+	}
+	return ed25519.Verify(pubKey, msg, sig)
+}
+
+//SM2Verify checks the SM3withSM2 signature of msg, the public key and signature use
+//the serialization of ontology-crypto
+func SM2Verify(pubKey, msg, sig []byte) bool {
+	pk, err := keypair.DeserializePublicKey(pubKey)
+	if err != nil || keypair.GetKeyType(pk) != keypair.PK_SM2 {
+		return false
+	}
+	sigObj, err := signature.Deserialize(sig)
+	if err != nil || sigObj.Scheme != signature.SM3withSM2 {
+		return false
+	}
+	return signature.Verify(pk, msg, sigObj)
+}
+
+//BLS12381PairingCheck checks e(a1, b1) * ... * e(an, bn) == 1. The input is a concatenation of
+//pairs, each pair is a G1 point followed by a G2 point in zcash uncompressed encoding
+func BLS12381PairingCheck(input []byte) (bool, error) {
+	if len(input)%BLS12381_PAIR_LEN != 0 {
+		return false, fmt.Errorf("invalid pairing input length %d", len(input))
+	}
+
+	engine := bls12381.NewEngine()
+	for i := 0; i < len(input); i += BLS12381_PAIR_LEN {
+		g1, err := engine.G1.FromUncompressed(input[i : i+BLS12381_G1_LEN])
+		if err != nil {
+			return false, fmt.Errorf("invalid G1 point of pair %d: %s", i/BLS12381_PAIR_LEN, err)
+		}
+		g2, err := engine.G2.FromUncompressed(input[i+BLS12381_G1_LEN : i+BLS12381_PAIR_LEN])
+		if err != nil {
+			return false, fmt.Errorf("invalid G2 point of pair %d: %s", i/BLS12381_PAIR_LEN, err)
+		}
+		engine.AddPair(g1, g2)
+	}
+
+	return engine.Check(), nil
+}
@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestHashes(t *testing.T) {
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(Keccak256(nil)))
+	assert.Equal(t, "9c1185a5c5e9fc54612808977ee8f548b2258d31", hex.EncodeToString(Ripemd160(nil)))
+	assert.Equal(t, "0e5751c026e543b2e8ab2eb06099daa1d1e5df47778f7787faab45cdf12fe3a8", hex.EncodeToString(Blake2b256(nil)))
+}
+
+func TestEcrecover(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	assert.Nil(t, err)
+	hash := Keccak256([]byte("hello"))
+	sig, err := ethcrypto.Sign(hash, key)
+	assert.Nil(t, err)
+
+	pub, err := Ecrecover(hash, sig)
+	assert.Nil(t, err)
+	assert.Equal(t, ethcrypto.FromECDSAPub(&key.PublicKey), pub)
+
+	sig[64] += 27
+	pub, err = Ecrecover(hash, sig)
+	assert.Nil(t, err)
+	assert.Equal(t, ethcrypto.FromECDSAPub(&key.PublicKey), pub)
+
+	_, err = Ecrecover(hash, sig[:64])
+	assert.NotNil(t, err)
+	sig[64] = 5
+	_, err = Ecrecover(hash, sig)
+	assert.NotNil(t, err)
+}
+
+func TestEd25519Verify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	msg := []byte("hello")
+	sig := ed25519.Sign(priv, msg)
+
+	assert.True(t, Ed25519Verify(pub, msg, sig))
+	assert.False(t, Ed25519Verify(pub, []byte("hellO"), sig))
+	assert.False(t, Ed25519Verify(pub[:31], msg, sig))
+}
+
+func TestSM2Verify(t *testing.T) {
+	priv, pub, err := keypair.GenerateKeyPair(keypair.PK_SM2, keypair.SM2P256V1)
+	assert.Nil(t, err)
+	msg := []byte("hello")
+	sig, err := signature.Sign(signature.SM3withSM2, priv, msg, nil)
+	assert.Nil(t, err)
+	sigData, err := signature.Serialize(sig)
+	assert.Nil(t, err)
+	pubData := keypair.SerializePublicKey(pub)
+
+	assert.True(t, SM2Verify(pubData, msg, sigData))
+	assert.False(t, SM2Verify(pubData, []byte("hellO"), sigData))
+	assert.False(t, SM2Verify(pubData[1:], msg, sigData))
+
+	ecPriv, ecPub, err := keypair.GenerateKeyPair(keypair.PK_ECDSA, keypair.P256)
+	assert.Nil(t, err)
+	sig, err = signature.Sign(signature.SHA256withECDSA, ecPriv, msg, nil)
+	assert.Nil(t, err)
+	sigData, err = signature.Serialize(sig)
+	assert.Nil(t, err)
+	assert.False(t, SM2Verify(keypair.SerializePublicKey(ecPub), msg, sigData))
+}
+
+func TestBLS12381PairingCheck(t *testing.T) {
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+	neg := g1.New()
+	g1.Neg(neg, g1.One())
+
+	pair := func(p1 *bls12381.PointG1, p2 *bls12381.PointG2) []byte {
+		return append(g1.ToUncompressed(p1), g2.ToUncompressed(p2)...)
+	}
+
+	input := append(pair(g1.One(), g2.One()), pair(neg, g2.One())...)
+	ok, err := BLS12381PairingCheck(input)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = BLS12381PairingCheck(pair(g1.One(), g2.One()))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, err = BLS12381PairingCheck(input[:BLS12381_PAIR_LEN-1])
+	assert.NotNil(t, err)
+
+	input[1] ^= 1
+	_, err = BLS12381PairingCheck(input)
+	assert.NotNil(t, err)
+}
@@ -73,6 +73,7 @@ func CrossChainNeoVMCall(this *native.NativeService, address common.Address, met
 	if err != nil {
 		return nil, err
 	}
+	engine.(*neovm.NeoVmService).Address = address
 	evalStack := engine.(*neovm.NeoVmService).Engine.EvalStack
 	if err := evalStack.Push(ntypes.VmValueFromArrayVal(array)); err != nil {
 		return nil, err
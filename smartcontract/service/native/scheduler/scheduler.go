@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+//Package scheduler is the native contract scheduling contract calls at future blocks. A scheduled call is executed
+//as a system invocation at the start of its block, and the gas is paid by the prepaid gas of caller
+package scheduler
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+)
+
+func InitScheduler() {
+	native.Contracts[utils.SchedulerContractAddress] = RegisterSchedulerContract
+}
+
+func RegisterSchedulerContract(native *native.NativeService) {
+	native.Register(SCHEDULE_NAME, Schedule)
+	native.Register(CANCEL_NAME, Cancel)
+	native.Register(GET_SCHEDULED_CALL_NAME, GetScheduledCall)
+}
+
+//Schedule registers a call and transfers the prepaid gas from caller to scheduler contract. A contract caller
+//need approve the prepaid gas of ong to scheduler contract first. Return the id of call
+func Schedule(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetSchedulerHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] scheduler is not enabled")
+	}
+	var param ScheduleParam
+	if err := param.Deserialization(common.NewZeroCopySource(native.Input)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] deserialize param error: %s", err)
+	}
+	if err := utils.ValidateOwner(native, param.Caller); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] %s", err)
+	}
+	if param.Height <= native.Height || param.Height-native.Height > MAX_SCHEDULE_BLOCKS {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] height %d should be in (%d, %d]", param.Height,
+			native.Height, native.Height+MAX_SCHEDULE_BLOCKS)
+	}
+	if len(param.Method) == 0 || len(param.Method) > MAX_METHOD_SIZE {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] method size should be in [1, %d]", MAX_METHOD_SIZE)
+	}
+	if len(param.Args) > MAX_ARGS_SIZE {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] args size should not exceed %d", MAX_ARGS_SIZE)
+	}
+	if param.GasLimit < neovm.MIN_TRANSACTION_GAS || param.GasLimit > MAX_CALL_GAS_LIMIT {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] gas limit should be in [%d, %d]", neovm.MIN_TRANSACTION_GAS,
+			MAX_CALL_GAS_LIMIT)
+	}
+	if param.GasPrice == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] gas price should not be zero")
+	}
+	prepaid, overflow := common.SafeMul(param.GasLimit, param.GasPrice)
+	if overflow {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] prepaid gas overflow")
+	}
+	vmType, err := getVmType(native, param.Contract)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] %s", err)
+	}
+
+	calling := native.ContextRef.CallingContext()
+	if calling != nil && calling.ContractAddress == param.Caller {
+		err = appCallTransferFromOng(native, utils.SchedulerContractAddress, param.Caller, utils.SchedulerContractAddress, prepaid)
+	} else {
+		err = appCallTransferOng(native, param.Caller, utils.SchedulerContractAddress, prepaid)
+	}
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] prepay gas error: %s", err)
+	}
+
+	id, err := nextCallId(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Schedule] get call id error: %s", err)
+	}
+	call := &ScheduledCall{Id: id, VmType: vmType, ScheduleParam: param}
+	putCall(native, call)
+	addNotification(native, SCHEDULE_NAME, id, param.Caller.ToBase58(), param.Contract.ToHexString(), param.Method,
+		param.Height, prepaid)
+	return common.BigIntToNeoBytes(new(big.Int).SetUint64(id)), nil
+}
+
+//Cancel removes a call not executed yet and refunds the prepaid gas to caller
+func Cancel(native *native.NativeService) ([]byte, error) {
+	if native.Height < config.GetSchedulerHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] scheduler is not enabled")
+	}
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] deserialize id error: %s", err)
+	}
+	call, err := GetCall(native, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] get call error: %s", err)
+	}
+	if call == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] call %d not found", id)
+	}
+	if err := utils.ValidateOwner(native, call.Caller); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] %s", err)
+	}
+	RemoveCall(native, call)
+	if err := appCallTransferOng(native, utils.SchedulerContractAddress, call.Caller, call.Prepaid()); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[Cancel] refund gas error: %s", err)
+	}
+	addNotification(native, CANCEL_NAME, id)
+	return utils.BYTE_TRUE, nil
+}
+
+//GetScheduledCall return the serialized call of id
+func GetScheduledCall(native *native.NativeService) ([]byte, error) {
+	id, err := utils.DecodeVarUint(common.NewZeroCopySource(native.Input))
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCall] deserialize id error: %s", err)
+	}
+	call, err := GetCall(native, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCall] get call error: %s", err)
+	}
+	if call == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("[GetScheduledCall] call %d not found", id)
+	}
+	return common.SerializeToBytes(call), nil
+}
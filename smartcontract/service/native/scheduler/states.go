@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+)
+
+const (
+	VM_TYPE_NATIVE byte = 0
+	VM_TYPE_NEOVM  byte = 1
+	VM_TYPE_WASMVM byte = 2
+)
+
+//ScheduleParam registers a call of contract method at height, the gas of GasLimit*GasPrice is prepaid by caller
+//Args is the raw input of native contract, the single bytearray param of neovm contract, or the serialized params
+//following the method of wasm contract
+type ScheduleParam struct {
+	Caller   common.Address
+	Contract common.Address
+	Method   string
+	Args     []byte
+	Height   uint32
+	GasLimit uint64
+	GasPrice uint64
+}
+
+func (this *ScheduleParam) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeAddress(sink, this.Caller)
+	utils.EncodeAddress(sink, this.Contract)
+	utils.EncodeString(sink, this.Method)
+	utils.EncodeVarBytes(sink, this.Args)
+	utils.EncodeVarUint(sink, uint64(this.Height))
+	utils.EncodeVarUint(sink, this.GasLimit)
+	utils.EncodeVarUint(sink, this.GasPrice)
+}
+
+func (this *ScheduleParam) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Caller, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("deserialize caller error: %s", err)
+	}
+	if this.Contract, err = utils.DecodeAddress(source); err != nil {
+		return fmt.Errorf("deserialize contract error: %s", err)
+	}
+	if this.Method, err = utils.DecodeString(source); err != nil {
+		return fmt.Errorf("deserialize method error: %s", err)
+	}
+	if this.Args, err = utils.DecodeVarBytes(source); err != nil {
+		return fmt.Errorf("deserialize args error: %s", err)
+	}
+	height, err := utils.DecodeVarUint(source)
+	if err != nil {
+		return fmt.Errorf("deserialize height error: %s", err)
+	}
+	if height > MAX_HEIGHT {
+		return fmt.Errorf("deserialize height error: %d out of range", height)
+	}
+	this.Height = uint32(height)
+	if this.GasLimit, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("deserialize gas limit error: %s", err)
+	}
+	if this.GasPrice, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("deserialize gas price error: %s", err)
+	}
+	return nil
+}
+
+//ScheduledCall is a registered call waiting to be executed
+type ScheduledCall struct {
+	Id     uint64
+	VmType byte
+	ScheduleParam
+}
+
+func (this *ScheduledCall) Serialization(sink *common.ZeroCopySink) {
+	utils.EncodeVarUint(sink, this.Id)
+	sink.WriteByte(this.VmType)
+	this.ScheduleParam.Serialization(sink)
+}
+
+func (this *ScheduledCall) Deserialization(source *common.ZeroCopySource) error {
+	var err error
+	if this.Id, err = utils.DecodeVarUint(source); err != nil {
+		return fmt.Errorf("deserialize id error: %s", err)
+	}
+	vmType, eof := source.NextByte()
+	if eof {
+		return fmt.Errorf("deserialize vm type error: unexpected eof")
+	}
+	this.VmType = vmType
+	return this.ScheduleParam.Deserialization(source)
+}
+
+//Prepaid return the prepaid gas of call
+func (this *ScheduledCall) Prepaid() uint64 {
+	return this.GasLimit * this.GasPrice
+}
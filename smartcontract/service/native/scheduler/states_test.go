@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduledCallSerialization(t *testing.T) {
+	caller, _ := common.AddressFromBase58("AFmseVrdL9f9oyCzZefL9tG6UbvhUMqNMV")
+	call := &ScheduledCall{
+		Id:     7,
+		VmType: VM_TYPE_NEOVM,
+		ScheduleParam: ScheduleParam{
+			Caller:   caller,
+			Contract: utils.OngContractAddress,
+			Method:   "transfer",
+			Args:     []byte{1, 2, 3},
+			Height:   MAX_HEIGHT,
+			GasLimit: 200000,
+			GasPrice: 2500,
+		},
+	}
+	decoded := new(ScheduledCall)
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(common.SerializeToBytes(call))))
+	assert.Equal(t, call, decoded)
+	assert.Equal(t, uint64(500000000), decoded.Prepaid())
+
+	raw := common.SerializeToBytes(call)
+	assert.NotNil(t, decoded.Deserialization(common.NewZeroCopySource(raw[:len(raw)-1])))
+}
+
+func TestScheduledCallTransaction(t *testing.T) {
+	call := &ScheduledCall{Id: 1, ScheduleParam: ScheduleParam{Contract: utils.OngContractAddress, Method: "balanceOf",
+		GasLimit: 20000, GasPrice: 1}}
+	for vmType, txType := range map[byte]types.TransactionType{VM_TYPE_NATIVE: types.InvokeNeo,
+		VM_TYPE_NEOVM: types.InvokeNeo, VM_TYPE_WASMVM: types.InvokeWasm} {
+		call.VmType = vmType
+		tx, err := call.Transaction()
+		assert.Nil(t, err)
+		assert.Equal(t, txType, tx.TxType)
+		assert.Equal(t, utils.SchedulerContractAddress, tx.Payer)
+		assert.Equal(t, 0, len(tx.Sigs))
+	}
+	call.VmType = 3
+	_, err := call.Transaction()
+	assert.NotNil(t, err)
+}
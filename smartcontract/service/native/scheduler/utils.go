@@ -0,0 +1,267 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package scheduler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/service/native"
+	"github.com/ontio/ontology/smartcontract/service/native/ont"
+	"github.com/ontio/ontology/smartcontract/service/native/utils"
+	"github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/states"
+	vm "github.com/ontio/ontology/vm/neovm"
+)
+
+const (
+	//method names
+	SCHEDULE_NAME           = "schedule"
+	CANCEL_NAME             = "cancel"
+	GET_SCHEDULED_CALL_NAME = "getScheduledCall"
+
+	//event names
+	EVENT_EXECUTE = "execute"
+	EVENT_FAIL    = "fail"
+
+	//storage key prefixes
+	NEXT_CALL_ID = "nextCallId"
+	CALL         = "call"
+	DUE          = "due"
+
+	MAX_HEIGHT = math.MaxUint32
+	//max due calls executed at the start of a block, the rest are delayed to next blocks
+	MAX_CALLS_PER_BLOCK = 32
+	//max blocks between schedule and execution
+	MAX_SCHEDULE_BLOCKS = 1000000
+	MAX_METHOD_SIZE     = 128
+	MAX_ARGS_SIZE       = 16 * 1024
+	MAX_CALL_GAS_LIMIT  = 20000000
+)
+
+func genCallKey(id uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], id)
+	return utils.ConcatKey(utils.SchedulerContractAddress, []byte(CALL), buf[:])
+}
+
+func genDuePrefix() []byte {
+	return utils.ConcatKey(utils.SchedulerContractAddress, []byte(DUE))
+}
+
+//due keys are ordered by height then id
+func genDueKey(height uint32, id uint64) []byte {
+	var buf [12]byte
+	binary.BigEndian.PutUint32(buf[:4], height)
+	binary.BigEndian.PutUint64(buf[4:], id)
+	return append(genDuePrefix(), buf[:]...)
+}
+
+func nextCallId(native *native.NativeService) (uint64, error) {
+	key := utils.ConcatKey(utils.SchedulerContractAddress, []byte(NEXT_CALL_ID))
+	id, err := utils.GetStorageUInt64(native, key)
+	if err != nil {
+		return 0, err
+	}
+	native.CacheDB.Put(key, utils.GenUInt64StorageItem(id+1).ToArray())
+	return id, nil
+}
+
+func putCall(native *native.NativeService, call *ScheduledCall) {
+	native.CacheDB.Put(genCallKey(call.Id), utils.GenVarBytesStorageItem(common.SerializeToBytes(call)).ToArray())
+	native.CacheDB.Put(genDueKey(call.Height, call.Id), utils.GenUInt64StorageItem(call.Id).ToArray())
+}
+
+//GetCall return the scheduled call of id, nil if not exist
+func GetCall(native *native.NativeService, id uint64) (*ScheduledCall, error) {
+	raw, err := utils.GetStorageVarBytes(native, genCallKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	call := new(ScheduledCall)
+	if err := call.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+//RemoveCall delete the scheduled call, it is removed before executed
+func RemoveCall(native *native.NativeService, call *ScheduledCall) {
+	native.CacheDB.Delete(genCallKey(call.Id))
+	native.CacheDB.Delete(genDueKey(call.Height, call.Id))
+}
+
+//GetDueCalls return the calls scheduled at or before height in order, at most limit calls
+func GetDueCalls(native *native.NativeService, height uint32, limit int) ([]*ScheduledCall, error) {
+	prefix := genDuePrefix()
+	var ids []uint64
+	iter := native.CacheDB.NewIterator(prefix)
+	for has := iter.First(); has && len(ids) < limit; has = iter.Next() {
+		key := iter.Key()
+		if len(key) != len(prefix)+12 || !bytes.HasPrefix(key, prefix) {
+			continue
+		}
+		if binary.BigEndian.Uint32(key[len(prefix):]) > height {
+			break
+		}
+		ids = append(ids, binary.BigEndian.Uint64(key[len(prefix)+4:]))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	calls := make([]*ScheduledCall, 0, len(ids))
+	for _, id := range ids {
+		call, err := GetCall(native, id)
+		if err != nil {
+			return nil, err
+		}
+		if call == nil {
+			return nil, fmt.Errorf("scheduled call %d not found", id)
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+func getVmType(native *native.NativeService, contract common.Address) (byte, error) {
+	if utils.IsNativeContract(contract) {
+		return VM_TYPE_NATIVE, nil
+	}
+	dep, err := native.CacheDB.GetContract(contract)
+	if err != nil {
+		return 0, err
+	}
+	if dep == nil {
+		return 0, fmt.Errorf("contract %s not found", contract.ToHexString())
+	}
+	if dep.VmType() == payload.WASMVM_TYPE {
+		return VM_TYPE_WASMVM, nil
+	}
+	return VM_TYPE_NEOVM, nil
+}
+
+//Transaction return the system transaction executing the call. Native contracts are invoked with Args as input,
+//neovm contracts with Method and an array of Args, wasm contracts with Method and Args appended as input.
+//The scheduler contract is the payer, and no address is witnessed during execution
+func (this *ScheduledCall) Transaction() (*types.Transaction, error) {
+	txType := types.InvokeNeo
+	var code []byte
+	switch this.VmType {
+	case VM_TYPE_NATIVE:
+		builder := vm.NewParamsBuilder(new(bytes.Buffer))
+		builder.EmitPushByteArray(this.Args)
+		builder.EmitPushByteArray([]byte(this.Method))
+		builder.EmitPushByteArray(this.Contract[:])
+		builder.EmitPushInteger(big.NewInt(0))
+		builder.Emit(vm.SYSCALL)
+		builder.EmitPushByteArray([]byte(neovm.NATIVE_INVOKE_NAME))
+		code = builder.ToArray()
+	case VM_TYPE_NEOVM:
+		builder := vm.NewParamsBuilder(new(bytes.Buffer))
+		builder.EmitPushByteArray(this.Args)
+		builder.EmitPushInteger(big.NewInt(1))
+		builder.Emit(vm.PACK)
+		builder.EmitPushByteArray([]byte(this.Method))
+		builder.EmitPushCall(this.Contract[:])
+		code = builder.ToArray()
+	case VM_TYPE_WASMVM:
+		sink := common.NewZeroCopySink(nil)
+		sink.WriteString(this.Method)
+		sink.WriteBytes(this.Args)
+		code = common.SerializeToBytes(&states.WasmContractParam{Address: this.Contract, Args: sink.Bytes()})
+		txType = types.InvokeWasm
+	default:
+		return nil, fmt.Errorf("unknown vm type %d", this.VmType)
+	}
+	mutable := &types.MutableTransaction{
+		TxType:   txType,
+		Nonce:    uint32(this.Id),
+		GasPrice: this.GasPrice,
+		GasLimit: this.GasLimit,
+		Payer:    utils.SchedulerContractAddress,
+		Payload:  &payload.InvokeCode{Code: code},
+	}
+	return mutable.IntoImmutable()
+}
+
+//Settle pay the gas fee of executed call to governance contract, refund the rest of prepaid gas to caller and
+//notify the execution result
+func Settle(native *native.NativeService, call *ScheduledCall, fee uint64, execErr error) error {
+	prepaid := call.Prepaid()
+	if fee > prepaid {
+		fee = prepaid
+	}
+	native.ContextRef.PushContext(&context.Context{ContractAddress: utils.SchedulerContractAddress})
+	transfers := &ont.Transfers{States: []ont.State{
+		{From: utils.SchedulerContractAddress, To: utils.GovernanceContractAddress, Value: fee},
+		{From: utils.SchedulerContractAddress, To: call.Caller, Value: prepaid - fee},
+	}}
+	if _, err := native.NativeCall(utils.OngContractAddress, "transfer", common.SerializeToBytes(transfers)); err != nil {
+		return fmt.Errorf("settle scheduled call %d error: %s", call.Id, err)
+	}
+	if execErr == nil {
+		addNotification(native, EVENT_EXECUTE, call.Id, fee)
+	} else {
+		addNotification(native, EVENT_FAIL, call.Id, fee, execErr.Error())
+	}
+	native.ContextRef.PushNotifications(native.Notifications)
+	native.ContextRef.PopContext()
+	return nil
+}
+
+func appCallTransferOng(native *native.NativeService, from, to common.Address, amount uint64) error {
+	transfers := &ont.Transfers{States: []ont.State{{From: from, To: to, Value: amount}}}
+	if _, err := native.NativeCall(utils.OngContractAddress, "transfer", common.SerializeToBytes(transfers)); err != nil {
+		return fmt.Errorf("appCallTransferOng, appCall error: %v", err)
+	}
+	return nil
+}
+
+func appCallTransferFromOng(native *native.NativeService, sender, from, to common.Address, amount uint64) error {
+	params := &ont.TransferFrom{Sender: sender, From: from, To: to, Value: amount}
+	if _, err := native.NativeCall(utils.OngContractAddress, "transferFrom", common.SerializeToBytes(params)); err != nil {
+		return fmt.Errorf("appCallTransferFromOng, appCall error: %v", err)
+	}
+	return nil
+}
+
+func addNotification(native *native.NativeService, states ...interface{}) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.Notifications = append(native.Notifications,
+		&event.NotifyEventInfo{
+			ContractAddress: utils.SchedulerContractAddress,
+			States:          states,
+		})
+}
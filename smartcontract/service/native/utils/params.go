@@ -35,6 +35,7 @@ var (
 	CrossChainContractAddress, _ = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09})
 	LockProxyContractAddress, _  = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0a})
 	OntFSContractAddress, _      = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0b})
+	SchedulerContractAddress, _  = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x0c})
 	//WARN: when add Contract Here, please update IsNativeContract function bellow.
 )
 
@@ -43,7 +44,7 @@ func IsNativeContract(addr common.Address) bool {
 	case OntContractAddress, OngContractAddress, OntIDContractAddress,
 		ParamContractAddress, AuthContractAddress, GovernanceContractAddress,
 		HeaderSyncContractAddress, CrossChainContractAddress, LockProxyContractAddress,
-		OntFSContractAddress:
+		OntFSContractAddress, SchedulerContractAddress:
 		return true
 	default:
 		return false
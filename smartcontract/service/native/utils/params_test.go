@@ -28,7 +28,8 @@ import (
 func TestIsNativeContract(t *testing.T) {
 	address := []common.Address{OntContractAddress, OngContractAddress, OntIDContractAddress,
 		ParamContractAddress, AuthContractAddress, GovernanceContractAddress,
-		HeaderSyncContractAddress, CrossChainContractAddress, LockProxyContractAddress}
+		HeaderSyncContractAddress, CrossChainContractAddress, LockProxyContractAddress,
+		OntFSContractAddress, SchedulerContractAddress}
 	for _, addr := range address {
 		assert.True(t, IsNativeContract(addr))
 	}
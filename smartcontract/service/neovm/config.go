@@ -33,6 +33,7 @@ var (
 	BLOCKCHAIN_GETCONTRACT_GAS    uint64 = 100
 	CONTRACT_CREATE_GAS           uint64 = 20000000
 	CONTRACT_MIGRATE_GAS          uint64 = 20000000
+	CONTRACT_UPGRADE_GAS          uint64 = 20000000
 	UINT_DEPLOY_CODE_LEN_GAS      uint64 = 200000
 	UINT_INVOKE_CODE_LEN_GAS      uint64 = 20000
 	NATIVE_INVOKE_GAS             uint64 = 1000
@@ -49,7 +50,19 @@ var (
 	SHA256_GAS                    uint64 = 10
 	HASH160_GAS                   uint64 = 20
 	HASH256_GAS                   uint64 = 20
+	KECCAK256_GAS                 uint64 = 10
+	RIPEMD160_GAS                 uint64 = 10
+	BLAKE2B256_GAS                uint64 = 10
+	ECRECOVER_GAS                 uint64 = 400
+	ED25519_VERIFY_GAS            uint64 = 400
+	SM2_VERIFY_GAS                uint64 = 800
+	BLS12381_PAIRING_GAS          uint64 = 6000
 	OPCODE_GAS                    uint64 = 1
+	//storage deposit is disabled by default, and can be enabled by the global param of STORAGE_BYTE_DEPOSIT_NAME
+	STORAGE_BYTE_DEPOSIT_GAS uint64 = 0
+
+	//method invoked on the new code when a contract is upgraded with migration
+	CONTRACT_MIGRATE_METHOD = "migrate"
 
 	PER_UNIT_CODE_LEN    = 1024
 	METHOD_LENGTH_LIMIT  = 1024
@@ -85,6 +98,7 @@ var (
 
 	CONTRACT_CREATE_NAME            = "Ontology.Contract.Create"
 	CONTRACT_MIGRATE_NAME           = "Ontology.Contract.Migrate"
+	CONTRACT_UPGRADE_NAME           = "Ontology.Contract.Upgrade"
 	CONTRACT_GETSTORAGECONTEXT_NAME = "System.Contract.GetStorageContext"
 	CONTRACT_DESTROY_NAME           = "System.Contract.Destroy"
 	CONTRACT_GETSCRIPT_NAME         = "Ontology.Contract.GetScript"
@@ -109,6 +123,14 @@ var (
 	RUNTIME_GETCURRENTBLOCKHASH_NAME = "Ontology.Runtime.GetCurrentBlockHash"
 	RUNTIME_VERIFYMUTISIG_NAME       = "Ontology.Runtime.VerifyMutiSig"
 
+	CRYPTO_KECCAK256_NAME            = "Ontology.Crypto.Keccak256"
+	CRYPTO_RIPEMD160_NAME            = "Ontology.Crypto.Ripemd160"
+	CRYPTO_BLAKE2B256_NAME           = "Ontology.Crypto.Blake2b256"
+	CRYPTO_ECRECOVER_NAME            = "Ontology.Crypto.Ecrecover"
+	CRYPTO_ED25519VERIFY_NAME        = "Ontology.Crypto.Ed25519Verify"
+	CRYPTO_SM2VERIFY_NAME            = "Ontology.Crypto.SM2Verify"
+	CRYPTO_BLS12381PAIRINGCHECK_NAME = "Ontology.Crypto.BLS12381PairingCheck"
+
 	NATIVE_INVOKE_NAME = "Ontology.Native.Invoke"
 	WASM_INVOKE_NAME   = "Ontology.Wasm.InvokeWasm"
 
@@ -125,6 +147,7 @@ var (
 	HASH256_NAME              = "HASH256"
 	UINT_DEPLOY_CODE_LEN_NAME = "Deploy.Code.Gas"
 	UINT_INVOKE_CODE_LEN_NAME = "Invoke.Code.Gas"
+	STORAGE_BYTE_DEPOSIT_NAME = "Storage.Byte.Deposit"
 
 	GAS_TABLE = initGAS_TABLE()
 
@@ -149,6 +172,7 @@ var (
 		UINT_DEPLOY_CODE_LEN_NAME,
 		UINT_INVOKE_CODE_LEN_NAME,
 		config.WASM_GAS_FACTOR,
+		STORAGE_BYTE_DEPOSIT_NAME,
 	}
 
 	INIT_GAS_TABLE = map[string]uint64{
@@ -197,13 +221,24 @@ func initGAS_TABLE() *sync.Map {
 	m.Store(UINT_DEPLOY_CODE_LEN_NAME, UINT_DEPLOY_CODE_LEN_GAS)
 	m.Store(UINT_INVOKE_CODE_LEN_NAME, UINT_INVOKE_CODE_LEN_GAS)
 
+	m.Store(CONTRACT_UPGRADE_NAME, CONTRACT_UPGRADE_GAS)
+
 	m.Store(RUNTIME_BASE58TOADDRESS_NAME, RUNTIME_BASE58TOADDRESS_GAS)
 	m.Store(RUNTIME_ADDRESSTOBASE58_NAME, RUNTIME_ADDRESSTOBASE58_GAS)
 
 	m.Store(RUNTIME_VERIFYMUTISIG_NAME, RUNTIME_VERIFYMUTISIG_GAS)
+
+	m.Store(CRYPTO_KECCAK256_NAME, KECCAK256_GAS)
+	m.Store(CRYPTO_RIPEMD160_NAME, RIPEMD160_GAS)
+	m.Store(CRYPTO_BLAKE2B256_NAME, BLAKE2B256_GAS)
+	m.Store(CRYPTO_ECRECOVER_NAME, ECRECOVER_GAS)
+	m.Store(CRYPTO_ED25519VERIFY_NAME, ED25519_VERIFY_GAS)
+	m.Store(CRYPTO_SM2VERIFY_NAME, SM2_VERIFY_GAS)
+	m.Store(CRYPTO_BLS12381PAIRINGCHECK_NAME, BLS12381_PAIRING_GAS)
 	m.Store(WASM_INVOKE_NAME, APPCALL_GAS)
 
 	m.Store(config.WASM_GAS_FACTOR, config.DEFAULT_WASM_GAS_FACTOR)
+	m.Store(STORAGE_BYTE_DEPOSIT_NAME, STORAGE_BYTE_DEPOSIT_GAS)
 
 	return &m
 }
@@ -19,12 +19,15 @@
 package neovm
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/errors"
 	vm "github.com/ontio/ontology/vm/neovm"
+	vmty "github.com/ontio/ontology/vm/neovm/types"
 )
 
 // ContractCreate create a new smart contract on blockchain, and put it to vm stack
@@ -68,16 +71,82 @@ func ContractMigrate(service *NeoVmService, engine *vm.Executor) error {
 		val := iter.Value()
 
 		newKey := genStorageKey(newAddr, key[20:])
-		service.CacheDB.Put(newKey, val)
-		service.CacheDB.Delete(key)
+		if err := service.CacheDB.MoveStorageItem(key, newKey, val); err != nil {
+			iter.Release()
+			return err
+		}
 	}
 	iter.Release()
 	if err := iter.Error(); err != nil {
 		return err
 	}
+	if err := service.CacheDB.MoveStorageSize(oldAddr, newAddr); err != nil {
+		return err
+	}
+	return engine.EvalStack.PushAsInteropValue(contract)
+}
+
+// ContractUpgrade replace the code of current contract while keeping its address and storage,
+// and invoke the migrate method of the new code in the same transaction if required
+func ContractUpgrade(service *NeoVmService, engine *vm.Executor) error {
+	if engine.EvalStack.Count() < 8 {
+		return errors.NewErr("[ContractUpgrade] Too few input parameters")
+	}
+	contract, err := isContractParamValid(engine)
+	if err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade] contract parameters invalid!")
+	}
+	migrate, err := engine.EvalStack.PopAsBool()
+	if err != nil {
+		return err
+	}
+	context := service.ContextRef.CurrentContext()
+	if context == nil {
+		return errors.NewErr("[ContractUpgrade] current contract context invalid!")
+	}
+	address := context.ContractAddress
+
+	var upgrader common.Address
+	var txHash common.Uint256
+	if service.Tx != nil {
+		upgrader = service.Tx.Payer
+		txHash = service.Tx.Hash()
+	}
+	if _, err := service.CacheDB.UpgradeContract(address, contract, service.Height, upgrader, txHash); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade] upgrade contract failed!")
+	}
+
+	if migrate {
+		if err := invokeMigrate(service, address, contract); err != nil {
+			return errors.NewDetailErr(err, errors.ErrNoCode, "[ContractUpgrade] invoke migrate failed!")
+		}
+	}
 	return engine.EvalStack.PushAsInteropValue(contract)
 }
 
+//invokeMigrate call the migrate method of the upgraded code with an empty argument list,
+//the calling address of the migrate method is the contract itself
+func invokeMigrate(service *NeoVmService, address common.Address, contract *payload.DeployCode) error {
+	code, err := contract.GetNeoCode()
+	if err != nil {
+		return err
+	}
+	engine, err := service.ContextRef.NewExecuteEngine(code, types.InvokeNeo)
+	if err != nil {
+		return err
+	}
+	neoService := engine.(*NeoVmService)
+	neoService.Address = address
+	if err := neoService.Engine.EvalStack.Push(vmty.VmValueFromArrayVal(vmty.NewArrayValue())); err != nil {
+		return err
+	}
+	if err := neoService.Engine.EvalStack.PushBytes([]byte(CONTRACT_MIGRATE_METHOD)); err != nil {
+		return err
+	}
+	_, err = engine.Invoke()
+	return err
+}
+
 // ContractDestory destroy a contract
 func ContractDestory(service *NeoVmService, engine *vm.Executor) error {
 	context := service.ContextRef.CurrentContext()
@@ -95,7 +164,10 @@ func ContractDestory(service *NeoVmService, engine *vm.Executor) error {
 	iter := service.CacheDB.NewIterator(addr[:])
 	for has := iter.First(); has; has = iter.Next() {
 		key := iter.Key()
-		service.CacheDB.Delete(key)
+		if err := service.ContextRef.PutStorage(key, nil); err != nil {
+			iter.Release()
+			return err
+		}
 	}
 	iter.Release()
 	if err := iter.Error(); err != nil {
@@ -118,12 +190,14 @@ func ContractGetStorageContext(service *NeoVmService, engine *vm.Executor) error
 	if !ok {
 		return errors.NewErr("[GetStorageContext] Pop data not contract!")
 	}
-	address := contractState.Address()
+	//the address of an upgraded contract is not the hash of its code, so the contract is checked against the code
+	//deployed at the current address
+	address := service.ContextRef.CurrentContext().ContractAddress
 	item, err := service.CacheDB.GetContract(address)
 	if err != nil || item == nil {
 		return errors.NewDetailErr(err, errors.ErrNoCode, "[GetStorageContext] Get StorageContext nil")
 	}
-	if address != service.ContextRef.CurrentContext().ContractAddress {
+	if !bytes.Equal(item.GetRawCode(), contractState.GetRawCode()) {
 		return errors.NewErr("[GetStorageContext] CodeHash not equal!")
 	}
 	return engine.EvalStack.PushAsInteropValue(NewStorageContext(address))
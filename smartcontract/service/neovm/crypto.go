@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neovm
+
+import (
+	"github.com/ontio/ontology/smartcontract/service/crypto"
+	vm "github.com/ontio/ontology/vm/neovm"
+)
+
+func cryptoHash(engine *vm.Executor, hash func([]byte) []byte) error {
+	data, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	return engine.EvalStack.PushBytes(hash(data))
+}
+
+func CryptoKeccak256(service *NeoVmService, engine *vm.Executor) error {
+	return cryptoHash(engine, crypto.Keccak256)
+}
+
+func CryptoRipemd160(service *NeoVmService, engine *vm.Executor) error {
+	return cryptoHash(engine, crypto.Ripemd160)
+}
+
+func CryptoBlake2b256(service *NeoVmService, engine *vm.Executor) error {
+	return cryptoHash(engine, crypto.Blake2b256)
+}
+
+//CryptoEcrecover pushes the recovered public key, or an empty bytearray if recovery failed
+func CryptoEcrecover(service *NeoVmService, engine *vm.Executor) error {
+	hash, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	sig, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	pub, err := crypto.Ecrecover(hash, sig)
+	if err != nil {
+		return engine.EvalStack.PushBytes([]byte{})
+	}
+	return engine.EvalStack.PushBytes(pub)
+}
+
+func cryptoVerify(engine *vm.Executor, verify func(pubKey, msg, sig []byte) bool) error {
+	pubKey, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	msg, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	sig, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	return engine.EvalStack.PushBool(verify(pubKey, msg, sig))
+}
+
+func CryptoEd25519Verify(service *NeoVmService, engine *vm.Executor) error {
+	return cryptoVerify(engine, crypto.Ed25519Verify)
+}
+
+func CryptoSM2Verify(service *NeoVmService, engine *vm.Executor) error {
+	return cryptoVerify(engine, crypto.SM2Verify)
+}
+
+func CryptoBLS12381PairingCheck(service *NeoVmService, engine *vm.Executor) error {
+	input, err := engine.EvalStack.PopAsBytes()
+	if err != nil {
+		return err
+	}
+	ok, err := crypto.BLS12381PairingCheck(input)
+	if err != nil {
+		return err
+	}
+	return engine.EvalStack.PushBool(ok)
+}
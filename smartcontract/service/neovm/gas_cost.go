@@ -20,6 +20,7 @@ package neovm
 
 import (
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/smartcontract/service/crypto"
 	vm "github.com/ontio/ontology/vm/neovm"
 )
 
@@ -39,10 +40,27 @@ func StoreGasCost(gasTable map[string]uint64, engine *vm.Executor) (uint64, erro
 	}
 }
 
+//DataGasCost charges the gas of name per unit of the bytes at the top of the stack
+func DataGasCost(gasTable map[string]uint64, engine *vm.Executor, name string, unit int) (uint64, error) {
+	data, err := engine.EvalStack.PeekAsBytes(0)
+	if err != nil {
+		return 0, err
+	}
+	if cost, ok := gasTable[name]; ok {
+		return uint64(len(data)/unit+1) * cost, nil
+	} else {
+		return uint64(0), errors.NewErr("[DataGasCost] get " + name + " gas failed")
+	}
+}
+
 func GasPrice(gasTable map[string]uint64, engine *vm.Executor, name string) (uint64, error) {
 	switch name {
 	case STORAGE_PUT_NAME:
 		return StoreGasCost(gasTable, engine)
+	case CRYPTO_KECCAK256_NAME, CRYPTO_RIPEMD160_NAME, CRYPTO_BLAKE2B256_NAME:
+		return DataGasCost(gasTable, engine, name, 1024)
+	case CRYPTO_BLS12381PAIRINGCHECK_NAME:
+		return DataGasCost(gasTable, engine, name, crypto.BLS12381_PAIR_LEN)
 	default:
 		if value, ok := gasTable[name]; ok {
 			return value, nil
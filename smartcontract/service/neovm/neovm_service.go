@@ -107,6 +107,22 @@ var (
 		RUNTIME_ADDRESSTOBASE58_NAME:     RuntimeAddressToBase58,
 		RUNTIME_GETCURRENTBLOCKHASH_NAME: RuntimeGetCurrentBlockHash,
 	}
+
+	// services enabled since crypto api height
+	ServiceMapCrypto = map[string]ServiceHandler{
+		CRYPTO_KECCAK256_NAME:            CryptoKeccak256,
+		CRYPTO_RIPEMD160_NAME:            CryptoRipemd160,
+		CRYPTO_BLAKE2B256_NAME:           CryptoBlake2b256,
+		CRYPTO_ECRECOVER_NAME:            CryptoEcrecover,
+		CRYPTO_ED25519VERIFY_NAME:        CryptoEd25519Verify,
+		CRYPTO_SM2VERIFY_NAME:            CryptoSM2Verify,
+		CRYPTO_BLS12381PAIRINGCHECK_NAME: CryptoBLS12381PairingCheck,
+	}
+
+	// services enabled since contract upgrade height
+	ServiceMapUpgrade = map[string]ServiceHandler{
+		CONTRACT_UPGRADE_NAME: ContractUpgrade,
+	}
 )
 
 var (
@@ -121,6 +137,12 @@ var (
 
 type ServiceHandler func(service *NeoVmService, engine *vm.Executor) error
 
+// Debugger is notified before every opcode is executed, the execution is paused until OnStep returns
+// and aborted if it returns an error
+type Debugger interface {
+	OnStep(service *NeoVmService, offset int, opCode vm.OpCode) error
+}
+
 // NeoVmService is a struct for smart contract provide interop service
 type NeoVmService struct {
 	Store         store.LedgerStore
@@ -128,6 +150,7 @@ type NeoVmService struct {
 	ContextRef    context.ContextRef
 	Notifications []*event.NotifyEventInfo
 	Code          []byte
+	Address       scommon.Address
 	GasTable      map[string]uint64
 	Tx            *types.Transaction
 	Time          uint32
@@ -135,6 +158,7 @@ type NeoVmService struct {
 	BlockHash     scommon.Uint256
 	Engine        *vm.Executor
 	PreExec       bool
+	Debugger      Debugger
 }
 
 // Invoke a smart contract
@@ -142,7 +166,12 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 	if len(this.Code) == 0 {
 		return nil, ERR_EXECUTE_CODE
 	}
-	this.ContextRef.PushContext(&context.Context{ContractAddress: scommon.AddressFromVmCode(this.Code), Code: this.Code})
+	//the address is only set for deployed code, which keeps its address after upgrade
+	address := this.Address
+	if address == scommon.ADDRESS_EMPTY {
+		address = scommon.AddressFromVmCode(this.Code)
+	}
+	this.ContextRef.PushContext(&context.Context{ContractAddress: address, Code: this.Code})
 	var gasTable [256]uint64
 	for {
 		//check the execution step count
@@ -155,6 +184,12 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 		if this.Engine.Context.GetInstructionPointer() >= len(this.Engine.Context.Code) {
 			break
 		}
+		if this.Debugger != nil {
+			offset := this.Engine.Context.GetInstructionPointer()
+			if err := this.Debugger.OnStep(this, offset, this.Engine.Context.NextInstruction()); err != nil {
+				return nil, err
+			}
+		}
 		opCode, eof := this.Engine.Context.ReadOpCode()
 		if eof {
 			return nil, io.EOF
@@ -212,6 +247,7 @@ func (this *NeoVmService) Invoke() (interface{}, error) {
 			if err != nil {
 				return nil, err
 			}
+			service.(*NeoVmService).Address = addr
 			err = this.Engine.EvalStack.CopyTo(service.(*NeoVmService).Engine.EvalStack)
 			if err != nil {
 				return nil, fmt.Errorf("[Appcall] EvalStack CopyTo error:%x", err)
@@ -256,6 +292,12 @@ func (this *NeoVmService) SystemCall(engine *vm.Executor) error {
 		return err
 	}
 	serviceHandler, ok := ServiceMap[serviceName]
+	if !ok && this.Height >= config.GetCryptoApiHeight() {
+		serviceHandler, ok = ServiceMapCrypto[serviceName]
+	}
+	if !ok && this.Height >= config.GetContractUpgradeHeight() {
+		serviceHandler, ok = ServiceMapUpgrade[serviceName]
+	}
 	if !ok {
 		if this.Height < config.GetContractApiDeprecateHeight() {
 			serviceHandler, ok = ServiceMapDeprecated[serviceName]
@@ -27,4 +27,14 @@ func TestNeoVmServiceMap(t *testing.T) {
 			panic("key in ServiceMap also in ServiceMapDeprecated or ServiceMapNew")
 		}
 	}
+	for k := range ServiceMapCrypto {
+		if ServiceMap[k] != nil || ServiceMapDeprecated[k] != nil || ServiceMapNew[k] != nil {
+			panic("key in ServiceMapCrypto also in other service maps")
+		}
+	}
+	for k := range ServiceMapUpgrade {
+		if ServiceMap[k] != nil || ServiceMapCrypto[k] != nil || ServiceMapDeprecated[k] != nil || ServiceMapNew[k] != nil {
+			panic("key in ServiceMapUpgrade also in other service maps")
+		}
+	}
 }
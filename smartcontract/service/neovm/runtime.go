@@ -161,7 +161,12 @@ func RuntimeLog(service *NeoVmService, engine *vm.Executor) error {
 	}
 	context := service.ContextRef.CurrentContext()
 	txHash := service.Tx.Hash()
-	event.PushSmartCodeEvent(txHash, 0, event.EVENT_LOG, &event.LogEventArgs{TxHash: txHash, ContractAddress: context.ContractAddress, Message: string(item)})
+	logEvent := &event.LogEventArgs{TxHash: txHash, ContractAddress: context.ContractAddress, Message: string(item)}
+	if collector, ok := service.Store.(event.LogCollector); ok {
+		collector.CollectLog(logEvent)
+	} else {
+		event.PushSmartCodeEvent(txHash, 0, event.EVENT_LOG, logEvent)
+	}
 
 	scv := sitem.Dump()
 	log.Debugf("[NeoContract]Debug:%s\n", scv)
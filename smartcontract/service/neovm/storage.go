@@ -53,7 +53,9 @@ func StoragePut(service *NeoVmService, engine *vm.Executor) error {
 		return err
 	}
 
-	service.CacheDB.Put(genStorageKey(context.Address, key), states.GenRawStorageItem(value))
+	if err := service.ContextRef.PutStorage(genStorageKey(context.Address, key), states.GenRawStorageItem(value)); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StoragePut] put storage error!")
+	}
 	return nil
 }
 
@@ -73,7 +75,9 @@ func StorageDelete(service *NeoVmService, engine *vm.Executor) error {
 	if err != nil {
 		return err
 	}
-	service.CacheDB.Delete(genStorageKey(context.Address, ba))
+	if err := service.ContextRef.PutStorage(genStorageKey(context.Address, ba), nil); err != nil {
+		return errors.NewDetailErr(err, errors.ErrNoCode, "[StorageDelete] delete storage error!")
+	}
 
 	return nil
 }
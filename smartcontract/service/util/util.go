@@ -78,6 +78,7 @@ func SetNeoServiceParamAndEngine(addr common.Address, engine context.Engine, sta
 	feature := service.Engine.Features
 	service.Engine = neovm.NewExecutor(code, feature)
 	service.Code = code
+	service.Address = addr
 
 	service.Engine.EvalStack = stack
 
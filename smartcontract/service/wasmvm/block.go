@@ -23,7 +23,7 @@ import (
 
 func GetCurrentBlockHash(proc *exec.Process, ptr uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CURRENT_BLOCK_HASH_GAS)
+	self.checkGas(self.gas().CurrentBlockHash)
 	blockhash := self.Service.BlockHash
 
 	length, err := proc.WriteAt(blockhash[:], int64(ptr))
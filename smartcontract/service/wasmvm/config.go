@@ -35,8 +35,20 @@ var (
 	STORAGE_GET_GAS          uint64 = 200
 	STORAGE_PUT_GAS          uint64 = 4000
 	STORAGE_DELETE_GAS       uint64 = 100
+	STORAGE_ITER_CREATE_GAS  uint64 = 200
+	STORAGE_ITER_NEXT_GAS    uint64 = 200
+	STORAGE_ITER_READ_GAS    uint64 = 1
 	UINT_DEPLOY_CODE_LEN_GAS uint64 = 200000
 	PER_UNIT_CODE_LEN        uint64 = 1024
 
-	SHA256_GAS uint64 = 10
+	SHA256_GAS           uint64 = 10
+	KECCAK256_GAS        uint64 = 10
+	RIPEMD160_GAS        uint64 = 10
+	BLAKE2B256_GAS       uint64 = 10
+	ECRECOVER_GAS        uint64 = 400
+	ED25519_VERIFY_GAS   uint64 = 400
+	SM2_VERIFY_GAS       uint64 = 800
+	BLS12381_PAIRING_GAS uint64 = 6000
+
+	MEMORY_GROW_PAGE_GAS uint64 = 0
 )
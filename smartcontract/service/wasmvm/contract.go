@@ -37,8 +37,10 @@ func migrateContractStorage(service *WasmVmService, newAddress common.Address) e
 
 		newkey := serializeStorageKey(newAddress, key[20:])
 
-		service.CacheDB.Put(newkey, val)
-		service.CacheDB.Delete(key)
+		if err := service.CacheDB.MoveStorageItem(key, newkey, val); err != nil {
+			iter.Release()
+			return err
+		}
 	}
 
 	iter.Release()
@@ -46,7 +48,7 @@ func migrateContractStorage(service *WasmVmService, newAddress common.Address) e
 		return err
 	}
 
-	return nil
+	return service.CacheDB.MoveStorageSize(oldAddress, newAddress)
 }
 
 func deleteContractStorage(service *WasmVmService) error {
@@ -54,7 +56,10 @@ func deleteContractStorage(service *WasmVmService) error {
 	iter := service.CacheDB.NewIterator(contractAddress[:])
 
 	for has := iter.First(); has; has = iter.Next() {
-		service.CacheDB.Delete(iter.Key())
+		if err := service.ContextRef.PutStorage(iter.Key(), nil); err != nil {
+			iter.Release()
+			return err
+		}
 	}
 	iter.Release()
 	if err := iter.Error(); err != nil {
@@ -86,7 +91,7 @@ func ContractCreate(proc *exec.Process,
 		panic(err)
 	}
 
-	cost := CONTRACT_CREATE_GAS + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*UINT_DEPLOY_CODE_LEN_GAS
+	cost := self.gas().ContractCreate + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*self.gas().DeployCodeLen
 	self.checkGas(cost)
 
 	name, err := ReadWasmMemory(proc, namePtr, nameLen)
@@ -123,6 +128,10 @@ func ContractCreate(proc *exec.Process,
 	if err != nil {
 		panic(err)
 	}
+	err = CheckDeployCode(self.Service.Height, wasmCode)
+	if err != nil {
+		panic(err)
+	}
 	_, err = ReadWasmModule(wasmCode, config.DefConfig.Common.WasmVerifyMethod)
 	if err != nil {
 		panic(err)
@@ -163,7 +172,7 @@ func ContractMigrate(proc *exec.Process,
 		panic(err)
 	}
 
-	cost := CONTRACT_CREATE_GAS + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*UINT_DEPLOY_CODE_LEN_GAS
+	cost := self.gas().ContractCreate + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*self.gas().DeployCodeLen
 	self.checkGas(cost)
 
 	name, err := ReadWasmMemory(proc, namePtr, nameLen)
@@ -200,6 +209,10 @@ func ContractMigrate(proc *exec.Process,
 	if err != nil {
 		panic(err)
 	}
+	err = CheckDeployCode(self.Service.Height, wasmCode)
+	if err != nil {
+		panic(err)
+	}
 	_, err = ReadWasmModule(wasmCode, config.DefConfig.Common.WasmVerifyMethod)
 	if err != nil {
 		panic(err)
@@ -224,6 +237,107 @@ func ContractMigrate(proc *exec.Process,
 	return uint32(length)
 }
 
+//ContractUpgrade replace the code of current contract while keeping its address and storage,
+//if migrate is not zero the migrate method of the new code is invoked and the output length returned
+func ContractUpgrade(proc *exec.Process,
+	codePtr uint32,
+	codeLen uint32,
+	vmType uint32,
+	namePtr uint32,
+	nameLen uint32,
+	verPtr uint32,
+	verLen uint32,
+	authorPtr uint32,
+	authorLen uint32,
+	emailPtr uint32,
+	emailLen uint32,
+	descPtr uint32,
+	descLen uint32,
+	migrate uint32) uint32 {
+
+	self := proc.HostData().(*Runtime)
+	if self.Service.Height < config.GetContractUpgradeHeight() {
+		panic(errors.NewErr("[ContractUpgrade] contract upgrade is not activated"))
+	}
+
+	code, err := ReadWasmMemory(proc, codePtr, codeLen)
+	if err != nil {
+		panic(err)
+	}
+
+	cost := self.gas().ContractCreate + uint64(uint64(codeLen)/PER_UNIT_CODE_LEN)*self.gas().DeployCodeLen
+	self.checkGas(cost)
+
+	name, err := ReadWasmMemory(proc, namePtr, nameLen)
+	if err != nil {
+		panic(err)
+	}
+
+	version, err := ReadWasmMemory(proc, verPtr, verLen)
+	if err != nil {
+		panic(err)
+	}
+
+	author, err := ReadWasmMemory(proc, authorPtr, authorLen)
+	if err != nil {
+		panic(err)
+	}
+
+	email, err := ReadWasmMemory(proc, emailPtr, emailLen)
+	if err != nil {
+		panic(err)
+	}
+
+	desc, err := ReadWasmMemory(proc, descPtr, descLen)
+	if err != nil {
+		panic(err)
+	}
+
+	dep, err := payload.CreateDeployCode(code, vmType, name, version, author, email, desc)
+	if err != nil {
+		panic(err)
+	}
+
+	wasmCode, err := dep.GetWasmCode()
+	if err != nil {
+		panic(err)
+	}
+	err = CheckDeployCode(self.Service.Height, wasmCode)
+	if err != nil {
+		panic(err)
+	}
+	_, err = ReadWasmModule(wasmCode, config.DefConfig.Common.WasmVerifyMethod)
+	if err != nil {
+		panic(err)
+	}
+
+	var upgrader common.Address
+	var txHash common.Uint256
+	if self.Service.Tx != nil {
+		upgrader = self.Service.Tx.Payer
+		txHash = self.Service.Tx.Hash()
+	}
+	contractAddr := self.Service.ContextRef.CurrentContext().ContractAddress
+	_, err = self.Service.CacheDB.UpgradeContract(contractAddr, dep, self.Service.Height, upgrader, txHash)
+	if err != nil {
+		panic(err)
+	}
+
+	if migrate == 0 {
+		return 0
+	}
+
+	self.checkGas(self.gas().CallContract)
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteString(CONTRACT_MIGRATE_METHOD)
+	result, err := callContractInner(self.Service, contractAddr, sink.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	self.CallOutPut = result
+	return uint32(len(self.CallOutPut))
+}
+
 func ContractDestroy(proc *exec.Process) {
 	self := proc.HostData().(*Runtime)
 	err := deleteContractStorage(self.Service)
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"github.com/ontio/ontology/smartcontract/service/crypto"
+	"github.com/ontio/wagon/exec"
+)
+
+func hashInner(proc *exec.Process, gas func(*GasSchedule) uint64, hash func([]byte) []byte, src uint32, slen uint32, dst uint32) {
+	self := proc.HostData().(*Runtime)
+	cost := uint64((slen/1024)+1) * gas(self.gas())
+	self.checkGas(cost)
+
+	bs, err := ReadWasmMemory(proc, src, slen)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = proc.WriteAt(hash(bs), int64(dst))
+	if err != nil {
+		panic(err)
+	}
+}
+
+func Keccak256(proc *exec.Process, src uint32, slen uint32, dst uint32) {
+	hashInner(proc, func(s *GasSchedule) uint64 { return s.Keccak256 }, crypto.Keccak256, src, slen, dst)
+}
+
+func Ripemd160(proc *exec.Process, src uint32, slen uint32, dst uint32) {
+	hashInner(proc, func(s *GasSchedule) uint64 { return s.Ripemd160 }, crypto.Ripemd160, src, slen, dst)
+}
+
+func Blake2b256(proc *exec.Process, src uint32, slen uint32, dst uint32) {
+	hashInner(proc, func(s *GasSchedule) uint64 { return s.Blake2b256 }, crypto.Blake2b256, src, slen, dst)
+}
+
+//Ecrecover writes the 65 bytes uncompressed public key to dst, returns 0 if recovery failed
+func Ecrecover(proc *exec.Process, hashPtr uint32, sigPtr uint32, dst uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	self.checkGas(self.gas().Ecrecover)
+
+	hash, err := ReadWasmMemory(proc, hashPtr, crypto.HASH_LEN)
+	if err != nil {
+		panic(err)
+	}
+	sig, err := ReadWasmMemory(proc, sigPtr, crypto.ECRECOVER_SIG_LEN)
+	if err != nil {
+		panic(err)
+	}
+
+	pub, err := crypto.Ecrecover(hash, sig)
+	if err != nil {
+		return 0
+	}
+	_, err = proc.WriteAt(pub, int64(dst))
+	if err != nil {
+		panic(err)
+	}
+
+	return 1
+}
+
+func Ed25519Verify(proc *exec.Process, pubKeyPtr uint32, msgPtr uint32, msgLen uint32, sigPtr uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	self.checkGas(self.gas().Ed25519Verify)
+
+	pubKey, err := ReadWasmMemory(proc, pubKeyPtr, crypto.ED25519_PUBKEY_LEN)
+	if err != nil {
+		panic(err)
+	}
+	msg, err := ReadWasmMemory(proc, msgPtr, msgLen)
+	if err != nil {
+		panic(err)
+	}
+	sig, err := ReadWasmMemory(proc, sigPtr, crypto.ED25519_SIG_LEN)
+	if err != nil {
+		panic(err)
+	}
+
+	if crypto.Ed25519Verify(pubKey, msg, sig) {
+		return 1
+	}
+	return 0
+}
+
+func SM2Verify(proc *exec.Process, pubKeyPtr uint32, pubKeyLen uint32, msgPtr uint32, msgLen uint32, sigPtr uint32, sigLen uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	self.checkGas(self.gas().SM2Verify)
+
+	pubKey, err := ReadWasmMemory(proc, pubKeyPtr, pubKeyLen)
+	if err != nil {
+		panic(err)
+	}
+	msg, err := ReadWasmMemory(proc, msgPtr, msgLen)
+	if err != nil {
+		panic(err)
+	}
+	sig, err := ReadWasmMemory(proc, sigPtr, sigLen)
+	if err != nil {
+		panic(err)
+	}
+
+	if crypto.SM2Verify(pubKey, msg, sig) {
+		return 1
+	}
+	return 0
+}
+
+func BLS12381PairingCheck(proc *exec.Process, inputPtr uint32, inputLen uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	cost := uint64(inputLen/crypto.BLS12381_PAIR_LEN+1) * self.gas().BLS12381Pairing
+	self.checkGas(cost)
+
+	input, err := ReadWasmMemory(proc, inputPtr, inputLen)
+	if err != nil {
+		panic(err)
+	}
+
+	ok, err := crypto.BLS12381PairingCheck(input)
+	if err != nil {
+		panic(err)
+	}
+	if ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,287 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/wagon/disasm"
+	"github.com/ontio/wagon/wasm"
+	ops "github.com/ontio/wagon/wasm/operators"
+)
+
+const (
+	HOST_MODULE_NAME     = "env"
+	HOST_FUNCTION_PREFIX = "ontio_"
+
+	MAX_WASM_IMPORTS         = 128
+	MAX_WASM_FUNCTIONS       = 8192
+	MAX_WASM_FUNCTION_SIZE   = 128 * 1024
+	MAX_WASM_FUNCTION_LOCALS = 4096
+	MAX_WASM_GLOBALS         = 1024
+	MAX_WASM_TABLE_SIZE      = 8192
+	MAX_WASM_MEMORY_PAGES    = 256
+	MAX_WASM_COMPLEXITY      = 1 << 20
+
+	//complexity weights, every other instruction and local counts 1
+	FUNCTION_COMPLEXITY = 16
+	CONTROL_COMPLEXITY  = 4
+)
+
+const (
+	valueTypeF32 wasm.ValueType = 0x7d
+	valueTypeF64 wasm.ValueType = 0x7c
+)
+
+//ValidateDeployCode check the wasm module to be deployed: imports are restricted to the ontio_* functions of host
+//module, sizes of functions, tables and memories are limited, floating point is forbidden, and the complexity
+//score of module should not exceed MAX_WASM_COMPLEXITY. Return the complexity score
+func ValidateDeployCode(code []byte) (uint64, error) {
+	m, err := decodeDeployModule(code)
+	if err != nil {
+		return 0, err
+	}
+	return validateModule(m)
+}
+
+//CheckDeployCode check the wasm code deployed at height: the host functions not activated at height can not be
+//imported, and the module is validated by ValidateDeployCode since the deploy validation height
+func CheckDeployCode(height uint32, code []byte) error {
+	m, err := decodeDeployModule(code)
+	if err != nil {
+		return err
+	}
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			if entry.ModuleName == HOST_MODULE_NAME && height < hostFunctionHeight(entry.FieldName) {
+				return fmt.Errorf("[Validate] import %s.%s: host function is not activated at height %d",
+					entry.ModuleName, entry.FieldName, height)
+			}
+		}
+	}
+	if height < config.GetWasmDeployValidationHeight() {
+		return nil
+	}
+	_, err = validateModule(m)
+	return err
+}
+
+//hostFunctionHeight return the activation height of host function, which is 0 for the functions since launch
+func hostFunctionHeight(name string) uint32 {
+	switch name {
+	case "ontio_storage_iter_create", "ontio_storage_iter_next", "ontio_storage_iter_key",
+		"ontio_storage_iter_value", "ontio_storage_iter_close":
+		return config.GetWasmStorageIterHeight()
+	case "ontio_keccak256", "ontio_ripemd160", "ontio_blake2b256", "ontio_ecrecover", "ontio_ed25519_verify",
+		"ontio_sm2_verify", "ontio_bls12381_pairing_check":
+		return config.GetCryptoApiHeight()
+	case "ontio_contract_upgrade":
+		return config.GetContractUpgradeHeight()
+	}
+	return 0
+}
+
+func decodeDeployModule(code []byte) (*wasm.Module, error) {
+	m, err := wasm.DecodeModule(bytes.NewReader(code))
+	if err != nil {
+		return nil, fmt.Errorf("[Validate] parse module error: %s", err)
+	}
+	return m, nil
+}
+
+func validateModule(m *wasm.Module) (uint64, error) {
+	if err := checkImports(m); err != nil {
+		return 0, err
+	}
+	if err := checkSizes(m); err != nil {
+		return 0, err
+	}
+	if err := checkFloatTypes(m); err != nil {
+		return 0, err
+	}
+
+	complexity := uint64(0)
+	if m.Import != nil {
+		complexity += uint64(len(m.Import.Entries)) * FUNCTION_COMPLEXITY
+	}
+	if m.Code != nil {
+		for i, body := range m.Code.Bodies {
+			score, err := functionComplexity(&body)
+			if err != nil {
+				return 0, fmt.Errorf("[Validate] function %d: %s", i, err)
+			}
+			complexity += score
+		}
+	}
+	if complexity > MAX_WASM_COMPLEXITY {
+		return 0, fmt.Errorf("[Validate] module complexity %d exceeds limit %d", complexity, MAX_WASM_COMPLEXITY)
+	}
+	return complexity, nil
+}
+
+func checkImports(m *wasm.Module) error {
+	if m.Import == nil {
+		return nil
+	}
+	if len(m.Import.Entries) > MAX_WASM_IMPORTS {
+		return fmt.Errorf("[Validate] import count %d exceeds limit %d", len(m.Import.Entries), MAX_WASM_IMPORTS)
+	}
+	host := NewHostModule().Export.Entries
+	for _, entry := range m.Import.Entries {
+		if entry.ModuleName != HOST_MODULE_NAME {
+			return fmt.Errorf("[Validate] import %s.%s: module %q is not allowed", entry.ModuleName, entry.FieldName,
+				entry.ModuleName)
+		}
+		if entry.Type.Kind() != wasm.ExternalFunction {
+			return fmt.Errorf("[Validate] import %s.%s: only function can be imported", entry.ModuleName, entry.FieldName)
+		}
+		if _, ok := host[entry.FieldName]; !ok || !strings.HasPrefix(entry.FieldName, HOST_FUNCTION_PREFIX) {
+			return fmt.Errorf("[Validate] import %s.%s: unknown host function", entry.ModuleName, entry.FieldName)
+		}
+	}
+	return nil
+}
+
+func checkSizes(m *wasm.Module) error {
+	if m.Function != nil && len(m.Function.Types) > MAX_WASM_FUNCTIONS {
+		return fmt.Errorf("[Validate] function count %d exceeds limit %d", len(m.Function.Types), MAX_WASM_FUNCTIONS)
+	}
+	if m.Global != nil && len(m.Global.Globals) > MAX_WASM_GLOBALS {
+		return fmt.Errorf("[Validate] global count %d exceeds limit %d", len(m.Global.Globals), MAX_WASM_GLOBALS)
+	}
+	if m.Table != nil {
+		for _, table := range m.Table.Entries {
+			if err := checkLimits("table size", table.Limits, MAX_WASM_TABLE_SIZE); err != nil {
+				return err
+			}
+		}
+	}
+	if m.Memory != nil {
+		for _, memory := range m.Memory.Entries {
+			if err := checkLimits("memory pages", memory.Limits, MAX_WASM_MEMORY_PAGES); err != nil {
+				return err
+			}
+		}
+	}
+	if m.Code != nil {
+		for i, body := range m.Code.Bodies {
+			if len(body.Code) > MAX_WASM_FUNCTION_SIZE {
+				return fmt.Errorf("[Validate] function %d: size %d exceeds limit %d", i, len(body.Code),
+					MAX_WASM_FUNCTION_SIZE)
+			}
+			locals := uint64(0)
+			for _, entry := range body.Locals {
+				locals += uint64(entry.Count)
+			}
+			if locals > MAX_WASM_FUNCTION_LOCALS {
+				return fmt.Errorf("[Validate] function %d: local count %d exceeds limit %d", i, locals,
+					MAX_WASM_FUNCTION_LOCALS)
+			}
+		}
+	}
+	return nil
+}
+
+func checkLimits(name string, limits wasm.ResizableLimits, max uint32) error {
+	if limits.Initial > max {
+		return fmt.Errorf("[Validate] initial %s %d exceeds limit %d", name, limits.Initial, max)
+	}
+	if limits.Flags&1 != 0 && limits.Maximum > max {
+		return fmt.Errorf("[Validate] maximum %s %d exceeds limit %d", name, limits.Maximum, max)
+	}
+	return nil
+}
+
+func checkFloatTypes(m *wasm.Module) error {
+	isFloat := func(types []wasm.ValueType) bool {
+		for _, t := range types {
+			if t == valueTypeF32 || t == valueTypeF64 {
+				return true
+			}
+		}
+		return false
+	}
+	if m.Types != nil {
+		for i, sig := range m.Types.Entries {
+			if isFloat(sig.ParamTypes) || isFloat(sig.ReturnTypes) {
+				return fmt.Errorf("[Validate] type %d: floating point is not allowed", i)
+			}
+		}
+	}
+	if m.Global != nil {
+		for i, global := range m.Global.Globals {
+			if isFloat([]wasm.ValueType{global.Type.Type}) {
+				return fmt.Errorf("[Validate] global %d: floating point is not allowed", i)
+			}
+		}
+	}
+	if m.Code != nil {
+		for i, body := range m.Code.Bodies {
+			for _, entry := range body.Locals {
+				if isFloat([]wasm.ValueType{entry.Type}) {
+					return fmt.Errorf("[Validate] function %d: floating point local is not allowed", i)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//isFloatOpcode return whether the opcode is a floating point instruction of wasm MVP
+func isFloatOpcode(code byte) bool {
+	switch {
+	case code == 0x2a || code == 0x2b || code == 0x38 || code == 0x39: // f32/f64 load and store
+		return true
+	case code == 0x43 || code == 0x44: // f32/f64 const
+		return true
+	case code >= 0x5b && code <= 0x66: // f32/f64 comparison
+		return true
+	case code >= 0x8b && code <= 0xa6: // f32/f64 arithmetic
+		return true
+	case code >= 0xa8 && code <= 0xab, code >= 0xae && code <= 0xbf: // conversions involving f32/f64
+		return true
+	}
+	return false
+}
+
+func functionComplexity(body *wasm.FunctionBody) (uint64, error) {
+	instrs, err := disasm.Disassemble(body.Code)
+	if err != nil {
+		if code, ok := err.(ops.InvalidOpcodeError); ok && isFloatOpcode(byte(code)) {
+			return 0, fmt.Errorf("floating point instruction %#x is not allowed", byte(code))
+		}
+		return 0, err
+	}
+	score := uint64(FUNCTION_COMPLEXITY)
+	for _, entry := range body.Locals {
+		score += uint64(entry.Count)
+	}
+	for _, instr := range instrs {
+		switch instr.Op.Code {
+		case ops.Block, ops.Loop, ops.If, ops.Br, ops.BrIf, ops.BrTable, ops.Call, ops.CallIndirect:
+			score += CONTROL_COMPLEXITY
+		default:
+			score++
+		}
+	}
+	return score, nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/wagon/wasm"
+	"github.com/stretchr/testify/assert"
+)
+
+//modifyModule decode the code, apply modify to the module and encode it back
+func modifyModule(t *testing.T, code []byte, modify func(m *wasm.Module)) []byte {
+	m, err := wasm.DecodeModule(bytes.NewReader(code))
+	assert.Nil(t, err)
+	modify(m)
+	buf := new(bytes.Buffer)
+	assert.Nil(t, wasm.EncodeModule(buf, m))
+	return buf.Bytes()
+}
+
+//withImport add an import of func() to growMemoryCode
+func withImport(t *testing.T, module, field string) []byte {
+	return modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+			{ModuleName: module, FieldName: field, Type: wasm.FuncImport{Type: 0}},
+		}}
+		m.Sections = append([]wasm.Section{m.Types, m.Import}, m.Sections[1:]...)
+	})
+}
+
+func TestValidateDeployCode(t *testing.T) {
+	complexity, err := ValidateDeployCode(growMemoryCode)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(FUNCTION_COMPLEXITY+3), complexity)
+
+	_, err = ValidateDeployCode([]byte{0x00, 0x61, 0x73, 0x6d})
+	assert.Contains(t, err.Error(), "parse module error")
+
+	_, err = ValidateDeployCode(withImport(t, HOST_MODULE_NAME, "ontio_timestamp"))
+	assert.Nil(t, err)
+	_, err = ValidateDeployCode(withImport(t, "wasi_unstable", "ontio_timestamp"))
+	assert.Contains(t, err.Error(), "is not allowed")
+	_, err = ValidateDeployCode(withImport(t, HOST_MODULE_NAME, "ontio_unknown"))
+	assert.Contains(t, err.Error(), "unknown host function")
+
+	code := modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		m.Memory.Entries[0].Limits.Initial = MAX_WASM_MEMORY_PAGES + 1
+	})
+	_, err = ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "initial memory pages")
+	code = modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		m.Memory.Entries[0].Limits = wasm.ResizableLimits{Flags: 1, Initial: 1, Maximum: MAX_WASM_MEMORY_PAGES + 1}
+	})
+	_, err = ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "maximum memory pages")
+
+	code = modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		// f32.const 0; drop
+		m.Code.Bodies[0].Code = []byte{0x43, 0x00, 0x00, 0x00, 0x00, 0x1a}
+	})
+	_, err = ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "floating point instruction 0x43")
+	code = modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		m.Code.Bodies[0].Locals = []wasm.LocalEntry{{Count: 1, Type: valueTypeF64}}
+	})
+	_, err = ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "floating point local")
+	code = modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		m.Code.Bodies[0].Locals = []wasm.LocalEntry{{Count: MAX_WASM_FUNCTION_LOCALS + 1, Type: wasm.ValueTypeI64}}
+	})
+	_, err = ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "local count")
+}
+
+func TestValidateDeployCodeComplexity(t *testing.T) {
+	nops := bytes.Repeat([]byte{0x01}, MAX_WASM_FUNCTION_SIZE)
+	functions := MAX_WASM_COMPLEXITY/MAX_WASM_FUNCTION_SIZE + 1
+	code := modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		for i := 0; i < functions; i++ {
+			m.Function.Types = append(m.Function.Types, 0)
+			m.Code.Bodies = append(m.Code.Bodies, wasm.FunctionBody{Code: nops})
+		}
+	})
+	_, err := ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "module complexity")
+
+	code = modifyModule(t, growMemoryCode, func(m *wasm.Module) {
+		m.Code.Bodies[0].Code = append(nops, 0x01)
+	})
+	_, err = ValidateDeployCode(code)
+	assert.Contains(t, err.Error(), "size 131073 exceeds limit")
+}
+
+func TestCheckDeployCodeHostFunction(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	assert.Nil(t, CheckDeployCode(100, withImport(t, HOST_MODULE_NAME, "ontio_timestamp")))
+	for _, name := range []string{"ontio_storage_iter_next", "ontio_keccak256", "ontio_contract_upgrade"} {
+		code := withImport(t, HOST_MODULE_NAME, name)
+		config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+		err := CheckDeployCode(100, code)
+		assert.Contains(t, err.Error(), "host function is not activated")
+
+		config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+		assert.Nil(t, CheckDeployCode(100, code))
+	}
+}
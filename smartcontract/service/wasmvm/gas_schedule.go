@@ -0,0 +1,211 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"github.com/ontio/ontology/common/config"
+)
+
+const (
+	//global param key of gas schedule version, the schedule params take effect only if version is not zero
+	GAS_SCHEDULE_VERSION_KEY = "WASM_GAS_SCHEDULE_VERSION"
+	//version of the builtin schedule, which is also implemented by the jit runtime
+	GAS_SCHEDULE_BUILTIN_VERSION uint64 = 0
+)
+
+//GasSchedule is the versioned gas schedule of wasm vm, which can be tuned by global params.
+//Instruction costs are not governed, both the interpreter and the jit runtime charge every instruction as one
+//class, GasFactor instructions cost 1 gas. The jit runtime charges the builtin costs of host functions it implements,
+//so a contract falls back to the interpreter only if the costs it depends on differ from the builtin schedule.
+type GasSchedule struct {
+	Version   uint64
+	GasFactor uint64
+
+	//gas of every 64KiB memory page grown during execution
+	MemoryGrowPage uint64
+
+	//gas of host calls
+	Timestamp         uint64
+	BlockHeight       uint64
+	SelfAddress       uint64
+	CallerAddress     uint64
+	EntryAddress      uint64
+	CheckWitness      uint64
+	CurrentBlockHash  uint64
+	CurrentTxHash     uint64
+	CallContract      uint64
+	NativeInvoke      uint64
+	ContractCreate    uint64
+	DeployCodeLen     uint64
+	StorageGet        uint64
+	StoragePut        uint64
+	StorageDelete     uint64
+	StorageIterCreate uint64
+	StorageIterNext   uint64
+	StorageIterRead   uint64
+	Sha256            uint64
+	Keccak256         uint64
+	Ripemd160         uint64
+	Blake2b256        uint64
+	Ecrecover         uint64
+	Ed25519Verify     uint64
+	SM2Verify         uint64
+	BLS12381Pairing   uint64
+}
+
+type gasScheduleParam struct {
+	key   string
+	field func(schedule *GasSchedule) *uint64
+}
+
+var gasScheduleParams = []gasScheduleParam{
+	{"WASM_GAS_MEMORY_GROW_PAGE", func(s *GasSchedule) *uint64 { return &s.MemoryGrowPage }},
+	{"WASM_GAS_TIMESTAMP", func(s *GasSchedule) *uint64 { return &s.Timestamp }},
+	{"WASM_GAS_BLOCK_HEIGHT", func(s *GasSchedule) *uint64 { return &s.BlockHeight }},
+	{"WASM_GAS_SELF_ADDRESS", func(s *GasSchedule) *uint64 { return &s.SelfAddress }},
+	{"WASM_GAS_CALLER_ADDRESS", func(s *GasSchedule) *uint64 { return &s.CallerAddress }},
+	{"WASM_GAS_ENTRY_ADDRESS", func(s *GasSchedule) *uint64 { return &s.EntryAddress }},
+	{"WASM_GAS_CHECK_WITNESS", func(s *GasSchedule) *uint64 { return &s.CheckWitness }},
+	{"WASM_GAS_CURRENT_BLOCKHASH", func(s *GasSchedule) *uint64 { return &s.CurrentBlockHash }},
+	{"WASM_GAS_CURRENT_TXHASH", func(s *GasSchedule) *uint64 { return &s.CurrentTxHash }},
+	{"WASM_GAS_CALL_CONTRACT", func(s *GasSchedule) *uint64 { return &s.CallContract }},
+	{"WASM_GAS_NATIVE_INVOKE", func(s *GasSchedule) *uint64 { return &s.NativeInvoke }},
+	{"WASM_GAS_CONTRACT_CREATE", func(s *GasSchedule) *uint64 { return &s.ContractCreate }},
+	{"WASM_GAS_DEPLOY_CODE_LEN", func(s *GasSchedule) *uint64 { return &s.DeployCodeLen }},
+	{"WASM_GAS_STORAGE_GET", func(s *GasSchedule) *uint64 { return &s.StorageGet }},
+	{"WASM_GAS_STORAGE_PUT", func(s *GasSchedule) *uint64 { return &s.StoragePut }},
+	{"WASM_GAS_STORAGE_DELETE", func(s *GasSchedule) *uint64 { return &s.StorageDelete }},
+	{"WASM_GAS_STORAGE_ITER_CREATE", func(s *GasSchedule) *uint64 { return &s.StorageIterCreate }},
+	{"WASM_GAS_STORAGE_ITER_NEXT", func(s *GasSchedule) *uint64 { return &s.StorageIterNext }},
+	{"WASM_GAS_STORAGE_ITER_READ", func(s *GasSchedule) *uint64 { return &s.StorageIterRead }},
+	{"WASM_GAS_SHA256", func(s *GasSchedule) *uint64 { return &s.Sha256 }},
+	{"WASM_GAS_KECCAK256", func(s *GasSchedule) *uint64 { return &s.Keccak256 }},
+	{"WASM_GAS_RIPEMD160", func(s *GasSchedule) *uint64 { return &s.Ripemd160 }},
+	{"WASM_GAS_BLAKE2B256", func(s *GasSchedule) *uint64 { return &s.Blake2b256 }},
+	{"WASM_GAS_ECRECOVER", func(s *GasSchedule) *uint64 { return &s.Ecrecover }},
+	{"WASM_GAS_ED25519_VERIFY", func(s *GasSchedule) *uint64 { return &s.Ed25519Verify }},
+	{"WASM_GAS_SM2_VERIFY", func(s *GasSchedule) *uint64 { return &s.SM2Verify }},
+	{"WASM_GAS_BLS12381_PAIRING", func(s *GasSchedule) *uint64 { return &s.BLS12381Pairing }},
+}
+
+//jitHostFunctionGas maps the host functions implemented by the jit runtime to the schedule params they charge.
+//NativeInvoke is charged by the go side for both engines and is not listed.
+var jitHostFunctionGas = map[string][]func(s *GasSchedule) *uint64{
+	"ontio_timestamp":         {func(s *GasSchedule) *uint64 { return &s.Timestamp }},
+	"ontio_block_height":      {func(s *GasSchedule) *uint64 { return &s.BlockHeight }},
+	"ontio_self_address":      {func(s *GasSchedule) *uint64 { return &s.SelfAddress }},
+	"ontio_caller_address":    {func(s *GasSchedule) *uint64 { return &s.CallerAddress }},
+	"ontio_entry_address":     {func(s *GasSchedule) *uint64 { return &s.EntryAddress }},
+	"ontio_check_witness":     {func(s *GasSchedule) *uint64 { return &s.CheckWitness }},
+	"ontio_current_blockhash": {func(s *GasSchedule) *uint64 { return &s.CurrentBlockHash }},
+	"ontio_current_txhash":    {func(s *GasSchedule) *uint64 { return &s.CurrentTxHash }},
+	"ontio_call_contract":     {func(s *GasSchedule) *uint64 { return &s.CallContract }},
+	"ontio_storage_read":      {func(s *GasSchedule) *uint64 { return &s.StorageGet }},
+	"ontio_storage_write":     {func(s *GasSchedule) *uint64 { return &s.StoragePut }},
+	"ontio_storage_delete":    {func(s *GasSchedule) *uint64 { return &s.StorageDelete }},
+	"ontio_sha256":            {func(s *GasSchedule) *uint64 { return &s.Sha256 }},
+	"ontio_contract_create": {
+		func(s *GasSchedule) *uint64 { return &s.ContractCreate },
+		func(s *GasSchedule) *uint64 { return &s.DeployCodeLen },
+	},
+	"ontio_contract_migrate": {
+		func(s *GasSchedule) *uint64 { return &s.ContractCreate },
+		func(s *GasSchedule) *uint64 { return &s.DeployCodeLen },
+	},
+}
+
+//DefaultGasSchedule return the builtin gas schedule
+func DefaultGasSchedule() *GasSchedule {
+	return &GasSchedule{
+		Version:           GAS_SCHEDULE_BUILTIN_VERSION,
+		GasFactor:         config.DEFAULT_WASM_GAS_FACTOR,
+		MemoryGrowPage:    MEMORY_GROW_PAGE_GAS,
+		Timestamp:         TIMESTAMP_GAS,
+		BlockHeight:       BLOCK_HEGHT_GAS,
+		SelfAddress:       SELF_ADDRESS_GAS,
+		CallerAddress:     CALLER_ADDRESS_GAS,
+		EntryAddress:      ENTRY_ADDRESS_GAS,
+		CheckWitness:      CHECKWITNESS_GAS,
+		CurrentBlockHash:  CURRENT_BLOCK_HASH_GAS,
+		CurrentTxHash:     CURRENT_TX_HASH_GAS,
+		CallContract:      CALL_CONTRACT_GAS,
+		NativeInvoke:      NATIVE_INVOKE_GAS,
+		ContractCreate:    CONTRACT_CREATE_GAS,
+		DeployCodeLen:     UINT_DEPLOY_CODE_LEN_GAS,
+		StorageGet:        STORAGE_GET_GAS,
+		StoragePut:        STORAGE_PUT_GAS,
+		StorageDelete:     STORAGE_DELETE_GAS,
+		StorageIterCreate: STORAGE_ITER_CREATE_GAS,
+		StorageIterNext:   STORAGE_ITER_NEXT_GAS,
+		StorageIterRead:   STORAGE_ITER_READ_GAS,
+		Sha256:            SHA256_GAS,
+		Keccak256:         KECCAK256_GAS,
+		Ripemd160:         RIPEMD160_GAS,
+		Blake2b256:        BLAKE2B256_GAS,
+		Ecrecover:         ECRECOVER_GAS,
+		Ed25519Verify:     ED25519_VERIFY_GAS,
+		SM2Verify:         SM2_VERIFY_GAS,
+		BLS12381Pairing:   BLS12381_PAIRING_GAS,
+	}
+}
+
+//GasScheduleKeys return the global param keys of gas schedule, exclude WASM_GAS_FACTOR
+func GasScheduleKeys() []string {
+	keys := []string{GAS_SCHEDULE_VERSION_KEY}
+	for _, param := range gasScheduleParams {
+		keys = append(keys, param.key)
+	}
+	return keys
+}
+
+//NewGasSchedule build gas schedule from gas table, params not in gas table keep the builtin value.
+//The params except WASM_GAS_FACTOR are ignored if the schedule version is zero.
+func NewGasSchedule(gasTable map[string]uint64) *GasSchedule {
+	schedule := DefaultGasSchedule()
+	if factor := gasTable[config.WASM_GAS_FACTOR]; factor != 0 {
+		schedule.GasFactor = factor
+	}
+	schedule.Version = gasTable[GAS_SCHEDULE_VERSION_KEY]
+	if schedule.Version == GAS_SCHEDULE_BUILTIN_VERSION {
+		return schedule
+	}
+	for _, param := range gasScheduleParams {
+		if val, ok := gasTable[param.key]; ok {
+			*param.field(schedule) = val
+		}
+	}
+	return schedule
+}
+
+//JitCompatible reports whether the jit runtime charges the same gas as this schedule for a contract importing
+//the host functions. Memory growth is not charged by the jit runtime, so it must keep the builtin cost.
+func (this *GasSchedule) JitCompatible(imports []string) bool {
+	builtin := builtinGasSchedule
+	if this.MemoryGrowPage != builtin.MemoryGrowPage {
+		return false
+	}
+	for _, name := range imports {
+		for _, field := range jitHostFunctionGas[name] {
+			if *field(this) != *field(builtin) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/smartcontract/states"
+	"github.com/stretchr/testify/assert"
+)
+
+//(module (memory 1) (func (export "invoke") (drop (grow_memory (i32.const 2)))))
+var growMemoryCode = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+	0x03, 0x02, 0x01, 0x00,
+	0x05, 0x03, 0x01, 0x00, 0x01,
+	0x07, 0x0a, 0x01, 0x06, 'i', 'n', 'v', 'o', 'k', 'e', 0x00, 0x00,
+	0x0a, 0x09, 0x01, 0x07, 0x00, 0x41, 0x02, 0x40, 0x00, 0x1a, 0x0b,
+}
+
+func TestNewGasSchedule(t *testing.T) {
+	schedule := NewGasSchedule(nil)
+	assert.Equal(t, DefaultGasSchedule(), schedule)
+	assert.True(t, schedule.JitCompatible([]string{"ontio_storage_read"}))
+
+	gasTable := map[string]uint64{
+		config.WASM_GAS_FACTOR:      1,
+		"WASM_GAS_STORAGE_GET":      STORAGE_GET_GAS * 2,
+		"WASM_GAS_MEMORY_GROW_PAGE": 100,
+	}
+	schedule = NewGasSchedule(gasTable)
+	assert.Equal(t, uint64(1), schedule.GasFactor)
+	assert.Equal(t, STORAGE_GET_GAS, schedule.StorageGet)
+	assert.True(t, schedule.JitCompatible([]string{"ontio_storage_read"}))
+
+	gasTable[GAS_SCHEDULE_VERSION_KEY] = 1
+	schedule = NewGasSchedule(gasTable)
+	assert.Equal(t, uint64(1), schedule.Version)
+	assert.Equal(t, STORAGE_GET_GAS*2, schedule.StorageGet)
+	assert.Equal(t, uint64(100), schedule.MemoryGrowPage)
+	assert.False(t, schedule.JitCompatible(nil))
+
+	schedule = NewGasSchedule(map[string]uint64{GAS_SCHEDULE_VERSION_KEY: 1})
+	assert.True(t, schedule.JitCompatible([]string{"ontio_storage_read"}))
+}
+
+func TestJitCompatible(t *testing.T) {
+	schedule := NewGasSchedule(map[string]uint64{
+		GAS_SCHEDULE_VERSION_KEY:   1,
+		"WASM_GAS_STORAGE_GET":     STORAGE_GET_GAS * 2,
+		"WASM_GAS_DEPLOY_CODE_LEN": UINT_DEPLOY_CODE_LEN_GAS * 2,
+		"WASM_GAS_NATIVE_INVOKE":   NATIVE_INVOKE_GAS * 2,
+		"WASM_GAS_KECCAK256":       KECCAK256_GAS * 2,
+	})
+	assert.True(t, schedule.JitCompatible(nil))
+	assert.True(t, schedule.JitCompatible([]string{"ontio_timestamp", "ontio_call_contract", "ontio_storage_write"}))
+	assert.False(t, schedule.JitCompatible([]string{"ontio_timestamp", "ontio_storage_read"}))
+	assert.False(t, schedule.JitCompatible([]string{"ontio_contract_migrate"}))
+
+	for name := range jitHostFunctionGas {
+		assert.False(t, INTERP_ONLY_HOST_FUNCS[name], name)
+	}
+}
+
+func TestGasScheduleKeys(t *testing.T) {
+	keys := make(map[string]bool)
+	for _, key := range GasScheduleKeys() {
+		assert.False(t, keys[key], key)
+		keys[key] = true
+	}
+	assert.Equal(t, len(gasScheduleParams)+1, len(keys))
+	assert.False(t, keys[config.WASM_GAS_FACTOR])
+}
+
+func TestMemoryGrowGas(t *testing.T) {
+	invoke := func(schedule *GasSchedule) uint64 {
+		gasLimit := uint64(100000)
+		execStep := uint64(100000)
+		service := &WasmVmService{
+			GasLimit:    &gasLimit,
+			ExecStep:    &execStep,
+			GasFactor:   schedule.GasFactor,
+			GasSchedule: schedule,
+		}
+		contract := &states.WasmContractParam{Address: common.AddressFromVmCode(growMemoryCode)}
+		_, err := invokeInterpreter(service, contract, growMemoryCode)
+		assert.Nil(t, err)
+		return 100000 - gasLimit
+	}
+
+	builtin := invoke(DefaultGasSchedule())
+	schedule := NewGasSchedule(map[string]uint64{GAS_SCHEDULE_VERSION_KEY: 1, "WASM_GAS_MEMORY_GROW_PAGE": 1000})
+	assert.Equal(t, builtin+2000, invoke(schedule))
+}
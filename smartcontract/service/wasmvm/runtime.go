@@ -54,23 +54,26 @@ type Runtime struct {
 	Input      []byte
 	Output     []byte
 	CallOutPut []byte
+
+	iterators    map[uint32]*storageIterator
+	nextIterator uint32
 }
 
 func Timestamp(proc *exec.Process) uint64 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(TIMESTAMP_GAS)
+	self.checkGas(self.gas().Timestamp)
 	return uint64(self.Service.Time)
 }
 
 func BlockHeight(proc *exec.Process) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(BLOCK_HEGHT_GAS)
+	self.checkGas(self.gas().BlockHeight)
 	return self.Service.Height
 }
 
 func SelfAddress(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(SELF_ADDRESS_GAS)
+	self.checkGas(self.gas().SelfAddress)
 	selfaddr := self.Service.ContextRef.CurrentContext().ContractAddress
 	_, err := proc.WriteAt(selfaddr[:], int64(dst))
 	if err != nil {
@@ -80,7 +83,7 @@ func SelfAddress(proc *exec.Process, dst uint32) {
 
 func Sha256(proc *exec.Process, src uint32, slen uint32, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	cost := uint64((slen/1024)+1) * SHA256_GAS
+	cost := uint64((slen/1024)+1) * self.gas().Sha256
 	self.checkGas(cost)
 
 	bs, err := ReadWasmMemory(proc, src, slen)
@@ -100,7 +103,7 @@ func Sha256(proc *exec.Process, src uint32, slen uint32, dst uint32) {
 
 func CallerAddress(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CALLER_ADDRESS_GAS)
+	self.checkGas(self.gas().CallerAddress)
 	if self.Service.ContextRef.CallingContext() != nil {
 		calleraddr := self.Service.ContextRef.CallingContext().ContractAddress
 		_, err := proc.WriteAt(calleraddr[:], int64(dst))
@@ -118,7 +121,7 @@ func CallerAddress(proc *exec.Process, dst uint32) {
 
 func EntryAddress(proc *exec.Process, dst uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(ENTRY_ADDRESS_GAS)
+	self.checkGas(self.gas().EntryAddress)
 	entryAddress := self.Service.ContextRef.EntryContext().ContractAddress
 	_, err := proc.WriteAt(entryAddress[:], int64(dst))
 	if err != nil {
@@ -128,7 +131,7 @@ func EntryAddress(proc *exec.Process, dst uint32) {
 
 func Checkwitness(proc *exec.Process, dst uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CHECKWITNESS_GAS)
+	self.checkGas(self.gas().CheckWitness)
 	var addr common.Address
 	_, err := proc.ReadAt(addr[:], int64(dst))
 	if err != nil {
@@ -223,7 +226,7 @@ func GetCallOut(proc *exec.Process, dst uint32) {
 
 func GetCurrentTxHash(proc *exec.Process, ptr uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(CURRENT_TX_HASH_GAS)
+	self.checkGas(self.gas().CurrentTxHash)
 
 	txhash := self.Service.Tx.Hash()
 
@@ -248,7 +251,7 @@ func RaiseException(proc *exec.Process, ptr uint32, len uint32) {
 func CallContract(proc *exec.Process, contractAddr uint32, inputPtr uint32, inputLen uint32) uint32 {
 	self := proc.HostData().(*Runtime)
 
-	self.checkGas(CALL_CONTRACT_GAS)
+	self.checkGas(self.gas().CallContract)
 	var contractAddress common.Address
 	_, err := proc.ReadAt(contractAddress[:], int64(contractAddr))
 	if err != nil {
@@ -341,6 +344,18 @@ func NewHostModule() *wasm.Module {
 				Form:       0, // value for the 'func' type constructor
 				ParamTypes: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
 			},
+			//func(uint32,uint32,uint32,uint32)uint32  [12]
+			{
+				Form:        0, // value for the 'func' type constructor
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			//func(uint32,uint32,uint32,uint32,uint32,uint32)uint32  [13]
+			{
+				Form:        0, // value for the 'func' type constructor
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
 		},
 	}
 	m.FunctionIndexSpace = []wasm.Function{
@@ -464,6 +479,71 @@ func NewHostModule() *wasm.Module {
 			Host: reflect.ValueOf(Sha256),
 			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
 		},
+		{ //24
+			Sig:  &m.Types.Entries[8],
+			Host: reflect.ValueOf(StorageIterCreate),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //25
+			Sig:  &m.Types.Entries[3],
+			Host: reflect.ValueOf(StorageIterNext),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //26
+			Sig:  &m.Types.Entries[12],
+			Host: reflect.ValueOf(StorageIterKey),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //27
+			Sig:  &m.Types.Entries[12],
+			Host: reflect.ValueOf(StorageIterValue),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //28
+			Sig:  &m.Types.Entries[2],
+			Host: reflect.ValueOf(StorageIterClose),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //29
+			Sig:  &m.Types.Entries[11],
+			Host: reflect.ValueOf(Keccak256),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //30
+			Sig:  &m.Types.Entries[11],
+			Host: reflect.ValueOf(Ripemd160),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //31
+			Sig:  &m.Types.Entries[11],
+			Host: reflect.ValueOf(Blake2b256),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //32
+			Sig:  &m.Types.Entries[5],
+			Host: reflect.ValueOf(Ecrecover),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //33
+			Sig:  &m.Types.Entries[12],
+			Host: reflect.ValueOf(Ed25519Verify),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //34
+			Sig:  &m.Types.Entries[13],
+			Host: reflect.ValueOf(SM2Verify),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //35
+			Sig:  &m.Types.Entries[8],
+			Host: reflect.ValueOf(BLS12381PairingCheck),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
+		{ //36
+			Sig:  &m.Types.Entries[9],
+			Host: reflect.ValueOf(ContractUpgrade),
+			Body: &wasm.FunctionBody{}, // create a dummy wasm body (the actual value will be taken from Host.)
+		},
 	}
 
 	m.Export = &wasm.SectionExports{
@@ -588,6 +668,71 @@ func NewHostModule() *wasm.Module {
 				Kind:     wasm.ExternalFunction,
 				Index:    23,
 			},
+			"ontio_storage_iter_create": {
+				FieldStr: "ontio_storage_iter_create",
+				Kind:     wasm.ExternalFunction,
+				Index:    24,
+			},
+			"ontio_storage_iter_next": {
+				FieldStr: "ontio_storage_iter_next",
+				Kind:     wasm.ExternalFunction,
+				Index:    25,
+			},
+			"ontio_storage_iter_key": {
+				FieldStr: "ontio_storage_iter_key",
+				Kind:     wasm.ExternalFunction,
+				Index:    26,
+			},
+			"ontio_storage_iter_value": {
+				FieldStr: "ontio_storage_iter_value",
+				Kind:     wasm.ExternalFunction,
+				Index:    27,
+			},
+			"ontio_storage_iter_close": {
+				FieldStr: "ontio_storage_iter_close",
+				Kind:     wasm.ExternalFunction,
+				Index:    28,
+			},
+			"ontio_keccak256": {
+				FieldStr: "ontio_keccak256",
+				Kind:     wasm.ExternalFunction,
+				Index:    29,
+			},
+			"ontio_ripemd160": {
+				FieldStr: "ontio_ripemd160",
+				Kind:     wasm.ExternalFunction,
+				Index:    30,
+			},
+			"ontio_blake2b256": {
+				FieldStr: "ontio_blake2b256",
+				Kind:     wasm.ExternalFunction,
+				Index:    31,
+			},
+			"ontio_ecrecover": {
+				FieldStr: "ontio_ecrecover",
+				Kind:     wasm.ExternalFunction,
+				Index:    32,
+			},
+			"ontio_ed25519_verify": {
+				FieldStr: "ontio_ed25519_verify",
+				Kind:     wasm.ExternalFunction,
+				Index:    33,
+			},
+			"ontio_sm2_verify": {
+				FieldStr: "ontio_sm2_verify",
+				Kind:     wasm.ExternalFunction,
+				Index:    34,
+			},
+			"ontio_bls12381_pairing_check": {
+				FieldStr: "ontio_bls12381_pairing_check",
+				Kind:     wasm.ExternalFunction,
+				Index:    35,
+			},
+			"ontio_contract_upgrade": {
+				FieldStr: "ontio_contract_upgrade",
+				Kind:     wasm.ExternalFunction,
+				Index:    36,
+			},
 		},
 	}
 
@@ -627,6 +772,10 @@ func checkGasInner(gasLimit *uint64, cost uint64) error {
 	return nil
 }
 
+func (self *Runtime) gas() *GasSchedule {
+	return self.Service.gasSchedule()
+}
+
 func (self *Runtime) checkGas(gaslimit uint64) {
 	err := checkGasInner(self.Service.vm.ExecMetrics.GasLimit, gaslimit)
 	if err != nil {
@@ -685,7 +834,7 @@ func callContractInner(service *WasmVmService, contractAddress common.Address, i
 			Args:    args,
 		}
 
-		err = checkGasInner(service.GasLimit, NATIVE_INVOKE_GAS)
+		err = checkGasInner(service.GasLimit, service.gasSchedule().NativeInvoke)
 		if err != nil {
 			return []byte{}, errors.NewErr("[wasm_Service]Insufficient gas limit")
 		}
@@ -19,9 +19,12 @@ package wasmvm
 
 import (
 	"errors"
+	"fmt"
 	"math"
 
+	"github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/states"
+	scom "github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/wagon/exec"
 )
 
@@ -57,7 +60,7 @@ func storageRead(service *WasmVmService, keybytes []byte, klen uint32, vlen uint
 
 func StorageRead(proc *exec.Process, keyPtr uint32, klen uint32, val uint32, vlen uint32, offset uint32) uint32 {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(STORAGE_GET_GAS)
+	self.checkGas(self.gas().StorageGet)
 	keybytes, err := ReadWasmMemory(proc, keyPtr, klen)
 	if err != nil {
 		panic(err)
@@ -91,22 +94,205 @@ func StorageWrite(proc *exec.Process, keyPtr uint32, keyLen uint32, valPtr uint3
 		panic(err)
 	}
 
-	cost := uint64((len(keybytes)+len(valbytes)-1)/1024+1) * STORAGE_PUT_GAS
+	cost := uint64((len(keybytes)+len(valbytes)-1)/1024+1) * self.gas().StoragePut
 	self.checkGas(cost)
 
 	key := serializeStorageKey(self.Service.ContextRef.CurrentContext().ContractAddress, keybytes)
 
-	self.Service.CacheDB.Put(key, states.GenRawStorageItem(valbytes))
+	err = self.Service.ContextRef.PutStorage(key, states.GenRawStorageItem(valbytes))
+	if err != nil {
+		panic(err)
+	}
 }
 
 func StorageDelete(proc *exec.Process, keyPtr uint32, keyLen uint32) {
 	self := proc.HostData().(*Runtime)
-	self.checkGas(STORAGE_DELETE_GAS)
+	self.checkGas(self.gas().StorageDelete)
 	keybytes, err := ReadWasmMemory(proc, keyPtr, keyLen)
 	if err != nil {
 		panic(err)
 	}
 	key := serializeStorageKey(self.Service.ContextRef.CurrentContext().ContractAddress, keybytes)
 
-	self.Service.CacheDB.Delete(key)
+	err = self.Service.ContextRef.PutStorage(key, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+//storageIterator walks the storage of one contract in ascending key order
+type storageIterator struct {
+	iter    scom.StoreIterator
+	started bool
+	valid   bool
+}
+
+func (self *storageIterator) next() bool {
+	if self.started && !self.valid {
+		return false
+	}
+	if self.started {
+		self.valid = self.iter.Next()
+	} else {
+		self.started = true
+		self.valid = self.iter.First()
+	}
+	return self.valid
+}
+
+func (self *storageIterator) key() ([]byte, error) {
+	if !self.valid {
+		return nil, errors.New("storage iterator is not positioned at an entry")
+	}
+	//strip the contract address prefix
+	key := self.iter.Key()[common.ADDR_LEN:]
+	return append([]byte{}, key...), nil
+}
+
+func (self *storageIterator) value() ([]byte, error) {
+	if !self.valid {
+		return nil, errors.New("storage iterator is not positioned at an entry")
+	}
+	return states.GetValueFromRawStorageItem(self.iter.Value())
+}
+
+func (self *storageIterator) release() error {
+	self.iter.Release()
+	return self.iter.Error()
+}
+
+func (self *Runtime) storageIterCreate(prefix []byte) (uint32, error) {
+	if len(self.iterators) >= STORAGE_ITERATOR_LIMIT {
+		return 0, fmt.Errorf("too many open storage iterators, limit is %d", STORAGE_ITERATOR_LIMIT)
+	}
+	if self.iterators == nil {
+		self.iterators = make(map[uint32]*storageIterator)
+	}
+
+	key := serializeStorageKey(self.Service.ContextRef.CurrentContext().ContractAddress, prefix)
+	self.nextIterator++
+	self.iterators[self.nextIterator] = &storageIterator{iter: self.Service.CacheDB.NewIterator(key)}
+
+	return self.nextIterator, nil
+}
+
+func (self *Runtime) storageIter(handle uint32) (*storageIterator, error) {
+	iter, ok := self.iterators[handle]
+	if !ok {
+		return nil, fmt.Errorf("invalid storage iterator handle %d", handle)
+	}
+	return iter, nil
+}
+
+func (self *Runtime) storageIterClose(handle uint32) error {
+	iter, err := self.storageIter(handle)
+	if err != nil {
+		return err
+	}
+	delete(self.iterators, handle)
+	return iter.release()
+}
+
+//closeStorageIters releases the iterators left open by the contract
+func (self *Runtime) closeStorageIters() {
+	for handle, iter := range self.iterators {
+		iter.release()
+		delete(self.iterators, handle)
+	}
+}
+
+//checkIterReadGas charge the read of key or value of iterator by byte, a read of empty data costs one byte
+func (self *Runtime) checkIterReadGas(data []byte) {
+	cost, overflow := common.SafeMul(self.gas().StorageIterRead, uint64(len(data)+1))
+	if overflow {
+		cost = math.MaxUint64
+	}
+	self.checkGas(cost)
+}
+
+//writeStorageSlice copies data[offset:] into wasm memory at dst, truncated to dlen bytes,
+//and returns the full length of data
+func writeStorageSlice(proc *exec.Process, data []byte, dst uint32, dlen uint32, offset uint32) uint32 {
+	if uint32(len(data)) < offset {
+		panic(errors.New("offset is invalid"))
+	}
+
+	part := data[offset:]
+	if uint32(len(part)) > dlen {
+		part = part[:dlen]
+	}
+	_, err := proc.WriteAt(part, int64(dst))
+	if err != nil {
+		panic(err)
+	}
+
+	return uint32(len(data))
+}
+
+func StorageIterCreate(proc *exec.Process, prefixPtr uint32, prefixLen uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	self.checkGas(self.gas().StorageIterCreate)
+	prefix, err := ReadWasmMemory(proc, prefixPtr, prefixLen)
+	if err != nil {
+		panic(err)
+	}
+
+	handle, err := self.storageIterCreate(prefix)
+	if err != nil {
+		panic(err)
+	}
+
+	return handle
+}
+
+func StorageIterNext(proc *exec.Process, handle uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	self.checkGas(self.gas().StorageIterNext)
+	iter, err := self.storageIter(handle)
+	if err != nil {
+		panic(err)
+	}
+
+	if iter.next() {
+		return 1
+	}
+	return 0
+}
+
+func StorageIterKey(proc *exec.Process, handle uint32, dst uint32, dlen uint32, offset uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	iter, err := self.storageIter(handle)
+	if err != nil {
+		panic(err)
+	}
+	key, err := iter.key()
+	if err != nil {
+		panic(err)
+	}
+	self.checkIterReadGas(key)
+
+	return writeStorageSlice(proc, key, dst, dlen, offset)
+}
+
+func StorageIterValue(proc *exec.Process, handle uint32, dst uint32, dlen uint32, offset uint32) uint32 {
+	self := proc.HostData().(*Runtime)
+	iter, err := self.storageIter(handle)
+	if err != nil {
+		panic(err)
+	}
+	val, err := iter.value()
+	if err != nil {
+		panic(err)
+	}
+	self.checkIterReadGas(val)
+
+	return writeStorageSlice(proc, val, dst, dlen, offset)
+}
+
+func StorageIterClose(proc *exec.Process, handle uint32) {
+	self := proc.HostData().(*Runtime)
+	err := self.storageIterClose(handle)
+	if err != nil {
+		panic(err)
+	}
 }
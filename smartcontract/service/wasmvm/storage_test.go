@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/smartcontract/context"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type testContextRef struct {
+	context.ContextRef
+	current *context.Context
+}
+
+func (self *testContextRef) CurrentContext() *context.Context {
+	return self.current
+}
+
+func collectStorage(t *testing.T, host *Runtime, prefix string) ([]string, []string) {
+	handle, err := host.storageIterCreate([]byte(prefix))
+	assert.Nil(t, err)
+	iter, err := host.storageIter(handle)
+	assert.Nil(t, err)
+
+	var keys, vals []string
+	for iter.next() {
+		key, err := iter.key()
+		assert.Nil(t, err)
+		val, err := iter.value()
+		assert.Nil(t, err)
+		keys = append(keys, string(key))
+		vals = append(vals, string(val))
+	}
+	assert.False(t, iter.next())
+	_, err = iter.key()
+	assert.NotNil(t, err)
+
+	assert.Nil(t, host.storageIterClose(handle))
+	return keys, vals
+}
+
+func TestStorageIterator(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := storage.NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+	self := common.Address{1}
+	other := common.Address{2}
+	put := func(addr common.Address, key, val string) {
+		cache.Put(serializeStorageKey(addr, []byte(key)), states.GenRawStorageItem([]byte(val)))
+	}
+	put(self, "b2", "v4")
+	put(self, "a2", "v2")
+	put(other, "a0", "x")
+	put(self, "a1", "v1")
+	cache.Commit()
+	put(self, "a3", "v3")
+	put(self, "a1", "v1'")
+	cache.Delete(serializeStorageKey(self, []byte("a2")))
+
+	host := &Runtime{Service: &WasmVmService{
+		CacheDB:    cache,
+		ContextRef: &testContextRef{current: &context.Context{ContractAddress: self}},
+	}}
+
+	keys, vals := collectStorage(t, host, "a")
+	assert.Equal(t, []string{"a1", "a3"}, keys)
+	assert.Equal(t, []string{"v1'", "v3"}, vals)
+
+	keys, _ = collectStorage(t, host, "")
+	assert.Equal(t, []string{"a1", "a3", "b2"}, keys)
+
+	keys, _ = collectStorage(t, host, "c")
+	assert.Nil(t, keys)
+
+	_, err := host.storageIter(1)
+	assert.NotNil(t, err)
+	assert.NotNil(t, host.storageIterClose(1))
+
+	for i := 0; i < STORAGE_ITERATOR_LIMIT; i++ {
+		_, err := host.storageIterCreate(nil)
+		assert.Nil(t, err)
+	}
+	_, err = host.storageIterCreate(nil)
+	assert.NotNil(t, err)
+
+	host.closeStorageIters()
+	assert.Equal(t, 0, len(host.iterators))
+}
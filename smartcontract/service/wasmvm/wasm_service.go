@@ -18,6 +18,7 @@
 package wasmvm
 
 import (
+	"bytes"
 	"sync"
 
 	lru "github.com/hashicorp/golang-lru"
@@ -46,10 +47,13 @@ type WasmVmService struct {
 	GasLimit      *uint64
 	ExecStep      *uint64
 	GasFactor     uint64
+	GasSchedule   *GasSchedule
 	IsTerminate   bool
 	JitMode       bool
 	ServiceIndex  uint64
 	vm            *exec.VM
+	//error of storage host functions of jit runtime, which can not trap the execution
+	jitStorageErr error
 }
 
 var (
@@ -62,16 +66,39 @@ var (
 	VM_EXEC_FAULT         = errors.NewErr("[WasmVmService] vm execute state fault!")
 	VM_INIT_FAULT         = errors.NewErr("[WasmVmService] vm init state fault!")
 
-	CODE_CACHE_SIZE      = 100
-	CONTRACT_METHOD_NAME = "invoke"
+	CODE_CACHE_SIZE         = 100
+	CONTRACT_METHOD_NAME    = "invoke"
+	CONTRACT_MIGRATE_METHOD = "migrate"
 
+	wasmPageSize = 64 * 1024
 	//max memory size of wasm vm
 	WASM_MEM_LIMITATION  uint64 = 10 * 1024 * 1024
 	VM_STEP_LIMIT               = 40000000
 	WASM_CALLSTACK_LIMIT        = 1024
+	//max open storage iterators of one contract invocation
+	STORAGE_ITERATOR_LIMIT = 64
+
+	//host functions not implemented by the jit runtime
+	INTERP_ONLY_HOST_FUNCS = map[string]bool{
+		"ontio_storage_iter_create":    true,
+		"ontio_storage_iter_next":      true,
+		"ontio_storage_iter_key":       true,
+		"ontio_storage_iter_value":     true,
+		"ontio_storage_iter_close":     true,
+		"ontio_keccak256":              true,
+		"ontio_ripemd160":              true,
+		"ontio_blake2b256":             true,
+		"ontio_ecrecover":              true,
+		"ontio_ed25519_verify":         true,
+		"ontio_sm2_verify":             true,
+		"ontio_bls12381_pairing_check": true,
+		"ontio_contract_upgrade":       true,
+	}
 
 	CodeCache *lru.ARCCache
 
+	builtinGasSchedule = DefaultGasSchedule()
+
 	serviceData        = make(map[uint64]*WasmVmService)
 	nextServiceDataIdx uint64
 	serviceDataMtx     sync.RWMutex
@@ -120,6 +147,13 @@ func unregisterWasmVmService(index uint64) {
 	delete(serviceData, index)
 }
 
+func (this *WasmVmService) gasSchedule() *GasSchedule {
+	if this.GasSchedule == nil {
+		return builtinGasSchedule
+	}
+	return this.GasSchedule
+}
+
 func (this *WasmVmService) Invoke() (interface{}, error) {
 	if len(this.Code) == 0 {
 		return nil, ERR_EXECUTE_CODE
@@ -149,7 +183,7 @@ func (this *WasmVmService) Invoke() (interface{}, error) {
 	this.ContextRef.PushContext(&context.Context{ContractAddress: contract.Address, Code: wasmCode})
 
 	var output []byte
-	if this.JitMode {
+	if this.JitMode && jitSupported(contract, wasmCode, this.gasSchedule()) {
 		output, err = invokeJit(this, contract, wasmCode)
 	} else {
 		output, err = invokeInterpreter(this, contract, wasmCode)
@@ -163,24 +197,56 @@ func (this *WasmVmService) Invoke() (interface{}, error) {
 	return output, nil
 }
 
-func invokeInterpreter(this *WasmVmService, contract *states.WasmContractParam, wasmCode []byte) ([]byte, error) {
-	host := &Runtime{Service: this, Input: contract.Args}
+//cachedModule keeps the code along with the compiled module, since the code at an address changes after upgrade
+type cachedModule struct {
+	code     []byte
+	compiled *exec.CompiledModule
+}
 
-	var compiled *exec.CompiledModule
+func loadCompiledModule(contract *states.WasmContractParam, wasmCode []byte) (*exec.CompiledModule, error) {
 	if CodeCache != nil {
 		cached, ok := CodeCache.Get(contract.Address.ToHexString())
-		if ok {
-			compiled = cached.(*exec.CompiledModule)
+		if ok && bytes.Equal(cached.(*cachedModule).code, wasmCode) {
+			return cached.(*cachedModule).compiled, nil
 		}
 	}
 
-	if compiled == nil {
-		module, err := ReadWasmModule(wasmCode, config.NoneVerifyMethod)
-		if err != nil {
-			return nil, err
+	compiled, err := ReadWasmModule(wasmCode, config.NoneVerifyMethod)
+	if err != nil {
+		return nil, err
+	}
+	CodeCache.Add(contract.Address.ToHexString(), &cachedModule{code: wasmCode, compiled: compiled})
+
+	return compiled, nil
+}
+
+//jitSupported reports whether the contract only imports host functions provided by the jit runtime, and the jit
+//runtime charges the same gas as schedule for them. Other contracts fall back to the interpreter
+func jitSupported(contract *states.WasmContractParam, wasmCode []byte, schedule *GasSchedule) bool {
+	compiled, err := loadCompiledModule(contract, wasmCode)
+	if err != nil || compiled.RawModule.Import == nil {
+		//let the jit runtime report the error
+		return schedule.JitCompatible(nil)
+	}
+
+	var imports []string
+	for _, entry := range compiled.RawModule.Import.Entries {
+		if INTERP_ONLY_HOST_FUNCS[entry.FieldName] {
+			return false
 		}
-		compiled = module
-		CodeCache.Add(contract.Address.ToHexString(), compiled)
+		imports = append(imports, entry.FieldName)
+	}
+
+	return schedule.JitCompatible(imports)
+}
+
+func invokeInterpreter(this *WasmVmService, contract *states.WasmContractParam, wasmCode []byte) ([]byte, error) {
+	host := &Runtime{Service: this, Input: contract.Args}
+	defer host.closeStorageIters()
+
+	compiled, err := loadCompiledModule(contract, wasmCode)
+	if err != nil {
+		return nil, err
 	}
 
 	vm, err := exec.NewVMWithCompiled(compiled, WASM_MEM_LIMITATION)
@@ -219,11 +285,21 @@ func invokeInterpreter(this *WasmVmService, contract *states.WasmContractParam,
 	//no args for passed in, all args in runtime input buffer
 	this.vm = vm
 
+	initPages := uint64(len(vm.Memory()) / wasmPageSize)
 	_, err = vm.ExecCode(index)
 
 	if err != nil {
 		return nil, errors.NewErr("[Call]ExecCode error!" + err.Error())
 	}
 
+	//memory can only grow, charge the grown pages after execution
+	grownPages := uint64(len(vm.Memory())/wasmPageSize) - initPages
+	if grownPages != 0 {
+		err = checkGasInner(this.GasLimit, grownPages*this.gasSchedule().MemoryGrowPage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return host.Output, nil
 }
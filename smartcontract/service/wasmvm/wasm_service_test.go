@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package wasmvm
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/smartcontract/states"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeCacheUpgrade(t *testing.T) {
+	contract := &states.WasmContractParam{Address: common.Address{0xaa}}
+	emptyCode := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+	compiled, err := loadCompiledModule(contract, growMemoryCode)
+	assert.Nil(t, err)
+	cached, err := loadCompiledModule(contract, growMemoryCode)
+	assert.Nil(t, err)
+	assert.True(t, compiled == cached)
+
+	//the code at the address has been upgraded
+	upgraded, err := loadCompiledModule(contract, emptyCode)
+	assert.Nil(t, err)
+	assert.True(t, compiled != upgraded)
+	assert.Nil(t, upgraded.RawModule.Export)
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/ontio/ontology/core/payload"
 	states2 "github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/smartcontract/impersonation"
 	"github.com/ontio/ontology/smartcontract/states"
 )
 
@@ -215,7 +216,9 @@ func ontio_storage_write_cgo(service_index C.uint64_t, key_s C.wasmjit_slice_t,
 	valbytes := jitSliceToBytes(val_s)
 
 	key := serializeStorageKey(service.ContextRef.CurrentContext().ContractAddress, keybytes)
-	service.CacheDB.Put(key, states2.GenRawStorageItem(valbytes))
+	if err := service.ContextRef.PutStorage(key, states2.GenRawStorageItem(valbytes)); err != nil {
+		service.setJitStorageErr(err)
+	}
 }
 
 //export ontio_storage_delete_cgo
@@ -224,7 +227,20 @@ func ontio_storage_delete_cgo(service_index C.uint64_t, key_s C.wasmjit_slice_t)
 	keybytes := jitSliceToBytes(key_s)
 
 	key := serializeStorageKey(service.ContextRef.CurrentContext().ContractAddress, keybytes)
-	service.CacheDB.Delete(key)
+	if err := service.ContextRef.PutStorage(key, nil); err != nil {
+		service.setJitStorageErr(err)
+	}
+}
+
+//setJitStorageErr record the first storage error, the transaction fails with it when the jit runtime returns as
+//the interpreter traps on it. Only the errors of db abort the block
+func (this *WasmVmService) setJitStorageErr(err error) {
+	if this.CacheDB.Error() != nil {
+		this.ContextRef.SetInternalErr()
+	}
+	if this.jitStorageErr == nil {
+		this.jitStorageErr = err
+	}
 }
 
 //export ontio_notify_cgo
@@ -292,7 +308,11 @@ func invokeJit(this *WasmVmService, contract *states.WasmContractParam, wasmCode
 	defer unregisterWasmVmService(index)
 
 	txHash := this.Tx.Hash()
-	witnessAddrBuff, witness_len := GetAddressBuff(this.Tx.GetSignatureAddresses())
+	witnessAddrs := this.Tx.GetSignatureAddresses()
+	if impersonated := impersonation.Addresses(); len(impersonated) != 0 {
+		witnessAddrs = append(append([]common.Address{}, witnessAddrs...), impersonated...)
+	}
+	witnessAddrBuff, witness_len := GetAddressBuff(witnessAddrs)
 	callersAddrBuff, callers_len := GetAddressBuff(this.ContextRef.GetCallerAddress())
 
 	var witnessPtr, callersPtr, inputPtr *C.uint8_t
@@ -334,6 +354,10 @@ func invokeJit(this *WasmVmService, contract *states.WasmContractParam, wasmCode
 	jit_ret := C.wasmjit_invoke(codeSlice, ctx)
 	*this.ExecStep = uint64(jit_ret.exec_step)
 	*this.GasLimit = uint64(jit_ret.gas_left)
+	if this.jitStorageErr != nil {
+		destroyWasmjitRet(jit_ret)
+		return nil, this.jitStorageErr
+	}
 
 	if jit_ret.res.kind != C.wasmjit_result_kind(wasmjit_result_success) {
 		err := errors.NewErr(C.GoStringN((*C.char)((unsafe.Pointer)(jit_ret.res.msg.data)), C.int(jit_ret.res.msg.len)))
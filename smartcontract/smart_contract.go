@@ -27,6 +27,7 @@ import (
 	ctypes "github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/smartcontract/context"
 	"github.com/ontio/ontology/smartcontract/event"
+	"github.com/ontio/ontology/smartcontract/impersonation"
 	"github.com/ontio/ontology/smartcontract/service/native"
 	"github.com/ontio/ontology/smartcontract/service/neovm"
 	"github.com/ontio/ontology/smartcontract/service/wasmvm"
@@ -51,6 +52,8 @@ type SmartContract struct {
 	WasmExecStep  uint64
 	JitMode       bool
 	PreExec       bool
+	Debugger      neovm.Debugger // debugger of neovm contracts, only used by pre-execution
+	StorageRefund uint64         // storage deposit refunded for freed bytes, see GasRefund
 	internelErr   bool
 	CrossHashes   []common.Uint256
 }
@@ -120,6 +123,47 @@ func (this *SmartContract) CheckUseGas(gas uint64) bool {
 	return true
 }
 
+// PutStorage put the raw storage item or delete it if value is empty. Since the storage accounting height the
+// storage size of contract is accounted, the deposit is charged for net new bytes and refunded for freed bytes
+func (this *SmartContract) PutStorage(key []byte, value []byte) error {
+	if this.Config.Height < config.GetStorageAccountingHeight() {
+		if len(value) == 0 {
+			this.CacheDB.Delete(key)
+		} else {
+			this.CacheDB.Put(key, value)
+		}
+		return nil
+	}
+	delta, err := this.CacheDB.PutStorage(key, value)
+	if err != nil {
+		return err
+	}
+	deposit := this.storageByteDeposit()
+	if delta > 0 {
+		if !this.CheckUseGas(uint64(delta) * deposit) {
+			return fmt.Errorf("gas insufficient for storage deposit of %d bytes", delta)
+		}
+	} else {
+		this.StorageRefund += uint64(-delta) * deposit
+	}
+	return nil
+}
+
+// GasRefund return the gas refunded for freed storage bytes, which is at most half of the consumed gas
+func (this *SmartContract) GasRefund(consumed uint64) uint64 {
+	if this.StorageRefund > consumed/2 {
+		return consumed / 2
+	}
+	return this.StorageRefund
+}
+
+func (this *SmartContract) storageByteDeposit() uint64 {
+	if this.Config.Height < config.GetStorageAccountingHeight() {
+		return 0
+	}
+	return this.GasTable[neovm.STORAGE_BYTE_DEPOSIT_NAME]
+}
+
 func (this *SmartContract) PutCrossStateHashes(hashes []common.Uint256) {
 	this.CrossHashes = append(this.CrossHashes, hashes...)
 }
@@ -163,26 +207,25 @@ func (this *SmartContract) NewExecuteEngine(code []byte, txtype ctypes.Transacti
 			BlockHash:  this.Config.BlockHash,
 			Engine:     vm.NewExecutor(code, feature),
 			PreExec:    this.PreExec,
+			Debugger:   this.Debugger,
 		}
 	case ctypes.InvokeWasm:
-		gasFactor := this.GasTable[config.WASM_GAS_FACTOR]
-		if gasFactor == 0 {
-			gasFactor = config.DEFAULT_WASM_GAS_FACTOR
-		}
+		gasSchedule := wasmvm.NewGasSchedule(this.GasTable)
 
 		service = &wasmvm.WasmVmService{
-			CacheDB:    this.CacheDB,
-			ContextRef: this,
-			Code:       code,
-			Tx:         this.Config.Tx,
-			Time:       this.Config.Time,
-			Height:     this.Config.Height,
-			BlockHash:  this.Config.BlockHash,
-			PreExec:    this.PreExec,
-			ExecStep:   &this.WasmExecStep,
-			GasLimit:   &this.Gas,
-			GasFactor:  gasFactor,
-			JitMode:    this.JitMode,
+			CacheDB:     this.CacheDB,
+			ContextRef:  this,
+			Code:        code,
+			Tx:          this.Config.Tx,
+			Time:        this.Config.Time,
+			Height:      this.Config.Height,
+			BlockHash:   this.Config.BlockHash,
+			PreExec:     this.PreExec,
+			ExecStep:    &this.WasmExecStep,
+			GasLimit:    &this.Gas,
+			GasFactor:   gasSchedule.GasFactor,
+			GasSchedule: gasSchedule,
+			JitMode:     this.JitMode && this.storageByteDeposit() == 0,
 		}
 	default:
 		return nil, errors.New("failed to construct execute engine, wrong transaction type")
@@ -217,6 +260,10 @@ func (this *SmartContract) CheckWitness(address common.Address) bool {
 	if this.checkAccountAddress(address) || this.checkContractAddress(address) {
 		return true
 	}
+	// impersonated address is regarded as witnessed in test mode
+	if impersonation.IsImpersonated(address) {
+		return true
+	}
 	return false
 }
 
@@ -21,6 +21,7 @@ package storage
 import (
 	comm "github.com/ontio/ontology/common"
 	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
 	"github.com/ontio/ontology/core/store/common"
 	"github.com/ontio/ontology/core/store/overlaydb"
 	"github.com/syndtr/goleveldb/leveldb/util"
@@ -49,6 +50,11 @@ func (self *CacheDB) Reset() {
 	self.memdb.Reset()
 }
 
+// Error return the error of underlying db, which fails the whole block
+func (self *CacheDB) Error() error {
+	return self.backend.Error()
+}
+
 func ensureBuffer(b []byte, n int) []byte {
 	if cap(b) < n {
 		return make([]byte, n)
@@ -101,8 +107,11 @@ func (self *CacheDB) GetContract(addr comm.Address) (*payload.DeployCode, error)
 }
 
 func (self *CacheDB) PutContract(contract *payload.DeployCode) {
-	address := contract.Address()
+	self.PutContractAt(contract.Address(), contract)
+}
 
+//PutContractAt store the contract under the given address, used by contract upgrade to keep the address stable
+func (self *CacheDB) PutContractAt(address comm.Address, contract *payload.DeployCode) {
 	sink := comm.NewZeroCopySink(nil)
 	contract.Serialization(sink)
 
@@ -114,6 +123,26 @@ func (self *CacheDB) DeleteContract(address comm.Address) {
 	self.delete(common.ST_CONTRACT, address[:])
 }
 
+func (self *CacheDB) GetContractHistory(address comm.Address) (*states.ContractHistory, error) {
+	value, err := self.get(common.ST_CONTRACT_HISTORY, address[:])
+	if err != nil {
+		return nil, err
+	}
+
+	history := new(states.ContractHistory)
+	if len(value) == 0 {
+		return history, nil
+	}
+	if err := history.Deserialization(comm.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (self *CacheDB) PutContractHistory(address comm.Address, history *states.ContractHistory) {
+	self.put(common.ST_CONTRACT_HISTORY, address[:], comm.SerializeToBytes(history))
+}
+
 func (self *CacheDB) Get(key []byte) ([]byte, error) {
 	return self.get(common.ST_STORAGE, key)
 }
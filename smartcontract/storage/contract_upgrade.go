@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
+)
+
+//ContractCodeHash return the sha256 hash of the contract code, which identifies a contract version
+func ContractCodeHash(contract *payload.DeployCode) comm.Uint256 {
+	return comm.Uint256(sha256.Sum256(contract.GetRawCode()))
+}
+
+//UpgradeContract replace the code deployed at address with contract, the address and storage are kept unchanged.
+//a version record is appended to the contract history and returned
+func (self *CacheDB) UpgradeContract(address comm.Address, contract *payload.DeployCode, height uint32,
+	upgrader comm.Address, txHash comm.Uint256) (*states.ContractVersion, error) {
+	old, err := self.GetContract(address)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, fmt.Errorf("[UpgradeContract] contract %s does not exist", address.ToHexString())
+	}
+	if old.VmType() != contract.VmType() {
+		return nil, fmt.Errorf("[UpgradeContract] vm type can not be changed from %d to %d", old.VmType(), contract.VmType())
+	}
+	prevHash := ContractCodeHash(old)
+	codeHash := ContractCodeHash(contract)
+	if prevHash == codeHash {
+		return nil, fmt.Errorf("[UpgradeContract] contract code is unchanged")
+	}
+
+	history, err := self.GetContractHistory(address)
+	if err != nil {
+		return nil, err
+	}
+	version := &states.ContractVersion{
+		Version:      uint32(len(history.Versions)) + 1,
+		CodeHash:     codeHash,
+		PrevCodeHash: prevHash,
+		Height:       height,
+		Upgrader:     upgrader,
+		TxHash:       txHash,
+	}
+	history.Versions = append(history.Versions, version)
+
+	self.PutContractAt(address, contract)
+	self.PutContractHistory(address, history)
+
+	return version, nil
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradeContract(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+	v1, _ := payload.NewDeployCode([]byte{1}, payload.NEOVM_TYPE, "", "", "", "", "")
+	v2, _ := payload.NewDeployCode([]byte{2}, payload.NEOVM_TYPE, "", "", "", "", "")
+	v3, _ := payload.NewDeployCode([]byte{3}, payload.NEOVM_TYPE, "", "", "", "", "")
+	wasm, _ := payload.NewDeployCode([]byte{4}, payload.WASMVM_TYPE, "", "", "", "", "")
+	address := v1.Address()
+	upgrader := common.Address{1}
+
+	_, err := cache.UpgradeContract(address, v2, 1, upgrader, common.UINT256_EMPTY)
+	assert.NotNil(t, err)
+
+	cache.PutContract(v1)
+	_, err = cache.UpgradeContract(address, v1, 1, upgrader, common.UINT256_EMPTY)
+	assert.NotNil(t, err)
+	_, err = cache.UpgradeContract(address, wasm, 1, upgrader, common.UINT256_EMPTY)
+	assert.NotNil(t, err)
+
+	_, err = cache.UpgradeContract(address, v2, 5, upgrader, common.UINT256_EMPTY)
+	assert.Nil(t, err)
+	version, err := cache.UpgradeContract(address, v3, 8, upgrader, common.UINT256_EMPTY)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), version.Version)
+	assert.Equal(t, ContractCodeHash(v2), version.PrevCodeHash)
+
+	contract, err := cache.GetContract(address)
+	assert.Nil(t, err)
+	assert.Equal(t, v3.GetRawCode(), contract.GetRawCode())
+	contract, err = cache.GetContract(v3.Address())
+	assert.Nil(t, err)
+	assert.Nil(t, contract)
+
+	cache.Commit()
+	cache = NewCacheDB(cache.backend)
+	history, err := cache.GetContractHistory(address)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(history.Versions))
+	assert.Equal(t, uint32(5), history.Versions[0].Height)
+	assert.Equal(t, ContractCodeHash(v1), history.Versions[0].PrevCodeHash)
+	assert.Equal(t, ContractCodeHash(v3), history.Latest().CodeHash)
+	assert.Equal(t, upgrader, history.Latest().Upgrader)
+}
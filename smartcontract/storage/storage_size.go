@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	comm "github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/common"
+)
+
+//StorageItemSize return the bytes accounted for a storage item, which is the length of key and raw value.
+//A deleted item takes no bytes
+func StorageItemSize(key []byte, value []byte) uint64 {
+	if len(value) == 0 {
+		return 0
+	}
+	return uint64(len(key) + len(value))
+}
+
+//GetStorageSize return the storage bytes of contract accounted since storage accounting is enabled
+func (self *CacheDB) GetStorageSize(address comm.Address) (uint64, error) {
+	value, err := self.get(common.ST_STORAGE_SIZE, address[:])
+	if err != nil {
+		return 0, err
+	}
+	if len(value) == 0 {
+		return 0, nil
+	}
+	if len(value) != 8 {
+		return 0, fmt.Errorf("[GetStorageSize] invalid storage size of contract %s", address.ToHexString())
+	}
+	return binary.LittleEndian.Uint64(value), nil
+}
+
+//PutStorageSize set the storage bytes of contract, the entry is deleted if size is zero
+func (self *CacheDB) PutStorageSize(address comm.Address, size uint64) {
+	if size == 0 {
+		self.delete(common.ST_STORAGE_SIZE, address[:])
+		return
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], size)
+	self.put(common.ST_STORAGE_SIZE, address[:], buf[:])
+}
+
+//isStorageAccounted return true if the raw storage item is written since storage accounting is enabled, and its
+//bytes are accounted in the storage size of contract
+func (self *CacheDB) isStorageAccounted(key []byte) (bool, error) {
+	value, err := self.get(common.ST_STORAGE_ACCOUNTED, key)
+	if err != nil {
+		return false, err
+	}
+	return len(value) != 0, nil
+}
+
+func (self *CacheDB) setStorageAccounted(key []byte, accounted bool) {
+	if accounted {
+		self.put(common.ST_STORAGE_ACCOUNTED, key, []byte{1})
+	} else {
+		self.delete(common.ST_STORAGE_ACCOUNTED, key)
+	}
+}
+
+//PutStorage put the raw storage item of contract or delete it if value is empty, the storage size of contract is
+//updated and the change of size is returned. The key is prefixed with contract address. Only the bytes of items
+//written since storage accounting is enabled are accounted, an item written before is accounted when it is
+//rewritten, and deleting it frees no accounted bytes
+func (self *CacheDB) PutStorage(key []byte, value []byte) (int64, error) {
+	if len(key) < comm.ADDR_LEN {
+		return 0, fmt.Errorf("[PutStorage] invalid storage key")
+	}
+	old, err := self.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	accounted, err := self.isStorageAccounted(key)
+	if err != nil {
+		return 0, err
+	}
+	oldSize := uint64(0)
+	if accounted {
+		oldSize = StorageItemSize(key, old)
+	}
+	newSize := StorageItemSize(key, value)
+	if len(value) == 0 {
+		self.Delete(key)
+	} else {
+		self.Put(key, value)
+	}
+	if accounted != (newSize != 0) {
+		self.setStorageAccounted(key, newSize != 0)
+	}
+
+	delta := int64(newSize) - int64(oldSize)
+	if delta == 0 {
+		return 0, nil
+	}
+	var address comm.Address
+	copy(address[:], key[:comm.ADDR_LEN])
+	size, err := self.GetStorageSize(address)
+	if err != nil {
+		return 0, err
+	}
+	if delta < 0 && uint64(-delta) > size {
+		return 0, fmt.Errorf("[PutStorage] storage size %d of contract %s less than freed bytes %d",
+			size, address.ToHexString(), -delta)
+	}
+	self.PutStorageSize(address, uint64(int64(size)+delta))
+	return delta, nil
+}
+
+//MoveStorageItem move the raw storage item from key to newKey together with its accounted flag, used when the
+//storage is migrated. The storage size is moved by MoveStorageSize
+func (self *CacheDB) MoveStorageItem(key []byte, newKey []byte, value []byte) error {
+	if len(newKey) < comm.ADDR_LEN {
+		return fmt.Errorf("[MoveStorageItem] invalid storage key")
+	}
+	accounted, err := self.isStorageAccounted(key)
+	if err != nil {
+		return err
+	}
+	//the accounted item replaced at newKey is freed from the storage size of new contract
+	replaced, err := self.isStorageAccounted(newKey)
+	if err != nil {
+		return err
+	}
+	if replaced {
+		old, err := self.Get(newKey)
+		if err != nil {
+			return err
+		}
+		var address comm.Address
+		copy(address[:], newKey[:comm.ADDR_LEN])
+		size, err := self.GetStorageSize(address)
+		if err != nil {
+			return err
+		}
+		freed := StorageItemSize(newKey, old)
+		if freed > size {
+			return fmt.Errorf("[MoveStorageItem] storage size %d of contract %s less than freed bytes %d",
+				size, address.ToHexString(), freed)
+		}
+		self.PutStorageSize(address, size-freed)
+	}
+	self.Put(newKey, value)
+	self.Delete(key)
+	if accounted {
+		self.setStorageAccounted(key, false)
+	}
+	if accounted != replaced {
+		self.setStorageAccounted(newKey, accounted)
+	}
+	return nil
+}
+
+//MoveStorageSize move the storage size of contract to a new address, used when the storage is migrated
+func (self *CacheDB) MoveStorageSize(from comm.Address, to comm.Address) error {
+	size, err := self.GetStorageSize(from)
+	if err != nil || size == 0 {
+		return err
+	}
+	toSize, err := self.GetStorageSize(to)
+	if err != nil {
+		return err
+	}
+	self.PutStorageSize(from, 0)
+	self.PutStorageSize(to, toSize+size)
+	return nil
+}
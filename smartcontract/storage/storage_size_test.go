@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutStorage(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+	address := common.Address{1}
+	key := append(address[:], []byte("key")...)
+
+	delta, err := cache.PutStorage(key, []byte("value"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(key)+5), delta)
+
+	delta, err = cache.PutStorage(key, []byte("v"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-4), delta)
+	size, _ := cache.GetStorageSize(address)
+	assert.Equal(t, uint64(len(key)+1), size)
+
+	//deleting the item written before accounting frees no accounted bytes
+	old := append(address[:], []byte("old")...)
+	cache.Put(old, []byte("value"))
+	delta, err = cache.PutStorage(old, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), delta)
+	size, _ = cache.GetStorageSize(address)
+	assert.Equal(t, uint64(len(key)+1), size)
+
+	//the item written before accounting is accounted when rewritten
+	cache.Put(old, []byte("value"))
+	delta, err = cache.PutStorage(old, []byte("v"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(old)+1), delta)
+	delta, err = cache.PutStorage(old, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, -int64(len(old)+1), delta)
+	size, _ = cache.GetStorageSize(address)
+	assert.Equal(t, uint64(len(key)+1), size)
+
+	_, err = cache.PutStorage([]byte("short"), nil)
+	assert.NotNil(t, err)
+}
+
+func TestMoveStorageSize(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+	from, to := common.Address{1}, common.Address{2}
+	assert.Nil(t, cache.MoveStorageSize(from, to))
+	cache.PutStorageSize(from, 10)
+	cache.PutStorageSize(to, 5)
+	assert.Nil(t, cache.MoveStorageSize(from, to))
+
+	size, _ := cache.GetStorageSize(from)
+	assert.Equal(t, uint64(0), size)
+	size, _ = cache.GetStorageSize(to)
+	assert.Equal(t, uint64(15), size)
+}
+
+func TestMoveStorageItem(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+	from, to := common.Address{1}, common.Address{2}
+	key := append(from[:], []byte("key")...)
+	newKey := append(to[:], []byte("key")...)
+	_, err := cache.PutStorage(key, []byte("value"))
+	assert.Nil(t, err)
+	assert.Nil(t, cache.MoveStorageItem(key, newKey, []byte("value")))
+	assert.Nil(t, cache.MoveStorageSize(from, to))
+
+	//the moved item is still accounted
+	delta, err := cache.PutStorage(newKey, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, -int64(len(newKey)+5), delta)
+	size, _ := cache.GetStorageSize(to)
+	assert.Equal(t, uint64(0), size)
+}
+
+func TestMoveStorageItemToPopulatedKey(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+	from, to := common.Address{1}, common.Address{2}
+	key := append(from[:], []byte("key")...)
+	newKey := append(to[:], []byte("key")...)
+	_, err := cache.PutStorage(key, []byte("value"))
+	assert.Nil(t, err)
+	//the key of new contract is already populated
+	_, err = cache.PutStorage(newKey, []byte("populated"))
+	assert.Nil(t, err)
+	_, err = cache.PutStorage(append(to[:], []byte("other")...), []byte("v"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, cache.MoveStorageItem(key, newKey, []byte("value")))
+	assert.Nil(t, cache.MoveStorageSize(from, to))
+	size, _ := cache.GetStorageSize(to)
+	assert.Equal(t, StorageItemSize(newKey, []byte("value"))+uint64(common.ADDR_LEN+5+1), size)
+	size, _ = cache.GetStorageSize(from)
+	assert.Equal(t, uint64(0), size)
+
+	//the moved item is accounted at the new key
+	delta, err := cache.PutStorage(newKey, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, -int64(StorageItemSize(newKey, []byte("value"))), delta)
+	delta, err = cache.PutStorage(key, []byte("v"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(key)+1), delta)
+
+	//the item written before accounting is not accounted at the new key, and the replaced item is freed
+	old := append(from[:], []byte("old")...)
+	newOld := append(to[:], []byte("old")...)
+	cache.Put(old, []byte("value"))
+	_, err = cache.PutStorage(newOld, []byte("populated"))
+	assert.Nil(t, err)
+	assert.Nil(t, cache.MoveStorageItem(old, newOld, []byte("value")))
+	size, _ = cache.GetStorageSize(to)
+	assert.Equal(t, uint64(common.ADDR_LEN+5+1), size)
+	delta, err = cache.PutStorage(newOld, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), delta)
+}
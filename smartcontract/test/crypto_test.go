@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology/account"
+	"github.com/ontio/ontology/common"
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/signature"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	"github.com/ontio/ontology/smartcontract/service/crypto"
+	svm "github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+//invokeSyscall runs a script calling the syscall name with args pushed in reverse order on a network with
+//the crypto api enabled
+func invokeSyscall(t *testing.T, name string, args ...[]byte) (*svm.NeoVmService, error) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+	return invokeSyscallAt(t, 10, name, args...)
+}
+
+func invokeSyscallAt(t *testing.T, height uint32, name string, args ...[]byte) (*svm.NeoVmService, error) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteByte(byte(neovm.SYSCALL))
+	sink.WriteString(name)
+	sink.WriteByte(byte(neovm.RET))
+
+	gasTable := make(map[string]uint64)
+	svm.GAS_TABLE.Range(func(key, value interface{}) bool {
+		gasTable[key.(string)] = value.(uint64)
+		return true
+	})
+	sc := smartcontract.SmartContract{
+		Config:   &smartcontract.Config{Time: 10, Height: height},
+		GasTable: gasTable,
+		Gas:      100000,
+	}
+	engine, err := sc.NewExecuteEngine(sink.Bytes(), types.InvokeNeo)
+	assert.Nil(t, err)
+
+	service := engine.(*svm.NeoVmService)
+	for i := len(args) - 1; i >= 0; i-- {
+		assert.Nil(t, service.Engine.EvalStack.PushBytes(args[i]))
+	}
+	_, err = engine.Invoke()
+	return service, err
+}
+
+func TestCryptoSyscallActivation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	_, err := invokeSyscallAt(t, config.GetCryptoApiHeight()-1, svm.CRYPTO_KECCAK256_NAME, []byte("hello"))
+	assert.NotNil(t, err)
+}
+
+func TestCryptoHashSyscall(t *testing.T) {
+	data := []byte("hello")
+	for name, hash := range map[string]func([]byte) []byte{
+		svm.CRYPTO_KECCAK256_NAME:  crypto.Keccak256,
+		svm.CRYPTO_RIPEMD160_NAME:  crypto.Ripemd160,
+		svm.CRYPTO_BLAKE2B256_NAME: crypto.Blake2b256,
+	} {
+		service, err := invokeSyscall(t, name, data)
+		assert.Nil(t, err)
+		res, err := service.Engine.EvalStack.PopAsBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, hash(data), res, name)
+	}
+}
+
+func TestCryptoVerifySyscall(t *testing.T) {
+	acc := account.NewAccount("SM3withSM2")
+	msg := []byte("hello")
+	sig, err := signature.Sign(acc, msg)
+	assert.Nil(t, err)
+	pub := keypair.SerializePublicKey(acc.PublicKey)
+
+	service, err := invokeSyscall(t, svm.CRYPTO_SM2VERIFY_NAME, pub, msg, sig)
+	assert.Nil(t, err)
+	ok, err := service.Engine.EvalStack.PopAsBool()
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	service, err = invokeSyscall(t, svm.CRYPTO_ED25519VERIFY_NAME, pub, msg, sig)
+	assert.Nil(t, err)
+	ok, err = service.Engine.EvalStack.PopAsBool()
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	service, err = invokeSyscall(t, svm.CRYPTO_ECRECOVER_NAME, crypto.Keccak256(msg), sig)
+	assert.Nil(t, err)
+	res, err := service.Engine.EvalStack.PopAsBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(res))
+
+	_, err = invokeSyscall(t, svm.CRYPTO_BLS12381PAIRINGCHECK_NAME, msg)
+	assert.NotNil(t, err)
+}
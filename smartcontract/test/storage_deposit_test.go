@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	svm "github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+var depositKey = []byte("key")
+
+func emitSyscall(sink *bytes.Buffer, name string) {
+	sink.WriteByte(byte(neovm.SYSCALL))
+	sink.WriteByte(byte(len(name)))
+	sink.WriteString(name)
+}
+
+func emitStorageCall(sink *bytes.Buffer, name string) {
+	sink.WriteByte(byte(len(depositKey)))
+	sink.Write(depositKey)
+	emitSyscall(sink, svm.STORAGE_GETCONTEXT_NAME)
+	emitSyscall(sink, name)
+	sink.WriteByte(byte(neovm.RET))
+}
+
+//depositScript builds a contract which puts the argument to storage, or deletes the item if the argument is empty
+func depositScript() []byte {
+	del := new(bytes.Buffer)
+	del.WriteByte(byte(neovm.DROP))
+	emitStorageCall(del, svm.STORAGE_DELETE_NAME)
+
+	sink := new(bytes.Buffer)
+	sink.WriteByte(byte(neovm.DUP))
+	sink.WriteByte(byte(neovm.SIZE))
+	sink.WriteByte(byte(neovm.JMPIF))
+	offset := 3 + del.Len()
+	sink.Write([]byte{byte(offset), byte(offset >> 8)})
+	sink.Write(del.Bytes())
+	emitStorageCall(sink, svm.STORAGE_PUT_NAME)
+	return sink.Bytes()
+}
+
+func invokeDeposit(t *testing.T, sc *smartcontract.SmartContract, value []byte) error {
+	contract, err := payload.NewDeployCode(depositScript(), payload.NEOVM_TYPE, "", "", "", "", "")
+	assert.Nil(t, err)
+	address := contract.Address()
+	sc.CacheDB.PutContract(contract)
+
+	builder := neovm.NewParamsBuilder(new(bytes.Buffer))
+	builder.EmitPushByteArray(value)
+	builder.EmitPushCall(address[:])
+	engine, err := sc.NewExecuteEngine(builder.ToArray(), types.InvokeNeo)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	return err
+}
+
+func newDepositContract(height uint32, deposit uint64) *smartcontract.SmartContract {
+	gasTable := make(map[string]uint64)
+	svm.GAS_TABLE.Range(func(k, value interface{}) bool {
+		gasTable[k.(string)] = value.(uint64)
+		return true
+	})
+	gasTable[svm.STORAGE_BYTE_DEPOSIT_NAME] = deposit
+
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	return &smartcontract.SmartContract{
+		Config:   &smartcontract.Config{Time: 10, Height: height},
+		CacheDB:  storage.NewCacheDB(overlaydb.NewOverlayDB(memback)),
+		GasTable: gasTable,
+		Gas:      100000,
+	}
+}
+
+func TestStorageDeposit(t *testing.T) {
+	height := config.GetStorageAccountingHeight()
+	contract, _ := payload.NewDeployCode(depositScript(), payload.NEOVM_TYPE, "", "", "", "", "")
+	address := contract.Address()
+	value := []byte("value")
+	itemSize := storage.StorageItemSize(append(address[:], depositKey...), states.GenRawStorageItem(value))
+
+	free := newDepositContract(height, 0)
+	assert.Nil(t, invokeDeposit(t, free, value))
+	size, err := free.CacheDB.GetStorageSize(address)
+	assert.Nil(t, err)
+	assert.Equal(t, itemSize, size)
+
+	//deposit is charged for net new bytes
+	sc := newDepositContract(height, 10)
+	assert.Nil(t, invokeDeposit(t, sc, value))
+	assert.Equal(t, free.Gas-10*itemSize, sc.Gas)
+
+	//and refunded for freed bytes
+	assert.Nil(t, invokeDeposit(t, sc, nil))
+	assert.Equal(t, 10*itemSize, sc.StorageRefund)
+	size, err = sc.CacheDB.GetStorageSize(address)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), size)
+	assert.Equal(t, uint64(5), sc.GasRefund(10))
+	assert.Equal(t, sc.StorageRefund, sc.GasRefund(sc.StorageRefund*2))
+
+	sc = newDepositContract(height, 100000)
+	assert.NotNil(t, invokeDeposit(t, sc, value))
+
+	if height > 0 {
+		//storage is not accounted before the accounting height
+		sc = newDepositContract(height-1, 10)
+		assert.Nil(t, invokeDeposit(t, sc, value))
+		size, err = sc.CacheDB.GetStorageSize(address)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(0), size)
+		assert.Equal(t, free.Gas, sc.Gas)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package test
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ontio/ontology/common/config"
+	"github.com/ontio/ontology/core/payload"
+	"github.com/ontio/ontology/core/states"
+	"github.com/ontio/ontology/core/store/leveldbstore"
+	"github.com/ontio/ontology/core/store/overlaydb"
+	"github.com/ontio/ontology/core/types"
+	"github.com/ontio/ontology/smartcontract"
+	svm "github.com/ontio/ontology/smartcontract/service/neovm"
+	"github.com/ontio/ontology/smartcontract/storage"
+	"github.com/ontio/ontology/vm/neovm"
+	"github.com/stretchr/testify/assert"
+)
+
+//upgradeScript builds a contract which upgrades itself to newCode
+func upgradeScript(newCode []byte, migrate bool) []byte {
+	sink := new(bytes.Buffer)
+	emitUpgrade(sink, newCode, migrate)
+	sink.WriteByte(byte(neovm.RET))
+	return sink.Bytes()
+}
+
+//emitUpgrade emits the syscall upgrading current contract to newCode, the new contract is left on the stack
+func emitUpgrade(sink *bytes.Buffer, newCode []byte, migrate bool) {
+	builder := neovm.NewParamsBuilder(sink)
+	builder.EmitPushBool(migrate)
+	for _, field := range []string{"desc", "email", "author", "2.0", "name"} {
+		builder.EmitPushByteArray([]byte(field))
+	}
+	builder.EmitPushInteger(big.NewInt(int64(payload.NEOVM_TYPE)))
+	builder.EmitPushByteArray(newCode)
+	emitSyscall(sink, svm.CONTRACT_UPGRADE_NAME)
+}
+
+func TestContractUpgrade(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	//the new code notifies the method it is invoked with
+	newCode := []byte{byte(neovm.SYSCALL), byte(len(svm.RUNTIME_NOTIFY_NAME))}
+	newCode = append(newCode, []byte(svm.RUNTIME_NOTIFY_NAME)...)
+	newCode = append(newCode, byte(neovm.RET))
+
+	for _, migrate := range []bool{false, true} {
+		memback, _ := leveldbstore.NewMemLevelDBStore()
+		cache := storage.NewCacheDB(overlaydb.NewOverlayDB(memback))
+
+		oldContract, err := payload.NewDeployCode(upgradeScript(newCode, migrate), payload.NEOVM_TYPE, "name", "1.0", "author", "email", "desc")
+		assert.Nil(t, err)
+		address := oldContract.Address()
+		cache.PutContract(oldContract)
+
+		builder := neovm.NewParamsBuilder(new(bytes.Buffer))
+		builder.EmitPushCall(address[:])
+		sc := smartcontract.SmartContract{
+			Config:   &smartcontract.Config{Time: 10, Height: 10},
+			CacheDB:  cache,
+			GasTable: map[string]uint64{},
+			Gas:      100000,
+		}
+		engine, err := sc.NewExecuteEngine(builder.ToArray(), types.InvokeNeo)
+		assert.Nil(t, err)
+		_, err = engine.Invoke()
+		assert.Nil(t, err)
+
+		contract, err := cache.GetContract(address)
+		assert.Nil(t, err)
+		assert.Equal(t, newCode, contract.GetRawCode())
+		assert.Equal(t, "2.0", contract.Version)
+
+		history, err := cache.GetContractHistory(address)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(history.Versions))
+		assert.Equal(t, uint32(1), history.Latest().Version)
+		assert.Equal(t, uint32(10), history.Latest().Height)
+		assert.Equal(t, storage.ContractCodeHash(oldContract), history.Latest().PrevCodeHash)
+		assert.Equal(t, storage.ContractCodeHash(contract), history.Latest().CodeHash)
+
+		if migrate {
+			assert.Equal(t, 1, len(sc.Notifications))
+			assert.Equal(t, address, sc.Notifications[0].ContractAddress)
+		} else {
+			assert.Equal(t, 0, len(sc.Notifications))
+		}
+
+		//the upgraded code is executed at the same address
+		builder = neovm.NewParamsBuilder(new(bytes.Buffer))
+		builder.EmitPushByteArray([]byte("hello"))
+		builder.EmitPushCall(address[:])
+		engine, err = sc.NewExecuteEngine(builder.ToArray(), types.InvokeNeo)
+		assert.Nil(t, err)
+		_, err = engine.Invoke()
+		assert.Nil(t, err)
+		notify := sc.Notifications[len(sc.Notifications)-1]
+		assert.Equal(t, address, notify.ContractAddress)
+	}
+}
+
+func TestContractUpgradeStorageContext(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	//upgrade, then write storage with the context of the returned contract
+	newCode := []byte{byte(neovm.RET)}
+	sink := new(bytes.Buffer)
+	builder := neovm.NewParamsBuilder(sink)
+	builder.EmitPushByteArray([]byte("value"))
+	builder.EmitPushByteArray([]byte("key"))
+	emitUpgrade(sink, newCode, false)
+	emitSyscall(sink, svm.CONTRACT_GETSTORAGECONTEXT_NAME)
+	emitSyscall(sink, svm.STORAGE_PUT_NAME)
+	sink.WriteByte(byte(neovm.RET))
+
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := storage.NewCacheDB(overlaydb.NewOverlayDB(memback))
+	oldContract, err := payload.NewDeployCode(sink.Bytes(), payload.NEOVM_TYPE, "name", "1.0", "author", "email", "desc")
+	assert.Nil(t, err)
+	address := oldContract.Address()
+	cache.PutContract(oldContract)
+
+	builder = neovm.NewParamsBuilder(new(bytes.Buffer))
+	builder.EmitPushCall(address[:])
+	sc := smartcontract.SmartContract{
+		Config:   &smartcontract.Config{Time: 10, Height: 10},
+		CacheDB:  cache,
+		GasTable: map[string]uint64{svm.STORAGE_PUT_NAME: svm.STORAGE_PUT_GAS},
+		Gas:      100000,
+	}
+	engine, err := sc.NewExecuteEngine(builder.ToArray(), types.InvokeNeo)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.Nil(t, err)
+
+	contract, err := cache.GetContract(address)
+	assert.Nil(t, err)
+	assert.Equal(t, newCode, contract.GetRawCode())
+	item, err := cache.Get(append(address[:], []byte("key")...))
+	assert.Nil(t, err)
+	value, err := states.GetValueFromRawStorageItem(item)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestContractUpgradeActivation(t *testing.T) {
+	networkId := config.DefConfig.P2PNode.NetworkId
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = networkId }()
+
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := storage.NewCacheDB(overlaydb.NewOverlayDB(memback))
+	oldContract, err := payload.NewDeployCode(upgradeScript([]byte{byte(neovm.RET)}, false), payload.NEOVM_TYPE, "name", "1.0", "author", "email", "desc")
+	assert.Nil(t, err)
+	address := oldContract.Address()
+	cache.PutContract(oldContract)
+
+	builder := neovm.NewParamsBuilder(new(bytes.Buffer))
+	builder.EmitPushCall(address[:])
+	sc := smartcontract.SmartContract{
+		Config:   &smartcontract.Config{Time: 10, Height: config.GetContractUpgradeHeight() - 1},
+		CacheDB:  cache,
+		GasTable: map[string]uint64{svm.CONTRACT_UPGRADE_NAME: svm.CONTRACT_UPGRADE_GAS},
+		Gas:      100000000,
+	}
+	engine, err := sc.NewExecuteEngine(builder.ToArray(), types.InvokeNeo)
+	assert.Nil(t, err)
+	_, err = engine.Invoke()
+	assert.NotNil(t, err)
+	//the syscall is unknown before activation, so its gas is not charged
+	assert.Contains(t, err.Error(), "not supported")
+	assert.True(t, sc.Gas > 100000000-svm.CONTRACT_UPGRADE_GAS)
+
+	contract, err := cache.GetContract(address)
+	assert.Nil(t, err)
+	assert.Equal(t, oldContract.GetRawCode(), contract.GetRawCode())
+}
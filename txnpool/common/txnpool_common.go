@@ -191,6 +191,18 @@ type GetPendingTxnRsp struct {
 	Txs []*types.Transaction
 }
 
+// GetTxnsReq specifies the api that how to get transactions in the pool.
+// Input: transaction hashes
+type GetTxnsReq struct {
+	Hashes []common.Uint256
+}
+
+// GetTxnsRsp returns transactions in the same order of the hashes,
+// the transaction is nil if it is not in the pool.
+type GetTxnsRsp struct {
+	Txs []*types.Transaction
+}
+
 // GetPendingTxnHashReq specifies the api that how to get a pending txHash list
 // in the pool.
 type GetPendingTxnHashReq struct {
@@ -34,6 +34,8 @@ import (
 	"github.com/ontio/ontology/core/ledger"
 	tx "github.com/ontio/ontology/core/types"
 	"github.com/ontio/ontology/errors"
+	"github.com/ontio/ontology/events"
+	"github.com/ontio/ontology/events/message"
 	httpcom "github.com/ontio/ontology/http/base/common"
 	msgpack "github.com/ontio/ontology/p2pserver/message/msg_pack"
 	p2p "github.com/ontio/ontology/p2pserver/net/protocol"
@@ -277,7 +279,7 @@ func (s *TXPoolServer) removePendingTx(hash common.Uint256,
 		}
 	}
 
-	if pt.sender == tc.HttpSender && pt.ch != nil {
+	if pt.ch != nil {
 		replyTxResult(pt.ch, hash, err, err.Error())
 	}
 
@@ -331,7 +333,7 @@ func (s *TXPoolServer) assignTxToWorker(tx *tx.Transaction,
 
 	if ok := s.setPendingTx(tx, sender, txResultCh); !ok {
 		s.increaseStats(tc.DuplicateStats)
-		if sender == tc.HttpSender && txResultCh != nil {
+		if txResultCh != nil {
 			replyTxResult(txResultCh, tx.Hash(), errors.ErrDuplicateInput,
 				"duplicated transaction input detected")
 		}
@@ -593,6 +595,8 @@ func (s *TXPoolServer) addTxList(txEntry *tc.TXEntry) bool {
 	ret := s.txPool.AddTxList(txEntry)
 	if !ret {
 		s.increaseStats(tc.DuplicateStats)
+	} else if events.DefActorPublisher != nil {
+		events.DefActorPublisher.Publish(message.TOPIC_TX_POOL_ADD, &message.TxPoolAddMsg{Hash: txEntry.Tx.Hash()})
 	}
 	return ret
 }
@@ -0,0 +1,386 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package crossvm_codec
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ontio/ontology/common"
+)
+
+const (
+	ABI_SECTION_NAME = "ontio_abi"
+
+	ABI_TYPE_BYTEARRAY = "bytearray"
+	ABI_TYPE_STRING    = "string"
+	ABI_TYPE_ADDRESS   = "address"
+	ABI_TYPE_BOOL      = "bool"
+	ABI_TYPE_INT       = "int"
+	ABI_TYPE_H256      = "h256"
+	ABI_TYPE_VOID      = "void"
+
+	wasmCustomSectionId byte = 0
+)
+
+var wasmMagic = []byte{0x00, 0x61, 0x73, 0x6d}
+
+//WasmParamAbi describe a named and typed param of wasm contract method or event
+type WasmParamAbi struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+//WasmMethodAbi describe a method of wasm contract
+type WasmMethodAbi struct {
+	Name       string          `json:"name"`
+	Parameters []*WasmParamAbi `json:"parameters"`
+	ReturnType string          `json:"returntype"`
+}
+
+//WasmEventAbi describe a event notified by wasm contract
+type WasmEventAbi struct {
+	Name       string          `json:"name"`
+	Parameters []*WasmParamAbi `json:"parameters"`
+}
+
+//WasmContractAbi is the metadata stored in the ontio_abi custom section of wasm code.
+//It is encoded as crossvm_codec list: [[method...], [event...]], where
+//method is [name, [[param name, param type]...], return type] and
+//event is [name, [[param name, param type]...]]
+type WasmContractAbi struct {
+	Methods []*WasmMethodAbi `json:"methods"`
+	Events  []*WasmEventAbi  `json:"events"`
+}
+
+func (this *WasmContractAbi) GetMethod(name string) *WasmMethodAbi {
+	for _, method := range this.Methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	return nil
+}
+
+func (this *WasmContractAbi) GetEvent(name string) *WasmEventAbi {
+	for _, event := range this.Events {
+		if event.Name == name {
+			return event
+		}
+	}
+	return nil
+}
+
+func isAbiParamType(ty string) bool {
+	switch ty {
+	case ABI_TYPE_BYTEARRAY, ABI_TYPE_STRING, ABI_TYPE_ADDRESS, ABI_TYPE_BOOL, ABI_TYPE_INT, ABI_TYPE_H256:
+		return true
+	}
+	return false
+}
+
+func validateAbiParams(params []*WasmParamAbi) error {
+	names := make(map[string]bool)
+	for _, param := range params {
+		if param == nil || param.Name == "" {
+			return fmt.Errorf("param name is empty")
+		}
+		if names[param.Name] {
+			return fmt.Errorf("duplicated param:%s", param.Name)
+		}
+		names[param.Name] = true
+		if !isAbiParamType(param.Type) {
+			return fmt.Errorf("param:%s unsupported type:%s", param.Name, param.Type)
+		}
+	}
+	return nil
+}
+
+//Validate check names are unique and not empty, and all types are supported
+func (this *WasmContractAbi) Validate() error {
+	methods := make(map[string]bool)
+	for _, method := range this.Methods {
+		if method == nil || method.Name == "" {
+			return fmt.Errorf("method name is empty")
+		}
+		if methods[method.Name] {
+			return fmt.Errorf("duplicated method:%s", method.Name)
+		}
+		methods[method.Name] = true
+		if err := validateAbiParams(method.Parameters); err != nil {
+			return fmt.Errorf("method:%s %s", method.Name, err)
+		}
+		if method.ReturnType != ABI_TYPE_VOID && !isAbiParamType(method.ReturnType) {
+			return fmt.Errorf("method:%s unsupported return type:%s", method.Name, method.ReturnType)
+		}
+	}
+	events := make(map[string]bool)
+	for _, event := range this.Events {
+		if event == nil || event.Name == "" {
+			return fmt.Errorf("event name is empty")
+		}
+		if events[event.Name] {
+			return fmt.Errorf("duplicated event:%s", event.Name)
+		}
+		events[event.Name] = true
+		if err := validateAbiParams(event.Parameters); err != nil {
+			return fmt.Errorf("event:%s %s", event.Name, err)
+		}
+	}
+	return nil
+}
+
+func encodeAbiParams(params []*WasmParamAbi) []interface{} {
+	list := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		list = append(list, []interface{}{param.Name, param.Type})
+	}
+	return list
+}
+
+//Serialize encode abi with crossvm_codec
+func (this *WasmContractAbi) Serialize() ([]byte, error) {
+	if err := this.Validate(); err != nil {
+		return nil, err
+	}
+	methods := make([]interface{}, 0, len(this.Methods))
+	for _, method := range this.Methods {
+		methods = append(methods, []interface{}{method.Name, encodeAbiParams(method.Parameters), method.ReturnType})
+	}
+	events := make([]interface{}, 0, len(this.Events))
+	for _, event := range this.Events {
+		events = append(events, []interface{}{event.Name, encodeAbiParams(event.Parameters)})
+	}
+	sink := common.NewZeroCopySink(nil)
+	err := EncodeList(sink, []interface{}{methods, events})
+	if err != nil {
+		return nil, err
+	}
+	return sink.Bytes(), nil
+}
+
+func decodeAbiList(val interface{}, size int) ([]interface{}, error) {
+	list, ok := val.([]interface{})
+	if !ok || (size >= 0 && len(list) != size) {
+		return nil, ERROR_PARAM_FORMAT
+	}
+	return list, nil
+}
+
+func decodeAbiString(val interface{}) (string, error) {
+	str, ok := val.(string)
+	if !ok {
+		return "", ERROR_PARAM_FORMAT
+	}
+	return str, nil
+}
+
+func decodeAbiParams(val interface{}) ([]*WasmParamAbi, error) {
+	list, err := decodeAbiList(val, -1)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]*WasmParamAbi, 0, len(list))
+	for _, item := range list {
+		pair, err := decodeAbiList(item, 2)
+		if err != nil {
+			return nil, err
+		}
+		name, err := decodeAbiString(pair[0])
+		if err != nil {
+			return nil, err
+		}
+		ty, err := decodeAbiString(pair[1])
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, &WasmParamAbi{Name: name, Type: ty})
+	}
+	return params, nil
+}
+
+//DeserializeAbi decode and validate abi encoded by WasmContractAbi.Serialize
+func DeserializeAbi(data []byte) (*WasmContractAbi, error) {
+	source := common.NewZeroCopySource(data)
+	val, err := DecodeValue(source)
+	if err != nil {
+		return nil, err
+	}
+	if source.Len() != 0 {
+		return nil, fmt.Errorf("abi has trailing bytes")
+	}
+	root, err := decodeAbiList(val, 2)
+	if err != nil {
+		return nil, err
+	}
+	methods, err := decodeAbiList(root[0], -1)
+	if err != nil {
+		return nil, err
+	}
+	events, err := decodeAbiList(root[1], -1)
+	if err != nil {
+		return nil, err
+	}
+	abi := &WasmContractAbi{
+		Methods: make([]*WasmMethodAbi, 0, len(methods)),
+		Events:  make([]*WasmEventAbi, 0, len(events)),
+	}
+	for _, item := range methods {
+		fields, err := decodeAbiList(item, 3)
+		if err != nil {
+			return nil, err
+		}
+		method := &WasmMethodAbi{}
+		if method.Name, err = decodeAbiString(fields[0]); err != nil {
+			return nil, err
+		}
+		if method.Parameters, err = decodeAbiParams(fields[1]); err != nil {
+			return nil, err
+		}
+		if method.ReturnType, err = decodeAbiString(fields[2]); err != nil {
+			return nil, err
+		}
+		abi.Methods = append(abi.Methods, method)
+	}
+	for _, item := range events {
+		fields, err := decodeAbiList(item, 2)
+		if err != nil {
+			return nil, err
+		}
+		event := &WasmEventAbi{}
+		if event.Name, err = decodeAbiString(fields[0]); err != nil {
+			return nil, err
+		}
+		if event.Parameters, err = decodeAbiParams(fields[1]); err != nil {
+			return nil, err
+		}
+		abi.Events = append(abi.Events, event)
+	}
+	if err := abi.Validate(); err != nil {
+		return nil, err
+	}
+	return abi, nil
+}
+
+func readWasmVarUint32(source *common.ZeroCopySource) (uint32, error) {
+	var result uint32
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, eof := source.NextByte()
+		if eof {
+			return 0, ERROR_PARAM_FORMAT
+		}
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+	}
+	return 0, ERROR_PARAM_FORMAT
+}
+
+func writeWasmVarUint32(sink *common.ZeroCopySink, val uint32) {
+	for {
+		b := byte(val & 0x7f)
+		val >>= 7
+		if val != 0 {
+			b |= 0x80
+		}
+		sink.WriteByte(b)
+		if val == 0 {
+			return
+		}
+	}
+}
+
+//ExtractAbiSection return the payload of the ontio_abi custom section of wasm code, nil if not exist
+func ExtractAbiSection(code []byte) ([]byte, error) {
+	if len(code) < 8 || !bytes.Equal(code[:4], wasmMagic) {
+		return nil, fmt.Errorf("invalid wasm code")
+	}
+	source := common.NewZeroCopySource(code[8:])
+	var abi []byte
+	for source.Len() > 0 {
+		id, _ := source.NextByte()
+		size, err := readWasmVarUint32(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wasm section size")
+		}
+		payload, eof := source.NextBytes(uint64(size))
+		if eof {
+			return nil, fmt.Errorf("invalid wasm section size")
+		}
+		if id != wasmCustomSectionId {
+			continue
+		}
+		section := common.NewZeroCopySource(payload)
+		nameLen, err := readWasmVarUint32(section)
+		if err != nil {
+			return nil, fmt.Errorf("invalid wasm custom section name")
+		}
+		name, eof := section.NextBytes(uint64(nameLen))
+		if eof {
+			return nil, fmt.Errorf("invalid wasm custom section name")
+		}
+		if string(name) != ABI_SECTION_NAME {
+			continue
+		}
+		if abi != nil {
+			return nil, fmt.Errorf("duplicated %s section", ABI_SECTION_NAME)
+		}
+		abi, _ = section.NextBytes(section.Len())
+	}
+	return abi, nil
+}
+
+//ReadWasmContractAbi return the abi of wasm code, nil if code has no abi section
+func ReadWasmContractAbi(code []byte) (*WasmContractAbi, error) {
+	data, err := ExtractAbiSection(code)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	abi, err := DeserializeAbi(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s section:%s", ABI_SECTION_NAME, err)
+	}
+	return abi, nil
+}
+
+//AppendAbiSection append abi as the ontio_abi custom section to wasm code
+func AppendAbiSection(code []byte, abi *WasmContractAbi) ([]byte, error) {
+	exist, err := ExtractAbiSection(code)
+	if err != nil {
+		return nil, err
+	}
+	if exist != nil {
+		return nil, fmt.Errorf("wasm code already has %s section", ABI_SECTION_NAME)
+	}
+	data, err := abi.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	payload := common.NewZeroCopySink(nil)
+	writeWasmVarUint32(payload, uint32(len(ABI_SECTION_NAME)))
+	payload.WriteBytes([]byte(ABI_SECTION_NAME))
+	payload.WriteBytes(data)
+
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBytes(code)
+	sink.WriteByte(wasmCustomSectionId)
+	writeWasmVarUint32(sink, uint32(payload.Size()))
+	sink.WriteBytes(payload.Bytes())
+	return sink.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2018 The ontology Authors
+ * This file is part of The ontology library.
+ *
+ * The ontology is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The ontology is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The ontology.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package crossvm_codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testWasmCode = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+
+func testAbi() *WasmContractAbi {
+	return &WasmContractAbi{
+		Methods: []*WasmMethodAbi{
+			{Name: "transfer", Parameters: []*WasmParamAbi{{"from", ABI_TYPE_ADDRESS}, {"to", ABI_TYPE_ADDRESS}, {"amount", ABI_TYPE_INT}}, ReturnType: ABI_TYPE_BOOL},
+			{Name: "init", Parameters: []*WasmParamAbi{}, ReturnType: ABI_TYPE_VOID},
+		},
+		Events: []*WasmEventAbi{
+			{Name: "transfer", Parameters: []*WasmParamAbi{{"from", ABI_TYPE_ADDRESS}, {"to", ABI_TYPE_ADDRESS}, {"amount", ABI_TYPE_INT}}},
+		},
+	}
+}
+
+func TestAbiSerialize(t *testing.T) {
+	abi := testAbi()
+	data, err := abi.Serialize()
+	assert.Nil(t, err)
+	decoded, err := DeserializeAbi(data)
+	assert.Nil(t, err)
+	assert.Equal(t, abi, decoded)
+	assert.Equal(t, ABI_TYPE_BOOL, decoded.GetMethod("transfer").ReturnType)
+	assert.Nil(t, decoded.GetMethod("balanceOf"))
+
+	_, err = DeserializeAbi(append(data, 0))
+	assert.NotNil(t, err)
+	_, err = DeserializeAbi(data[:len(data)-1])
+	assert.NotNil(t, err)
+}
+
+func TestAbiValidate(t *testing.T) {
+	abi := testAbi()
+	abi.Methods[0].ReturnType = "float"
+	assert.NotNil(t, abi.Validate())
+
+	abi = testAbi()
+	abi.Methods[1].Name = "transfer"
+	assert.NotNil(t, abi.Validate())
+
+	abi = testAbi()
+	abi.Events[0].Parameters[1].Name = "from"
+	assert.NotNil(t, abi.Validate())
+
+	abi = testAbi()
+	abi.Methods[0].Parameters[0].Type = ABI_TYPE_VOID
+	_, err := abi.Serialize()
+	assert.NotNil(t, err)
+}
+
+func TestAbiSection(t *testing.T) {
+	abi, err := ReadWasmContractAbi(testWasmCode)
+	assert.Nil(t, err)
+	assert.Nil(t, abi)
+
+	code, err := AppendAbiSection(testWasmCode, testAbi())
+	assert.Nil(t, err)
+	abi, err = ReadWasmContractAbi(code)
+	assert.Nil(t, err)
+	assert.Equal(t, testAbi(), abi)
+
+	_, err = AppendAbiSection(code, testAbi())
+	assert.NotNil(t, err)
+
+	_, err = ReadWasmContractAbi(code[:len(code)-1])
+	assert.NotNil(t, err)
+	_, err = ReadWasmContractAbi([]byte("not wasm"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,85 @@
+#![cfg_attr(not(feature = "mock"), no_std)]
+extern crate ontio_std as ostd;
+use ostd::abi::{Sink, Source};
+use ostd::prelude::*;
+use ostd::runtime;
+
+mod env {
+    extern "C" {
+        pub fn ontio_keccak256(data: *const u8, len: u32, dst: *mut u8);
+        pub fn ontio_ripemd160(data: *const u8, len: u32, dst: *mut u8);
+        pub fn ontio_blake2b256(data: *const u8, len: u32, dst: *mut u8);
+        pub fn ontio_ecrecover(hash: *const u8, sig: *const u8, dst: *mut u8) -> u32;
+        pub fn ontio_ed25519_verify(pk: *const u8, msg: *const u8, len: u32, sig: *const u8) -> u32;
+        pub fn ontio_bls12381_pairing_check(input: *const u8, len: u32) -> u32;
+    }
+}
+
+#[no_mangle]
+pub fn invoke() {
+    let input = runtime::input();
+    let mut source = Source::new(&input);
+    let action: &[u8] = source.read().unwrap();
+    let mut sink = Sink::new(12);
+    match action {
+        b"keccak256" => {
+            let data: &[u8] = source.read().unwrap();
+            let mut hash = [0u8; 32];
+            unsafe { env::ontio_keccak256(data.as_ptr(), data.len() as u32, hash.as_mut_ptr()) };
+            sink.write(&hash[..])
+        }
+        b"ripemd160" => {
+            let data: &[u8] = source.read().unwrap();
+            let mut hash = [0u8; 20];
+            unsafe { env::ontio_ripemd160(data.as_ptr(), data.len() as u32, hash.as_mut_ptr()) };
+            sink.write(&hash[..])
+        }
+        b"blake2b256" => {
+            let data: &[u8] = source.read().unwrap();
+            let mut hash = [0u8; 32];
+            unsafe { env::ontio_blake2b256(data.as_ptr(), data.len() as u32, hash.as_mut_ptr()) };
+            sink.write(&hash[..])
+        }
+        b"ecrecover" => {
+            let (hash, sig): (&[u8], &[u8]) = source.read().unwrap();
+            assert_eq!(hash.len(), 32);
+            assert_eq!(sig.len(), 65);
+            let mut pk = [0u8; 65];
+            if unsafe { env::ontio_ecrecover(hash.as_ptr(), sig.as_ptr(), pk.as_mut_ptr()) } == 1 {
+                sink.write(&pk[..])
+            }
+        }
+        b"ed25519_verify" => {
+            let (pk, msg, sig): (&[u8], &[u8], &[u8]) = source.read().unwrap();
+            assert_eq!(pk.len(), 32);
+            assert_eq!(sig.len(), 64);
+            let ok = unsafe { env::ontio_ed25519_verify(pk.as_ptr(), msg.as_ptr(), msg.len() as u32, sig.as_ptr()) };
+            sink.write(ok == 1)
+        }
+        b"bls12381_pairing_check" => {
+            let data: &[u8] = source.read().unwrap();
+            let ok = unsafe { env::ontio_bls12381_pairing_check(data.as_ptr(), data.len() as u32) };
+            sink.write(ok == 1)
+        }
+        b"testcase" => sink.write(testcase()),
+        _ => panic!("unsupported action!"),
+    }
+
+    runtime::ret(sink.bytes())
+}
+
+fn testcase() -> String {
+    r#"
+    [
+        [{"method":"keccak256", "param":"string:abc", "expected":"bytearray:4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+        {"method":"ripemd160", "param":"string:abc", "expected":"bytearray:8eb208f7e05d987a9b044a8e98c6b087f15a0bfc"},
+        {"method":"blake2b256", "param":"string:abc", "expected":"bytearray:bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319"},
+        {"method":"ed25519_verify", "param":"bytearray:d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a, bytearray:, bytearray:e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b", "expected":"bool:true"},
+        {"method":"ed25519_verify", "param":"bytearray:d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a, bytearray:00, bytearray:e5564300c360ac729086e2cc806e828a84877f1eb8e5d974d873e065224901555fb8821590a33bacc61e39701cf9b46bd25bf5f0595bbe24655141438e7a100b", "expected":"bool:false"},
+        {"method":"ecrecover", "param":"bytearray:4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45, bytearray:35415fdd7be6ce3d906d1218e89e1a23de779c15b8c4693a0784aef9a69731ea04abcdac9aac062617c01bf8bcda72cdc07d2f040c80a6644b86e5539db2640e00", "expected":"bytearray:04074f0ffad7fb96495d0dc6dc38a812e2ab138a705bc1a067a545050c050c6121ea9723c5cfbe03585faaa6c55a8e1e4de60fbfa2431ea07bb8c0f01c91f1e24b"},
+        {"method":"bls12381_pairing_check", "param":"bytearray:", "expected":"bool:true"}
+        ]
+    ]
+        "#
+    .to_string()
+}
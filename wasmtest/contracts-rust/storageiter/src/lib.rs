@@ -0,0 +1,139 @@
+#![cfg_attr(not(feature = "mock"), no_std)]
+extern crate ontio_std as ostd;
+use ostd::abi::{Sink, Source};
+use ostd::prelude::*;
+use ostd::runtime;
+
+mod env {
+    extern "C" {
+        pub fn ontio_storage_iter_create(prefix: *const u8, len: u32) -> u32;
+        pub fn ontio_storage_iter_next(iter: u32) -> u32;
+        pub fn ontio_storage_iter_key(iter: u32, dst: *mut u8, len: u32, offset: u32) -> u32;
+        pub fn ontio_storage_iter_value(iter: u32, dst: *mut u8, len: u32, offset: u32) -> u32;
+        pub fn ontio_storage_iter_close(iter: u32);
+    }
+}
+
+type ReadFn = unsafe extern "C" fn(u32, *mut u8, u32, u32) -> u32;
+
+struct StorageIter {
+    handle: u32,
+}
+
+impl StorageIter {
+    fn new(prefix: &[u8]) -> StorageIter {
+        let handle = unsafe { env::ontio_storage_iter_create(prefix.as_ptr(), prefix.len() as u32) };
+        StorageIter { handle }
+    }
+
+    fn read(&self, f: ReadFn) -> Vec<u8> {
+        let size = unsafe { f(self.handle, core::ptr::null_mut(), 0, 0) };
+        let mut buf = Vec::new();
+        buf.resize(size as usize, 0u8);
+        if size > 0 {
+            unsafe { f(self.handle, buf.as_mut_ptr(), size, 0) };
+        }
+        buf
+    }
+}
+
+impl Iterator for StorageIter {
+    type Item = (Vec<u8>, Vec<u8>);
+
+    fn next(&mut self) -> Option<Self::Item> {
+        if unsafe { env::ontio_storage_iter_next(self.handle) } == 0 {
+            return None;
+        }
+        Some((self.read(env::ontio_storage_iter_key), self.read(env::ontio_storage_iter_value)))
+    }
+}
+
+impl Drop for StorageIter {
+    fn drop(&mut self) {
+        unsafe { env::ontio_storage_iter_close(self.handle) }
+    }
+}
+
+fn list(prefix: &[u8]) -> String {
+    let mut res = Vec::new();
+    for (key, val) in StorageIter::new(prefix) {
+        res.extend_from_slice(&key);
+        res.push(b'=');
+        res.extend_from_slice(&val);
+        res.push(b';');
+    }
+    String::from_utf8(res).unwrap()
+}
+
+#[no_mangle]
+pub fn invoke() {
+    let input = runtime::input();
+    let mut source = Source::new(&input);
+    let action: &[u8] = source.read().unwrap();
+    let mut sink = Sink::new(12);
+    match action {
+        b"storage_write" => {
+            let (key, val): (&[u8], &[u8]) = source.read().unwrap();
+            runtime::storage_write(key, val);
+        }
+        b"storage_delete" => {
+            let key: &[u8] = source.read().unwrap();
+            runtime::storage_delete(key);
+        }
+        b"list" => {
+            let prefix: &[u8] = source.read().unwrap();
+            sink.write(list(prefix))
+        }
+        b"count" => {
+            let prefix: &[u8] = source.read().unwrap();
+            sink.write(StorageIter::new(prefix).count() as U128)
+        }
+        b"write_during_iter" => {
+            // storage can be modified while an iterator is open
+            let mut iter = StorageIter::new(b"user.");
+            let (key, _) = iter.next().unwrap();
+            runtime::storage_write(b"user.0", b"0");
+            runtime::storage_delete(b"user.0");
+            sink.write(&key[..])
+        }
+        b"partial_read" => {
+            let mut iter = StorageIter::new(b"user.");
+            assert!(iter.next().is_some());
+            let mut buf = [0u8; 2];
+            let size = unsafe { env::ontio_storage_iter_key(iter.handle, buf.as_mut_ptr(), 2, 3) };
+            assert_eq!(size, 6);
+            sink.write(&buf[..])
+        }
+        b"leak_iter" => {
+            // the host releases iterators left open when the invocation ends
+            let iter = StorageIter::new(b"");
+            core::mem::forget(iter);
+        }
+        b"testcase" => sink.write(testcase()),
+        _ => panic!("unsupported action!"),
+    }
+
+    runtime::ret(sink.bytes())
+}
+
+fn testcase() -> String {
+    r#"
+    [
+        [{"method":"storage_write", "param":"string:user.c, string:3"},
+        {"method":"storage_write", "param":"string:user.a, string:1"},
+        {"method":"storage_write", "param":"string:zz, string:9"},
+        {"method":"storage_write", "param":"string:user.b, string:2"},
+        {"method":"list", "param":"string:user.", "expected":"string:user.a=1;user.b=2;user.c=3;"},
+        {"method":"list", "param":"string:", "expected":"string:user.a=1;user.b=2;user.c=3;zz=9;"},
+        {"method":"list", "param":"string:none", "expected":"string:"},
+        {"method":"count", "param":"string:user.", "expected":"int:3"},
+        {"method":"storage_delete", "param":"string:user.b"},
+        {"method":"list", "param":"string:user.", "expected":"string:user.a=1;user.c=3;"},
+        {"method":"write_during_iter", "expected":"bytearray:757365722e61"},
+        {"method":"partial_read", "expected":"bytearray:722e"},
+        {"method":"leak_iter"}
+        ]
+    ]
+        "#
+    .to_string()
+}
@@ -242,6 +242,47 @@ func execTxGasTest(tx *types.Transaction, database *ledger.Ledger) {
 	})
 	checkErr(err)
 	assertEq(res_jit, res_inter)
+
+	// governed gas schedule keeping the host function costs of jit runtime, jit runs against the interpreter
+	for _, schedule := range []map[string]uint64{jitGasSchedule(), interpGasSchedule()} {
+		res_jit, err = database.GetStore().(*ledgerstore.LedgerStoreImp).PreExecuteContractWithParam(tx, ledgerstore.PrexecuteParam{
+			JitMode:    true,
+			WasmFactor: config.DEFAULT_WASM_GAS_FACTOR,
+			MinGas:     false,
+			GasTable:   schedule,
+		})
+		checkErr(err)
+
+		res_inter, err = database.GetStore().(*ledgerstore.LedgerStoreImp).PreExecuteContractWithParam(tx, ledgerstore.PrexecuteParam{
+			JitMode:    false,
+			WasmFactor: config.DEFAULT_WASM_GAS_FACTOR,
+			MinGas:     false,
+			GasTable:   schedule,
+		})
+		checkErr(err)
+		assertEq(res_jit, res_inter)
+	}
+}
+
+//jitGasSchedule only tunes the costs charged by go side or interpreter only host functions, which jit runtime honors
+func jitGasSchedule() map[string]uint64 {
+	builtin := wasmvm.DefaultGasSchedule()
+	return map[string]uint64{
+		wasmvm.GAS_SCHEDULE_VERSION_KEY: 1,
+		"WASM_GAS_NATIVE_INVOKE":        builtin.NativeInvoke * 2,
+		"WASM_GAS_KECCAK256":            builtin.Keccak256 * 2,
+		"WASM_GAS_STORAGE_ITER_NEXT":    builtin.StorageIterNext * 2,
+	}
+}
+
+//interpGasSchedule tunes the costs charged by jit runtime, contracts depending on them fall back to the interpreter
+func interpGasSchedule() map[string]uint64 {
+	schedule := map[string]uint64{wasmvm.GAS_SCHEDULE_VERSION_KEY: 1, "WASM_GAS_MEMORY_GROW_PAGE": 1000}
+	builtin := wasmvm.DefaultGasSchedule()
+	schedule["WASM_GAS_STORAGE_GET"] = builtin.StorageGet * 2
+	schedule["WASM_GAS_STORAGE_PUT"] = builtin.StoragePut * 2
+	schedule["WASM_GAS_CALL_CONTRACT"] = builtin.CallContract * 2
+	return schedule
 }
 
 func execTxCheckRes(tx *types.Transaction, testCase common3.TestCase, database *ledger.Ledger, addr common.Address, acct *account.Account) {